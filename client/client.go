@@ -0,0 +1,393 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+)
+
+// Setup contains the client metadata.
+type Setup struct {
+	// BaseURL pets API address, e.g. http://localhost:8080.
+	BaseURL string
+	// Token is sent as a bearer token when it is not empty.
+	Token string
+	// Actor is sent in the X-Actor header to be recorded in the pets history
+	// as the asserted actor, the server does not authenticate it.
+	Actor string
+	// Timeout maximum time of every attempt of a request, including reading
+	// its response. Without timeout requests are only limited by their
+	// context.
+	Timeout time.Duration
+	// Retry says how failed requests are retried, they are not retried by
+	// default.
+	Retry RetryPolicy
+	// Propagator injects the trace context of the request context in the
+	// request headers, the global otel propagator by default.
+	Propagator propagation.TextMapPropagator
+	// HTTPClient used to send requests, http.DefaultClient if it is nil.
+	HTTPClient *http.Client
+	Logger     *slog.Logger
+}
+
+// Client calls the pets HTTP API.
+type Client struct {
+	baseURL    string
+	token      string
+	actor      string
+	timeout    time.Duration
+	retry      RetryPolicy
+	propagator propagation.TextMapPropagator
+	httpClient *http.Client
+	logger     *slog.Logger
+}
+
+// import content types by format.
+var importContentTypes = map[string]string{
+	"csv":    "text/csv",
+	"ndjson": "application/x-ndjson",
+}
+
+var (
+	// ErrEmptyBaseURL is returned when the client has not any base url.
+	ErrEmptyBaseURL      = errors.New("base url must be given")
+	errUnsupportedFormat = errors.New("unsupported format, it must be csv or ndjson")
+)
+
+const (
+	jsonContentType = "application/json"
+	requestIDHeader = "X-Request-ID"
+	// maxErrorBodySize maximum size of an error response that is read.
+	maxErrorBodySize = 1 << 20
+)
+
+// New creates a pets API client.
+func New(setup Setup) *Client {
+	httpClient := setup.HTTPClient
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+
+	logger := setup.Logger
+	if logger == nil {
+		logger = slog.New(slog.NewTextHandler(io.Discard, nil))
+	}
+
+	propagator := setup.Propagator
+	if propagator == nil {
+		propagator = otel.GetTextMapPropagator()
+	}
+
+	newClient := Client{
+		baseURL:    strings.TrimSuffix(setup.BaseURL, "/"),
+		token:      setup.Token,
+		actor:      setup.Actor,
+		timeout:    setup.Timeout,
+		retry:      setup.Retry,
+		propagator: propagator,
+		httpClient: httpClient,
+		logger:     logger,
+	}
+
+	return &newClient
+}
+
+// Create creates a pet and returns its id. Every call sends a new
+// idempotency key, so a retried request does not create the pet twice.
+func (c *Client) Create(ctx context.Context, newPet NewPet) (string, error) {
+	var id string
+
+	header := http.Header{}
+	header.Set("Idempotency-Key", uuid.NewString())
+
+	err := c.do(ctx, http.MethodPost, "/pets", nil, header, newPet, &id)
+	if err != nil {
+		return "", fmt.Errorf("unable to create pet: %w", err)
+	}
+
+	return id, nil
+}
+
+// QueryByID returns the pet with the given id or ErrNotFound.
+func (c *Client) QueryByID(ctx context.Context, id string) (*Pet, error) {
+	var pet *Pet
+
+	err := c.do(ctx, http.MethodGet, "/pets/"+url.PathEscape(id), nil, nil, nil, &pet)
+	if err != nil {
+		return nil, fmt.Errorf("unable to query pet %q: %w", id, err)
+	}
+
+	if pet == nil {
+		return nil, fmt.Errorf("unable to query pet %q: %w", id, ErrNotFound)
+	}
+
+	return pet, nil
+}
+
+// Update updates the name and location of a pet.
+func (c *Client) Update(ctx context.Context, updatePet UpdatePet) error {
+	err := c.do(ctx, http.MethodPut, "/pets", nil, nil, updatePet, nil)
+	if err != nil {
+		return fmt.Errorf("unable to update pet %q: %w", updatePet.ID, err)
+	}
+
+	return nil
+}
+
+// Delete deletes the pet with the given id.
+func (c *Client) Delete(ctx context.Context, id string) error {
+	err := c.do(ctx, http.MethodDelete, "/pets/"+url.PathEscape(id), nil, nil, nil, nil)
+	if err != nil {
+		return fmt.Errorf("unable to delete pet %q: %w", id, err)
+	}
+
+	return nil
+}
+
+// Query searches pets with the given filter.
+func (c *Client) Query(ctx context.Context, filter QueryFilter) (*SearchResult, error) {
+	var result SearchResult
+
+	err := c.do(ctx, http.MethodGet, "/pets", filter.values(), nil, nil, &result)
+	if err != nil {
+		return nil, fmt.Errorf("unable to search pets: %w", err)
+	}
+
+	return &result, nil
+}
+
+// Import starts importing the pets read from source in the given format,
+// csv or ndjson. The import runs on the server, use ImportJob to poll it.
+func (c *Client) Import(ctx context.Context, format string, source io.Reader) (*ImportJob, error) {
+	contentType, ok := importContentTypes[format]
+	if !ok {
+		return nil, errUnsupportedFormat
+	}
+
+	req, err := c.newRequest(ctx, http.MethodPost, "/pets/import", nil, source)
+	if err != nil {
+		return nil, fmt.Errorf("unable to import pets: %w", err)
+	}
+
+	req.Header.Set("Content-Type", contentType)
+
+	var job ImportJob
+
+	err = c.send(req, &job)
+	if err != nil {
+		return nil, fmt.Errorf("unable to import pets: %w", err)
+	}
+
+	return &job, nil
+}
+
+// ImportJob returns the status of an import.
+func (c *Client) ImportJob(ctx context.Context, id string) (*ImportJob, error) {
+	var job ImportJob
+
+	err := c.do(ctx, http.MethodGet, "/pets/import/"+url.PathEscape(id), nil, nil, nil, &job)
+	if err != nil {
+		return nil, fmt.Errorf("unable to get import job %q: %w", id, err)
+	}
+
+	return &job, nil
+}
+
+// Export writes the pets to target in the filter format as they are
+// received.
+func (c *Client) Export(ctx context.Context, filter ExportFilter, target io.Writer) error {
+	format := filter.Format
+	if format == "" {
+		format = "csv"
+	}
+
+	if _, ok := importContentTypes[format]; !ok {
+		return errUnsupportedFormat
+	}
+
+	query := url.Values{}
+	query.Set("format", format)
+	if filter.IncludeDeleted {
+		query.Set("include_deleted", "true")
+	}
+
+	req, err := c.newRequest(ctx, http.MethodGet, "/pets/export", query, nil)
+	if err != nil {
+		return fmt.Errorf("unable to export pets: %w", err)
+	}
+
+	resp, err := c.roundTrip(req)
+	if err != nil {
+		return fmt.Errorf("unable to export pets: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unable to export pets: %w", readError(resp))
+	}
+
+	_, err = io.Copy(target, resp.Body)
+	if err != nil {
+		return fmt.Errorf("unable to read exported pets: %w", err)
+	}
+
+	return nil
+}
+
+// do sends body as JSON and decodes the data of the result into data when
+// it is not nil.
+func (c *Client) do(ctx context.Context, method, path string, query url.Values, header http.Header, body, data any) error {
+	var content io.Reader
+	if body != nil {
+		encoded, err := json.Marshal(body)
+		if err != nil {
+			return fmt.Errorf("unable to encode request: %w", err)
+		}
+		content = bytes.NewReader(encoded)
+	}
+
+	req, err := c.newRequest(ctx, method, path, query, content)
+	if err != nil {
+		return err
+	}
+
+	for k, v := range header {
+		req.Header[k] = v
+	}
+
+	if body != nil {
+		req.Header.Set("Content-Type", jsonContentType)
+	}
+
+	return c.send(req, data)
+}
+
+func (c *Client) newRequest(ctx context.Context, method, path string, query url.Values, body io.Reader) (*http.Request, error) {
+	if c.baseURL == "" {
+		return nil, ErrEmptyBaseURL
+	}
+
+	target := c.baseURL + path
+	if len(query) > 0 {
+		target += "?" + query.Encode()
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, target, body)
+	if err != nil {
+		return nil, fmt.Errorf("unable to create request: %w", err)
+	}
+
+	req.Header.Set("Accept", jsonContentType)
+
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+
+	if c.actor != "" {
+		req.Header.Set("X-Actor", c.actor)
+	}
+
+	c.propagator.Inject(ctx, propagation.HeaderCarrier(req.Header))
+
+	return req, nil
+}
+
+// send sends the request and decodes the data of the result into data.
+func (c *Client) send(req *http.Request, data any) error {
+	c.logger.Debug("sending request", slog.String("method", req.Method), slog.String("url", req.URL.String()))
+
+	resp, err := c.roundTrip(req)
+	if err != nil {
+		return fmt.Errorf("unable to send request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusAccepted {
+		return readError(resp)
+	}
+
+	var message result
+
+	err = json.NewDecoder(resp.Body).Decode(&message)
+	if err != nil {
+		return fmt.Errorf("unable to decode response: %w", err)
+	}
+
+	if !message.Success {
+		return newError(resp.StatusCode, resp.Header.Get(requestIDHeader), message.Errors)
+	}
+
+	if data == nil || len(message.Data) == 0 {
+		return nil
+	}
+
+	err = json.Unmarshal(message.Data, data)
+	if err != nil {
+		return fmt.Errorf("unable to decode response data: %w", err)
+	}
+
+	return nil
+}
+
+// readError builds the error of a response that is not successful, its
+// body is a result with errors or an error message.
+func readError(resp *http.Response) *Error {
+	requestID := resp.Header.Get(requestIDHeader)
+
+	content, err := io.ReadAll(io.LimitReader(resp.Body, maxErrorBodySize))
+	if err != nil {
+		return newError(resp.StatusCode, requestID, nil)
+	}
+
+	var message result
+	if json.Unmarshal(content, &message) == nil && len(message.Errors) > 0 {
+		return newError(resp.StatusCode, requestID, message.Errors)
+	}
+
+	var errorMessage errorResponse
+	if json.Unmarshal(content, &errorMessage) == nil && errorMessage.Message != "" {
+		return newError(resp.StatusCode, requestID, []string{errorMessage.Message})
+	}
+
+	return newError(resp.StatusCode, requestID, nil)
+}
+
+// values returns the query parameters of the filter.
+func (q QueryFilter) values() url.Values {
+	query := url.Values{}
+
+	if q.Name != "" {
+		query.Set("name", q.Name)
+	}
+
+	if q.OrderBy != "" {
+		query.Set("orderby", q.OrderBy)
+	}
+
+	if q.Page > 0 {
+		query.Set("page", strconv.Itoa(int(q.Page)))
+	}
+
+	if q.PageSize > 0 {
+		query.Set("pagesize", strconv.Itoa(int(q.PageSize)))
+	}
+
+	if q.IncludeDeleted {
+		query.Set("include_deleted", "true")
+	}
+
+	return query
+}
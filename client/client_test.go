@@ -0,0 +1,351 @@
+package client_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/fernandoocampo/basic-micro/client"
+	"github.com/fernandoocampo/basic-micro/internal/adapter/stores"
+	"github.com/fernandoocampo/basic-micro/internal/application"
+	"github.com/fernandoocampo/basic-micro/internal/pets"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestPetLifecycle(t *testing.T) {
+	// Given
+	server := newPetsServer(t, nil)
+	petsClient := client.New(client.Setup{BaseURL: server.URL, Actor: "alice"})
+	ctx := context.Background()
+	newPet := client.NewPet{
+		Name:     "Drila",
+		Location: &client.Location{Latitude: 4.6, Longitude: -74.1},
+	}
+
+	// When
+	id, err := petsClient.Create(ctx, newPet)
+	require.NoError(t, err)
+	created, err := petsClient.QueryByID(ctx, id)
+	require.NoError(t, err)
+	err = petsClient.Update(ctx, client.UpdatePet{ID: id, Name: "Drilita", Location: created.Location})
+	require.NoError(t, err)
+	found, err := petsClient.Query(ctx, client.QueryFilter{Name: "dri", Page: 1, PageSize: 5})
+	require.NoError(t, err)
+	err = petsClient.Delete(ctx, id)
+	require.NoError(t, err)
+	_, errDeleted := petsClient.QueryByID(ctx, id)
+	deleted, err := petsClient.Query(ctx, client.QueryFilter{Name: "dri", IncludeDeleted: true})
+	require.NoError(t, err)
+
+	// Then
+	assert.Equal(t, &client.Pet{ID: id, Name: "Drila", Location: newPet.Location}, created)
+	require.Len(t, found.Pets, 1)
+	assert.Equal(t, "Drilita", found.Pets[0].Name)
+	assert.Equal(t, 1, found.Total)
+	assert.Equal(t, uint8(5), found.PageSize)
+	assert.ErrorIs(t, errDeleted, client.ErrNotFound)
+	require.Len(t, deleted.Pets, 1)
+	assert.NotNil(t, deleted.Pets[0].DeletedAt)
+}
+
+func TestActorIsRecordedAsAsserted(t *testing.T) {
+	// Given
+	server := newPetsServer(t, nil)
+	petsClient := client.New(client.Setup{BaseURL: server.URL, Actor: "alice"})
+	id, err := petsClient.Create(context.Background(), client.NewPet{Name: "Drila"})
+	require.NoError(t, err)
+
+	// When
+	resp, err := http.Get(server.URL + "/pets/" + id + "/history")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	// Then
+	var history struct {
+		Data struct {
+			Records []struct {
+				Actor         string `json:"actor"`
+				AssertedActor string `json:"asserted_actor"`
+			} `json:"records"`
+		} `json:"data"`
+	}
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&history))
+	require.Len(t, history.Data.Records, 1)
+	assert.Equal(t, pets.AnonymousActor, history.Data.Records[0].Actor)
+	assert.Equal(t, "alice", history.Data.Records[0].AssertedActor)
+}
+
+func TestCreateInvalidPet(t *testing.T) {
+	// Given
+	server := newPetsServer(t, nil)
+	petsClient := client.New(client.Setup{BaseURL: server.URL})
+
+	// When
+	_, err := petsClient.Create(context.Background(), client.NewPet{})
+
+	// Then
+	assert.ErrorIs(t, err, client.ErrInvalidRequest)
+	var apiErr *client.Error
+	require.ErrorAs(t, err, &apiErr)
+	assert.Equal(t, http.StatusBadRequest, apiErr.StatusCode)
+	assert.NotEmpty(t, apiErr.RequestID)
+	assert.Contains(t, apiErr.Messages[0], "invalid pet data")
+}
+
+func TestUpdateMissingPet(t *testing.T) {
+	// Given
+	server := newPetsServer(t, nil)
+	petsClient := client.New(client.Setup{BaseURL: server.URL})
+
+	// When
+	err := petsClient.Update(context.Background(), client.UpdatePet{ID: "missing", Name: "Drila"})
+
+	// Then
+	assert.ErrorIs(t, err, client.ErrNotFound)
+	var apiErr *client.Error
+	require.ErrorAs(t, err, &apiErr)
+	assert.Equal(t, http.StatusNotFound, apiErr.StatusCode)
+}
+
+func TestImportAndExport(t *testing.T) {
+	// Given
+	server := newPetsServer(t, nil)
+	petsClient := client.New(client.Setup{BaseURL: server.URL})
+	ctx := context.Background()
+	source := strings.NewReader("name,latitude,longitude\nDrila,4.6,-74.1\n,1,2\n")
+
+	// When
+	job, err := petsClient.Import(ctx, "csv", source)
+	require.NoError(t, err)
+	for !job.Finished() {
+		time.Sleep(10 * time.Millisecond)
+		job, err = petsClient.ImportJob(ctx, job.ID)
+		require.NoError(t, err)
+	}
+	var exported bytes.Buffer
+	err = petsClient.Export(ctx, client.ExportFilter{Format: "ndjson"}, &exported)
+	require.NoError(t, err)
+	_, errMissingJob := petsClient.ImportJob(ctx, "missing")
+
+	// Then
+	assert.Equal(t, client.ImportCompleted, job.Status)
+	assert.Equal(t, 2, job.Report.Total)
+	assert.Equal(t, 1, job.Report.Imported)
+	assert.Len(t, job.Report.Rejected, 1)
+	assert.Contains(t, exported.String(), `"name":"Drila"`)
+	assert.ErrorIs(t, errMissingJob, client.ErrNotFound)
+}
+
+func TestRetryUnavailableService(t *testing.T) {
+	// Given
+	var calls atomic.Int32
+	server := newPetsServer(t, func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if calls.Add(1) < 3 {
+				w.WriteHeader(http.StatusServiceUnavailable)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	})
+	petsClient := client.New(client.Setup{
+		BaseURL: server.URL,
+		Retry:   client.RetryPolicy{MaxAttempts: 3, Backoff: time.Millisecond},
+	})
+
+	// When
+	id, err := petsClient.Create(context.Background(), client.NewPet{Name: "Drila"})
+
+	// Then
+	require.NoError(t, err)
+	assert.NotEmpty(t, id)
+	assert.Equal(t, int32(3), calls.Load())
+}
+
+func TestRetryAttemptsAreExhausted(t *testing.T) {
+	// Given
+	var calls atomic.Int32
+	server := newPetsServer(t, func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			calls.Add(1)
+			w.WriteHeader(http.StatusServiceUnavailable)
+		})
+	})
+	petsClient := client.New(client.Setup{
+		BaseURL: server.URL,
+		Retry:   client.RetryPolicy{MaxAttempts: 2, Backoff: time.Millisecond},
+	})
+
+	// When
+	_, err := petsClient.QueryByID(context.Background(), "any")
+
+	// Then
+	assert.ErrorIs(t, err, client.ErrUnavailable)
+	assert.Equal(t, int32(2), calls.Load())
+}
+
+func TestServerErrorsAreNotRetried(t *testing.T) {
+	// Given
+	var calls atomic.Int32
+	server := newPetsServer(t, func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			calls.Add(1)
+			w.WriteHeader(http.StatusInternalServerError)
+		})
+	})
+	petsClient := client.New(client.Setup{
+		BaseURL: server.URL,
+		Retry:   client.RetryPolicy{MaxAttempts: 3, Backoff: time.Millisecond},
+	})
+
+	// When
+	err := petsClient.Delete(context.Background(), "any")
+
+	// Then
+	assert.ErrorIs(t, err, client.ErrServer)
+	assert.Equal(t, int32(1), calls.Load())
+}
+
+func TestStatusErrors(t *testing.T) {
+	cases := map[int]error{
+		http.StatusNotFound:             client.ErrNotFound,
+		http.StatusBadRequest:           client.ErrInvalidRequest,
+		http.StatusUnsupportedMediaType: client.ErrInvalidRequest,
+		http.StatusConflict:             client.ErrConflict,
+		http.StatusUnprocessableEntity:  client.ErrConflict,
+		http.StatusTooManyRequests:      client.ErrUnavailable,
+		http.StatusBadGateway:           client.ErrUnavailable,
+		http.StatusInternalServerError:  client.ErrServer,
+	}
+	for statusCode, want := range cases {
+		t.Run(http.StatusText(statusCode), func(t *testing.T) {
+			// Given
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(statusCode)
+				io.WriteString(w, `{"message":"something went wrong"}`)
+			}))
+			t.Cleanup(server.Close)
+			petsClient := client.New(client.Setup{BaseURL: server.URL})
+
+			// When
+			_, err := petsClient.Query(context.Background(), client.QueryFilter{Name: "drila"})
+
+			// Then
+			assert.ErrorIs(t, err, want)
+			var apiErr *client.Error
+			require.ErrorAs(t, err, &apiErr)
+			assert.Equal(t, statusCode, apiErr.StatusCode)
+			assert.Equal(t, []string{"something went wrong"}, apiErr.Messages)
+		})
+	}
+}
+
+func TestAttemptTimeout(t *testing.T) {
+	// Given
+	server := newPetsServer(t, func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			select {
+			case <-r.Context().Done():
+			case <-time.After(time.Second):
+			}
+		})
+	})
+	petsClient := client.New(client.Setup{BaseURL: server.URL, Timeout: 20 * time.Millisecond})
+
+	// When
+	_, err := petsClient.QueryByID(context.Background(), "any")
+
+	// Then
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
+
+func TestTraceContextPropagation(t *testing.T) {
+	// Given
+	var traceparent atomic.Value
+	server := newPetsServer(t, func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			traceparent.Store(r.Header.Get("traceparent"))
+			next.ServeHTTP(w, r)
+		})
+	})
+	petsClient := client.New(client.Setup{
+		BaseURL:    server.URL,
+		Propagator: propagation.TraceContext{},
+	})
+	spanContext := trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID:    trace.TraceID{0x4b, 0xf9, 0x2f, 0x35, 0x77, 0xb3, 0x4d, 0xa6, 0xa3, 0xce, 0x92, 0x9d, 0x0e, 0x0e, 0x47, 0x36},
+		SpanID:     trace.SpanID{0x00, 0xf0, 0x67, 0xaa, 0x0b, 0xa9, 0x02, 0xb7},
+		TraceFlags: trace.FlagsSampled,
+	})
+	ctx := trace.ContextWithSpanContext(context.Background(), spanContext)
+
+	// When
+	_, err := petsClient.Query(ctx, client.QueryFilter{Name: "drila"})
+
+	// Then
+	require.NoError(t, err)
+	assert.Equal(t, "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", traceparent.Load())
+}
+
+func TestCanceledRequest(t *testing.T) {
+	// Given
+	server := newPetsServer(t, nil)
+	petsClient := client.New(client.Setup{BaseURL: server.URL, Retry: client.DefaultRetryPolicy})
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	// When
+	err := petsClient.Delete(ctx, "any")
+
+	// Then
+	assert.True(t, errors.Is(err, context.Canceled))
+}
+
+// newPetsServer serves the pets router with a memory store, wrap is
+// optional and wraps the router to change the responses of the service.
+func newPetsServer(t *testing.T, wrap func(http.Handler) http.Handler) *httptest.Server {
+	t.Helper()
+
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	store := stores.NewMemoryStore(stores.Setup{Logger: logger})
+	service := pets.NewService(pets.ServiceSetup{
+		Storer:      store,
+		AuditStorer: store,
+		Logger:      logger,
+	})
+
+	importer := pets.NewImporter(pets.ImporterSetup{Service: service, Logger: logger})
+	t.Cleanup(func() {
+		importer.Stop(context.Background())
+	})
+
+	handler, err := application.NewRouter(application.RouterSetup{
+		Service:           service,
+		Importer:          importer,
+		ValidateRequests:  true,
+		ValidateResponses: true,
+		Logger:            logger,
+	})
+	require.NoError(t, err)
+	if wrap != nil {
+		handler = wrap(handler)
+	}
+
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+
+	return server
+}
@@ -0,0 +1,14 @@
+// Package client is a Go client of the pets HTTP API.
+//
+//	petsClient := client.New(client.Setup{
+//		BaseURL: "http://localhost:8080",
+//		Timeout: 5 * time.Second,
+//		Retry:   client.DefaultRetryPolicy,
+//	})
+//	id, err := petsClient.Create(ctx, client.NewPet{Name: "Drila"})
+//
+// Failed responses are returned as *Error, use errors.Is with its kinds,
+// like ErrNotFound or ErrInvalidRequest, to handle them. The trace context
+// of the request context is sent with the W3C trace context headers when
+// the otel propagator supports them.
+package client
@@ -0,0 +1,86 @@
+package client
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// Error is returned when the pets API answers a request with errors. Use
+// errors.Is with ErrNotFound, ErrInvalidRequest, ErrConflict, ErrUnavailable
+// or ErrServer to know its kind.
+type Error struct {
+	// StatusCode HTTP status of the response.
+	StatusCode int
+	// Messages errors of the response.
+	Messages []string
+	// RequestID X-Request-ID of the response, it can be used to find the
+	// request in the service logs.
+	RequestID string
+	kind      error
+}
+
+// error kinds
+var (
+	// ErrNotFound the pet or the import job does not exist.
+	ErrNotFound = errors.New("not found")
+	// ErrInvalidRequest the request was rejected, like a pet without name.
+	ErrInvalidRequest = errors.New("invalid request")
+	// ErrConflict the idempotency key is in use by another request or it was
+	// used with a different request, or an atomic batch was rolled back.
+	ErrConflict = errors.New("conflict")
+	// ErrUnavailable the service cannot answer now, the request can be
+	// retried.
+	ErrUnavailable = errors.New("service unavailable")
+	// ErrServer the service failed to process the request.
+	ErrServer = errors.New("server error")
+)
+
+// newError builds the error of a response with the given status and
+// messages, its kind depends only on the status.
+func newError(statusCode int, requestID string, messages []string) *Error {
+	newErr := Error{
+		StatusCode: statusCode,
+		Messages:   messages,
+		RequestID:  requestID,
+		kind:       kindOf(statusCode),
+	}
+
+	return &newErr
+}
+
+func kindOf(statusCode int) error {
+	switch statusCode {
+	case http.StatusNotFound:
+		return ErrNotFound
+	case http.StatusConflict, http.StatusUnprocessableEntity:
+		return ErrConflict
+	case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return ErrUnavailable
+	}
+
+	if statusCode >= http.StatusInternalServerError {
+		return ErrServer
+	}
+
+	return ErrInvalidRequest
+}
+
+func (e *Error) Error() string {
+	if len(e.Messages) == 0 {
+		return fmt.Sprintf("%s: status %d", e.kind, e.StatusCode)
+	}
+
+	return fmt.Sprintf("%s: status %d: %s", e.kind, e.StatusCode, strings.Join(e.Messages, ", "))
+}
+
+// Is says if the error is of the given kind.
+func (e *Error) Is(target error) bool {
+	return e.kind == target
+}
+
+// Retryable says if the request can be sent again.
+func (e *Error) Retryable() bool {
+	return e.kind == ErrUnavailable
+}
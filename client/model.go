@@ -0,0 +1,109 @@
+package client
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Location contains geographic coordinates in decimal degrees.
+type Location struct {
+	Latitude  float64 `json:"latitude"`
+	Longitude float64 `json:"longitude"`
+}
+
+// Pet contains pet data.
+type Pet struct {
+	ID       string    `json:"id"`
+	Name     string    `json:"name"`
+	Location *Location `json:"location,omitempty"`
+	// DeletedAt when the pet was deleted, only set for deleted pets.
+	DeletedAt *time.Time `json:"deleted_at,omitempty"`
+}
+
+// NewPet contains data to create a pet.
+type NewPet struct {
+	Name     string    `json:"name"`
+	Location *Location `json:"location,omitempty"`
+}
+
+// UpdatePet contains data to update a pet.
+type UpdatePet struct {
+	ID       string    `json:"id"`
+	Name     string    `json:"name"`
+	Location *Location `json:"location,omitempty"`
+}
+
+// QueryFilter contains filters to search pets, empty filters are not sent.
+type QueryFilter struct {
+	Name string
+	// OrderBy field used to order pets.
+	OrderBy string
+	// Page is the page to query, starting from 1.
+	Page uint8
+	// PageSize number of pets per page.
+	PageSize       uint8
+	IncludeDeleted bool
+}
+
+// SearchResult contains a page of pets.
+type SearchResult struct {
+	Pets     []Pet `json:"pets"`
+	Total    int   `json:"total"`
+	Page     uint8 `json:"page"`
+	PageSize uint8 `json:"page_size"`
+}
+
+// RejectedRow contains a row that was not imported and the reason.
+type RejectedRow struct {
+	Line   int    `json:"line"`
+	Reason string `json:"reason"`
+}
+
+// ImportReport contains the result of an import.
+type ImportReport struct {
+	Total    int           `json:"total"`
+	Imported int           `json:"imported"`
+	Rejected []RejectedRow `json:"rejected"`
+}
+
+// ImportJob contains the status of an import.
+type ImportJob struct {
+	ID     string       `json:"id"`
+	Status string       `json:"status"`
+	Report ImportReport `json:"report"`
+	// Error why the import failed, only set for failed jobs.
+	Error      string     `json:"error,omitempty"`
+	StartedAt  time.Time  `json:"started_at"`
+	FinishedAt *time.Time `json:"finished_at,omitempty"`
+}
+
+// ExportFilter contains filters to export pets.
+type ExportFilter struct {
+	// Format is csv or ndjson, csv by default.
+	Format         string
+	IncludeDeleted bool
+}
+
+// import job statuses.
+const (
+	ImportRunning   = "running"
+	ImportCompleted = "completed"
+	ImportFailed    = "failed"
+)
+
+// result is the body of every pets API response.
+type result struct {
+	Success bool            `json:"success"`
+	Data    json.RawMessage `json:"data"`
+	Errors  []string        `json:"errors"`
+}
+
+// errorResponse is the body of the requests that could not be handled.
+type errorResponse struct {
+	Message string `json:"message"`
+}
+
+// Finished says if the import job is not running anymore.
+func (i ImportJob) Finished() bool {
+	return i.Status != ImportRunning
+}
@@ -0,0 +1,162 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// RetryPolicy says how failed requests are retried. Only requests that can
+// be sent twice safely are retried: GET, PUT and DELETE requests and
+// requests with an Idempotency-Key, like Create. They are retried when the
+// service could not be reached or it answered 429, 502, 503 or 504.
+type RetryPolicy struct {
+	// MaxAttempts number of times a request is sent, requests are sent
+	// once if it is less than 2.
+	MaxAttempts int
+	// Backoff time to wait before the first retry, it is doubled on every
+	// retry.
+	Backoff time.Duration
+	// MaxBackoff maximum time to wait between retries.
+	MaxBackoff time.Duration
+}
+
+// DefaultRetryPolicy retries a request twice.
+var DefaultRetryPolicy = RetryPolicy{
+	MaxAttempts: 3,
+	Backoff:     100 * time.Millisecond,
+	MaxBackoff:  2 * time.Second,
+}
+
+// cancelBody cancels the context of an attempt once its response is read.
+type cancelBody struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+// roundTrip sends the request until it succeeds, it cannot be retried or
+// the policy attempts are used. Every attempt is limited by the client
+// timeout, until its response body is closed.
+func (c *Client) roundTrip(req *http.Request) (*http.Response, error) {
+	attempts := c.retry.MaxAttempts
+	if attempts < 1 || !retryable(req) {
+		attempts = 1
+	}
+
+	for attempt := 1; ; attempt++ {
+		resp, err := c.attempt(req)
+
+		if attempt == attempts || !shouldRetry(resp, err) {
+			return resp, err
+		}
+
+		wait := c.backoff(attempt, resp)
+
+		if resp != nil {
+			io.Copy(io.Discard, io.LimitReader(resp.Body, 1<<16))
+			resp.Body.Close()
+		}
+
+		c.logger.Debug("retrying request",
+			"error", err,
+			"method", req.Method,
+			"url", req.URL.String(),
+			"attempt", attempt,
+			"wait", wait)
+
+		select {
+		case <-req.Context().Done():
+			return nil, req.Context().Err()
+		case <-time.After(wait):
+		}
+
+		if req.Body != nil {
+			req.Body, err = req.GetBody()
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+}
+
+// attempt sends the request once with the client timeout.
+func (c *Client) attempt(req *http.Request) (*http.Response, error) {
+	if c.timeout <= 0 {
+		return c.httpClient.Do(req)
+	}
+
+	ctx, cancel := context.WithTimeout(req.Context(), c.timeout)
+
+	resp, err := c.httpClient.Do(req.WithContext(ctx))
+	if err != nil {
+		cancel()
+
+		return nil, err
+	}
+
+	resp.Body = &cancelBody{ReadCloser: resp.Body, cancel: cancel}
+
+	return resp, nil
+}
+
+// backoff returns the time to wait before the next attempt, it is the
+// Retry-After of the response if it has one.
+func (c *Client) backoff(attempt int, resp *http.Response) time.Duration {
+	if resp != nil {
+		seconds, err := strconv.Atoi(resp.Header.Get("Retry-After"))
+		if err == nil && seconds >= 0 {
+			return time.Duration(seconds) * time.Second
+		}
+	}
+
+	wait := c.retry.Backoff << (attempt - 1)
+	if wait <= 0 || (c.retry.MaxBackoff > 0 && wait > c.retry.MaxBackoff) {
+		wait = c.retry.MaxBackoff
+	}
+
+	if wait <= 0 {
+		return 0
+	}
+
+	// jitter keeps clients that failed at the same time from retrying at
+	// the same time.
+	return wait/2 + rand.N(wait/2+1)
+}
+
+// retryable says if the request can be sent again without side effects.
+func retryable(req *http.Request) bool {
+	if req.Body != nil && req.Body != http.NoBody && req.GetBody == nil {
+		return false
+	}
+
+	switch req.Method {
+	case http.MethodGet, http.MethodHead, http.MethodPut, http.MethodDelete:
+		return true
+	}
+
+	return req.Header.Get("Idempotency-Key") != ""
+}
+
+// shouldRetry says if the attempt failed for a reason that can go away.
+func shouldRetry(resp *http.Response, err error) bool {
+	if err != nil {
+		return !errors.Is(err, context.Canceled)
+	}
+
+	switch resp.StatusCode {
+	case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+
+	return false
+}
+
+func (c *cancelBody) Close() error {
+	defer c.cancel()
+
+	return c.ReadCloser.Close()
+}
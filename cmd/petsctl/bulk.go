@@ -0,0 +1,167 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/fernandoocampo/basic-micro/client"
+)
+
+// defaultPollInterval time between import job polls by default.
+const defaultPollInterval = time.Second
+
+// runImport uploads a file to import and prints the import job, with -wait
+// it polls the job until it finishes.
+func runImport(args []string) error {
+	flags, commandOptions := newFlagSet(importCommand)
+	format := flags.String("format", "", "file format, csv or ndjson. By default it is taken from the file extension")
+	wait := flags.Bool("wait", false, "wait until the import finishes")
+	pollInterval := flags.Duration("poll", defaultPollInterval, "time between import job polls with -wait")
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "usage: petsctl import [-format csv|ndjson] [-wait] [flags] <file>\n")
+		flags.PrintDefaults()
+	}
+
+	err := parseFlags(flags, args)
+	if err != nil {
+		return err
+	}
+
+	if flags.NArg() != 1 {
+		flags.Usage()
+
+		return newUsageError(errors.New("file to import was not provided"))
+	}
+
+	filename := flags.Arg(0)
+
+	importFormat, err := fileFormat(*format, filename)
+	if err != nil {
+		return newUsageError(err)
+	}
+
+	file, err := os.Open(filename)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	petsClient, err := newClient(commandOptions)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := newContext(commandOptions)
+	defer cancel()
+
+	job, err := petsClient.Import(ctx, importFormat, file)
+	if err != nil {
+		return err
+	}
+
+	if *wait {
+		job, err = waitImport(ctx, petsClient, job, *pollInterval)
+		if err != nil {
+			return err
+		}
+	}
+
+	err = printValue(commandOptions.output, job)
+	if err != nil {
+		return err
+	}
+
+	if job.Status == client.ImportFailed {
+		return fmt.Errorf("import job %s failed: %s", job.ID, job.Error)
+	}
+
+	return nil
+}
+
+// runExport writes the pets to the given file or to stdout.
+func runExport(args []string) error {
+	flags, commandOptions := newFlagSet(exportCommand)
+	format := flags.String("format", "", "file format, csv or ndjson. By default it is taken from the file extension or it is csv")
+	filename := flags.String("file", "", "file to write, stdout by default")
+	includeDeleted := flags.Bool("include-deleted", false, "export deleted pets too")
+
+	err := parseFlags(flags, args)
+	if err != nil {
+		return err
+	}
+
+	exportFormat := "csv"
+	if *format != "" || *filename != "" {
+		exportFormat, err = fileFormat(*format, *filename)
+		if err != nil {
+			return newUsageError(err)
+		}
+	}
+
+	petsClient, err := newClient(commandOptions)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := newContext(commandOptions)
+	defer cancel()
+
+	var target io.Writer = os.Stdout
+	if *filename != "" {
+		file, err := os.Create(*filename)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+
+		target = file
+	}
+
+	return petsClient.Export(ctx, client.ExportFilter{
+		Format:         exportFormat,
+		IncludeDeleted: *includeDeleted,
+	}, target)
+}
+
+// waitImport polls the import job until it finishes.
+func waitImport(ctx context.Context, petsClient *client.Client, job *client.ImportJob, pollInterval time.Duration) (*client.ImportJob, error) {
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+
+	for !job.Finished() {
+		select {
+		case <-ctx.Done():
+			return nil, fmt.Errorf("import job %s did not finish: %w", job.ID, ctx.Err())
+		case <-ticker.C:
+		}
+
+		var err error
+
+		job, err = petsClient.ImportJob(ctx, job.ID)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return job, nil
+}
+
+// fileFormat returns the given format or the one of the file extension.
+func fileFormat(format, filename string) (string, error) {
+	if format == "" {
+		format = strings.TrimPrefix(filepath.Ext(filename), ".")
+	}
+
+	switch format {
+	case "csv", "ndjson":
+		return format, nil
+	}
+
+	return "", fmt.Errorf("unsupported format %q, it must be csv or ndjson", format)
+}
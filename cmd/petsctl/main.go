@@ -0,0 +1,228 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"github.com/fernandoocampo/basic-micro/client"
+)
+
+// command is a petsctl subcommand.
+type command struct {
+	name  string
+	usage string
+	// failure is logged before the error of the command.
+	failure string
+	run     func(args []string) error
+}
+
+// usageError is returned when a command is called with wrong arguments.
+type usageError struct {
+	err error
+}
+
+// options are the flags shared by every command.
+type options struct {
+	config  string
+	profile string
+	url     string
+	token   string
+	actor   string
+	output  string
+	timeout time.Duration
+}
+
+// commands
+const (
+	createCommand  = "create"
+	getCommand     = "get"
+	updateCommand  = "update"
+	deleteCommand  = "delete"
+	searchCommand  = "search"
+	importCommand  = "import"
+	exportCommand  = "export"
+	profileCommand = "profile"
+)
+
+// exit codes
+const (
+	exitOK = 0
+	// exitFailure the command failed, like a pet that does not exist or a
+	// server that cannot be reached.
+	exitFailure = 1
+	// exitUsage the command was called with wrong arguments.
+	exitUsage = 2
+)
+
+// defaultTimeout maximum time to wait for a command by default.
+const defaultTimeout = 30 * time.Second
+
+var commands = []command{
+	{name: createCommand, usage: "create a pet", failure: "unable to create pet", run: runCreate},
+	{name: getCommand, usage: "print the pet with the given id", failure: "unable to get pet", run: runGet},
+	{name: updateCommand, usage: "update the name or location of a pet", failure: "unable to update pet", run: runUpdate},
+	{name: deleteCommand, usage: "delete the pet with the given id", failure: "unable to delete pet", run: runDelete},
+	{name: searchCommand, usage: "search pets", failure: "unable to search pets", run: runSearch},
+	{name: importCommand, usage: "import pets from a csv or ndjson file", failure: "unable to import pets", run: runImport},
+	{name: exportCommand, usage: "export pets to a csv or ndjson file", failure: "unable to export pets", run: runExport},
+	{name: profileCommand, usage: "list, set or use server profiles", failure: "unable to manage profiles", run: runProfile},
+}
+
+func main() {
+	log.SetFlags(0)
+	log.SetPrefix("petsctl: ")
+
+	os.Exit(run(os.Args[1:]))
+}
+
+// run runs the command of the arguments and returns the exit code.
+func run(args []string) int {
+	if len(args) == 0 {
+		printUsage()
+
+		return exitUsage
+	}
+
+	name, args := args[0], args[1:]
+
+	for _, command := range commands {
+		if command.name == name {
+			return exitCode(command, command.run(args))
+		}
+	}
+
+	if name == "-h" || name == "-help" || name == "help" {
+		printUsage()
+
+		return exitOK
+	}
+
+	log.Printf("unknown command %q", name)
+	printUsage()
+
+	return exitUsage
+}
+
+// exitCode logs the error of the command and returns its exit code.
+func exitCode(command command, err error) int {
+	if err == nil || errors.Is(err, flag.ErrHelp) {
+		return exitOK
+	}
+
+	log.Printf("%s: %s", command.failure, err)
+
+	var commandUsageErr *usageError
+	if errors.As(err, &commandUsageErr) {
+		return exitUsage
+	}
+
+	return exitFailure
+}
+
+func printUsage() {
+	fmt.Fprintln(os.Stderr, "usage: petsctl <command> [flags]")
+	fmt.Fprintln(os.Stderr)
+	fmt.Fprintln(os.Stderr, "commands:")
+
+	for _, command := range commands {
+		fmt.Fprintf(os.Stderr, "  %-8s %s\n", command.name, command.usage)
+	}
+}
+
+// newFlagSet creates the flags of a command with the flags shared by every
+// command.
+func newFlagSet(name string) (*flag.FlagSet, *options) {
+	var commandOptions options
+
+	flags := flag.NewFlagSet("petsctl "+name, flag.ContinueOnError)
+	flags.StringVar(&commandOptions.config, "config", os.Getenv("PETSCTL_CONFIG"), "profiles file, by default petsctl/config.yaml in the user config directory")
+	flags.StringVar(&commandOptions.profile, "profile", os.Getenv("PETSCTL_PROFILE"), "profile to use, the current profile by default")
+	flags.StringVar(&commandOptions.url, "url", "", "pets API address, it overrides the profile url")
+	flags.StringVar(&commandOptions.token, "token", "", "bearer token, it overrides the profile token")
+	flags.StringVar(&commandOptions.actor, "actor", "", "actor recorded in the pets history, it overrides the profile actor")
+	flags.StringVar(&commandOptions.output, "o", tableOutput, "output format, table, json or yaml")
+	flags.DurationVar(&commandOptions.timeout, "timeout", defaultTimeout, "maximum time to wait for the command")
+
+	return flags, &commandOptions
+}
+
+// parseFlags parses the flags of a command, wrong flags are usage errors.
+func parseFlags(flags *flag.FlagSet, args []string) error {
+	err := flags.Parse(args)
+	if err != nil && !errors.Is(err, flag.ErrHelp) {
+		return newUsageError(err)
+	}
+
+	return err
+}
+
+// newClient creates a pets client with the profile of the options, the
+// url, token and actor flags take precedence over the profile.
+func newClient(commandOptions *options) (*client.Client, error) {
+	profile, err := loadProfile(commandOptions.config, commandOptions.profile)
+	if err != nil {
+		return nil, err
+	}
+
+	if commandOptions.url != "" {
+		profile.URL = commandOptions.url
+	}
+
+	if commandOptions.token != "" {
+		profile.Token = commandOptions.token
+	}
+
+	if commandOptions.actor != "" {
+		profile.Actor = commandOptions.actor
+	}
+
+	if profile.URL == "" {
+		return nil, newUsageError(errors.New("server url was not provided, use -url or a profile"))
+	}
+
+	newClient := client.New(client.Setup{
+		BaseURL: profile.URL,
+		Token:   profile.Token,
+		Actor:   profile.Actor,
+		Retry:   client.DefaultRetryPolicy,
+	})
+
+	return newClient, nil
+}
+
+// newContext returns the context of a command limited by its timeout.
+func newContext(commandOptions *options) (context.Context, context.CancelFunc) {
+	if commandOptions.timeout <= 0 {
+		return context.WithCancel(context.Background())
+	}
+
+	return context.WithTimeout(context.Background(), commandOptions.timeout)
+}
+
+// idArg returns the only argument of the command, the pet id.
+func idArg(flags *flag.FlagSet) (string, error) {
+	if flags.NArg() != 1 {
+		flags.Usage()
+
+		return "", newUsageError(errors.New("pet id was not provided"))
+	}
+
+	return flags.Arg(0), nil
+}
+
+func newUsageError(err error) *usageError {
+	return &usageError{err: err}
+}
+
+func (u *usageError) Error() string {
+	return u.err.Error()
+}
+
+func (u *usageError) Unwrap() error {
+	return u.err
+}
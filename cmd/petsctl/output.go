@@ -0,0 +1,131 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"text/tabwriter"
+	"time"
+
+	"github.com/fernandoocampo/basic-micro/client"
+	"gopkg.in/yaml.v3"
+)
+
+// output formats
+const (
+	tableOutput = "table"
+	jsonOutput  = "json"
+	yamlOutput  = "yaml"
+)
+
+// printValue writes value to stdout in the given output format.
+func printValue(output string, value any) error {
+	switch output {
+	case tableOutput:
+		return printTable(os.Stdout, value)
+	case jsonOutput:
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+
+		return encoder.Encode(value)
+	case yamlOutput:
+		return printYAML(os.Stdout, value)
+	}
+
+	return newUsageError(fmt.Errorf("unsupported output %q, it must be table, json or yaml", output))
+}
+
+// printYAML writes value as YAML with the same field names of its JSON, so
+// every output format has the same fields.
+func printYAML(w io.Writer, value any) error {
+	content, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+
+	var generic any
+
+	err = json.Unmarshal(content, &generic)
+	if err != nil {
+		return err
+	}
+
+	encoder := yaml.NewEncoder(w)
+	encoder.SetIndent(2)
+
+	err = encoder.Encode(generic)
+	if err != nil {
+		return err
+	}
+
+	return encoder.Close()
+}
+
+// printTable writes the values that petsctl prints as aligned columns.
+func printTable(w io.Writer, value any) error {
+	table := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+
+	switch v := value.(type) {
+	case createdPet:
+		fmt.Fprintln(table, "ID")
+		fmt.Fprintln(table, v.ID)
+	case *client.Pet:
+		printPets(table, []client.Pet{*v})
+	case *client.SearchResult:
+		printPets(table, v.Pets)
+		table.Flush()
+		fmt.Fprintf(w, "\npage %d, %d pets per page, %d pets found\n", v.Page, v.PageSize, v.Total)
+	case *client.ImportJob:
+		printImportJob(table, v)
+	case []profileRow:
+		fmt.Fprintln(table, "CURRENT\tNAME\tURL\tACTOR")
+		for _, row := range v {
+			current := ""
+			if row.Current {
+				current = "*"
+			}
+			fmt.Fprintf(table, "%s\t%s\t%s\t%s\n", current, row.Name, row.URL, row.Actor)
+		}
+	default:
+		return fmt.Errorf("unable to print %T as a table", value)
+	}
+
+	return table.Flush()
+}
+
+func printPets(table *tabwriter.Writer, petsToPrint []client.Pet) {
+	fmt.Fprintln(table, "ID\tNAME\tLATITUDE\tLONGITUDE\tDELETED AT")
+
+	for _, pet := range petsToPrint {
+		latitude, longitude := "", ""
+		if pet.Location != nil {
+			latitude = strconv.FormatFloat(pet.Location.Latitude, 'f', -1, 64)
+			longitude = strconv.FormatFloat(pet.Location.Longitude, 'f', -1, 64)
+		}
+
+		deletedAt := ""
+		if pet.DeletedAt != nil {
+			deletedAt = pet.DeletedAt.Format(time.RFC3339)
+		}
+
+		fmt.Fprintf(table, "%s\t%s\t%s\t%s\t%s\n", pet.ID, pet.Name, latitude, longitude, deletedAt)
+	}
+}
+
+func printImportJob(table *tabwriter.Writer, job *client.ImportJob) {
+	fmt.Fprintln(table, "ID\tSTATUS\tTOTAL\tIMPORTED\tREJECTED\tERROR")
+	fmt.Fprintf(table, "%s\t%s\t%d\t%d\t%d\t%s\n", job.ID, job.Status, job.Report.Total, job.Report.Imported, len(job.Report.Rejected), job.Error)
+
+	if len(job.Report.Rejected) == 0 {
+		return
+	}
+
+	fmt.Fprintln(table)
+	fmt.Fprintln(table, "LINE\tREASON")
+
+	for _, row := range job.Report.Rejected {
+		fmt.Fprintf(table, "%d\t%s\n", row.Line, row.Reason)
+	}
+}
@@ -0,0 +1,265 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/fernandoocampo/basic-micro/client"
+)
+
+// createdPet is the output of the create command.
+type createdPet struct {
+	ID string `json:"id"`
+}
+
+// runCreate creates a pet and prints its id.
+func runCreate(args []string) error {
+	flags, commandOptions := newFlagSet(createCommand)
+	name := flags.String("name", "", "pet's name")
+	latitude := flags.Float64("lat", 0, "latitude of the pet location")
+	longitude := flags.Float64("lon", 0, "longitude of the pet location")
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "usage: petsctl create -name <name> [-lat <latitude> -lon <longitude>] [flags]\n")
+		flags.PrintDefaults()
+	}
+
+	err := parseFlags(flags, args)
+	if err != nil {
+		return err
+	}
+
+	if *name == "" {
+		flags.Usage()
+
+		return newUsageError(errors.New("name was not provided"))
+	}
+
+	location, err := locationFlags(flags, *latitude, *longitude)
+	if err != nil {
+		return err
+	}
+
+	petsClient, err := newClient(commandOptions)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := newContext(commandOptions)
+	defer cancel()
+
+	id, err := petsClient.Create(ctx, client.NewPet{
+		Name:     *name,
+		Location: location,
+	})
+	if err != nil {
+		return err
+	}
+
+	return printValue(commandOptions.output, createdPet{ID: id})
+}
+
+// runGet prints the pet with the given id.
+func runGet(args []string) error {
+	flags, commandOptions := newFlagSet(getCommand)
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "usage: petsctl get [flags] <id>\n")
+		flags.PrintDefaults()
+	}
+
+	err := parseFlags(flags, args)
+	if err != nil {
+		return err
+	}
+
+	id, err := idArg(flags)
+	if err != nil {
+		return err
+	}
+
+	petsClient, err := newClient(commandOptions)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := newContext(commandOptions)
+	defer cancel()
+
+	pet, err := petsClient.QueryByID(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	return printValue(commandOptions.output, pet)
+}
+
+// runUpdate updates the given fields of a pet, the fields that are not
+// given keep their current value.
+func runUpdate(args []string) error {
+	flags, commandOptions := newFlagSet(updateCommand)
+	name := flags.String("name", "", "new pet's name")
+	latitude := flags.Float64("lat", 0, "latitude of the new pet location")
+	longitude := flags.Float64("lon", 0, "longitude of the new pet location")
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "usage: petsctl update [-name <name>] [-lat <latitude> -lon <longitude>] [flags] <id>\n")
+		flags.PrintDefaults()
+	}
+
+	err := parseFlags(flags, args)
+	if err != nil {
+		return err
+	}
+
+	id, err := idArg(flags)
+	if err != nil {
+		return err
+	}
+
+	location, err := locationFlags(flags, *latitude, *longitude)
+	if err != nil {
+		return err
+	}
+
+	if *name == "" && location == nil {
+		flags.Usage()
+
+		return newUsageError(errors.New("name or location must be provided"))
+	}
+
+	petsClient, err := newClient(commandOptions)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := newContext(commandOptions)
+	defer cancel()
+
+	pet, err := petsClient.QueryByID(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	updatePet := client.UpdatePet{
+		ID:       pet.ID,
+		Name:     pet.Name,
+		Location: pet.Location,
+	}
+
+	if *name != "" {
+		updatePet.Name = *name
+	}
+
+	if location != nil {
+		updatePet.Location = location
+	}
+
+	err = petsClient.Update(ctx, updatePet)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(os.Stderr, "pet %s was updated\n", id)
+
+	return nil
+}
+
+// runDelete deletes the pet with the given id.
+func runDelete(args []string) error {
+	flags, commandOptions := newFlagSet(deleteCommand)
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "usage: petsctl delete [flags] <id>\n")
+		flags.PrintDefaults()
+	}
+
+	err := parseFlags(flags, args)
+	if err != nil {
+		return err
+	}
+
+	id, err := idArg(flags)
+	if err != nil {
+		return err
+	}
+
+	petsClient, err := newClient(commandOptions)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := newContext(commandOptions)
+	defer cancel()
+
+	err = petsClient.Delete(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(os.Stderr, "pet %s was deleted\n", id)
+
+	return nil
+}
+
+// runSearch prints a page of the pets that match the filters.
+func runSearch(args []string) error {
+	flags, commandOptions := newFlagSet(searchCommand)
+	name := flags.String("name", "", "pet's name to search")
+	orderBy := flags.String("orderby", "", "field to order pets, Name by default")
+	page := flags.Uint("page", 0, "page to query, starting from 1")
+	pageSize := flags.Uint("pagesize", 0, "pets per page")
+	includeDeleted := flags.Bool("include-deleted", false, "search deleted pets too")
+
+	err := parseFlags(flags, args)
+	if err != nil {
+		return err
+	}
+
+	if *page > 255 || *pageSize > 255 {
+		return newUsageError(errors.New("page and page size must be less than 256"))
+	}
+
+	petsClient, err := newClient(commandOptions)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := newContext(commandOptions)
+	defer cancel()
+
+	result, err := petsClient.Query(ctx, client.QueryFilter{
+		Name:           *name,
+		OrderBy:        *orderBy,
+		Page:           uint8(*page),
+		PageSize:       uint8(*pageSize),
+		IncludeDeleted: *includeDeleted,
+	})
+	if err != nil {
+		return err
+	}
+
+	return printValue(commandOptions.output, result)
+}
+
+// locationFlags returns the location of the lat and lon flags, both of them
+// must be given or none.
+func locationFlags(flags *flag.FlagSet, latitude, longitude float64) (*client.Location, error) {
+	given := map[string]bool{}
+	flags.Visit(func(f *flag.Flag) {
+		given[f.Name] = true
+	})
+
+	if !given["lat"] && !given["lon"] {
+		return nil, nil
+	}
+
+	if given["lat"] != given["lon"] {
+		return nil, newUsageError(errors.New("lat and lon must be provided together"))
+	}
+
+	location := client.Location{
+		Latitude:  latitude,
+		Longitude: longitude,
+	}
+
+	return &location, nil
+}
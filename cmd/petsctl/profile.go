@@ -0,0 +1,278 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"gopkg.in/yaml.v3"
+)
+
+// profile contains the server and credentials used by petsctl.
+type profile struct {
+	URL   string `yaml:"url"`
+	Token string `yaml:"token,omitempty"`
+	Actor string `yaml:"actor,omitempty"`
+}
+
+// profiles is the content of the profiles file.
+type profiles struct {
+	// Current profile used when no profile is given.
+	Current  string             `yaml:"current"`
+	Profiles map[string]profile `yaml:"profiles"`
+}
+
+// profileRow is a profile printed by the profile list command, tokens are
+// never printed.
+type profileRow struct {
+	Name    string `json:"name"`
+	URL     string `json:"url"`
+	Actor   string `json:"actor,omitempty"`
+	Current bool   `json:"current"`
+}
+
+// profile subcommands
+const (
+	profileList = "list"
+	profileSet  = "set"
+	profileUse  = "use"
+)
+
+// profilesFileMode profiles contain tokens, so only the owner can read them.
+const profilesFileMode = 0o600
+
+var errProfileNotFound = errors.New("profile not found")
+
+// runProfile lists, sets or selects the profiles of the profiles file.
+func runProfile(args []string) error {
+	if len(args) == 0 {
+		return newUsageError(errors.New("profile subcommand was not provided, it must be list, set or use"))
+	}
+
+	subcommand, args := args[0], args[1:]
+
+	switch subcommand {
+	case profileList:
+		return runProfileList(args)
+	case profileSet:
+		return runProfileSet(args)
+	case profileUse:
+		return runProfileUse(args)
+	}
+
+	return newUsageError(fmt.Errorf("unknown profile subcommand %q, it must be list, set or use", subcommand))
+}
+
+func runProfileList(args []string) error {
+	flags, commandOptions := newFlagSet(profileCommand + " " + profileList)
+
+	err := parseFlags(flags, args)
+	if err != nil {
+		return err
+	}
+
+	filename, err := profilesFilename(commandOptions.config)
+	if err != nil {
+		return err
+	}
+
+	savedProfiles, err := readProfiles(filename)
+	if err != nil {
+		return err
+	}
+
+	names := make([]string, 0, len(savedProfiles.Profiles))
+	for name := range savedProfiles.Profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	rows := make([]profileRow, 0, len(names))
+	for _, name := range names {
+		rows = append(rows, profileRow{
+			Name:    name,
+			URL:     savedProfiles.Profiles[name].URL,
+			Actor:   savedProfiles.Profiles[name].Actor,
+			Current: name == savedProfiles.Current,
+		})
+	}
+
+	return printValue(commandOptions.output, rows)
+}
+
+// runProfileSet creates or updates a profile with the url, token and actor
+// flags, the first profile becomes the current one.
+func runProfileSet(args []string) error {
+	flags, commandOptions := newFlagSet(profileCommand + " " + profileSet)
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "usage: petsctl profile set [-url <url>] [-token <token>] [-actor <actor>] [flags] <name>\n")
+		flags.PrintDefaults()
+	}
+
+	err := parseFlags(flags, args)
+	if err != nil {
+		return err
+	}
+
+	if flags.NArg() != 1 {
+		flags.Usage()
+
+		return newUsageError(errors.New("profile name was not provided"))
+	}
+
+	name := flags.Arg(0)
+
+	filename, err := profilesFilename(commandOptions.config)
+	if err != nil {
+		return err
+	}
+
+	savedProfiles, err := readProfiles(filename)
+	if err != nil {
+		return err
+	}
+
+	newProfile := savedProfiles.Profiles[name]
+	if commandOptions.url != "" {
+		newProfile.URL = commandOptions.url
+	}
+	if commandOptions.token != "" {
+		newProfile.Token = commandOptions.token
+	}
+	if commandOptions.actor != "" {
+		newProfile.Actor = commandOptions.actor
+	}
+
+	savedProfiles.Profiles[name] = newProfile
+	if savedProfiles.Current == "" {
+		savedProfiles.Current = name
+	}
+
+	return writeProfiles(filename, savedProfiles)
+}
+
+// runProfileUse makes the given profile the current one.
+func runProfileUse(args []string) error {
+	flags, commandOptions := newFlagSet(profileCommand + " " + profileUse)
+
+	err := parseFlags(flags, args)
+	if err != nil {
+		return err
+	}
+
+	if flags.NArg() != 1 {
+		return newUsageError(errors.New("profile name was not provided"))
+	}
+
+	name := flags.Arg(0)
+
+	filename, err := profilesFilename(commandOptions.config)
+	if err != nil {
+		return err
+	}
+
+	savedProfiles, err := readProfiles(filename)
+	if err != nil {
+		return err
+	}
+
+	if _, ok := savedProfiles.Profiles[name]; !ok {
+		return fmt.Errorf("%w: %s", errProfileNotFound, name)
+	}
+
+	savedProfiles.Current = name
+
+	return writeProfiles(filename, savedProfiles)
+}
+
+// loadProfile returns the profile with the given name or the current one.
+// Without profiles file an empty profile is returned, unless a profile name
+// is given.
+func loadProfile(config, name string) (profile, error) {
+	filename, err := profilesFilename(config)
+	if err != nil {
+		return profile{}, err
+	}
+
+	savedProfiles, err := readProfiles(filename)
+	if err != nil {
+		return profile{}, err
+	}
+
+	if name == "" {
+		name = savedProfiles.Current
+	}
+
+	if name == "" {
+		return profile{}, nil
+	}
+
+	selected, ok := savedProfiles.Profiles[name]
+	if !ok {
+		return profile{}, fmt.Errorf("%w: %s", errProfileNotFound, name)
+	}
+
+	return selected, nil
+}
+
+// profilesFilename returns the given profiles file or the default one.
+func profilesFilename(config string) (string, error) {
+	if config != "" {
+		return config, nil
+	}
+
+	configDir, err := os.UserConfigDir()
+	if err != nil {
+		return "", fmt.Errorf("unable to find user config directory: %w", err)
+	}
+
+	return filepath.Join(configDir, "petsctl", "config.yaml"), nil
+}
+
+// readProfiles reads the profiles file, a missing file has no profiles.
+func readProfiles(filename string) (*profiles, error) {
+	savedProfiles := profiles{
+		Profiles: map[string]profile{},
+	}
+
+	content, err := os.ReadFile(filename)
+	if errors.Is(err, fs.ErrNotExist) {
+		return &savedProfiles, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("unable to read profiles: %w", err)
+	}
+
+	err = yaml.Unmarshal(content, &savedProfiles)
+	if err != nil {
+		return nil, fmt.Errorf("unable to parse profiles file %s: %w", filename, err)
+	}
+
+	if savedProfiles.Profiles == nil {
+		savedProfiles.Profiles = map[string]profile{}
+	}
+
+	return &savedProfiles, nil
+}
+
+func writeProfiles(filename string, savedProfiles *profiles) error {
+	content, err := yaml.Marshal(savedProfiles)
+	if err != nil {
+		return fmt.Errorf("unable to encode profiles: %w", err)
+	}
+
+	err = os.MkdirAll(filepath.Dir(filename), 0o700)
+	if err != nil {
+		return fmt.Errorf("unable to create profiles directory: %w", err)
+	}
+
+	err = os.WriteFile(filename, content, profilesFileMode)
+	if err != nil {
+		return fmt.Errorf("unable to write profiles: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,110 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/fernandoocampo/basic-micro/internal/adapter/bulk"
+	"github.com/fernandoocampo/basic-micro/internal/pets"
+)
+
+// runImport imports the pets of the given file and writes the report to stdout.
+func runImport(args []string) error {
+	flags := newFlagSet(importCommand)
+	format := flags.String("format", "", "file format, csv or ndjson. By default it is taken from the file extension")
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "usage: petsd import [-format csv|ndjson] [flags] <file>\n")
+		flags.PrintDefaults()
+	}
+
+	err := parseFlags(flags, args)
+	if err != nil {
+		return err
+	}
+
+	if flags.NArg() != 1 {
+		flags.Usage()
+
+		return newUsageError(errors.New("file to import was not provided"))
+	}
+
+	filename := flags.Arg(0)
+
+	importFormat, err := fileFormat(*format, filename)
+	if err != nil {
+		return newUsageError(err)
+	}
+
+	file, err := os.Open(filename)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	reader, err := bulk.NewReader(importFormat, file)
+	if err != nil {
+		return err
+	}
+
+	report, err := newApplication(flags).Import(context.Background(), reader)
+	if err != nil {
+		return err
+	}
+
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+
+	return encoder.Encode(report)
+}
+
+// runExport writes all pets to the given file or to stdout.
+func runExport(args []string) error {
+	flags := newFlagSet(exportCommand)
+	format := flags.String("format", "", "file format, csv, ndjson or parquet. By default it is taken from the output extension or it is csv")
+	output := flags.String("o", "", "file to write, stdout by default")
+	includeDeleted := flags.Bool("include-deleted", false, "export deleted pets too")
+
+	err := parseFlags(flags, args)
+	if err != nil {
+		return err
+	}
+
+	exportFormat := bulk.CSV
+	if *format != "" || *output != "" {
+		exportFormat, err = fileFormat(*format, *output)
+		if err != nil {
+			return newUsageError(err)
+		}
+	}
+
+	file := os.Stdout
+	if *output != "" {
+		file, err = os.Create(*output)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+	}
+
+	writer, err := bulk.NewWriter(exportFormat, file)
+	if err != nil {
+		return err
+	}
+
+	filter := pets.ExportFilter{
+		IncludeDeleted: *includeDeleted,
+	}
+
+	exported, err := newApplication(flags).Export(context.Background(), filter, writer)
+	if err != nil {
+		return err
+	}
+
+	log.Printf("%d pets were exported", exported)
+
+	return nil
+}
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/fernandoocampo/basic-micro/internal/setups"
+)
+
+// runConfig runs the config subcommands, print writes the merged
+// configuration with the source of each value.
+func runConfig(args []string) error {
+	if len(args) == 0 || args[0] != printCommand {
+		return newUsageError(fmt.Errorf("usage: petsd %s %s [flags]", configCommand, printCommand))
+	}
+
+	flags := newFlagSet(configCommand + " " + printCommand)
+
+	err := parseFlags(flags, args[1:])
+	if err != nil {
+		return err
+	}
+
+	configuration, err := setups.LoadFrom(setups.Sources{Flags: flags})
+
+	var validationErr *setups.ValidationError
+	if err != nil && !errors.As(err, &validationErr) {
+		return err
+	}
+
+	writer := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(writer, "NAME\tVALUE\tSOURCE")
+
+	for _, value := range configuration.Values() {
+		fmt.Fprintf(writer, "%s\t%s\t%s\n", value.Name, value.Redacted(), value.Source)
+	}
+
+	flushErr := writer.Flush()
+	if flushErr != nil {
+		return flushErr
+	}
+
+	return err
+}
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+)
+
+// runMigrate applies the database migrations and writes the applied ones
+// to stdout.
+func runMigrate(args []string) error {
+	flags := newFlagSet(migrateCommand)
+
+	err := parseFlags(flags, args)
+	if err != nil {
+		return err
+	}
+
+	applied, err := newApplication(flags).Migrate(context.Background())
+	if err != nil {
+		return err
+	}
+
+	for _, migration := range applied {
+		fmt.Println(migration)
+	}
+
+	log.Printf("%d migrations were applied", len(applied))
+
+	return nil
+}
+
+// runSeed creates the sample pets.
+func runSeed(args []string) error {
+	flags := newFlagSet(seedCommand)
+
+	err := parseFlags(flags, args)
+	if err != nil {
+		return err
+	}
+
+	created, err := newApplication(flags).Seed(context.Background())
+	if err != nil {
+		return err
+	}
+
+	log.Printf("%d pets were created", created)
+
+	return nil
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/fernandoocampo/basic-micro/internal/setups"
+)
+
+// probes of the healthcheck command by name.
+var probePaths = map[string]string{
+	"live":    "/healthz/live",
+	"ready":   "/healthz/ready",
+	"startup": "/healthz/startup",
+}
+
+// runHealthcheck calls a probe of the service running on the configured
+// port, it fails if the probe does not pass. Containers without curl, like
+// distroless ones, can use it as their HEALTHCHECK.
+func runHealthcheck(args []string) error {
+	flags := newFlagSet(healthcheckCommand)
+	probe := flags.String("probe", "ready", "probe to check, live, ready or startup")
+	url := flags.String("url", "", "url of the probe, by default it is taken from the probe and APPLICATION_PORT")
+	timeout := flags.Duration("timeout", 3*time.Second, "how long the probe can take")
+
+	err := parseFlags(flags, args)
+	if err != nil {
+		return err
+	}
+
+	probePath, ok := probePaths[*probe]
+	if !ok {
+		return newUsageError(fmt.Errorf("unknown probe %q, it must be live, ready or startup", *probe))
+	}
+
+	if *url == "" {
+		// the probe only needs the port, so an otherwise invalid
+		// configuration is accepted.
+		configuration, err := setups.LoadFrom(setups.Sources{Flags: flags})
+
+		var validationErr *setups.ValidationError
+		if err != nil && !errors.As(err, &validationErr) {
+			return err
+		}
+
+		*url, err = probeURL(configuration.Application.ApplicationPort, probePath)
+		if err != nil {
+			return err
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), *timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, *url, nil)
+	if err != nil {
+		return err
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s probe answered %s", *probe, resp.Status)
+	}
+
+	return nil
+}
+
+// probeURL returns the url of the probe in the local service listening on
+// address, like :8080.
+func probeURL(address, probePath string) (string, error) {
+	host, port, err := net.SplitHostPort(address)
+	if err != nil {
+		return "", fmt.Errorf("invalid application port %q: %w", address, err)
+	}
+
+	if host == "" || net.ParseIP(host).IsUnspecified() {
+		host = "127.0.0.1"
+	}
+
+	return "http://" + net.JoinHostPort(host, port) + probePath, nil
+}
@@ -1,20 +1,164 @@
 package main
 
 import (
+	"errors"
+	"flag"
+	"fmt"
 	"log"
 	"os"
-	"time"
 
+	"github.com/fernandoocampo/basic-micro/internal/adapter/bulk"
 	"github.com/fernandoocampo/basic-micro/internal/application"
+	"github.com/fernandoocampo/basic-micro/internal/setups"
 )
 
+// command is a petsd subcommand.
+type command struct {
+	name  string
+	usage string
+	// failure is logged before the error of the command.
+	failure string
+	run     func(args []string) error
+}
+
+// usageError is returned when a command is called with wrong arguments.
+type usageError struct {
+	err error
+}
+
+// commands
+const (
+	serveCommand       = "serve"
+	migrateCommand     = "migrate"
+	seedCommand        = "seed"
+	importCommand      = "import"
+	exportCommand      = "export"
+	configCommand      = "config"
+	versionCommand     = "version"
+	healthcheckCommand = "healthcheck"
+	printCommand       = "print"
+)
+
+// exit codes
+const (
+	exitOK = 0
+	// exitFailure the command failed, like an unhealthy service or a
+	// database that cannot be reached.
+	exitFailure = 1
+	// exitUsage the command was called with wrong arguments.
+	exitUsage = 2
+)
+
+var commands = []command{
+	{name: serveCommand, usage: "start the service, it is the default command", failure: "unable to start service", run: runServe},
+	{name: migrateCommand, usage: "apply the database migrations", failure: "unable to migrate database", run: runMigrate},
+	{name: seedCommand, usage: "create sample pets for test environments", failure: "unable to seed pets", run: runSeed},
+	{name: importCommand, usage: "import pets from a csv or ndjson file", failure: "unable to import pets", run: runImport},
+	{name: exportCommand, usage: "export pets to a csv, ndjson or parquet file", failure: "unable to export pets", run: runExport},
+	{name: configCommand, usage: "print the configuration and the source of each value", failure: "unable to load configuration", run: runConfig},
+	{name: versionCommand, usage: "print the version of the service", failure: "unable to print version", run: runVersion},
+	{name: healthcheckCommand, usage: "check a probe of a running service, for container health checks", failure: "service is not healthy", run: runHealthcheck},
+}
+
 func main() {
-	log.Println("starting application")
+	os.Exit(run(os.Args[1:]))
+}
+
+// run runs the command of the arguments and returns the exit code. Without
+// a command, or with flags only, the service is started.
+func run(args []string) int {
+	name := serveCommand
+	if len(args) > 0 && !isFlag(args[0]) {
+		name, args = args[0], args[1:]
+	}
+
+	for _, command := range commands {
+		if command.name == name {
+			return exitCode(command, command.run(args))
+		}
+	}
+
+	log.Printf("unknown command %q", name)
+	printUsage()
+
+	return exitUsage
+}
+
+// exitCode logs the error of the command and returns its exit code.
+func exitCode(command command, err error) int {
+	if err == nil || errors.Is(err, flag.ErrHelp) {
+		return exitOK
+	}
+
+	log.Printf("%s: %s", command.failure, err)
+
+	var commandUsageErr *usageError
+	if errors.As(err, &commandUsageErr) {
+		return exitUsage
+	}
+
+	return exitFailure
+}
+
+func printUsage() {
+	fmt.Fprintln(os.Stderr, "usage: petsd <command> [flags]")
+	fmt.Fprintln(os.Stderr)
+	fmt.Fprintln(os.Stderr, "commands:")
+
+	for _, command := range commands {
+		fmt.Fprintf(os.Stderr, "  %-12s %s\n", command.name, command.usage)
+	}
+}
+
+// newFlagSet creates the flags of a command with the configuration flags.
+func newFlagSet(name string) *flag.FlagSet {
+	flags := flag.NewFlagSet("petsd "+name, flag.ContinueOnError)
+	setups.RegisterFlags(flags)
+
+	return flags
+}
+
+// parseFlags parses the flags of a command, wrong flags are usage errors.
+func parseFlags(flags *flag.FlagSet, args []string) error {
+	err := flags.Parse(args)
+	if err != nil && !errors.Is(err, flag.ErrHelp) {
+		return newUsageError(err)
+	}
+
+	return err
+}
 
-	if err := application.New().Run(); err != nil {
-		log.Printf("unable to start service: %s", err)
-		os.Exit(-1)
+// newApplication creates the application with the configuration flags of
+// the command.
+func newApplication(flags *flag.FlagSet) *application.Server {
+	sources := setups.Sources{
+		Flags: flags,
 	}
 
-	log.Println("finishing application", time.Now())
+	return application.New().WithConfigSources(sources)
+}
+
+// fileFormat returns the given format or the one of the file extension.
+func fileFormat(format, filename string) (bulk.Format, error) {
+	if format != "" {
+		return bulk.ParseFormat(format)
+	}
+
+	return bulk.FormatFromFilename(filename)
+}
+
+func isFlag(arg string) bool {
+	return len(arg) > 1 && arg[0] == '-'
+}
+
+func newUsageError(err error) *usageError {
+	return &usageError{err: err}
+}
+
+func (u *usageError) Error() string {
+	return u.err.Error()
+}
+
+func (u *usageError) Unwrap() error {
+	return u.err
 }
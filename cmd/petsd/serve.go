@@ -0,0 +1,27 @@
+package main
+
+import (
+	"log"
+	"time"
+)
+
+// runServe starts the service until it is stopped.
+func runServe(args []string) error {
+	flags := newFlagSet(serveCommand)
+
+	err := parseFlags(flags, args)
+	if err != nil {
+		return err
+	}
+
+	log.Println("starting application")
+
+	err = newApplication(flags).Run()
+	if err != nil {
+		return err
+	}
+
+	log.Println("finishing application", time.Now())
+
+	return nil
+}
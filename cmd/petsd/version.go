@@ -0,0 +1,45 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"runtime"
+
+	"github.com/fernandoocampo/basic-micro/internal/application"
+)
+
+// versionInfo is the build metadata of the binary.
+type versionInfo struct {
+	Version    string `json:"version"`
+	CommitHash string `json:"commit_hash"`
+	BuildDate  string `json:"build_date"`
+	GoVersion  string `json:"go_version"`
+}
+
+// runVersion writes the build metadata to stdout.
+func runVersion(args []string) error {
+	flags := flag.NewFlagSet("petsd "+versionCommand, flag.ContinueOnError)
+	asJSON := flags.Bool("json", false, "write the version as json")
+
+	err := parseFlags(flags, args)
+	if err != nil {
+		return err
+	}
+
+	info := versionInfo{
+		Version:    application.Version,
+		CommitHash: application.CommitHash,
+		BuildDate:  application.BuildDate,
+		GoVersion:  runtime.Version(),
+	}
+
+	if *asJSON {
+		return json.NewEncoder(os.Stdout).Encode(info)
+	}
+
+	fmt.Printf("petsd %s (commit %s, built %s, %s)\n", info.Version, info.CommitHash, info.BuildDate, info.GoVersion)
+
+	return nil
+}
@@ -0,0 +1,12 @@
+// Package docs contains the documentation of the pets API.
+package docs
+
+import (
+	_ "embed"
+)
+
+// OpenAPI is the OpenAPI document of the pets HTTP API, it is maintained by
+// hand, so routes are compared with it in the application tests.
+//
+//go:embed application.yml
+var OpenAPI []byte
@@ -0,0 +1,2 @@
+// Package bulk reads and writes pets in bulk formats like CSV and NDJSON.
+package bulk
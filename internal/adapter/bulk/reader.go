@@ -0,0 +1,240 @@
+package bulk
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/fernandoocampo/basic-micro/internal/pets"
+)
+
+// Format defines a bulk format.
+type Format string
+
+// supported formats
+const (
+	CSV     Format = "csv"
+	NDJSON  Format = "ndjson"
+	Parquet Format = "parquet"
+)
+
+// csv columns
+const (
+	idColumn        = "id"
+	nameColumn      = "name"
+	latitudeColumn  = "latitude"
+	longitudeColumn = "longitude"
+	deletedAtColumn = "deleted_at"
+)
+
+// maxNDJSONLineSize is the size of the longest NDJSON line that can be read.
+const maxNDJSONLineSize = 1024 * 1024
+
+// CSVReader reads pets from CSV with a header row, name column is
+// required, latitude and longitude are optional.
+type CSVReader struct {
+	reader  *csv.Reader
+	columns map[string]int
+}
+
+// NDJSONReader reads pets from newline delimited JSON, one pet per line.
+type NDJSONReader struct {
+	scanner *bufio.Scanner
+	line    int
+}
+
+// ndjsonPet is the data of every NDJSON line, id and deleted_at are
+// ignored by imports.
+type ndjsonPet struct {
+	ID        string         `json:"id,omitempty"`
+	Name      string         `json:"name"`
+	Location  *pets.Location `json:"location,omitempty"`
+	DeletedAt *time.Time     `json:"deleted_at,omitempty"`
+}
+
+var (
+	errUnknownFormat = errors.New("unknown format, it must be csv, ndjson or parquet")
+	errNotReadable   = errors.New("pets can only be read from csv or ndjson")
+	errMissingName   = errors.New("csv header must have a name column")
+)
+
+// ParseFormat returns the format with the given name.
+func ParseFormat(name string) (Format, error) {
+	switch Format(strings.ToLower(name)) {
+	case CSV:
+		return CSV, nil
+	case NDJSON, "jsonl":
+		return NDJSON, nil
+	case Parquet:
+		return Parquet, nil
+	}
+
+	return "", errUnknownFormat
+}
+
+// FormatFromFilename returns the format of the file based on its extension.
+func FormatFromFilename(filename string) (Format, error) {
+	return ParseFormat(strings.TrimPrefix(filepath.Ext(filename), "."))
+}
+
+// NewReader creates a reader of pets in the given format.
+func NewReader(format Format, r io.Reader) (pets.ImportReader, error) {
+	switch format {
+	case CSV:
+		return NewCSVReader(r)
+	case NDJSON:
+		return NewNDJSONReader(r), nil
+	}
+
+	return nil, errNotReadable
+}
+
+// NewCSVReader creates a CSV reader and reads the header row.
+func NewCSVReader(r io.Reader) (*CSVReader, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("unable to read csv header: %w", err)
+	}
+
+	columns := make(map[string]int, len(header))
+	for index, column := range header {
+		columns[strings.ToLower(strings.TrimSpace(column))] = index
+	}
+
+	if _, ok := columns[nameColumn]; !ok {
+		return nil, errMissingName
+	}
+
+	newReader := CSVReader{
+		reader:  reader,
+		columns: columns,
+	}
+
+	return &newReader, nil
+}
+
+// NewNDJSONReader creates a NDJSON reader.
+func NewNDJSONReader(r io.Reader) *NDJSONReader {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, bufio.MaxScanTokenSize), maxNDJSONLineSize)
+
+	newReader := NDJSONReader{
+		scanner: scanner,
+	}
+
+	return &newReader
+}
+
+// Next reads the next CSV row, rows that cannot be parsed are returned
+// with an error so they are rejected.
+func (c *CSVReader) Next() (pets.ImportRow, error) {
+	record, err := c.reader.Read()
+	if errors.Is(err, io.EOF) {
+		return pets.ImportRow{}, io.EOF
+	}
+
+	var parseError *csv.ParseError
+	if errors.As(err, &parseError) {
+		return pets.ImportRow{Line: parseError.Line, Err: parseError.Err}, nil
+	}
+
+	if err != nil {
+		return pets.ImportRow{}, err
+	}
+
+	line, _ := c.reader.FieldPos(0)
+	row := pets.ImportRow{
+		Line: line,
+		NewPet: pets.NewPet{
+			Name: c.value(record, nameColumn),
+		},
+	}
+
+	row.NewPet.Location, row.Err = parseLocation(
+		c.value(record, latitudeColumn),
+		c.value(record, longitudeColumn),
+	)
+
+	return row, nil
+}
+
+func (c *CSVReader) value(record []string, column string) string {
+	index, ok := c.columns[column]
+	if !ok || index >= len(record) {
+		return ""
+	}
+
+	return strings.TrimSpace(record[index])
+}
+
+// Next reads the next NDJSON line, blank lines are skipped.
+func (n *NDJSONReader) Next() (pets.ImportRow, error) {
+	for n.scanner.Scan() {
+		n.line++
+
+		content := strings.TrimSpace(n.scanner.Text())
+		if content == "" {
+			continue
+		}
+
+		var pet ndjsonPet
+		err := json.Unmarshal([]byte(content), &pet)
+		if err != nil {
+			return pets.ImportRow{Line: n.line, Err: fmt.Errorf("invalid json: %w", err)}, nil
+		}
+
+		row := pets.ImportRow{
+			Line: n.line,
+			NewPet: pets.NewPet{
+				Name:     pet.Name,
+				Location: pet.Location,
+			},
+		}
+
+		return row, nil
+	}
+
+	if err := n.scanner.Err(); err != nil {
+		return pets.ImportRow{}, err
+	}
+
+	return pets.ImportRow{}, io.EOF
+}
+
+func parseLocation(latitude, longitude string) (*pets.Location, error) {
+	if latitude == "" && longitude == "" {
+		return nil, nil
+	}
+
+	if latitude == "" || longitude == "" {
+		return nil, errors.New("latitude and longitude must be given together")
+	}
+
+	parsedLatitude, err := strconv.ParseFloat(latitude, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid latitude %q", latitude)
+	}
+
+	parsedLongitude, err := strconv.ParseFloat(longitude, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid longitude %q", longitude)
+	}
+
+	location := pets.Location{
+		Latitude:  parsedLatitude,
+		Longitude: parsedLongitude,
+	}
+
+	return &location, nil
+}
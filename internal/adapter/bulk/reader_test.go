@@ -0,0 +1,104 @@
+package bulk_test
+
+import (
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/fernandoocampo/basic-micro/internal/adapter/bulk"
+	"github.com/fernandoocampo/basic-micro/internal/pets"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCSVReader(t *testing.T) {
+	// Given
+	givenCSV := "name,latitude,longitude\n" +
+		"drila,4.711,-74.0721\n" +
+		"michael,,\n" +
+		"lucky,north,-74.0721\n"
+	expectedRows := []pets.ImportRow{
+		{Line: 2, NewPet: pets.NewPet{Name: "drila", Location: &pets.Location{Latitude: 4.711, Longitude: -74.0721}}},
+		{Line: 3, NewPet: pets.NewPet{Name: "michael"}},
+	}
+
+	reader, err := bulk.NewReader(bulk.CSV, strings.NewReader(givenCSV))
+	assert.NoError(t, err)
+
+	// When
+	got := readAll(t, reader)
+
+	// Then
+	assert.Len(t, got, 3)
+	assert.Equal(t, expectedRows, got[:2])
+	assert.Equal(t, 4, got[2].Line)
+	assert.EqualError(t, got[2].Err, `invalid latitude "north"`)
+}
+
+func TestCSVReaderWithoutNameColumn(t *testing.T) {
+	// When
+	_, err := bulk.NewReader(bulk.CSV, strings.NewReader("latitude,longitude\n4.711,-74.0721\n"))
+
+	// Then
+	assert.EqualError(t, err, "csv header must have a name column")
+}
+
+func TestNDJSONReader(t *testing.T) {
+	// Given
+	givenNDJSON := `{"name":"drila","location":{"latitude":4.711,"longitude":-74.0721}}` + "\n" +
+		"\n" +
+		`{"name":` + "\n" +
+		`{"name":"michael"}` + "\n"
+	expectedRows := []pets.ImportRow{
+		{Line: 1, NewPet: pets.NewPet{Name: "drila", Location: &pets.Location{Latitude: 4.711, Longitude: -74.0721}}},
+		{Line: 4, NewPet: pets.NewPet{Name: "michael"}},
+	}
+
+	reader, err := bulk.NewReader(bulk.NDJSON, strings.NewReader(givenNDJSON))
+	assert.NoError(t, err)
+
+	// When
+	got := readAll(t, reader)
+
+	// Then
+	assert.Len(t, got, 3)
+	assert.Equal(t, 3, got[1].Line)
+	assert.Error(t, got[1].Err)
+	assert.Equal(t, expectedRows, []pets.ImportRow{got[0], got[2]})
+}
+
+func TestParseFormat(t *testing.T) {
+	cases := map[string]struct {
+		given string
+		want  bulk.Format
+		err   bool
+	}{
+		"csv":    {given: "CSV", want: bulk.CSV},
+		"ndjson": {given: "ndjson", want: bulk.NDJSON},
+		"jsonl":  {given: "jsonl", want: bulk.NDJSON},
+		"xml":    {given: "xml", err: true},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			got, err := bulk.ParseFormat(tc.given)
+			assert.Equal(t, tc.want, got)
+			assert.Equal(t, tc.err, err != nil)
+		})
+	}
+}
+
+func readAll(t *testing.T, reader pets.ImportReader) []pets.ImportRow {
+	t.Helper()
+
+	var rows []pets.ImportRow
+	for {
+		row, err := reader.Next()
+		if err == io.EOF {
+			return rows
+		}
+		if err != nil {
+			t.Fatalf("unexpected error reading rows: %s", err)
+		}
+		rows = append(rows, row)
+	}
+}
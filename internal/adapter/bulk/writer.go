@@ -0,0 +1,204 @@
+package bulk
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/fernandoocampo/basic-micro/internal/pets"
+	"github.com/xitongsys/parquet-go/writer"
+)
+
+// Writer writes pets in a bulk format. Close writes the pets that are
+// still buffered, it does not close the underlying writer.
+type Writer interface {
+	Write(pet pets.Pet) error
+	// Flush writes the buffered pets, if the format allows it.
+	Flush() error
+	Close() error
+}
+
+// CSVWriter writes pets as CSV with a header row.
+type CSVWriter struct {
+	writer        *csv.Writer
+	headerWritten bool
+}
+
+// NDJSONWriter writes pets as newline delimited JSON, one pet per line.
+type NDJSONWriter struct {
+	encoder *json.Encoder
+}
+
+// ParquetWriter writes pets as a parquet file.
+type ParquetWriter struct {
+	writer *writer.ParquetWriter
+}
+
+// parquetPet is the schema of the parquet file.
+type parquetPet struct {
+	ID        string   `parquet:"name=id, type=BYTE_ARRAY, convertedtype=UTF8"`
+	Name      string   `parquet:"name=name, type=BYTE_ARRAY, convertedtype=UTF8"`
+	Latitude  *float64 `parquet:"name=latitude, type=DOUBLE, repetitiontype=OPTIONAL"`
+	Longitude *float64 `parquet:"name=longitude, type=DOUBLE, repetitiontype=OPTIONAL"`
+	DeletedAt *int64   `parquet:"name=deleted_at, type=INT64, convertedtype=TIMESTAMP_MILLIS, repetitiontype=OPTIONAL"`
+}
+
+// parquetParallelism number of goroutines used to marshal parquet rows.
+const parquetParallelism = 1
+
+var csvHeader = []string{idColumn, nameColumn, latitudeColumn, longitudeColumn, deletedAtColumn}
+
+// NewWriter creates a writer of pets in the given format.
+func NewWriter(format Format, w io.Writer) (Writer, error) {
+	switch format {
+	case CSV:
+		return NewCSVWriter(w), nil
+	case NDJSON:
+		return NewNDJSONWriter(w), nil
+	case Parquet:
+		return NewParquetWriter(w)
+	}
+
+	return nil, errUnknownFormat
+}
+
+// NewCSVWriter creates a CSV writer.
+func NewCSVWriter(w io.Writer) *CSVWriter {
+	newWriter := CSVWriter{
+		writer: csv.NewWriter(w),
+	}
+
+	return &newWriter
+}
+
+// NewNDJSONWriter creates a NDJSON writer.
+func NewNDJSONWriter(w io.Writer) *NDJSONWriter {
+	newWriter := NDJSONWriter{
+		encoder: json.NewEncoder(w),
+	}
+
+	return &newWriter
+}
+
+// NewParquetWriter creates a parquet writer.
+func NewParquetWriter(w io.Writer) (*ParquetWriter, error) {
+	parquetWriter, err := writer.NewParquetWriterFromWriter(w, new(parquetPet), parquetParallelism)
+	if err != nil {
+		return nil, err
+	}
+
+	newWriter := ParquetWriter{
+		writer: parquetWriter,
+	}
+
+	return &newWriter, nil
+}
+
+// Write writes the header before the first pet.
+func (c *CSVWriter) Write(pet pets.Pet) error {
+	err := c.writeHeader()
+	if err != nil {
+		return err
+	}
+
+	record := []string{pet.ID.String(), pet.Name, "", "", formatTime(pet.DeletedAt)}
+	if pet.Location != nil {
+		record[2] = strconv.FormatFloat(pet.Location.Latitude, 'f', -1, 64)
+		record[3] = strconv.FormatFloat(pet.Location.Longitude, 'f', -1, 64)
+	}
+
+	return c.writer.Write(record)
+}
+
+func (c *CSVWriter) Flush() error {
+	c.writer.Flush()
+
+	return c.writer.Error()
+}
+
+// Close writes the header if there were not pets, so the output is
+// always a valid CSV.
+func (c *CSVWriter) Close() error {
+	err := c.writeHeader()
+	if err != nil {
+		return err
+	}
+
+	return c.Flush()
+}
+
+func (c *CSVWriter) writeHeader() error {
+	if c.headerWritten {
+		return nil
+	}
+
+	c.headerWritten = true
+
+	return c.writer.Write(csvHeader)
+}
+
+func (n *NDJSONWriter) Write(pet pets.Pet) error {
+	record := ndjsonPet{
+		ID:        pet.ID.String(),
+		Name:      pet.Name,
+		Location:  pet.Location,
+		DeletedAt: pet.DeletedAt,
+	}
+
+	return n.encoder.Encode(record)
+}
+
+// Flush does nothing, every pet is written right away.
+func (n *NDJSONWriter) Flush() error {
+	return nil
+}
+
+func (n *NDJSONWriter) Close() error {
+	return nil
+}
+
+func (p *ParquetWriter) Write(pet pets.Pet) error {
+	record := parquetPet{
+		ID:   pet.ID.String(),
+		Name: pet.Name,
+	}
+
+	if pet.Location != nil {
+		record.Latitude = &pet.Location.Latitude
+		record.Longitude = &pet.Location.Longitude
+	}
+
+	if pet.DeletedAt != nil {
+		deletedAt := pet.DeletedAt.UnixMilli()
+		record.DeletedAt = &deletedAt
+	}
+
+	return p.writer.Write(record)
+}
+
+// Flush does nothing, rows are written in row groups when the writer is
+// closed or the row group is full.
+func (p *ParquetWriter) Flush() error {
+	return nil
+}
+
+// Close writes the remaining rows and the parquet footer.
+func (p *ParquetWriter) Close() error {
+	err := p.writer.WriteStop()
+	if err != nil {
+		return errors.Join(errors.New("unable to finish parquet file"), err)
+	}
+
+	return nil
+}
+
+func formatTime(value *time.Time) string {
+	if value == nil {
+		return ""
+	}
+
+	return value.UTC().Format(time.RFC3339)
+}
@@ -0,0 +1,113 @@
+package bulk_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/fernandoocampo/basic-micro/internal/adapter/bulk"
+	"github.com/fernandoocampo/basic-micro/internal/pets"
+	"github.com/stretchr/testify/assert"
+	"github.com/xitongsys/parquet-go-source/buffer"
+	"github.com/xitongsys/parquet-go/reader"
+)
+
+func TestCSVWriter(t *testing.T) {
+	// Given
+	deletedAt := time.Date(2024, 4, 9, 10, 30, 0, 0, time.UTC)
+	givenPets := []pets.Pet{
+		{ID: "1", Name: "drila", Location: &pets.Location{Latitude: 4.711, Longitude: -74.0721}},
+		{ID: "2", Name: "michael", DeletedAt: &deletedAt},
+	}
+	expectedCSV := "id,name,latitude,longitude,deleted_at\n" +
+		"1,drila,4.711,-74.0721,\n" +
+		"2,michael,,,2024-04-09T10:30:00Z\n"
+
+	var output bytes.Buffer
+	writer, err := bulk.NewWriter(bulk.CSV, &output)
+	assert.NoError(t, err)
+
+	// When
+	writePets(t, writer, givenPets)
+
+	// Then
+	assert.Equal(t, expectedCSV, output.String())
+}
+
+func TestCSVWriterWithoutPets(t *testing.T) {
+	// Given
+	var output bytes.Buffer
+	writer, err := bulk.NewWriter(bulk.CSV, &output)
+	assert.NoError(t, err)
+
+	// When
+	err = writer.Close()
+
+	// Then
+	assert.NoError(t, err)
+	assert.Equal(t, "id,name,latitude,longitude,deleted_at\n", output.String())
+}
+
+func TestNDJSONWriterCanBeImported(t *testing.T) {
+	// Given
+	givenPets := []pets.Pet{
+		{ID: "1", Name: "drila", Location: &pets.Location{Latitude: 4.711, Longitude: -74.0721}},
+		{ID: "2", Name: "michael"},
+	}
+	expectedNDJSON := `{"id":"1","name":"drila","location":{"latitude":4.711,"longitude":-74.0721}}` + "\n" +
+		`{"id":"2","name":"michael"}` + "\n"
+
+	var output bytes.Buffer
+	writer, err := bulk.NewWriter(bulk.NDJSON, &output)
+	assert.NoError(t, err)
+
+	// When
+	writePets(t, writer, givenPets)
+
+	// Then
+	assert.Equal(t, expectedNDJSON, output.String())
+
+	importReader, err := bulk.NewReader(bulk.NDJSON, strings.NewReader(output.String()))
+	assert.NoError(t, err)
+	rows := readAll(t, importReader)
+	assert.Len(t, rows, 2)
+	assert.Equal(t, pets.NewPet{Name: "drila", Location: givenPets[0].Location}, rows[0].NewPet)
+}
+
+func TestParquetWriter(t *testing.T) {
+	// Given
+	givenPets := []pets.Pet{
+		{ID: "1", Name: "drila", Location: &pets.Location{Latitude: 4.711, Longitude: -74.0721}},
+		{ID: "2", Name: "michael"},
+	}
+
+	var output bytes.Buffer
+	writer, err := bulk.NewWriter(bulk.Parquet, &output)
+	assert.NoError(t, err)
+
+	// When
+	writePets(t, writer, givenPets)
+
+	// Then
+	file, err := buffer.NewBufferFile(output.Bytes())
+	assert.NoError(t, err)
+	parquetReader, err := reader.NewParquetReader(file, nil, 1)
+	assert.NoError(t, err)
+	defer parquetReader.ReadStop()
+	assert.Equal(t, int64(2), parquetReader.GetNumRows())
+}
+
+func writePets(t *testing.T, writer bulk.Writer, petsToWrite []pets.Pet) {
+	t.Helper()
+
+	for _, pet := range petsToWrite {
+		if err := writer.Write(pet); err != nil {
+			t.Fatalf("unexpected error writing pet: %s", err)
+		}
+	}
+
+	if err := writer.Close(); err != nil {
+		t.Fatalf("unexpected error closing writer: %s", err)
+	}
+}
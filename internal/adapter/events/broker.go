@@ -0,0 +1,264 @@
+package events
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"slices"
+	"sync"
+
+	"github.com/fernandoocampo/basic-micro/internal/pets"
+)
+
+// Event is a change made to a pet, its id grows with every event
+// published since the broker was created.
+type Event struct {
+	ID     uint64
+	Record pets.AuditRecord
+}
+
+// Filter selects the events of a subscription, empty fields select every
+// event.
+type Filter struct {
+	PetIDs  []pets.PetID
+	Actions []pets.AuditAction
+}
+
+// BrokerSetup contains event broker metadata.
+type BrokerSetup struct {
+	// LogSize number of events kept to resume subscriptions,
+	// DefaultLogSize if it is not positive.
+	LogSize int
+	// SubscriberBuffer number of events kept for a subscriber that is not
+	// reading, DefaultSubscriberBuffer if it is not positive. When it is
+	// full the subscription ends, so a slow subscriber does not block the
+	// service, it can resume from the log.
+	SubscriberBuffer int
+	Logger           *slog.Logger
+}
+
+// Broker keeps the latest events and sends every published event to the
+// subscriptions whose filter selects it.
+type Broker struct {
+	mutex            sync.Mutex
+	log              []Event
+	logStart         int
+	lastID           uint64
+	subscriptions    map[*Subscription]struct{}
+	subscriberBuffer int
+	closed           bool
+	logger           *slog.Logger
+}
+
+// Subscription receives the events published after it was created, the
+// events it missed are in its backlog.
+type Subscription struct {
+	broker  *Broker
+	filter  Filter
+	events  chan Event
+	backlog []Event
+	missed  bool
+}
+
+// broker defaults
+const (
+	DefaultLogSize          = 1000
+	DefaultSubscriberBuffer = 64
+)
+
+// ErrClosed is returned by Subscribe after the broker was closed.
+var ErrClosed = errors.New("event broker is closed")
+
+// NewBroker creates a broker without events.
+func NewBroker(setup BrokerSetup) *Broker {
+	logSize := setup.LogSize
+	if logSize <= 0 {
+		logSize = DefaultLogSize
+	}
+
+	newBroker := Broker{
+		log:              make([]Event, 0, logSize),
+		subscriptions:    make(map[*Subscription]struct{}),
+		subscriberBuffer: setup.SubscriberBuffer,
+		logger:           setup.Logger,
+	}
+
+	if newBroker.subscriberBuffer <= 0 {
+		newBroker.subscriberBuffer = DefaultSubscriberBuffer
+	}
+
+	return &newBroker
+}
+
+// Publish adds the record to the log and sends it to the subscriptions,
+// it implements pets.Publisher. Records published after the broker was
+// closed are dropped.
+func (b *Broker) Publish(ctx context.Context, record pets.AuditRecord) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	if b.closed {
+		return
+	}
+
+	b.lastID++
+	event := Event{ID: b.lastID, Record: record}
+	b.append(event)
+
+	for subscription := range b.subscriptions {
+		if !subscription.filter.Matches(event) {
+			continue
+		}
+
+		select {
+		case subscription.events <- event:
+		default:
+			b.logger.Warn("ending subscription that is not reading events",
+				slog.Uint64("event_id", event.ID))
+			b.end(subscription)
+		}
+	}
+}
+
+// Subscribe creates a subscription with the filter. after is the id of the
+// last event the subscriber received, 0 if it is a new subscriber. The
+// events of the log after that id are in the backlog of the subscription,
+// if some of them are not in the log anymore the subscription is marked as
+// missed and the backlog has every event of the log.
+func (b *Broker) Subscribe(filter Filter, after uint64) (*Subscription, error) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	if b.closed {
+		return nil, ErrClosed
+	}
+
+	newSubscription := Subscription{
+		broker: b,
+		filter: filter,
+		events: make(chan Event, b.subscriberBuffer),
+	}
+
+	if after > 0 {
+		newSubscription.missed = b.missed(after)
+		if newSubscription.missed {
+			after = 0
+		}
+
+		newSubscription.backlog = b.eventsAfter(after, filter)
+	}
+
+	b.subscriptions[&newSubscription] = struct{}{}
+
+	return &newSubscription, nil
+}
+
+// Close ends every subscription and rejects new ones, so event streams
+// finish when the server shuts down.
+func (b *Broker) Close() {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	if b.closed {
+		return
+	}
+
+	b.closed = true
+
+	for subscription := range b.subscriptions {
+		b.end(subscription)
+	}
+
+	b.logger.Info("event broker was closed")
+}
+
+// append adds the event to the log, the oldest event is overwritten when
+// the log is full.
+func (b *Broker) append(event Event) {
+	if len(b.log) < cap(b.log) {
+		b.log = append(b.log, event)
+
+		return
+	}
+
+	b.log[b.logStart] = event
+	b.logStart = (b.logStart + 1) % len(b.log)
+}
+
+// missed says if events after the given id are not in the log anymore, an
+// id the broker did not publish was given by a previous process.
+func (b *Broker) missed(after uint64) bool {
+	if after > b.lastID {
+		return true
+	}
+
+	if len(b.log) == 0 {
+		return false
+	}
+
+	return b.log[b.logStart].ID > after+1
+}
+
+// eventsAfter returns the events of the log after the given id that match
+// the filter, oldest first.
+func (b *Broker) eventsAfter(after uint64, filter Filter) []Event {
+	var events []Event
+
+	for index := range b.log {
+		event := b.log[(b.logStart+index)%len(b.log)]
+		if event.ID > after && filter.Matches(event) {
+			events = append(events, event)
+		}
+	}
+
+	return events
+}
+
+// end removes the subscription and closes its events, it is called with
+// the mutex locked.
+func (b *Broker) end(subscription *Subscription) {
+	if _, ok := b.subscriptions[subscription]; !ok {
+		return
+	}
+
+	delete(b.subscriptions, subscription)
+	close(subscription.events)
+}
+
+// Events returns the events published after the subscription was created,
+// it is closed when the subscription ends.
+func (s *Subscription) Events() <-chan Event {
+	return s.events
+}
+
+// Backlog returns the events of the log the subscriber did not receive.
+func (s *Subscription) Backlog() []Event {
+	return s.backlog
+}
+
+// Missed says if events the subscriber did not receive are not in the log
+// anymore, so it must reload what it knows about pets.
+func (s *Subscription) Missed() bool {
+	return s.missed
+}
+
+// Close ends the subscription.
+func (s *Subscription) Close() {
+	s.broker.mutex.Lock()
+	defer s.broker.mutex.Unlock()
+
+	s.broker.end(s)
+}
+
+// Matches says if the filter selects the event.
+func (f Filter) Matches(event Event) bool {
+	if len(f.PetIDs) > 0 && !slices.Contains(f.PetIDs, event.Record.PetID) {
+		return false
+	}
+
+	if len(f.Actions) > 0 && !slices.Contains(f.Actions, event.Record.Action) {
+		return false
+	}
+
+	return true
+}
@@ -0,0 +1,160 @@
+package events_test
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"testing"
+
+	"github.com/fernandoocampo/basic-micro/internal/adapter/events"
+	"github.com/fernandoocampo/basic-micro/internal/pets"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPublishSendsTheEventsOfTheFilter(t *testing.T) {
+	t.Parallel()
+
+	// Given
+	broker := events.NewBroker(events.BrokerSetup{Logger: newLogger()})
+	all, err := broker.Subscribe(events.Filter{}, 0)
+	require.NoError(t, err)
+	drilaDeleted, err := broker.Subscribe(events.Filter{
+		PetIDs:  []pets.PetID{"drila"},
+		Actions: []pets.AuditAction{pets.AuditDeleted},
+	}, 0)
+	require.NoError(t, err)
+
+	// When
+	publish(broker,
+		pets.AuditRecord{PetID: "drila", Action: pets.AuditCreated},
+		pets.AuditRecord{PetID: "michi", Action: pets.AuditDeleted},
+		pets.AuditRecord{PetID: "drila", Action: pets.AuditDeleted},
+	)
+
+	// Then
+	assert.Equal(t, []uint64{1, 2, 3}, receiveIDs(all, 3))
+	assert.Equal(t, []uint64{3}, receiveIDs(drilaDeleted, 1))
+	assert.Empty(t, all.Backlog())
+	assert.False(t, all.Missed())
+}
+
+func TestSubscribeResumesFromTheLog(t *testing.T) {
+	t.Parallel()
+
+	cases := map[string]struct {
+		after       uint64
+		wantBacklog []uint64
+		wantMissed  bool
+	}{
+		"events in the log": {
+			after:       3,
+			wantBacklog: []uint64{4, 5},
+		},
+		"last event": {
+			after:       5,
+			wantBacklog: nil,
+		},
+		"events out of the log": {
+			after:       1,
+			wantBacklog: []uint64{3, 4, 5},
+			wantMissed:  true,
+		},
+		"event of a previous process": {
+			after:       99,
+			wantBacklog: []uint64{3, 4, 5},
+			wantMissed:  true,
+		},
+	}
+
+	for name, testCase := range cases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			// Given
+			broker := events.NewBroker(events.BrokerSetup{LogSize: 3, Logger: newLogger()})
+			for range 5 {
+				publish(broker, pets.AuditRecord{PetID: "drila", Action: pets.AuditUpdated})
+			}
+
+			// When
+			subscription, err := broker.Subscribe(events.Filter{}, testCase.after)
+
+			// Then
+			require.NoError(t, err)
+			assert.Equal(t, testCase.wantBacklog, eventIDs(subscription.Backlog()))
+			assert.Equal(t, testCase.wantMissed, subscription.Missed())
+		})
+	}
+}
+
+func TestSlowSubscriptionIsEnded(t *testing.T) {
+	t.Parallel()
+
+	// Given
+	broker := events.NewBroker(events.BrokerSetup{SubscriberBuffer: 1, Logger: newLogger()})
+	subscription, err := broker.Subscribe(events.Filter{}, 0)
+	require.NoError(t, err)
+
+	// When
+	publish(broker,
+		pets.AuditRecord{PetID: "drila", Action: pets.AuditCreated},
+		pets.AuditRecord{PetID: "drila", Action: pets.AuditUpdated},
+	)
+
+	// Then
+	event, ok := <-subscription.Events()
+	assert.True(t, ok)
+	assert.Equal(t, uint64(1), event.ID)
+	_, ok = <-subscription.Events()
+	assert.False(t, ok)
+}
+
+func TestCloseEndsSubscriptions(t *testing.T) {
+	t.Parallel()
+
+	// Given
+	broker := events.NewBroker(events.BrokerSetup{Logger: newLogger()})
+	subscription, err := broker.Subscribe(events.Filter{}, 0)
+	require.NoError(t, err)
+
+	// When
+	broker.Close()
+	publish(broker, pets.AuditRecord{PetID: "drila", Action: pets.AuditCreated})
+	_, errClosed := broker.Subscribe(events.Filter{}, 0)
+	subscription.Close()
+
+	// Then
+	_, ok := <-subscription.Events()
+	assert.False(t, ok)
+	assert.ErrorIs(t, errClosed, events.ErrClosed)
+}
+
+func publish(broker *events.Broker, records ...pets.AuditRecord) {
+	for _, record := range records {
+		broker.Publish(context.Background(), record)
+	}
+}
+
+// receiveIDs receives the ids of the next events of the subscription.
+func receiveIDs(subscription *events.Subscription, count int) []uint64 {
+	received := make([]events.Event, 0, count)
+	for range count {
+		received = append(received, <-subscription.Events())
+	}
+
+	return eventIDs(received)
+}
+
+func eventIDs(received []events.Event) []uint64 {
+	var ids []uint64
+	for _, event := range received {
+		ids = append(ids, event.ID)
+	}
+
+	return ids
+}
+
+func newLogger() *slog.Logger {
+	return slog.New(slog.NewTextHandler(io.Discard, nil))
+}
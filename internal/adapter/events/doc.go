@@ -0,0 +1,4 @@
+// Package events publishes the changes made to pets to subscribers, like
+// the clients of the event stream. The latest events are kept in a
+// bounded log, so subscribers can resume after they reconnect.
+package events
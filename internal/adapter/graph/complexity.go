@@ -0,0 +1,151 @@
+package graph
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/graphql-go/graphql/language/ast"
+)
+
+// listSize says which argument gives the size of the list a field returns
+// and the size when the argument is not given.
+type listSize struct {
+	argument    string
+	defaultSize int
+}
+
+// queryCost is the complexity and depth of a selection.
+type queryCost struct {
+	complexity int
+	depth      int
+}
+
+// listSizes of the fields that return lists or pages. The ids of pets is
+// a list, its size is the number of ids.
+var listSizes = map[string]listSize{
+	"pets":       {argument: "ids", defaultSize: 1},
+	"searchPets": {argument: "pageSize", defaultSize: 10},
+	"nearby":     {argument: "limit", defaultSize: 20},
+	"petHistory": {argument: "pageSize", defaultSize: 10},
+	"sightings":  {argument: "limit", defaultSize: defaultSightingsLimit},
+}
+
+// costOf returns the cost of the operation. Every field costs 1 and the
+// cost of the fields selected from a list is multiplied by the size of
+// the list. Introspection fields are free, so tools can read the schema.
+func costOf(document *ast.Document, operation *ast.OperationDefinition, variables map[string]any) queryCost {
+	fragments := make(map[string]*ast.FragmentDefinition)
+	for _, definition := range document.Definitions {
+		if fragment, ok := definition.(*ast.FragmentDefinition); ok {
+			fragments[fragment.Name.Value] = fragment
+		}
+	}
+
+	walker := costWalker{
+		fragments: fragments,
+		variables: variables,
+	}
+
+	return walker.selectionSet(operation.SelectionSet)
+}
+
+// costWalker walks the selections of an operation, fragments were
+// validated, so they do not have cycles.
+type costWalker struct {
+	fragments map[string]*ast.FragmentDefinition
+	variables map[string]any
+}
+
+func (c costWalker) selectionSet(selectionSet *ast.SelectionSet) queryCost {
+	var cost queryCost
+	if selectionSet == nil {
+		return cost
+	}
+
+	for _, selection := range selectionSet.Selections {
+		var selectionCost queryCost
+
+		switch selection := selection.(type) {
+		case *ast.Field:
+			selectionCost = c.field(selection)
+		case *ast.InlineFragment:
+			selectionCost = c.selectionSet(selection.SelectionSet)
+		case *ast.FragmentSpread:
+			fragment, ok := c.fragments[selection.Name.Value]
+			if ok {
+				selectionCost = c.selectionSet(fragment.SelectionSet)
+			}
+		}
+
+		cost.complexity += selectionCost.complexity
+		cost.depth = max(cost.depth, selectionCost.depth)
+	}
+
+	return cost
+}
+
+func (c costWalker) field(field *ast.Field) queryCost {
+	if strings.HasPrefix(field.Name.Value, "__") {
+		return queryCost{}
+	}
+
+	children := c.selectionSet(field.SelectionSet)
+
+	return queryCost{
+		complexity: 1 + c.sizeOf(field)*children.complexity,
+		depth:      1 + children.depth,
+	}
+}
+
+// sizeOf returns the size of the list the field returns, 1 if it is not a
+// list.
+func (c costWalker) sizeOf(field *ast.Field) int {
+	size, ok := listSizes[field.Name.Value]
+	if !ok {
+		return 1
+	}
+
+	for _, argument := range field.Arguments {
+		if argument.Name.Value != size.argument {
+			continue
+		}
+
+		value := c.valueOf(argument.Value)
+
+		switch value := value.(type) {
+		case int:
+			return max(value, 1)
+		case []any:
+			return max(len(value), 1)
+		}
+	}
+
+	return size.defaultSize
+}
+
+// valueOf returns the int or list value of an argument, variables are
+// replaced by their values.
+func (c costWalker) valueOf(value ast.Value) any {
+	switch value := value.(type) {
+	case *ast.IntValue:
+		number, err := strconv.Atoi(value.Value)
+		if err != nil {
+			return nil
+		}
+
+		return number
+	case *ast.ListValue:
+		return make([]any, len(value.Values))
+	case *ast.Variable:
+		switch variable := c.variables[value.Name.Value].(type) {
+		case float64:
+			return int(variable)
+		case int:
+			return variable
+		case []any:
+			return variable
+		}
+	}
+
+	return nil
+}
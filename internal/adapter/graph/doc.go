@@ -0,0 +1,4 @@
+// Package graph serves the pets service over GraphQL. Pets and sightings
+// are read with loaders that batch the reads of a request, and operations
+// are rejected when their depth or complexity exceed the limits.
+package graph
@@ -0,0 +1,317 @@
+package graph
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"mime"
+	"net/http"
+	"strings"
+
+	"github.com/fernandoocampo/basic-micro/internal/adapter/web"
+	"github.com/fernandoocampo/basic-micro/internal/pets"
+	"github.com/graphql-go/graphql"
+	"github.com/graphql-go/graphql/gqlerrors"
+	"github.com/graphql-go/graphql/language/ast"
+	"github.com/graphql-go/graphql/language/parser"
+	"github.com/graphql-go/graphql/language/source"
+)
+
+// HandlerSetup contains the data to create the GraphQL handler.
+type HandlerSetup struct {
+	Service *pets.Service
+	// MaxComplexity is the highest cost of an operation, DefaultMaxComplexity
+	// if it is not positive.
+	MaxComplexity int
+	// MaxDepth is the deepest selection of an operation, DefaultMaxDepth if
+	// it is not positive.
+	MaxDepth int
+	// Playground serves GraphiQL to browsers that GET the endpoint without
+	// a query.
+	Playground bool
+	Logger     *slog.Logger
+}
+
+// Handler serves the GraphQL endpoint.
+type Handler struct {
+	schema        graphql.Schema
+	service       *pets.Service
+	maxComplexity int
+	maxDepth      int
+	playground    bool
+	logger        *slog.Logger
+}
+
+// request is the body of a GraphQL request.
+type request struct {
+	Query         string         `json:"query"`
+	OperationName string         `json:"operationName"`
+	Variables     map[string]any `json:"variables"`
+}
+
+// default limits of an operation.
+const (
+	DefaultMaxComplexity = 1000
+	DefaultMaxDepth      = 10
+)
+
+// MaxBodySize is the size of the largest request body.
+const MaxBodySize = 1 << 20
+
+const (
+	jsonContentType = "application/json; charset=utf-8"
+	htmlContentType = "text/html; charset=utf-8"
+)
+
+var (
+	errEmptyQuery       = errors.New("query cannot be empty")
+	errOperationMissing = errors.New("operation does not exist")
+	errOperationName    = errors.New("operationName is required when the query has more than one operation")
+	errMutationOverGET  = errors.New("mutations must be sent with POST")
+)
+
+// playgroundPage renders GraphiQL against the endpoint it is served from.
+const playgroundPage = `<!DOCTYPE html>
+<html>
+  <head>
+    <title>pets graphql</title>
+    <meta charset="utf-8"/>
+    <meta name="viewport" content="width=device-width, initial-scale=1">
+    <link rel="stylesheet" href="https://unpkg.com/graphiql@3.7.1/graphiql.min.css"/>
+    <style>body { margin: 0; } #graphiql { height: 100vh; }</style>
+  </head>
+  <body>
+    <div id="graphiql"></div>
+    <script crossorigin src="https://unpkg.com/react@18.3.1/umd/react.production.min.js"></script>
+    <script crossorigin src="https://unpkg.com/react-dom@18.3.1/umd/react-dom.production.min.js"></script>
+    <script crossorigin src="https://unpkg.com/graphiql@3.7.1/graphiql.min.js"></script>
+    <script>
+      const fetcher = GraphiQL.createFetcher({ url: window.location.pathname });
+      ReactDOM.createRoot(document.getElementById('graphiql')).render(
+        React.createElement(GraphiQL, { fetcher: fetcher }),
+      );
+    </script>
+  </body>
+</html>
+`
+
+// NewHandler builds the schema and creates the handler.
+func NewHandler(setup HandlerSetup) (*Handler, error) {
+	schema, err := newSchema(newResolver(setup.Service, setup.Logger))
+	if err != nil {
+		return nil, fmt.Errorf("unable to build graphql schema: %w", err)
+	}
+
+	newHandler := Handler{
+		schema:        schema,
+		service:       setup.Service,
+		maxComplexity: setup.MaxComplexity,
+		maxDepth:      setup.MaxDepth,
+		playground:    setup.Playground,
+		logger:        setup.Logger,
+	}
+
+	if newHandler.maxComplexity <= 0 {
+		newHandler.maxComplexity = DefaultMaxComplexity
+	}
+
+	if newHandler.maxDepth <= 0 {
+		newHandler.maxDepth = DefaultMaxDepth
+	}
+
+	return &newHandler, nil
+}
+
+// ServeHTTP executes queries sent with GET and operations sent with POST.
+// Requests that cannot be executed get 400 with the errors in the GraphQL
+// format, errors of the resolvers are part of a 200 result.
+func (h *Handler) ServeHTTP(rw http.ResponseWriter, req *http.Request) {
+	var gqlRequest request
+
+	switch req.Method {
+	case http.MethodGet:
+		if h.playground && req.URL.Query().Get("query") == "" && acceptsHTML(req) {
+			rw.Header().Set("Content-Type", htmlContentType)
+			rw.WriteHeader(http.StatusOK)
+			rw.Write([]byte(playgroundPage))
+
+			return
+		}
+
+		var err error
+
+		gqlRequest, err = requestFromQuery(req)
+		if err != nil {
+			h.writeErrors(rw, http.StatusBadRequest, err)
+
+			return
+		}
+	case http.MethodPost:
+		mediaType, _, _ := mime.ParseMediaType(req.Header.Get("Content-Type"))
+		if mediaType != "application/json" {
+			h.writeErrors(rw, http.StatusUnsupportedMediaType, fmt.Errorf("unsupported content type %q, it must be application/json", mediaType))
+
+			return
+		}
+
+		err := json.NewDecoder(io.LimitReader(req.Body, MaxBodySize)).Decode(&gqlRequest)
+		if err != nil {
+			h.writeErrors(rw, http.StatusBadRequest, fmt.Errorf("invalid request body: %w", err))
+
+			return
+		}
+	default:
+		rw.Header().Set("Allow", "GET, POST")
+		h.writeErrors(rw, http.StatusMethodNotAllowed, fmt.Errorf("method %s is not allowed", req.Method))
+
+		return
+	}
+
+	h.execute(rw, req, gqlRequest)
+}
+
+// execute parses, validates and limits the operation before it is run.
+func (h *Handler) execute(rw http.ResponseWriter, req *http.Request, gqlRequest request) {
+	if strings.TrimSpace(gqlRequest.Query) == "" {
+		h.writeErrors(rw, http.StatusBadRequest, errEmptyQuery)
+
+		return
+	}
+
+	document, err := parser.Parse(parser.ParseParams{
+		Source: source.NewSource(&source.Source{Body: []byte(gqlRequest.Query), Name: "GraphQL request"}),
+	})
+	if err != nil {
+		h.writeResult(rw, http.StatusBadRequest, &graphql.Result{Errors: gqlerrors.FormatErrors(err)})
+
+		return
+	}
+
+	validation := graphql.ValidateDocument(&h.schema, document, nil)
+	if !validation.IsValid {
+		h.writeResult(rw, http.StatusBadRequest, &graphql.Result{Errors: validation.Errors})
+
+		return
+	}
+
+	operation, err := operationOf(document, gqlRequest.OperationName)
+	if err != nil {
+		h.writeErrors(rw, http.StatusBadRequest, err)
+
+		return
+	}
+
+	if req.Method == http.MethodGet && operation.Operation == ast.OperationTypeMutation {
+		rw.Header().Set("Allow", http.MethodPost)
+		h.writeErrors(rw, http.StatusMethodNotAllowed, errMutationOverGET)
+
+		return
+	}
+
+	cost := costOf(document, operation, gqlRequest.Variables)
+	if cost.depth > h.maxDepth {
+		h.writeErrors(rw, http.StatusBadRequest, fmt.Errorf("query depth %d exceeds the maximum of %d", cost.depth, h.maxDepth))
+
+		return
+	}
+
+	if cost.complexity > h.maxComplexity {
+		h.writeErrors(rw, http.StatusBadRequest, fmt.Errorf("query complexity %d exceeds the maximum of %d", cost.complexity, h.maxComplexity))
+
+		return
+	}
+
+	ctx := withLoaders(req.Context(), newLoaders(h.service))
+	if actor := req.Header.Get(web.ActorHeader); actor != "" {
+		ctx = pets.WithAssertedActor(ctx, actor)
+	}
+
+	result := graphql.Execute(graphql.ExecuteParams{
+		Schema:        h.schema,
+		AST:           document,
+		OperationName: gqlRequest.OperationName,
+		Args:          gqlRequest.Variables,
+		Context:       ctx,
+	})
+
+	h.logger.Debug("graphql operation executed",
+		slog.String("operation", gqlRequest.OperationName),
+		slog.Int("complexity", cost.complexity),
+		slog.Int("errors", len(result.Errors)))
+
+	h.writeResult(rw, http.StatusOK, result)
+}
+
+func (h *Handler) writeErrors(rw http.ResponseWriter, status int, err error) {
+	h.writeResult(rw, status, &graphql.Result{
+		Errors: []gqlerrors.FormattedError{gqlerrors.NewFormattedError(err.Error())},
+	})
+}
+
+func (h *Handler) writeResult(rw http.ResponseWriter, status int, result *graphql.Result) {
+	rw.Header().Set("Content-Type", jsonContentType)
+	rw.WriteHeader(status)
+
+	err := json.NewEncoder(rw).Encode(result)
+	if err != nil {
+		h.logger.Error("unable to encode graphql result", slog.String("error", err.Error()))
+	}
+}
+
+// requestFromQuery reads the request from the query string of a GET.
+func requestFromQuery(req *http.Request) (request, error) {
+	query := req.URL.Query()
+
+	gqlRequest := request{
+		Query:         query.Get("query"),
+		OperationName: query.Get("operationName"),
+	}
+
+	if variables := query.Get("variables"); variables != "" {
+		err := json.Unmarshal([]byte(variables), &gqlRequest.Variables)
+		if err != nil {
+			return request{}, fmt.Errorf("invalid variables: %w", err)
+		}
+	}
+
+	return gqlRequest, nil
+}
+
+// operationOf returns the operation of the document with the given name,
+// the name can be empty when the document has one operation.
+func operationOf(document *ast.Document, name string) (*ast.OperationDefinition, error) {
+	var found *ast.OperationDefinition
+
+	for _, definition := range document.Definitions {
+		operation, ok := definition.(*ast.OperationDefinition)
+		if !ok {
+			continue
+		}
+
+		if name == "" {
+			if found != nil {
+				return nil, errOperationName
+			}
+
+			found = operation
+
+			continue
+		}
+
+		if operation.Name != nil && operation.Name.Value == name {
+			return operation, nil
+		}
+	}
+
+	if found == nil {
+		return nil, errOperationMissing
+	}
+
+	return found, nil
+}
+
+func acceptsHTML(req *http.Request) bool {
+	return strings.Contains(req.Header.Get("Accept"), "text/html")
+}
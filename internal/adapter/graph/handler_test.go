@@ -0,0 +1,320 @@
+package graph_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/fernandoocampo/basic-micro/internal/adapter/graph"
+	"github.com/fernandoocampo/basic-micro/internal/adapter/stores"
+	"github.com/fernandoocampo/basic-micro/internal/adapter/web"
+	"github.com/fernandoocampo/basic-micro/internal/pets"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// countingStore counts the batched reads of the memory store.
+type countingStore struct {
+	*stores.MemoryStore
+	queryByIDsCalls     atomic.Int32
+	querySightingsCalls atomic.Int32
+}
+
+type graphqlResult struct {
+	Data   map[string]any `json:"data"`
+	Errors []struct {
+		Message string `json:"message"`
+	} `json:"errors"`
+}
+
+func TestNestedPetsAndSightingsAreBatched(t *testing.T) {
+	// Given
+	store, service, handler := newGraphQLHandler(t, graph.HandlerSetup{})
+	ctx := context.Background()
+	for _, name := range []string{"Drila", "Drilita", "Michi"} {
+		id, err := service.Create(ctx, pets.NewPet{Name: name})
+		require.NoError(t, err)
+		for range 2 {
+			_, err = service.ReportSighting(ctx, pets.NewSighting{
+				PetID:    id,
+				Location: pets.Location{Latitude: 4.6, Longitude: -74.1},
+				SeenAt:   time.Now().UTC(),
+			})
+			require.NoError(t, err)
+		}
+	}
+	query := `{
+		searchPets(name: "i", orderBy: "name") {
+			total
+			pets { name sightings(limit: 1) { pet { name } } }
+		}
+	}`
+
+	// When
+	status, result := postQuery(t, handler, query, nil, "")
+
+	// Then
+	require.Equal(t, http.StatusOK, status)
+	require.Empty(t, result.Errors)
+	page := result.Data["searchPets"].(map[string]any)
+	assert.Equal(t, float64(3), page["total"])
+	found := page["pets"].([]any)
+	require.Len(t, found, 3)
+	for _, item := range found {
+		pet := item.(map[string]any)
+		sightings := pet["sightings"].([]any)
+		require.Len(t, sightings, 1)
+		assert.Equal(t, pet["name"], sightings[0].(map[string]any)["pet"].(map[string]any)["name"])
+	}
+	assert.Equal(t, int32(1), store.querySightingsCalls.Load())
+	assert.Equal(t, int32(1), store.queryByIDsCalls.Load())
+}
+
+func TestPetsByIDs(t *testing.T) {
+	// Given
+	store, service, handler := newGraphQLHandler(t, graph.HandlerSetup{})
+	ctx := context.Background()
+	drila, err := service.Create(ctx, pets.NewPet{Name: "Drila"})
+	require.NoError(t, err)
+	michi, err := service.Create(ctx, pets.NewPet{Name: "Michi"})
+	require.NoError(t, err)
+	variables := map[string]any{"ids": []string{string(drila), "858455b7-e182-4122-a1b6-132c64d2f77b", string(michi)}}
+
+	// When
+	status, result := postQuery(t, handler, `query($ids: [ID!]!) { pets(ids: $ids) { name } }`, variables, "")
+
+	// Then
+	require.Equal(t, http.StatusOK, status)
+	require.Empty(t, result.Errors)
+	found := result.Data["pets"].([]any)
+	require.Len(t, found, 3)
+	assert.Equal(t, "Drila", found[0].(map[string]any)["name"])
+	assert.Nil(t, found[1])
+	assert.Equal(t, "Michi", found[2].(map[string]any)["name"])
+	assert.Equal(t, int32(1), store.queryByIDsCalls.Load())
+}
+
+func TestMutationsRecordTheAssertedActor(t *testing.T) {
+	// Given
+	_, _, handler := newGraphQLHandler(t, graph.HandlerSetup{})
+	mutation := `mutation {
+		createPet(input: {name: "Drila", location: {latitude: 4.6, longitude: -74.1}}) { id name location { latitude } }
+	}`
+
+	// When
+	status, created := postQuery(t, handler, mutation, nil, "alice")
+	require.Equal(t, http.StatusOK, status)
+	require.Empty(t, created.Errors)
+	id := created.Data["createPet"].(map[string]any)["id"].(string)
+	rename := `mutation($id: ID!) {
+		updatePet(input: {id: $id, name: "Drilita"}) { name }
+		deletePet(id: $id)
+		restorePet(id: $id) { name deletedAt }
+	}`
+	_, changed := postQuery(t, handler, rename, map[string]any{"id": id}, "alice")
+	_, history := postQuery(t, handler, `query($id: ID!) { petHistory(petId: $id) { total records { action actor assertedActor } } }`, map[string]any{"id": id}, "")
+
+	// Then
+	require.Empty(t, changed.Errors)
+	assert.Equal(t, "Drilita", changed.Data["updatePet"].(map[string]any)["name"])
+	assert.Equal(t, true, changed.Data["deletePet"])
+	assert.Nil(t, changed.Data["restorePet"].(map[string]any)["deletedAt"])
+	require.Empty(t, history.Errors)
+	records := history.Data["petHistory"].(map[string]any)["records"].([]any)
+	require.Len(t, records, 4)
+	for _, record := range records {
+		assert.Equal(t, pets.AnonymousActor, record.(map[string]any)["actor"])
+		assert.Equal(t, "alice", record.(map[string]any)["assertedActor"])
+	}
+}
+
+func TestResolverErrorsArePartOfTheResult(t *testing.T) {
+	// Given
+	_, _, handler := newGraphQLHandler(t, graph.HandlerSetup{})
+
+	// When
+	status, result := postQuery(t, handler, `mutation { createPet(input: {name: ""}) { id } }`, nil, "")
+
+	// Then
+	assert.Equal(t, http.StatusOK, status)
+	require.Len(t, result.Errors, 1)
+	assert.Contains(t, result.Errors[0].Message, "invalid pet data")
+}
+
+func TestOperationsOverTheLimitsAreRejected(t *testing.T) {
+	cases := map[string]struct {
+		query     string
+		variables map[string]any
+		want      string
+	}{
+		"complexity of page size": {
+			query: `{ searchPets(pageSize: 100) { pets { sightings { id } } } }`,
+			want:  "query complexity 1201 exceeds the maximum of 100",
+		},
+		"complexity of variables": {
+			query:     `query($size: Int) { searchPets(pageSize: $size) { pets { id name } } }`,
+			variables: map[string]any{"size": 50},
+			want:      "query complexity 151 exceeds the maximum of 100",
+		},
+		"depth": {
+			query: `{ pet(id: "1") { sightings(limit: 1) { pet { sightings(limit: 1) { pet { name } } } } } }`,
+			want:  "query depth 6 exceeds the maximum of 5",
+		},
+	}
+
+	for name, testCase := range cases {
+		t.Run(name, func(t *testing.T) {
+			// Given
+			_, _, handler := newGraphQLHandler(t, graph.HandlerSetup{MaxComplexity: 100, MaxDepth: 5})
+
+			// When
+			status, result := postQuery(t, handler, testCase.query, testCase.variables, "")
+
+			// Then
+			assert.Equal(t, http.StatusBadRequest, status)
+			require.Len(t, result.Errors, 1)
+			assert.Equal(t, testCase.want, result.Errors[0].Message)
+		})
+	}
+}
+
+func TestInvalidRequests(t *testing.T) {
+	// Given
+	_, _, handler := newGraphQLHandler(t, graph.HandlerSetup{})
+
+	// When
+	invalidStatus, invalid := postQuery(t, handler, `{ pet(id: "1") { owner } }`, nil, "")
+	mutationOverGET := httptest.NewRecorder()
+	handler.ServeHTTP(mutationOverGET, httptest.NewRequest(http.MethodGet,
+		"/graphql?query="+url.QueryEscape(`mutation { deletePet(id: "1") }`), nil))
+	textBody := httptest.NewRecorder()
+	textRequest := httptest.NewRequest(http.MethodPost, "/graphql", bytes.NewBufferString(`{ pets }`))
+	textRequest.Header.Set("Content-Type", "text/plain")
+	handler.ServeHTTP(textBody, textRequest)
+
+	// Then
+	assert.Equal(t, http.StatusBadRequest, invalidStatus)
+	require.NotEmpty(t, invalid.Errors)
+	assert.Contains(t, invalid.Errors[0].Message, `Cannot query field "owner"`)
+	assert.Equal(t, http.StatusMethodNotAllowed, mutationOverGET.Code)
+	assert.Equal(t, http.MethodPost, mutationOverGET.Header().Get("Allow"))
+	assert.Equal(t, http.StatusUnsupportedMediaType, textBody.Code)
+}
+
+func TestQueryOverGET(t *testing.T) {
+	// Given
+	_, service, handler := newGraphQLHandler(t, graph.HandlerSetup{})
+	id, err := service.Create(context.Background(), pets.NewPet{Name: "Drila"})
+	require.NoError(t, err)
+	query := url.Values{
+		"query":     []string{`query($id: ID!) { pet(id: $id) { name } }`},
+		"variables": []string{`{"id": "` + string(id) + `"}`},
+	}
+	recorder := httptest.NewRecorder()
+
+	// When
+	handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/graphql?"+query.Encode(), nil))
+
+	// Then
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	result := decodeResult(t, recorder.Body)
+	require.Empty(t, result.Errors)
+	assert.Equal(t, "Drila", result.Data["pet"].(map[string]any)["name"])
+}
+
+func TestPlayground(t *testing.T) {
+	cases := map[string]struct {
+		playground bool
+		wantStatus int
+	}{
+		"enabled":  {playground: true, wantStatus: http.StatusOK},
+		"disabled": {playground: false, wantStatus: http.StatusBadRequest},
+	}
+
+	for name, testCase := range cases {
+		t.Run(name, func(t *testing.T) {
+			// Given
+			_, _, handler := newGraphQLHandler(t, graph.HandlerSetup{Playground: testCase.playground})
+			request := httptest.NewRequest(http.MethodGet, "/graphql", nil)
+			request.Header.Set("Accept", "text/html,application/xhtml+xml")
+			recorder := httptest.NewRecorder()
+
+			// When
+			handler.ServeHTTP(recorder, request)
+
+			// Then
+			assert.Equal(t, testCase.wantStatus, recorder.Code)
+			if testCase.playground {
+				assert.Contains(t, recorder.Body.String(), "graphiql")
+			}
+		})
+	}
+}
+
+func (c *countingStore) QueryByIDs(ctx context.Context, ids []pets.PetID) ([]pets.Pet, error) {
+	c.queryByIDsCalls.Add(1)
+
+	return c.MemoryStore.QueryByIDs(ctx, ids)
+}
+
+func (c *countingStore) QuerySightings(ctx context.Context, petIDs []pets.PetID) ([]pets.Sighting, error) {
+	c.querySightingsCalls.Add(1)
+
+	return c.MemoryStore.QuerySightings(ctx, petIDs)
+}
+
+func newGraphQLHandler(t *testing.T, setup graph.HandlerSetup) (*countingStore, *pets.Service, *graph.Handler) {
+	t.Helper()
+
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	memoryStore := stores.NewMemoryStore(stores.Setup{Logger: logger})
+	store := countingStore{MemoryStore: memoryStore}
+	service := pets.NewService(pets.ServiceSetup{
+		Storer:      &store,
+		AuditStorer: memoryStore,
+		Logger:      logger,
+	})
+
+	setup.Service = service
+	setup.Logger = logger
+
+	handler, err := graph.NewHandler(setup)
+	require.NoError(t, err)
+
+	return &store, service, handler
+}
+
+func postQuery(t *testing.T, handler http.Handler, query string, variables map[string]any, actor string) (int, graphqlResult) {
+	t.Helper()
+
+	body, err := json.Marshal(map[string]any{"query": query, "variables": variables})
+	require.NoError(t, err)
+
+	request := httptest.NewRequest(http.MethodPost, "/graphql", bytes.NewReader(body))
+	request.Header.Set("Content-Type", "application/json")
+	if actor != "" {
+		request.Header.Set(web.ActorHeader, actor)
+	}
+
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, request)
+
+	return recorder.Code, decodeResult(t, recorder.Body)
+}
+
+func decodeResult(t *testing.T, body io.Reader) graphqlResult {
+	t.Helper()
+
+	var result graphqlResult
+	require.NoError(t, json.NewDecoder(body).Decode(&result))
+
+	return result
+}
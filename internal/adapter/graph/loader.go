@@ -0,0 +1,148 @@
+package graph
+
+import (
+	"context"
+	"sync"
+
+	"github.com/fernandoocampo/basic-micro/internal/pets"
+)
+
+// loader batches the loads of a request. The executor resolves a level of
+// the query before it calls the thunks of that level, so the keys loaded
+// by a level are fetched with one call when the first of their values is
+// needed. Values are kept until the request ends.
+type loader[K comparable, V any] struct {
+	fetch   func(ctx context.Context, keys []K) (map[K]V, error)
+	mutex   sync.Mutex
+	pending []K
+	results map[K]loadResult[V]
+}
+
+// loadResult is the value of a key or the error of the fetch that read it.
+type loadResult[V any] struct {
+	value V
+	err   error
+}
+
+// loaders are the loaders of a request.
+type loaders struct {
+	pets      *loader[pets.PetID, *pets.Pet]
+	sightings *loader[pets.PetID, []pets.Sighting]
+}
+
+type contextKey int
+
+const loadersKey contextKey = iota
+
+func newLoader[K comparable, V any](fetch func(ctx context.Context, keys []K) (map[K]V, error)) *loader[K, V] {
+	newLoader := loader[K, V]{
+		fetch:   fetch,
+		results: make(map[K]loadResult[V]),
+	}
+
+	return &newLoader
+}
+
+// newLoaders creates the loaders of a request on top of the service.
+func newLoaders(service *pets.Service) *loaders {
+	newLoaders := loaders{
+		pets: newLoader(func(ctx context.Context, ids []pets.PetID) (map[pets.PetID]*pets.Pet, error) {
+			found, err := service.QueryByIDs(ctx, ids)
+			if err != nil {
+				return nil, err
+			}
+
+			petsByID := make(map[pets.PetID]*pets.Pet, len(found))
+			for index := range found {
+				petsByID[found[index].ID] = &found[index]
+			}
+
+			return petsByID, nil
+		}),
+		sightings: newLoader(func(ctx context.Context, ids []pets.PetID) (map[pets.PetID][]pets.Sighting, error) {
+			sightings, err := service.Sightings(ctx, ids)
+			if err != nil {
+				return nil, err
+			}
+
+			sightingsByPet := make(map[pets.PetID][]pets.Sighting, len(ids))
+			for _, sighting := range sightings {
+				sightingsByPet[sighting.PetID] = append(sightingsByPet[sighting.PetID], sighting)
+			}
+
+			return sightingsByPet, nil
+		}),
+	}
+
+	return &newLoaders
+}
+
+// withLoaders returns a copy of ctx with the loaders of the request.
+func withLoaders(ctx context.Context, requestLoaders *loaders) context.Context {
+	return context.WithValue(ctx, loadersKey, requestLoaders)
+}
+
+// loadersFromContext returns the loaders of the request.
+func loadersFromContext(ctx context.Context) *loaders {
+	requestLoaders, _ := ctx.Value(loadersKey).(*loaders)
+
+	return requestLoaders
+}
+
+// load adds the key to the next fetch and returns a thunk that returns its
+// value, the zero value if the fetch did not find it.
+func (l *loader[K, V]) load(ctx context.Context, key K) func() (V, error) {
+	l.mutex.Lock()
+	if _, ok := l.results[key]; !ok && !l.isPending(key) {
+		l.pending = append(l.pending, key)
+	}
+	l.mutex.Unlock()
+
+	return func() (V, error) {
+		l.mutex.Lock()
+		defer l.mutex.Unlock()
+
+		if _, ok := l.results[key]; !ok {
+			if !l.isPending(key) {
+				l.pending = append(l.pending, key)
+			}
+
+			l.dispatch(ctx)
+		}
+
+		result := l.results[key]
+
+		return result.value, result.err
+	}
+}
+
+// forget removes the value of the key, so it is fetched again after it
+// was changed.
+func (l *loader[K, V]) forget(key K) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	delete(l.results, key)
+}
+
+// dispatch fetches the pending keys, it is called with the mutex locked.
+func (l *loader[K, V]) dispatch(ctx context.Context) {
+	keys := l.pending
+	l.pending = nil
+
+	values, err := l.fetch(ctx, keys)
+
+	for _, key := range keys {
+		l.results[key] = loadResult[V]{value: values[key], err: err}
+	}
+}
+
+func (l *loader[K, V]) isPending(key K) bool {
+	for _, pending := range l.pending {
+		if pending == key {
+			return true
+		}
+	}
+
+	return false
+}
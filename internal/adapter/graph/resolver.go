@@ -0,0 +1,322 @@
+package graph
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"math"
+	"time"
+
+	"github.com/fernandoocampo/basic-micro/internal/pets"
+	"github.com/graphql-go/graphql"
+)
+
+// resolver resolves the fields of the schema with the pets service.
+// Pets and sightings of other resources are read with the loaders of the
+// request, so a list of them is read with one store call.
+type resolver struct {
+	service *pets.Service
+	logger  *slog.Logger
+}
+
+// defaultSightingsLimit maximum number of sightings of a pet by default.
+const defaultSightingsLimit = 10
+
+var (
+	errPetNotFound = errors.New("pet does not exist")
+	errEmptyPetID  = errors.New("pet id cannot be empty")
+)
+
+func newResolver(service *pets.Service, logger *slog.Logger) *resolver {
+	newResolver := resolver{
+		service: service,
+		logger:  logger,
+	}
+
+	return &newResolver
+}
+
+func (r *resolver) pet(p graphql.ResolveParams) (any, error) {
+	return r.loadPet(p, pets.PetID(stringArg(p.Args, "id"))), nil
+}
+
+func (r *resolver) pets(p graphql.ResolveParams) (any, error) {
+	ids, _ := p.Args["ids"].([]any)
+
+	thunks := make([]any, 0, len(ids))
+	for _, id := range ids {
+		thunks = append(thunks, r.loadPet(p, pets.PetID(fmt.Sprint(id))))
+	}
+
+	return thunks, nil
+}
+
+func (r *resolver) searchPets(p graphql.ResolveParams) (any, error) {
+	page, err := uint8Arg(p.Args, "page")
+	if err != nil {
+		return nil, err
+	}
+
+	pageSize, err := uint8Arg(p.Args, "pageSize")
+	if err != nil {
+		return nil, err
+	}
+
+	includeDeleted, _ := p.Args["includeDeleted"].(bool)
+
+	filter := pets.QueryFilter{
+		PetName:        stringArg(p.Args, "name"),
+		OrderBy:        pets.OrderByField(stringArg(p.Args, "orderBy")),
+		PageNumber:     page,
+		RowsPerPage:    pageSize,
+		IncludeDeleted: includeDeleted,
+	}
+
+	result, err := r.service.Query(p.Context, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+func (r *resolver) nearby(p graphql.ResolveParams) (any, error) {
+	limit, err := uint8Arg(p.Args, "limit")
+	if err != nil {
+		return nil, err
+	}
+
+	radiusKm, _ := p.Args["radiusKm"].(float64)
+
+	filter := pets.NearbyFilter{
+		Location: *locationArg(p.Args, "location"),
+		RadiusKm: radiusKm,
+		Limit:    limit,
+	}
+
+	result, err := r.service.QueryNearby(p.Context, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+func (r *resolver) petHistory(p graphql.ResolveParams) (any, error) {
+	page, err := uint8Arg(p.Args, "page")
+	if err != nil {
+		return nil, err
+	}
+
+	pageSize, err := uint8Arg(p.Args, "pageSize")
+	if err != nil {
+		return nil, err
+	}
+
+	filter := pets.AuditFilter{
+		PetID:       pets.PetID(stringArg(p.Args, "petId")),
+		PageNumber:  page,
+		RowsPerPage: pageSize,
+	}
+
+	result, err := r.service.History(p.Context, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// petSightings loads the sightings of the pet with the sightings of the
+// other pets of the same level of the query.
+func (r *resolver) petSightings(p graphql.ResolveParams) (any, error) {
+	pet := sourceOf[pets.Pet](p.Source)
+
+	limit, _ := p.Args["limit"].(int)
+	if limit < 0 {
+		return nil, errors.New("limit cannot be negative")
+	}
+
+	load := loadersFromContext(p.Context).sightings.load(p.Context, pet.ID)
+
+	return func() (any, error) {
+		sightings, err := load()
+		if err != nil {
+			return nil, err
+		}
+
+		if len(sightings) > limit {
+			sightings = sightings[:limit]
+		}
+
+		return sightings, nil
+	}, nil
+}
+
+func (r *resolver) sightingPet(p graphql.ResolveParams) (any, error) {
+	sighting := sourceOf[pets.Sighting](p.Source)
+
+	return r.loadPet(p, sighting.PetID), nil
+}
+
+func (r *resolver) createPet(p graphql.ResolveParams) (any, error) {
+	input, _ := p.Args["input"].(map[string]any)
+
+	newPet := pets.NewPet{
+		Name:     stringArg(input, "name"),
+		Location: locationArg(input, "location"),
+	}
+
+	id, err := r.service.Create(p.Context, newPet)
+	if err != nil {
+		return nil, err
+	}
+
+	return r.reloadPet(p, id)
+}
+
+func (r *resolver) updatePet(p graphql.ResolveParams) (any, error) {
+	input, _ := p.Args["input"].(map[string]any)
+
+	updatePet := pets.UpdatePet{
+		ID:       pets.PetID(stringArg(input, "id")),
+		Name:     stringArg(input, "name"),
+		Location: locationArg(input, "location"),
+	}
+
+	err := r.service.Update(p.Context, updatePet)
+	if err != nil {
+		return nil, err
+	}
+
+	return r.reloadPet(p, updatePet.ID)
+}
+
+func (r *resolver) deletePet(p graphql.ResolveParams) (any, error) {
+	id := pets.PetID(stringArg(p.Args, "id"))
+
+	err := r.service.Delete(p.Context, id)
+	if err != nil {
+		return nil, err
+	}
+
+	loadersFromContext(p.Context).pets.forget(id)
+
+	return true, nil
+}
+
+func (r *resolver) restorePet(p graphql.ResolveParams) (any, error) {
+	id := pets.PetID(stringArg(p.Args, "id"))
+
+	err := r.service.Restore(p.Context, id)
+	if err != nil {
+		return nil, err
+	}
+
+	return r.reloadPet(p, id)
+}
+
+func (r *resolver) reportSighting(p graphql.ResolveParams) (any, error) {
+	input, _ := p.Args["input"].(map[string]any)
+
+	seenAt, ok := input["seenAt"].(time.Time)
+	if !ok {
+		seenAt = time.Now().UTC()
+	}
+
+	newSighting := pets.NewSighting{
+		PetID:    pets.PetID(stringArg(input, "petId")),
+		Location: *locationArg(input, "location"),
+		Notes:    stringArg(input, "notes"),
+		SeenAt:   seenAt,
+	}
+
+	id, err := r.service.ReportSighting(p.Context, newSighting)
+	if err != nil {
+		return nil, err
+	}
+
+	loadersFromContext(p.Context).sightings.forget(newSighting.PetID)
+
+	return string(id), nil
+}
+
+// loadPet returns a thunk with the pet of the given id, it is loaded with
+// the other pets of the same level of the query. An empty id fails
+// alone, so it does not fail the load of the other pets.
+func (r *resolver) loadPet(p graphql.ResolveParams, id pets.PetID) func() (any, error) {
+	if id == pets.EmptyPetID {
+		return func() (any, error) {
+			return nil, errEmptyPetID
+		}
+	}
+
+	load := loadersFromContext(p.Context).pets.load(p.Context, id)
+
+	return func() (any, error) {
+		pet, err := load()
+		if err != nil {
+			return nil, err
+		}
+
+		if pet == nil {
+			return nil, nil
+		}
+
+		return pet, nil
+	}
+}
+
+// reloadPet reads a pet that was changed by a mutation right away, the
+// mutations of a request are resolved before their thunks are called, so
+// the next mutation could change it. The value loaded before the change
+// is forgotten.
+func (r *resolver) reloadPet(p graphql.ResolveParams, id pets.PetID) (any, error) {
+	loadersFromContext(p.Context).pets.forget(id)
+
+	pet, err := r.service.QueryByID(p.Context, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if pet == nil {
+		return nil, errPetNotFound
+	}
+
+	return pet, nil
+}
+
+func stringArg(args map[string]any, name string) string {
+	value, _ := args[name].(string)
+
+	return value
+}
+
+// uint8Arg returns the int argument as the uint8 the service uses for
+// pages and limits, 0 if it was not given.
+func uint8Arg(args map[string]any, name string) (uint8, error) {
+	value, ok := args[name].(int)
+	if !ok {
+		return 0, nil
+	}
+
+	if value < 0 || value > math.MaxUint8 {
+		return 0, fmt.Errorf("%s must be between 0 and %d", name, math.MaxUint8)
+	}
+
+	return uint8(value), nil
+}
+
+// locationArg returns the location input of the arguments, nil if it was
+// not given.
+func locationArg(args map[string]any, name string) *pets.Location {
+	input, ok := args[name].(map[string]any)
+	if !ok {
+		return nil
+	}
+
+	latitude, _ := input["latitude"].(float64)
+	longitude, _ := input["longitude"].(float64)
+
+	return &pets.Location{Latitude: latitude, Longitude: longitude}
+}
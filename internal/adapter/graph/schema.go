@@ -0,0 +1,402 @@
+package graph
+
+import (
+	"github.com/fernandoocampo/basic-micro/internal/pets"
+	"github.com/graphql-go/graphql"
+)
+
+// newSchema creates the GraphQL schema of the pets service, its fields are
+// resolved by the resolver.
+func newSchema(resolver *resolver) (graphql.Schema, error) {
+	locationType := graphql.NewObject(graphql.ObjectConfig{
+		Name:        "Location",
+		Description: "Geographic coordinates in decimal degrees.",
+		Fields: graphql.Fields{
+			"latitude": &graphql.Field{
+				Type:    graphql.NewNonNull(graphql.Float),
+				Resolve: getter(func(location pets.Location) any { return location.Latitude }),
+			},
+			"longitude": &graphql.Field{
+				Type:    graphql.NewNonNull(graphql.Float),
+				Resolve: getter(func(location pets.Location) any { return location.Longitude }),
+			},
+		},
+	})
+
+	locationInput := graphql.NewInputObject(graphql.InputObjectConfig{
+		Name: "LocationInput",
+		Fields: graphql.InputObjectConfigFieldMap{
+			"latitude":  &graphql.InputObjectFieldConfig{Type: graphql.NewNonNull(graphql.Float)},
+			"longitude": &graphql.InputObjectFieldConfig{Type: graphql.NewNonNull(graphql.Float)},
+		},
+	})
+
+	// pet and sighting refer to each other, so their fields are thunks.
+	var petType, sightingType *graphql.Object
+
+	petType = graphql.NewObject(graphql.ObjectConfig{
+		Name: "Pet",
+		Fields: graphql.FieldsThunk(func() graphql.Fields {
+			return graphql.Fields{
+				"id": &graphql.Field{
+					Type:    graphql.NewNonNull(graphql.ID),
+					Resolve: getter(func(pet pets.Pet) any { return string(pet.ID) }),
+				},
+				"name": &graphql.Field{
+					Type:    graphql.NewNonNull(graphql.String),
+					Resolve: getter(func(pet pets.Pet) any { return pet.Name }),
+				},
+				"location": &graphql.Field{
+					Type:    locationType,
+					Resolve: getter(func(pet pets.Pet) any { return pet.Location }),
+				},
+				"deletedAt": &graphql.Field{
+					Type:        graphql.DateTime,
+					Description: "Set when the pet was deleted, deleted pets can be restored until they are purged.",
+					Resolve:     getter(func(pet pets.Pet) any { return pet.DeletedAt }),
+				},
+				"sightings": &graphql.Field{
+					Type:        graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(sightingType))),
+					Description: "Sightings of the pet, newest first.",
+					Args: graphql.FieldConfigArgument{
+						"limit": &graphql.ArgumentConfig{Type: graphql.Int, DefaultValue: defaultSightingsLimit},
+					},
+					Resolve: resolver.petSightings,
+				},
+			}
+		}),
+	})
+
+	sightingType = graphql.NewObject(graphql.ObjectConfig{
+		Name: "Sighting",
+		Fields: graphql.FieldsThunk(func() graphql.Fields {
+			return graphql.Fields{
+				"id": &graphql.Field{
+					Type:    graphql.NewNonNull(graphql.ID),
+					Resolve: getter(func(sighting pets.Sighting) any { return string(sighting.ID) }),
+				},
+				"petId": &graphql.Field{
+					Type:    graphql.NewNonNull(graphql.ID),
+					Resolve: getter(func(sighting pets.Sighting) any { return string(sighting.PetID) }),
+				},
+				"pet": &graphql.Field{
+					Type:        petType,
+					Description: "The pet that was seen, null if it was deleted.",
+					Resolve:     resolver.sightingPet,
+				},
+				"location": &graphql.Field{
+					Type:    graphql.NewNonNull(locationType),
+					Resolve: getter(func(sighting pets.Sighting) any { return sighting.Location }),
+				},
+				"notes": &graphql.Field{
+					Type:    graphql.NewNonNull(graphql.String),
+					Resolve: getter(func(sighting pets.Sighting) any { return sighting.Notes }),
+				},
+				"seenAt": &graphql.Field{
+					Type:    graphql.NewNonNull(graphql.DateTime),
+					Resolve: getter(func(sighting pets.Sighting) any { return sighting.SeenAt }),
+				},
+			}
+		}),
+	})
+
+	petPageType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "PetPage",
+		Fields: graphql.Fields{
+			"pets": &graphql.Field{
+				Type:    graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(petType))),
+				Resolve: getter(func(result pets.SearchPetsResult) any { return result.Pets }),
+			},
+			"total": &graphql.Field{
+				Type:        graphql.NewNonNull(graphql.Int),
+				Description: "Number of pets that match the filter.",
+				Resolve:     getter(func(result pets.SearchPetsResult) any { return result.Total }),
+			},
+			"page": &graphql.Field{
+				Type:    graphql.NewNonNull(graphql.Int),
+				Resolve: getter(func(result pets.SearchPetsResult) any { return int(result.Page) }),
+			},
+			"pageSize": &graphql.Field{
+				Type:    graphql.NewNonNull(graphql.Int),
+				Resolve: getter(func(result pets.SearchPetsResult) any { return int(result.RowsPerPage) }),
+			},
+		},
+	})
+
+	nearbyPetType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "NearbyPet",
+		Fields: graphql.Fields{
+			"pet": &graphql.Field{
+				Type:    graphql.NewNonNull(petType),
+				Resolve: getter(func(nearby pets.NearbyPet) any { return nearby.Pet }),
+			},
+			"distanceKm": &graphql.Field{
+				Type:    graphql.NewNonNull(graphql.Float),
+				Resolve: getter(func(nearby pets.NearbyPet) any { return nearby.DistanceKm }),
+			},
+		},
+	})
+
+	nearbySightingType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "NearbySighting",
+		Fields: graphql.Fields{
+			"sighting": &graphql.Field{
+				Type:    graphql.NewNonNull(sightingType),
+				Resolve: getter(func(nearby pets.NearbySighting) any { return nearby.Sighting }),
+			},
+			"distanceKm": &graphql.Field{
+				Type:    graphql.NewNonNull(graphql.Float),
+				Resolve: getter(func(nearby pets.NearbySighting) any { return nearby.DistanceKm }),
+			},
+		},
+	})
+
+	nearbyType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Nearby",
+		Fields: graphql.Fields{
+			"pets": &graphql.Field{
+				Type:        graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(nearbyPetType))),
+				Description: "Pets ordered by distance.",
+				Resolve:     getter(func(result pets.NearbyResult) any { return result.Pets }),
+			},
+			"sightings": &graphql.Field{
+				Type:        graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(nearbySightingType))),
+				Description: "Sightings ordered by distance.",
+				Resolve:     getter(func(result pets.NearbyResult) any { return result.Sightings }),
+			},
+		},
+	})
+
+	fieldChangeType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "FieldChange",
+		Fields: graphql.Fields{
+			"field": &graphql.Field{
+				Type:    graphql.NewNonNull(graphql.String),
+				Resolve: getter(func(change pets.FieldChange) any { return change.Field }),
+			},
+			"before": &graphql.Field{
+				Type:    graphql.NewNonNull(graphql.String),
+				Resolve: getter(func(change pets.FieldChange) any { return change.Before }),
+			},
+			"after": &graphql.Field{
+				Type:    graphql.NewNonNull(graphql.String),
+				Resolve: getter(func(change pets.FieldChange) any { return change.After }),
+			},
+		},
+	})
+
+	auditRecordType := graphql.NewObject(graphql.ObjectConfig{
+		Name:        "AuditRecord",
+		Description: "A change made to a pet.",
+		Fields: graphql.Fields{
+			"id": &graphql.Field{
+				Type:    graphql.NewNonNull(graphql.ID),
+				Resolve: getter(func(record pets.AuditRecord) any { return string(record.ID) }),
+			},
+			"petId": &graphql.Field{
+				Type:    graphql.NewNonNull(graphql.ID),
+				Resolve: getter(func(record pets.AuditRecord) any { return string(record.PetID) }),
+			},
+			"action": &graphql.Field{
+				Type:    graphql.NewNonNull(graphql.String),
+				Resolve: getter(func(record pets.AuditRecord) any { return string(record.Action) }),
+			},
+			"actor": &graphql.Field{
+				Type:    graphql.NewNonNull(graphql.String),
+				Resolve: getter(func(record pets.AuditRecord) any { return record.Actor }),
+			},
+			"assertedActor": &graphql.Field{
+				Type:        graphql.NewNonNull(graphql.String),
+				Description: "The actor the client says it is, it is not authenticated.",
+				Resolve:     getter(func(record pets.AuditRecord) any { return record.AssertedActor }),
+			},
+			"requestId": &graphql.Field{
+				Type:    graphql.NewNonNull(graphql.String),
+				Resolve: getter(func(record pets.AuditRecord) any { return record.RequestID }),
+			},
+			"timestamp": &graphql.Field{
+				Type:    graphql.NewNonNull(graphql.DateTime),
+				Resolve: getter(func(record pets.AuditRecord) any { return record.Timestamp }),
+			},
+			"changes": &graphql.Field{
+				Type:    graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(fieldChangeType))),
+				Resolve: getter(func(record pets.AuditRecord) any { return record.Changes }),
+			},
+		},
+	})
+
+	petHistoryType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "PetHistory",
+		Fields: graphql.Fields{
+			"records": &graphql.Field{
+				Type:        graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(auditRecordType))),
+				Description: "Changes of the pet, oldest first.",
+				Resolve:     getter(func(result pets.AuditRecordsResult) any { return result.Records }),
+			},
+			"total": &graphql.Field{
+				Type:    graphql.NewNonNull(graphql.Int),
+				Resolve: getter(func(result pets.AuditRecordsResult) any { return result.Total }),
+			},
+			"page": &graphql.Field{
+				Type:    graphql.NewNonNull(graphql.Int),
+				Resolve: getter(func(result pets.AuditRecordsResult) any { return int(result.Page) }),
+			},
+			"pageSize": &graphql.Field{
+				Type:    graphql.NewNonNull(graphql.Int),
+				Resolve: getter(func(result pets.AuditRecordsResult) any { return int(result.RowsPerPage) }),
+			},
+		},
+	})
+
+	queryType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Query",
+		Fields: graphql.Fields{
+			"pet": &graphql.Field{
+				Type:        petType,
+				Description: "The pet with the given id, null if it does not exist or it was deleted.",
+				Args: graphql.FieldConfigArgument{
+					"id": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.ID)},
+				},
+				Resolve: resolver.pet,
+			},
+			"pets": &graphql.Field{
+				Type:        graphql.NewNonNull(graphql.NewList(petType)),
+				Description: "The pets with the given ids in the same order, null for pets that do not exist or were deleted.",
+				Args: graphql.FieldConfigArgument{
+					"ids": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(graphql.ID)))},
+				},
+				Resolve: resolver.pets,
+			},
+			"searchPets": &graphql.Field{
+				Type:        graphql.NewNonNull(petPageType),
+				Description: "Pets whose name contains the given name, the name is required to find pets.",
+				Args: graphql.FieldConfigArgument{
+					"name":           &graphql.ArgumentConfig{Type: graphql.String},
+					"orderBy":        &graphql.ArgumentConfig{Type: graphql.String},
+					"page":           &graphql.ArgumentConfig{Type: graphql.Int},
+					"pageSize":       &graphql.ArgumentConfig{Type: graphql.Int},
+					"includeDeleted": &graphql.ArgumentConfig{Type: graphql.Boolean},
+				},
+				Resolve: resolver.searchPets,
+			},
+			"nearby": &graphql.Field{
+				Type:        graphql.NewNonNull(nearbyType),
+				Description: "Pets and sightings around a location.",
+				Args: graphql.FieldConfigArgument{
+					"location": &graphql.ArgumentConfig{Type: graphql.NewNonNull(locationInput)},
+					"radiusKm": &graphql.ArgumentConfig{Type: graphql.Float},
+					"limit":    &graphql.ArgumentConfig{Type: graphql.Int},
+				},
+				Resolve: resolver.nearby,
+			},
+			"petHistory": &graphql.Field{
+				Type:        graphql.NewNonNull(petHistoryType),
+				Description: "Changes made to a pet, oldest first.",
+				Args: graphql.FieldConfigArgument{
+					"petId":    &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.ID)},
+					"page":     &graphql.ArgumentConfig{Type: graphql.Int},
+					"pageSize": &graphql.ArgumentConfig{Type: graphql.Int},
+				},
+				Resolve: resolver.petHistory,
+			},
+		},
+	})
+
+	createPetInput := graphql.NewInputObject(graphql.InputObjectConfig{
+		Name: "CreatePetInput",
+		Fields: graphql.InputObjectConfigFieldMap{
+			"name":     &graphql.InputObjectFieldConfig{Type: graphql.NewNonNull(graphql.String)},
+			"location": &graphql.InputObjectFieldConfig{Type: locationInput},
+		},
+	})
+
+	updatePetInput := graphql.NewInputObject(graphql.InputObjectConfig{
+		Name: "UpdatePetInput",
+		Fields: graphql.InputObjectConfigFieldMap{
+			"id":       &graphql.InputObjectFieldConfig{Type: graphql.NewNonNull(graphql.ID)},
+			"name":     &graphql.InputObjectFieldConfig{Type: graphql.NewNonNull(graphql.String)},
+			"location": &graphql.InputObjectFieldConfig{Type: locationInput},
+		},
+	})
+
+	reportSightingInput := graphql.NewInputObject(graphql.InputObjectConfig{
+		Name: "ReportSightingInput",
+		Fields: graphql.InputObjectConfigFieldMap{
+			"petId":    &graphql.InputObjectFieldConfig{Type: graphql.NewNonNull(graphql.ID)},
+			"location": &graphql.InputObjectFieldConfig{Type: graphql.NewNonNull(locationInput)},
+			"notes":    &graphql.InputObjectFieldConfig{Type: graphql.String},
+			"seenAt":   &graphql.InputObjectFieldConfig{Type: graphql.DateTime, Description: "Now if it is not given."},
+		},
+	})
+
+	mutationType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Mutation",
+		Fields: graphql.Fields{
+			"createPet": &graphql.Field{
+				Type: graphql.NewNonNull(petType),
+				Args: graphql.FieldConfigArgument{
+					"input": &graphql.ArgumentConfig{Type: graphql.NewNonNull(createPetInput)},
+				},
+				Resolve: resolver.createPet,
+			},
+			"updatePet": &graphql.Field{
+				Type: graphql.NewNonNull(petType),
+				Args: graphql.FieldConfigArgument{
+					"input": &graphql.ArgumentConfig{Type: graphql.NewNonNull(updatePetInput)},
+				},
+				Resolve: resolver.updatePet,
+			},
+			"deletePet": &graphql.Field{
+				Type:        graphql.NewNonNull(graphql.Boolean),
+				Description: "Marks the pet as deleted, it can be restored until it is purged.",
+				Args: graphql.FieldConfigArgument{
+					"id": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.ID)},
+				},
+				Resolve: resolver.deletePet,
+			},
+			"restorePet": &graphql.Field{
+				Type: graphql.NewNonNull(petType),
+				Args: graphql.FieldConfigArgument{
+					"id": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.ID)},
+				},
+				Resolve: resolver.restorePet,
+			},
+			"reportSighting": &graphql.Field{
+				Type:        graphql.NewNonNull(graphql.ID),
+				Description: "Records that a pet was seen somewhere and returns the id of the sighting.",
+				Args: graphql.FieldConfigArgument{
+					"input": &graphql.ArgumentConfig{Type: graphql.NewNonNull(reportSightingInput)},
+				},
+				Resolve: resolver.reportSighting,
+			},
+		},
+	})
+
+	return graphql.NewSchema(graphql.SchemaConfig{
+		Query:    queryType,
+		Mutation: mutationType,
+	})
+}
+
+// getter resolves a field with a value of its source, the source can be
+// an S or a pointer to an S.
+func getter[S any](get func(source S) any) graphql.FieldResolveFn {
+	return func(p graphql.ResolveParams) (any, error) {
+		return get(sourceOf[S](p.Source)), nil
+	}
+}
+
+func sourceOf[S any](source any) S {
+	switch value := source.(type) {
+	case S:
+		return value
+	case *S:
+		if value != nil {
+			return *value
+		}
+	}
+
+	var zero S
+
+	return zero
+}
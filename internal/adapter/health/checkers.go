@@ -0,0 +1,57 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// Pinger defines behavior of dependencies that can be pinged, like stores.
+type Pinger interface {
+	Ping(ctx context.Context) error
+}
+
+// Ready defines behavior of flags that say if the service can receive traffic.
+type Ready interface {
+	IsReady() bool
+}
+
+var (
+	errNotReady          = errors.New("service is shutting down")
+	errUnsupportedStatfs = errors.New("disk space check is not supported in this platform")
+)
+
+// PingChecker checks that the pinger answers.
+func PingChecker(pinger Pinger) Checker {
+	return CheckerFunc(func(ctx context.Context) error {
+		return pinger.Ping(ctx)
+	})
+}
+
+// ReadyChecker fails when ready says the service cannot receive traffic.
+func ReadyChecker(ready Ready) Checker {
+	return CheckerFunc(func(ctx context.Context) error {
+		if !ready.IsReady() {
+			return errNotReady
+		}
+
+		return nil
+	})
+}
+
+// DiskSpaceChecker fails if the file system of path has less than
+// minFreeBytes available.
+func DiskSpaceChecker(path string, minFreeBytes uint64) Checker {
+	return CheckerFunc(func(ctx context.Context) error {
+		free, err := freeBytes(path)
+		if err != nil {
+			return err
+		}
+
+		if free < minFreeBytes {
+			return fmt.Errorf("%s has %d bytes free but %d are required", path, free, minFreeBytes)
+		}
+
+		return nil
+	})
+}
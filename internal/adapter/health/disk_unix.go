@@ -0,0 +1,18 @@
+//go:build unix
+
+package health
+
+import "syscall"
+
+// freeBytes returns the bytes available to unprivileged users in the file
+// system of path.
+func freeBytes(path string) (uint64, error) {
+	var stat syscall.Statfs_t
+
+	err := syscall.Statfs(path, &stat)
+	if err != nil {
+		return 0, err
+	}
+
+	return uint64(stat.Bavail) * uint64(stat.Bsize), nil
+}
@@ -0,0 +1,3 @@
+// Package health checks the dependencies of the service for the liveness,
+// readiness and startup probes.
+package health
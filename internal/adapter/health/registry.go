@@ -0,0 +1,225 @@
+package health
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// Probe defines the kind of probe a check belongs to.
+type Probe string
+
+// Status defines the result of a check.
+type Status string
+
+// Checker checks a dependency, it returns an error if the dependency is
+// not healthy.
+type Checker interface {
+	Check(ctx context.Context) error
+}
+
+// CheckerFunc is a function that works as a Checker.
+type CheckerFunc func(ctx context.Context) error
+
+// Check contains data to register a checker.
+type Check struct {
+	Name    string
+	Checker Checker
+	// Probes the check is run for.
+	Probes []Probe
+	// NoCache runs the checker on every probe, it is for cheap checks
+	// whose result must be seen right away.
+	NoCache bool
+}
+
+// CheckResult contains the result of a check.
+type CheckResult struct {
+	Status    Status    `json:"status"`
+	Error     string    `json:"error,omitempty"`
+	Duration  string    `json:"duration"`
+	CheckedAt time.Time `json:"checked_at"`
+}
+
+// Report contains the result of all checks of a probe, it fails if any
+// check fails.
+type Report struct {
+	Status Status                 `json:"status"`
+	Checks map[string]CheckResult `json:"checks"`
+}
+
+// RegistrySetup contains health registry metadata.
+type RegistrySetup struct {
+	// Timeout how long a check can take before it fails.
+	Timeout time.Duration
+	// CacheTTL how long a check result is reused, so probes do not
+	// overload dependencies like the database.
+	CacheTTL time.Duration
+	Logger   *slog.Logger
+}
+
+// Registry keeps the checks of every probe.
+type Registry struct {
+	mutex    sync.RWMutex
+	checks   map[Probe][]*cachedCheck
+	timeout  time.Duration
+	cacheTTL time.Duration
+	logger   *slog.Logger
+}
+
+// cachedCheck keeps the last result of a check, its mutex makes sure a
+// check runs once at a time no matter how many probes ask for it.
+type cachedCheck struct {
+	check     Check
+	mutex     sync.Mutex
+	result    CheckResult
+	expiresAt time.Time
+}
+
+// probes
+const (
+	Liveness  Probe = "live"
+	Readiness Probe = "ready"
+	Startup   Probe = "startup"
+)
+
+// statuses
+const (
+	StatusPass Status = "pass"
+	StatusFail Status = "fail"
+)
+
+func NewRegistry(setup RegistrySetup) *Registry {
+	newRegistry := Registry{
+		checks:   make(map[Probe][]*cachedCheck),
+		timeout:  setup.Timeout,
+		cacheTTL: setup.CacheTTL,
+		logger:   setup.Logger,
+	}
+
+	return &newRegistry
+}
+
+// Check calls f(ctx).
+func (f CheckerFunc) Check(ctx context.Context) error {
+	return f(ctx)
+}
+
+// Register adds the check to its probes, a check registered for many
+// probes shares its cached result.
+func (r *Registry) Register(check Check) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	newCheck := cachedCheck{
+		check: check,
+	}
+
+	for _, probe := range check.Probes {
+		r.checks[probe] = append(r.checks[probe], &newCheck)
+	}
+}
+
+// SetTimeouts changes the timeout and the cache TTL of the next checks.
+func (r *Registry) SetTimeouts(timeout, cacheTTL time.Duration) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	r.timeout = timeout
+	r.cacheTTL = cacheTTL
+}
+
+// Run runs the checks of the probe at the same time. A probe without
+// checks passes.
+func (r *Registry) Run(ctx context.Context, probe Probe) Report {
+	r.mutex.RLock()
+	checks := r.checks[probe]
+	timeout := r.timeout
+	cacheTTL := r.cacheTTL
+	r.mutex.RUnlock()
+
+	report := Report{
+		Status: StatusPass,
+		Checks: make(map[string]CheckResult, len(checks)),
+	}
+
+	results := make([]CheckResult, len(checks))
+
+	var wg sync.WaitGroup
+	for index, check := range checks {
+		wg.Add(1)
+
+		go func(index int, check *cachedCheck) {
+			defer wg.Done()
+
+			results[index] = r.run(ctx, check, timeout, cacheTTL)
+		}(index, check)
+	}
+
+	wg.Wait()
+
+	for index, check := range checks {
+		report.Checks[check.check.Name] = results[index]
+
+		if results[index].Status == StatusFail {
+			report.Status = StatusFail
+		}
+	}
+
+	return report
+}
+
+// run returns the cached result of the check or runs it if it expired.
+func (r *Registry) run(ctx context.Context, check *cachedCheck, timeout, cacheTTL time.Duration) CheckResult {
+	check.mutex.Lock()
+	defer check.mutex.Unlock()
+
+	now := time.Now()
+	if !check.check.NoCache && now.Before(check.expiresAt) {
+		return check.result
+	}
+
+	checkCtx := ctx
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		checkCtx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	err := runChecker(checkCtx, check.check.Checker)
+
+	result := CheckResult{
+		Status:    StatusPass,
+		Duration:  time.Since(now).String(),
+		CheckedAt: now.UTC(),
+	}
+
+	if err != nil {
+		r.logger.Warn("health check failed", slog.String("check", check.check.Name), "error", err)
+
+		result.Status = StatusFail
+		result.Error = err.Error()
+	}
+
+	check.result = result
+	check.expiresAt = now.Add(cacheTTL)
+
+	return result
+}
+
+// runChecker returns the checker error, or the context error if the
+// checker does not finish in time.
+func runChecker(ctx context.Context, checker Checker) error {
+	errStream := make(chan error, 1)
+
+	go func() {
+		errStream <- checker.Check(ctx)
+	}()
+
+	select {
+	case err := <-errStream:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,129 @@
+package health_test
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"math"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/fernandoocampo/basic-micro/internal/adapter/health"
+	"github.com/stretchr/testify/assert"
+)
+
+// countChecker counts how many times it was called and answers err.
+type countChecker struct {
+	calls atomic.Int32
+	err   error
+}
+
+func (c *countChecker) Check(ctx context.Context) error {
+	c.calls.Add(1)
+
+	return c.err
+}
+
+func TestRegistryRun(t *testing.T) {
+	// Given
+	registry := newRegistry(time.Minute, time.Second)
+	registry.Register(health.Check{
+		Name:    "store",
+		Checker: &countChecker{},
+		Probes:  []health.Probe{health.Readiness, health.Startup},
+	})
+	registry.Register(health.Check{
+		Name:    "telemetry",
+		Checker: &countChecker{err: errors.New("exporter is down")},
+		Probes:  []health.Probe{health.Readiness},
+	})
+
+	// When
+	ready := registry.Run(context.TODO(), health.Readiness)
+	startup := registry.Run(context.TODO(), health.Startup)
+	live := registry.Run(context.TODO(), health.Liveness)
+
+	// Then
+	assert.Equal(t, health.StatusFail, ready.Status)
+	assert.Equal(t, health.StatusPass, ready.Checks["store"].Status)
+	assert.Equal(t, health.StatusFail, ready.Checks["telemetry"].Status)
+	assert.Equal(t, "exporter is down", ready.Checks["telemetry"].Error)
+	assert.Equal(t, health.StatusPass, startup.Status)
+	assert.Len(t, startup.Checks, 1)
+	assert.Equal(t, health.StatusPass, live.Status)
+	assert.Empty(t, live.Checks)
+}
+
+func TestRegistryCachesResults(t *testing.T) {
+	// Given
+	cached := &countChecker{}
+	notCached := &countChecker{}
+	registry := newRegistry(time.Minute, time.Second)
+	registry.Register(health.Check{
+		Name:    "store",
+		Checker: cached,
+		Probes:  []health.Probe{health.Readiness, health.Startup},
+	})
+	registry.Register(health.Check{
+		Name:    "shutdown",
+		Checker: notCached,
+		Probes:  []health.Probe{health.Readiness},
+		NoCache: true,
+	})
+
+	// When
+	for i := 0; i < 3; i++ {
+		registry.Run(context.TODO(), health.Readiness)
+		registry.Run(context.TODO(), health.Startup)
+	}
+
+	// Then
+	assert.Equal(t, int32(1), cached.calls.Load())
+	assert.Equal(t, int32(3), notCached.calls.Load())
+}
+
+func TestRegistryCheckTimeout(t *testing.T) {
+	// Given
+	registry := newRegistry(0, 20*time.Millisecond)
+	registry.Register(health.Check{
+		Name: "store",
+		Checker: health.CheckerFunc(func(ctx context.Context) error {
+			time.Sleep(time.Second)
+
+			return nil
+		}),
+		Probes: []health.Probe{health.Readiness},
+	})
+
+	// When
+	start := time.Now()
+	got := registry.Run(context.TODO(), health.Readiness)
+
+	// Then
+	assert.Less(t, time.Since(start), time.Second)
+	assert.Equal(t, health.StatusFail, got.Status)
+	assert.Equal(t, context.DeadlineExceeded.Error(), got.Checks["store"].Error)
+}
+
+func TestDiskSpaceChecker(t *testing.T) {
+	// Given
+	enoughSpace := health.DiskSpaceChecker(t.TempDir(), 1)
+	notEnoughSpace := health.DiskSpaceChecker(t.TempDir(), math.MaxUint64)
+
+	// When
+	enoughErr := enoughSpace.Check(context.TODO())
+	notEnoughErr := notEnoughSpace.Check(context.TODO())
+
+	// Then
+	assert.NoError(t, enoughErr)
+	assert.Error(t, notEnoughErr)
+}
+
+func newRegistry(cacheTTL, timeout time.Duration) *health.Registry {
+	return health.NewRegistry(health.RegistrySetup{
+		Timeout:  timeout,
+		CacheTTL: cacheTTL,
+		Logger:   slog.Default(),
+	})
+}
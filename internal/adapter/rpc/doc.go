@@ -0,0 +1,3 @@
+// Package rpc serves the pets endpoints over gRPC, the service is defined
+// in proto/pets/v1/pets.proto.
+package rpc
@@ -0,0 +1,65 @@
+package rpc
+
+import (
+	"context"
+	"errors"
+
+	"github.com/fernandoocampo/basic-micro/internal/pets"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+var (
+	errMissingLocation  = errors.New("location must be given")
+	errPageTooLarge     = errors.New("page cannot be larger than 255")
+	errPageSizeTooLarge = errors.New("page size cannot be larger than 255")
+	errLimitTooLarge    = errors.New("limit cannot be larger than 255")
+)
+
+// resultError returns the status error of an endpoint call that failed
+// with err or with the error message and kind of its result, or nil if it
+// did not fail. Calls that failed because they were cancelled or timed out
+// answer CANCELED or DEADLINE_EXCEEDED.
+func resultError(ctx context.Context, err error, message string, kind pets.ErrorKind) error {
+	return resultStatus(ctx, err, message, kind).Err()
+}
+
+// resultStatus is resultError as a status, so details can be added to it.
+func resultStatus(ctx context.Context, err error, message string, kind pets.ErrorKind) *status.Status {
+	if err != nil {
+		message = err.Error()
+		kind = pets.KindOf(err)
+	}
+
+	if message == "" {
+		return nil
+	}
+
+	if ctxErr := ctx.Err(); ctxErr != nil {
+		return status.FromContextError(ctxErr)
+	}
+
+	return status.New(codeOf(kind), message)
+}
+
+// invalidArgument returns an INVALID_ARGUMENT status error, it is used for
+// requests that cannot be converted to domain requests.
+func invalidArgument(err error) error {
+	return status.Error(codes.InvalidArgument, err.Error())
+}
+
+// codeOf returns the status code of the domain error kind.
+func codeOf(kind pets.ErrorKind) codes.Code {
+	switch kind {
+	case pets.InvalidError:
+		return codes.InvalidArgument
+	case pets.NotFoundError:
+		return codes.NotFound
+	case pets.AbortedError:
+		return codes.Aborted
+	case pets.UnavailableError:
+		return codes.Unavailable
+	}
+
+	return codes.Internal
+}
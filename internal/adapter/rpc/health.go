@@ -0,0 +1,85 @@
+package rpc
+
+import (
+	"context"
+	"time"
+
+	"github.com/fernandoocampo/basic-micro/internal/adapter/health"
+	petsv1 "github.com/fernandoocampo/basic-micro/proto/pets/v1"
+	"google.golang.org/grpc/codes"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+)
+
+// healthService answers the gRPC health checks of the server and the pets
+// service with the readiness probe, so it stops serving when the service
+// shuts down or a dependency fails.
+type healthService struct {
+	healthpb.UnimplementedHealthServer
+	registry *health.Registry
+}
+
+// watchInterval how often the status of a watched service is checked.
+const watchInterval = 5 * time.Second
+
+func newHealthService(registry *health.Registry) *healthService {
+	newService := healthService{
+		registry: registry,
+	}
+
+	return &newService
+}
+
+func (h *healthService) Check(ctx context.Context, req *healthpb.HealthCheckRequest) (*healthpb.HealthCheckResponse, error) {
+	if !knownService(req.GetService()) {
+		return nil, status.Errorf(codes.NotFound, "unknown service %q", req.GetService())
+	}
+
+	return &healthpb.HealthCheckResponse{Status: h.status(ctx)}, nil
+}
+
+// Watch sends the status of the service and then every change until the
+// call is cancelled.
+func (h *healthService) Watch(req *healthpb.HealthCheckRequest, stream healthpb.Health_WatchServer) error {
+	ticker := time.NewTicker(watchInterval)
+	defer ticker.Stop()
+
+	var last healthpb.HealthCheckResponse_ServingStatus = -1
+
+	for {
+		current := healthpb.HealthCheckResponse_SERVICE_UNKNOWN
+		if knownService(req.GetService()) {
+			current = h.status(stream.Context())
+		}
+
+		if current != last {
+			err := stream.Send(&healthpb.HealthCheckResponse{Status: current})
+			if err != nil {
+				return err
+			}
+
+			last = current
+		}
+
+		select {
+		case <-stream.Context().Done():
+			return status.FromContextError(stream.Context().Err()).Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+func (h *healthService) status(ctx context.Context) healthpb.HealthCheckResponse_ServingStatus {
+	report := h.registry.Run(ctx, health.Readiness)
+	if report.Status != health.StatusPass {
+		return healthpb.HealthCheckResponse_NOT_SERVING
+	}
+
+	return healthpb.HealthCheckResponse_SERVING
+}
+
+// knownService says if the service is the server, the empty name, or the
+// pets service.
+func knownService(service string) bool {
+	return service == "" || service == petsv1.PetService_ServiceDesc.ServiceName
+}
@@ -0,0 +1,159 @@
+package rpc
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"runtime/debug"
+	"time"
+
+	"github.com/fernandoocampo/basic-micro/internal/pets"
+	"github.com/google/uuid"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// metadata keys, they are the HTTP headers of the web API in lower case.
+const (
+	ActorKey      = "x-actor"
+	RequestIDKey  = "x-request-id"
+	TotalCountKey = "x-total-count"
+)
+
+// contextStream is a server stream with a different context.
+type contextStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+// unaryRequestMetadata adds the actor and request id of the call metadata
+// to its context, so they can be part of the audit records. A request id
+// is created if the client did not send it and it is returned in the
+// response header.
+func unaryRequestMetadata(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	ctx, requestID := contextWithRequestMetadata(ctx)
+
+	err := grpc.SetHeader(ctx, metadata.Pairs(RequestIDKey, requestID))
+	if err != nil {
+		return nil, err
+	}
+
+	return handler(ctx, req)
+}
+
+// streamRequestMetadata is unaryRequestMetadata for streams.
+func streamRequestMetadata(srv any, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	ctx, requestID := contextWithRequestMetadata(stream.Context())
+
+	err := stream.SetHeader(metadata.Pairs(RequestIDKey, requestID))
+	if err != nil {
+		return err
+	}
+
+	return handler(srv, &contextStream{ServerStream: stream, ctx: ctx})
+}
+
+// unaryAccessLog logs every call with its status code and duration.
+func unaryAccessLog(logger *slog.Logger) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		start := time.Now()
+
+		resp, err := handler(ctx, req)
+
+		logCall(ctx, logger, info.FullMethod, err, start)
+
+		return resp, err
+	}
+}
+
+// streamAccessLog is unaryAccessLog for streams.
+func streamAccessLog(logger *slog.Logger) grpc.StreamServerInterceptor {
+	return func(srv any, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		start := time.Now()
+
+		err := handler(srv, stream)
+
+		logCall(stream.Context(), logger, info.FullMethod, err, start)
+
+		return err
+	}
+}
+
+// unaryRecovery answers INTERNAL if the handler panics, so a single call
+// does not end the server.
+func unaryRecovery(logger *slog.Logger) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (resp any, err error) {
+		defer func() {
+			recovered := recover()
+			if recovered != nil {
+				err = recoveredError(ctx, logger, info.FullMethod, recovered)
+			}
+		}()
+
+		return handler(ctx, req)
+	}
+}
+
+// streamRecovery is unaryRecovery for streams.
+func streamRecovery(logger *slog.Logger) grpc.StreamServerInterceptor {
+	return func(srv any, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) (err error) {
+		defer func() {
+			recovered := recover()
+			if recovered != nil {
+				err = recoveredError(stream.Context(), logger, info.FullMethod, recovered)
+			}
+		}()
+
+		return handler(srv, stream)
+	}
+}
+
+func contextWithRequestMetadata(ctx context.Context) (context.Context, string) {
+	md, _ := metadata.FromIncomingContext(ctx)
+
+	if actor := firstValue(md, ActorKey); actor != "" {
+		ctx = pets.WithAssertedActor(ctx, actor)
+	}
+
+	requestID := firstValue(md, RequestIDKey)
+	if requestID == "" {
+		requestID = uuid.New().String()
+	}
+
+	return pets.WithRequestID(ctx, requestID), requestID
+}
+
+func logCall(ctx context.Context, logger *slog.Logger, method string, err error, start time.Time) {
+	logger.Info("call served",
+		slog.String("method", method),
+		slog.String("code", status.Code(err).String()),
+		slog.Duration("duration", time.Since(start)),
+		slog.String("request_id", pets.RequestIDFromContext(ctx)),
+	)
+}
+
+func recoveredError(ctx context.Context, logger *slog.Logger, method string, recovered any) error {
+	logger.Error("call panicked",
+		slog.String("method", method),
+		slog.String("panic", fmt.Sprint(recovered)),
+		slog.String("request_id", pets.RequestIDFromContext(ctx)),
+		slog.String("stack", string(debug.Stack())),
+	)
+
+	return status.Error(codes.Internal, "internal error")
+}
+
+func firstValue(md metadata.MD, key string) string {
+	values := md.Get(key)
+	if len(values) == 0 {
+		return ""
+	}
+
+	return values[0]
+}
+
+func (c *contextStream) Context() context.Context {
+	return c.ctx
+}
@@ -0,0 +1,274 @@
+package rpc
+
+import (
+	"math"
+
+	"github.com/fernandoocampo/basic-micro/internal/pets"
+	petsv1 "github.com/fernandoocampo/basic-micro/proto/pets/v1"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// proto values of the batch operation types and item statuses.
+var (
+	batchOperationTypes = map[pets.BatchOperationType]petsv1.BatchOperationType{
+		pets.BatchCreate: petsv1.BatchOperationType_BATCH_OPERATION_TYPE_CREATE,
+		pets.BatchUpdate: petsv1.BatchOperationType_BATCH_OPERATION_TYPE_UPDATE,
+		pets.BatchDelete: petsv1.BatchOperationType_BATCH_OPERATION_TYPE_DELETE,
+	}
+	batchItemStatuses = map[pets.BatchItemStatus]petsv1.BatchItemStatus{
+		pets.BatchSucceeded: petsv1.BatchItemStatus_BATCH_ITEM_STATUS_SUCCEEDED,
+		pets.BatchFailed:    petsv1.BatchItemStatus_BATCH_ITEM_STATUS_FAILED,
+		pets.BatchAborted:   petsv1.BatchItemStatus_BATCH_ITEM_STATUS_ABORTED,
+	}
+)
+
+// toNewPet transforms a create pet request to a domain new pet.
+func toNewPet(req *petsv1.CreatePetRequest) *pets.NewPet {
+	return &pets.NewPet{
+		Name:     req.GetName(),
+		Location: toLocation(req.GetLocation()),
+	}
+}
+
+// toUpdatePet transforms an update pet request to a domain update pet.
+func toUpdatePet(req *petsv1.UpdatePetRequest) *pets.UpdatePet {
+	return &pets.UpdatePet{
+		ID:       pets.PetID(req.GetId()),
+		Name:     req.GetName(),
+		Location: toLocation(req.GetLocation()),
+	}
+}
+
+// toQueryFilter transforms a search pets request to a domain query filter.
+func toQueryFilter(req *petsv1.SearchPetsRequest) (pets.QueryFilter, error) {
+	if req.GetPage() > math.MaxUint8 {
+		return pets.QueryFilter{}, errPageTooLarge
+	}
+
+	if req.GetPageSize() > math.MaxUint8 {
+		return pets.QueryFilter{}, errPageSizeTooLarge
+	}
+
+	filter := pets.QueryFilter{
+		PetName:        req.GetName(),
+		OrderBy:        pets.OrderByField(req.GetOrderBy()),
+		PageNumber:     uint8(req.GetPage()),
+		RowsPerPage:    uint8(req.GetPageSize()),
+		IncludeDeleted: req.GetIncludeDeleted(),
+	}
+
+	return filter, nil
+}
+
+// toAuditFilter transforms a pet history request to a domain audit filter.
+func toAuditFilter(req *petsv1.GetPetHistoryRequest) (pets.AuditFilter, error) {
+	if req.GetPage() > math.MaxUint8 {
+		return pets.AuditFilter{}, errPageTooLarge
+	}
+
+	if req.GetPageSize() > math.MaxUint8 {
+		return pets.AuditFilter{}, errPageSizeTooLarge
+	}
+
+	filter := pets.AuditFilter{
+		PetID:       pets.PetID(req.GetPetId()),
+		PageNumber:  uint8(req.GetPage()),
+		RowsPerPage: uint8(req.GetPageSize()),
+	}
+
+	return filter, nil
+}
+
+// toBatchRequest transforms a batch request to a domain batch request,
+// operations without data are rejected by the service.
+func toBatchRequest(req *petsv1.BatchPetsRequest) *pets.BatchRequest {
+	batchRequest := pets.BatchRequest{
+		Operations: make([]pets.BatchOperation, 0, len(req.GetOperations())),
+		Atomic:     req.GetAtomic(),
+	}
+
+	for _, operation := range req.GetOperations() {
+		var batchOperation pets.BatchOperation
+
+		switch {
+		case operation.GetCreate() != nil:
+			batchOperation.Type = pets.BatchCreate
+			batchOperation.NewPet = toNewPet(operation.GetCreate())
+		case operation.GetUpdate() != nil:
+			batchOperation.Type = pets.BatchUpdate
+			batchOperation.UpdatePet = toUpdatePet(operation.GetUpdate())
+		case operation.GetDelete() != nil:
+			batchOperation.Type = pets.BatchDelete
+			batchOperation.PetID = pets.PetID(operation.GetDelete().GetId())
+		}
+
+		batchRequest.Operations = append(batchRequest.Operations, batchOperation)
+	}
+
+	return &batchRequest
+}
+
+// toNewSighting transforms a report sighting request to a domain new
+// sighting.
+func toNewSighting(req *petsv1.ReportSightingRequest) (*pets.NewSighting, error) {
+	if req.GetLocation() == nil {
+		return nil, errMissingLocation
+	}
+
+	newSighting := pets.NewSighting{
+		PetID:    pets.PetID(req.GetPetId()),
+		Location: *toLocation(req.GetLocation()),
+		Notes:    req.GetNotes(),
+	}
+
+	if req.GetSeenAt() != nil {
+		newSighting.SeenAt = req.GetSeenAt().AsTime()
+	}
+
+	return &newSighting, nil
+}
+
+// toNearbyFilter transforms a search nearby request to a domain nearby
+// filter.
+func toNearbyFilter(req *petsv1.SearchNearbyRequest) (pets.NearbyFilter, error) {
+	if req.GetLocation() == nil {
+		return pets.NearbyFilter{}, errMissingLocation
+	}
+
+	if req.GetLimit() > math.MaxUint8 {
+		return pets.NearbyFilter{}, errLimitTooLarge
+	}
+
+	filter := pets.NearbyFilter{
+		Location: *toLocation(req.GetLocation()),
+		RadiusKm: req.GetRadiusKm(),
+		Limit:    uint8(req.GetLimit()),
+	}
+
+	return filter, nil
+}
+
+func toLocation(location *petsv1.Location) *pets.Location {
+	if location == nil {
+		return nil
+	}
+
+	return &pets.Location{
+		Latitude:  location.GetLatitude(),
+		Longitude: location.GetLongitude(),
+	}
+}
+
+// toPet transforms a domain pet to a proto pet.
+func toPet(pet *pets.Pet) *petsv1.Pet {
+	protoPet := petsv1.Pet{
+		Id:       pet.ID.String(),
+		Name:     pet.Name,
+		Location: toProtoLocation(pet.Location),
+	}
+
+	if pet.DeletedAt != nil {
+		protoPet.DeletedAt = timestamppb.New(*pet.DeletedAt)
+	}
+
+	return &protoPet
+}
+
+func toProtoLocation(location *pets.Location) *petsv1.Location {
+	if location == nil {
+		return nil
+	}
+
+	return &petsv1.Location{
+		Latitude:  location.Latitude,
+		Longitude: location.Longitude,
+	}
+}
+
+// toBatchResponse transforms a domain batch result to a batch response.
+func toBatchResponse(result pets.BatchResult) *petsv1.BatchPetsResponse {
+	response := petsv1.BatchPetsResponse{
+		Items: make([]*petsv1.BatchItemResult, 0, len(result.Items)),
+	}
+
+	for _, item := range result.Items {
+		response.Items = append(response.Items, &petsv1.BatchItemResult{
+			Index:  int32(item.Index),
+			Type:   batchOperationTypes[item.Type],
+			Id:     item.ID.String(),
+			Status: batchItemStatuses[item.Status],
+			Error:  item.Err,
+		})
+	}
+
+	return &response
+}
+
+// toPetHistoryResponse transforms domain audit records to a pet history
+// response.
+func toPetHistoryResponse(result pets.AuditRecordsResult) *petsv1.GetPetHistoryResponse {
+	response := petsv1.GetPetHistoryResponse{
+		Records:  make([]*petsv1.AuditRecord, 0, len(result.Records)),
+		Total:    int32(result.Total),
+		Page:     uint32(result.Page),
+		PageSize: uint32(result.RowsPerPage),
+	}
+
+	for _, record := range result.Records {
+		protoRecord := petsv1.AuditRecord{
+			Id:            string(record.ID),
+			PetId:         record.PetID.String(),
+			Action:        string(record.Action),
+			Actor:         record.Actor,
+			AssertedActor: record.AssertedActor,
+			RequestId:     record.RequestID,
+			Timestamp:     timestamppb.New(record.Timestamp),
+			Changes:       make([]*petsv1.FieldChange, 0, len(record.Changes)),
+		}
+
+		for _, change := range record.Changes {
+			protoRecord.Changes = append(protoRecord.Changes, &petsv1.FieldChange{
+				Field:  change.Field,
+				Before: change.Before,
+				After:  change.After,
+			})
+		}
+
+		response.Records = append(response.Records, &protoRecord)
+	}
+
+	return &response
+}
+
+// toSearchNearbyResponse transforms a domain nearby result to a search
+// nearby response.
+func toSearchNearbyResponse(result pets.NearbyResult) *petsv1.SearchNearbyResponse {
+	response := petsv1.SearchNearbyResponse{
+		Pets:      make([]*petsv1.NearbyPet, 0, len(result.Pets)),
+		Sightings: make([]*petsv1.NearbySighting, 0, len(result.Sightings)),
+	}
+
+	for index := range result.Pets {
+		response.Pets = append(response.Pets, &petsv1.NearbyPet{
+			Pet:        toPet(&result.Pets[index].Pet),
+			DistanceKm: result.Pets[index].DistanceKm,
+		})
+	}
+
+	for _, nearbySighting := range result.Sightings {
+		sighting := nearbySighting.Sighting
+
+		response.Sightings = append(response.Sightings, &petsv1.NearbySighting{
+			Sighting: &petsv1.Sighting{
+				Id:       sighting.ID.String(),
+				PetId:    sighting.PetID.String(),
+				Location: toProtoLocation(&sighting.Location),
+				Notes:    sighting.Notes,
+				SeenAt:   timestamppb.New(sighting.SeenAt),
+			},
+			DistanceKm: nearbySighting.DistanceKm,
+		})
+	}
+
+	return &response
+}
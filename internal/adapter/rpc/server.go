@@ -0,0 +1,137 @@
+package rpc
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net"
+	"sync/atomic"
+	"time"
+
+	"github.com/fernandoocampo/basic-micro/internal/adapter/health"
+	"github.com/fernandoocampo/basic-micro/internal/pets"
+	petsv1 "github.com/fernandoocampo/basic-micro/proto/pets/v1"
+	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
+	"google.golang.org/grpc"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/reflection"
+)
+
+// ServerSetup contains gRPC server metadata.
+type ServerSetup struct {
+	Address   string
+	Endpoints pets.Endpoints
+	// Health answers the gRPC health checks with its readiness probe.
+	Health *health.Registry
+	// DrainTimeout how long in-flight calls have to finish, after that
+	// they are cancelled.
+	DrainTimeout time.Duration
+	Logger       *slog.Logger
+}
+
+// Server is a gRPC server with the pets, health and reflection services
+// that drains in-flight calls when it shuts down.
+type Server struct {
+	grpcServer *grpc.Server
+	address    string
+	// drainTimeout can be changed while the server runs, see
+	// SetDrainTimeout.
+	drainTimeout atomic.Int64
+	logger       *slog.Logger
+}
+
+var errDrainTimeout = errors.New("in-flight calls did not finish before the drain timeout")
+
+// NewServer creates a gRPC server with the pets, health and reflection
+// services, an empty health registry is used when setup has none.
+func NewServer(setup ServerSetup) *Server {
+	registry := setup.Health
+	if registry == nil {
+		registry = health.NewRegistry(health.RegistrySetup{Logger: setup.Logger})
+	}
+
+	grpcServer := grpc.NewServer(
+		grpc.StatsHandler(otelgrpc.NewServerHandler()),
+		grpc.ChainUnaryInterceptor(
+			unaryRequestMetadata,
+			unaryAccessLog(setup.Logger),
+			unaryRecovery(setup.Logger),
+		),
+		grpc.ChainStreamInterceptor(
+			streamRequestMetadata,
+			streamAccessLog(setup.Logger),
+			streamRecovery(setup.Logger),
+		),
+	)
+
+	petsv1.RegisterPetServiceServer(grpcServer, newPetService(setup.Endpoints, setup.Logger))
+	healthpb.RegisterHealthServer(grpcServer, newHealthService(registry))
+	reflection.Register(grpcServer)
+
+	newServer := Server{
+		grpcServer: grpcServer,
+		address:    setup.Address,
+		logger:     setup.Logger,
+	}
+
+	newServer.SetDrainTimeout(setup.DrainTimeout)
+
+	return &newServer
+}
+
+// ListenAndServe listens on the server address and serves calls until the
+// server shuts down, in that case it returns nil.
+func (s *Server) ListenAndServe() error {
+	listener, err := net.Listen("tcp", s.address)
+	if err != nil {
+		return err
+	}
+
+	return s.Serve(listener)
+}
+
+// Serve serves calls received by listener until the server shuts down, in
+// that case it returns nil.
+func (s *Server) Serve(listener net.Listener) error {
+	return s.grpcServer.Serve(listener)
+}
+
+// Shutdown stops accepting connections and waits until in-flight calls
+// finish. If they do not finish before the drain timeout or ctx is done,
+// they are cancelled.
+func (s *Server) Shutdown(ctx context.Context) error {
+	drainTimeout := time.Duration(s.drainTimeout.Load())
+
+	if drainTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, drainTimeout)
+		defer cancel()
+	}
+
+	s.logger.Info("draining in-flight calls", slog.Duration("timeout", drainTimeout))
+
+	drained := make(chan struct{})
+
+	go func() {
+		s.grpcServer.GracefulStop()
+		close(drained)
+	}()
+
+	select {
+	case <-drained:
+		s.logger.Info("grpc server was drained")
+
+		return nil
+	case <-ctx.Done():
+		s.logger.Error("cancelling in-flight calls", "error", ctx.Err())
+		s.grpcServer.Stop()
+		<-drained
+
+		return errDrainTimeout
+	}
+}
+
+// SetDrainTimeout changes the drain timeout of the next shutdown.
+func (s *Server) SetDrainTimeout(drainTimeout time.Duration) {
+	s.drainTimeout.Store(int64(drainTimeout))
+}
@@ -0,0 +1,287 @@
+package rpc_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"net"
+	"testing"
+
+	"github.com/fernandoocampo/basic-micro/internal/adapter/health"
+	"github.com/fernandoocampo/basic-micro/internal/adapter/rpc"
+	"github.com/fernandoocampo/basic-micro/internal/adapter/stores"
+	"github.com/fernandoocampo/basic-micro/internal/pets"
+	petsv1 "github.com/fernandoocampo/basic-micro/proto/pets/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	reflectionpb "google.golang.org/grpc/reflection/grpc_reflection_v1"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+func TestPetLifecycle(t *testing.T) {
+	// Given
+	conn := newPetsServer(t, nil)
+	client := petsv1.NewPetServiceClient(conn)
+	ctx := metadata.AppendToOutgoingContext(context.Background(), rpc.ActorKey, "alice")
+	location := &petsv1.Location{Latitude: 4.6, Longitude: -74.1}
+
+	// When
+	var header metadata.MD
+	created, err := client.CreatePet(ctx, &petsv1.CreatePetRequest{Name: "Drila", Location: location}, grpc.Header(&header))
+	require.NoError(t, err)
+	_, err = client.UpdatePet(ctx, &petsv1.UpdatePetRequest{Id: created.GetId(), Name: "Drilita", Location: location})
+	require.NoError(t, err)
+	found, err := client.GetPet(ctx, &petsv1.GetPetRequest{Id: created.GetId()})
+	require.NoError(t, err)
+	_, err = client.DeletePet(ctx, &petsv1.DeletePetRequest{Id: created.GetId()})
+	require.NoError(t, err)
+	_, errDeleted := client.GetPet(ctx, &petsv1.GetPetRequest{Id: created.GetId()})
+	history, err := client.GetPetHistory(ctx, &petsv1.GetPetHistoryRequest{PetId: created.GetId()})
+	require.NoError(t, err)
+
+	// Then
+	assert.NotEmpty(t, header.Get(rpc.RequestIDKey))
+	assert.Equal(t, "Drilita", found.GetName())
+	assert.Equal(t, 4.6, found.GetLocation().GetLatitude())
+	assert.Equal(t, codes.NotFound, status.Code(errDeleted))
+	require.Len(t, history.GetRecords(), 3)
+	for _, record := range history.GetRecords() {
+		assert.Equal(t, pets.AnonymousActor, record.GetActor())
+		assert.Equal(t, "alice", record.GetAssertedActor())
+		assert.NotEmpty(t, record.GetRequestId())
+	}
+}
+
+func TestStatusCodes(t *testing.T) {
+	// Given
+	conn := newPetsServer(t, nil)
+	client := petsv1.NewPetServiceClient(conn)
+	ctx := context.Background()
+
+	// When
+	_, errInvalidPet := client.CreatePet(ctx, &petsv1.CreatePetRequest{})
+	_, errEmptyID := client.GetPet(ctx, &petsv1.GetPetRequest{})
+	_, errMissingPet := client.RestorePet(ctx, &petsv1.RestorePetRequest{Id: "858455b7-e182-4122-a1b6-132c64d2f77b"})
+	_, errMissingLocation := client.SearchNearby(ctx, &petsv1.SearchNearbyRequest{RadiusKm: 1})
+	_, errEmptyBatch := client.BatchPets(ctx, &petsv1.BatchPetsRequest{})
+
+	// Then
+	assert.Equal(t, codes.InvalidArgument, status.Code(errInvalidPet))
+	assert.Contains(t, status.Convert(errInvalidPet).Message(), "invalid pet data")
+	assert.Equal(t, codes.InvalidArgument, status.Code(errEmptyID))
+	assert.Equal(t, codes.NotFound, status.Code(errMissingPet))
+	assert.Equal(t, codes.InvalidArgument, status.Code(errMissingLocation))
+	assert.Equal(t, codes.InvalidArgument, status.Code(errEmptyBatch))
+}
+
+func TestSearchPetsStreamsThePage(t *testing.T) {
+	// Given
+	conn := newPetsServer(t, nil)
+	client := petsv1.NewPetServiceClient(conn)
+	ctx := context.Background()
+	for _, name := range []string{"Drila", "Drilita", "Michi"} {
+		_, err := client.CreatePet(ctx, &petsv1.CreatePetRequest{Name: name})
+		require.NoError(t, err)
+	}
+
+	// When
+	stream, err := client.SearchPets(ctx, &petsv1.SearchPetsRequest{Name: "dri", OrderBy: "name", Page: 1, PageSize: 1})
+	require.NoError(t, err)
+	found := receiveAll(t, stream)
+	header, err := stream.Header()
+	require.NoError(t, err)
+	_, errPageSize := receiveError(client.SearchPets(ctx, &petsv1.SearchPetsRequest{PageSize: 256}))
+
+	// Then
+	require.Len(t, found, 1)
+	assert.Equal(t, "Drila", found[0].GetName())
+	assert.Equal(t, []string{"2"}, header.Get(rpc.TotalCountKey))
+	assert.Equal(t, codes.InvalidArgument, status.Code(errPageSize))
+}
+
+func TestExportPetsStreamsAllPets(t *testing.T) {
+	// Given
+	conn := newPetsServer(t, nil)
+	client := petsv1.NewPetServiceClient(conn)
+	ctx := context.Background()
+	var deletedID string
+	for _, name := range []string{"Drila", "Drilita", "Michi"} {
+		created, err := client.CreatePet(ctx, &petsv1.CreatePetRequest{Name: name})
+		require.NoError(t, err)
+		deletedID = created.GetId()
+	}
+	_, err := client.DeletePet(ctx, &petsv1.DeletePetRequest{Id: deletedID})
+	require.NoError(t, err)
+
+	// When
+	stream, err := client.ExportPets(ctx, &petsv1.ExportPetsRequest{})
+	require.NoError(t, err)
+	exported := receiveAll(t, stream)
+	stream, err = client.ExportPets(ctx, &petsv1.ExportPetsRequest{IncludeDeleted: true})
+	require.NoError(t, err)
+	exportedWithDeleted := receiveAll(t, stream)
+
+	// Then
+	assert.Len(t, exported, 2)
+	assert.Len(t, exportedWithDeleted, 3)
+}
+
+func TestAtomicBatchIsAborted(t *testing.T) {
+	// Given
+	conn := newPetsServer(t, nil)
+	client := petsv1.NewPetServiceClient(conn)
+	request := petsv1.BatchPetsRequest{
+		Atomic: true,
+		Operations: []*petsv1.BatchOperation{
+			{Operation: &petsv1.BatchOperation_Create{Create: &petsv1.CreatePetRequest{Name: "Drila"}}},
+			{Operation: &petsv1.BatchOperation_Create{Create: &petsv1.CreatePetRequest{}}},
+		},
+	}
+
+	// When
+	_, err := client.BatchPets(context.Background(), &request)
+
+	// Then
+	batchStatus := status.Convert(err)
+	assert.Equal(t, codes.Aborted, batchStatus.Code())
+	require.Len(t, batchStatus.Details(), 1)
+	response, ok := batchStatus.Details()[0].(*petsv1.BatchPetsResponse)
+	require.True(t, ok)
+	require.Len(t, response.GetItems(), 2)
+	assert.Equal(t, petsv1.BatchItemStatus_BATCH_ITEM_STATUS_ABORTED, response.GetItems()[0].GetStatus())
+	assert.Equal(t, petsv1.BatchItemStatus_BATCH_ITEM_STATUS_FAILED, response.GetItems()[1].GetStatus())
+	assert.NotEmpty(t, response.GetItems()[1].GetError())
+}
+
+func TestHealthCheck(t *testing.T) {
+	// Given
+	registry := health.NewRegistry(health.RegistrySetup{Logger: newLogger()})
+	var storeErr error
+	registry.Register(health.Check{
+		Name: "store",
+		Checker: health.CheckerFunc(func(ctx context.Context) error {
+			return storeErr
+		}),
+		Probes:  []health.Probe{health.Readiness},
+		NoCache: true,
+	})
+	conn := newPetsServer(t, registry)
+	client := healthpb.NewHealthClient(conn)
+	ctx := context.Background()
+
+	// When
+	serving, err := client.Check(ctx, &healthpb.HealthCheckRequest{Service: "pets.v1.PetService"})
+	require.NoError(t, err)
+	storeErr = errors.New("store is down")
+	notServing, err := client.Check(ctx, &healthpb.HealthCheckRequest{})
+	require.NoError(t, err)
+	_, errUnknown := client.Check(ctx, &healthpb.HealthCheckRequest{Service: "unknown"})
+
+	// Then
+	assert.Equal(t, healthpb.HealthCheckResponse_SERVING, serving.GetStatus())
+	assert.Equal(t, healthpb.HealthCheckResponse_NOT_SERVING, notServing.GetStatus())
+	assert.Equal(t, codes.NotFound, status.Code(errUnknown))
+}
+
+func TestReflectionListsServices(t *testing.T) {
+	// Given
+	conn := newPetsServer(t, nil)
+	client := reflectionpb.NewServerReflectionClient(conn)
+
+	// When
+	stream, err := client.ServerReflectionInfo(context.Background())
+	require.NoError(t, err)
+	err = stream.Send(&reflectionpb.ServerReflectionRequest{
+		MessageRequest: &reflectionpb.ServerReflectionRequest_ListServices{},
+	})
+	require.NoError(t, err)
+	response, err := stream.Recv()
+	require.NoError(t, err)
+	require.NoError(t, stream.CloseSend())
+
+	// Then
+	var services []string
+	for _, service := range response.GetListServicesResponse().GetService() {
+		services = append(services, service.GetName())
+	}
+	assert.Contains(t, services, "pets.v1.PetService")
+	assert.Contains(t, services, "grpc.health.v1.Health")
+}
+
+// newPetsServer serves the pets service with a memory store and returns a
+// connection to it, registry is optional.
+func newPetsServer(t *testing.T, registry *health.Registry) *grpc.ClientConn {
+	t.Helper()
+
+	logger := newLogger()
+	store := stores.NewMemoryStore(stores.Setup{Logger: logger})
+	service := pets.NewService(pets.ServiceSetup{
+		Storer:      store,
+		AuditStorer: store,
+		Logger:      logger,
+	})
+
+	importer := pets.NewImporter(pets.ImporterSetup{Service: service, Logger: logger})
+
+	server := rpc.NewServer(rpc.ServerSetup{
+		Endpoints: pets.NewEndpoints(service, importer, logger),
+		Health:    registry,
+		Logger:    logger,
+	})
+
+	listener := bufconn.Listen(1 << 20)
+	go server.Serve(listener)
+	t.Cleanup(func() {
+		server.Shutdown(context.Background())
+	})
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return listener.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		conn.Close()
+	})
+
+	return conn
+}
+
+// receiveAll receives the pets of the stream until it ends.
+func receiveAll(t *testing.T, stream grpc.ServerStreamingClient[petsv1.Pet]) []*petsv1.Pet {
+	t.Helper()
+
+	var received []*petsv1.Pet
+
+	for {
+		pet, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			return received
+		}
+		require.NoError(t, err)
+
+		received = append(received, pet)
+	}
+}
+
+// receiveError returns the error of a stream that fails.
+func receiveError(stream grpc.ServerStreamingClient[petsv1.Pet], err error) (*petsv1.Pet, error) {
+	if err != nil {
+		return nil, err
+	}
+
+	return stream.Recv()
+}
+
+func newLogger() *slog.Logger {
+	return slog.New(slog.NewTextHandler(io.Discard, nil))
+}
@@ -0,0 +1,210 @@
+package rpc
+
+import (
+	"context"
+	"log/slog"
+	"strconv"
+
+	"github.com/fernandoocampo/basic-micro/internal/pets"
+	petsv1 "github.com/fernandoocampo/basic-micro/proto/pets/v1"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// petService serves the pets endpoints as the PetService of the proto.
+type petService struct {
+	petsv1.UnimplementedPetServiceServer
+	endpoints pets.Endpoints
+	logger    *slog.Logger
+}
+
+func newPetService(endpoints pets.Endpoints, logger *slog.Logger) *petService {
+	newService := petService{
+		endpoints: endpoints,
+		logger:    logger,
+	}
+
+	return &newService
+}
+
+func (p *petService) GetPet(ctx context.Context, req *petsv1.GetPetRequest) (*petsv1.Pet, error) {
+	result, err := p.endpoints.GetPetWithIDEndpoint.Do(ctx, pets.PetID(req.GetId()))
+	if err := resultError(ctx, err, result.Err, result.ErrKind); err != nil {
+		return nil, err
+	}
+
+	if result.Pet == nil {
+		return nil, status.Errorf(codes.NotFound, "pet %q does not exist", req.GetId())
+	}
+
+	return toPet(result.Pet), nil
+}
+
+func (p *petService) CreatePet(ctx context.Context, req *petsv1.CreatePetRequest) (*petsv1.CreatePetResponse, error) {
+	result, err := p.endpoints.CreatePetEndpoint.Do(ctx, toNewPet(req))
+	if err := resultError(ctx, err, result.Err, result.ErrKind); err != nil {
+		return nil, err
+	}
+
+	return &petsv1.CreatePetResponse{Id: result.ID.String()}, nil
+}
+
+func (p *petService) UpdatePet(ctx context.Context, req *petsv1.UpdatePetRequest) (*petsv1.UpdatePetResponse, error) {
+	result, err := p.endpoints.UpdatePetEndpoint.Do(ctx, toUpdatePet(req))
+	if err := resultError(ctx, err, result.Err, result.ErrKind); err != nil {
+		return nil, err
+	}
+
+	return &petsv1.UpdatePetResponse{}, nil
+}
+
+func (p *petService) DeletePet(ctx context.Context, req *petsv1.DeletePetRequest) (*petsv1.DeletePetResponse, error) {
+	result, err := p.endpoints.DeletePetEndpoint.Do(ctx, pets.PetID(req.GetId()))
+	if err := resultError(ctx, err, result.Err, result.ErrKind); err != nil {
+		return nil, err
+	}
+
+	return &petsv1.DeletePetResponse{}, nil
+}
+
+func (p *petService) RestorePet(ctx context.Context, req *petsv1.RestorePetRequest) (*petsv1.RestorePetResponse, error) {
+	result, err := p.endpoints.RestorePetEndpoint.Do(ctx, pets.PetID(req.GetId()))
+	if err := resultError(ctx, err, result.Err, result.ErrKind); err != nil {
+		return nil, err
+	}
+
+	return &petsv1.RestorePetResponse{}, nil
+}
+
+// SearchPets sends the total number of pets found in the header and then
+// the pets of the page.
+func (p *petService) SearchPets(req *petsv1.SearchPetsRequest, stream grpc.ServerStreamingServer[petsv1.Pet]) error {
+	ctx := stream.Context()
+
+	filter, err := toQueryFilter(req)
+	if err != nil {
+		return invalidArgument(err)
+	}
+
+	result, err := p.endpoints.SearchPetsEndpoint.Do(ctx, filter)
+	if err := resultError(ctx, err, result.Err, result.ErrKind); err != nil {
+		return err
+	}
+
+	err = stream.SendHeader(metadata.Pairs(TotalCountKey, strconv.Itoa(result.SearchResult.Total)))
+	if err != nil {
+		return err
+	}
+
+	for index := range result.SearchResult.Pets {
+		err := stream.Send(toPet(&result.SearchResult.Pets[index]))
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// ExportPets sends the pets as they are read from the store.
+func (p *petService) ExportPets(req *petsv1.ExportPetsRequest, stream grpc.ServerStreamingServer[petsv1.Pet]) error {
+	ctx := stream.Context()
+
+	exportRequest := pets.ExportPetsRequest{
+		Filter: pets.ExportFilter{IncludeDeleted: req.GetIncludeDeleted()},
+	}
+
+	result, err := p.endpoints.ExportPetsEndpoint.Do(ctx, exportRequest)
+	if err := resultError(ctx, err, result.Err, result.ErrKind); err != nil {
+		return err
+	}
+	defer result.Pets.Close()
+
+	for result.Pets.Next() {
+		pet := result.Pets.Pet()
+
+		err := stream.Send(toPet(&pet))
+		if err != nil {
+			return err
+		}
+	}
+
+	err = result.Pets.Err()
+	if err != nil {
+		p.logger.Error("reading exported pets", "error", err)
+
+		return resultError(ctx, nil, "unable to read exported pets", pets.InternalError)
+	}
+
+	return nil
+}
+
+// BatchPets returns the result of every operation, if the batch failed
+// they are part of the status details.
+func (p *petService) BatchPets(ctx context.Context, req *petsv1.BatchPetsRequest) (*petsv1.BatchPetsResponse, error) {
+	result, err := p.endpoints.BatchPetsEndpoint.Do(ctx, toBatchRequest(req))
+
+	response := toBatchResponse(result.Result)
+
+	batchStatus := resultStatus(ctx, err, result.Err, result.ErrKind)
+	if batchStatus == nil {
+		return response, nil
+	}
+
+	if len(response.Items) == 0 {
+		return nil, batchStatus.Err()
+	}
+
+	detailedStatus, err := batchStatus.WithDetails(response)
+	if err != nil {
+		p.logger.Error("adding batch result to status", "error", err)
+
+		return nil, batchStatus.Err()
+	}
+
+	return nil, detailedStatus.Err()
+}
+
+func (p *petService) GetPetHistory(ctx context.Context, req *petsv1.GetPetHistoryRequest) (*petsv1.GetPetHistoryResponse, error) {
+	filter, err := toAuditFilter(req)
+	if err != nil {
+		return nil, invalidArgument(err)
+	}
+
+	result, err := p.endpoints.PetHistoryEndpoint.Do(ctx, filter)
+	if err := resultError(ctx, err, result.Err, result.ErrKind); err != nil {
+		return nil, err
+	}
+
+	return toPetHistoryResponse(result.Result), nil
+}
+
+func (p *petService) ReportSighting(ctx context.Context, req *petsv1.ReportSightingRequest) (*petsv1.ReportSightingResponse, error) {
+	newSighting, err := toNewSighting(req)
+	if err != nil {
+		return nil, invalidArgument(err)
+	}
+
+	result, err := p.endpoints.ReportSightingEndpoint.Do(ctx, newSighting)
+	if err := resultError(ctx, err, result.Err, result.ErrKind); err != nil {
+		return nil, err
+	}
+
+	return &petsv1.ReportSightingResponse{Id: result.ID.String()}, nil
+}
+
+func (p *petService) SearchNearby(ctx context.Context, req *petsv1.SearchNearbyRequest) (*petsv1.SearchNearbyResponse, error) {
+	filter, err := toNearbyFilter(req)
+	if err != nil {
+		return nil, invalidArgument(err)
+	}
+
+	result, err := p.endpoints.SearchNearbyEndpoint.Do(ctx, filter)
+	if err := resultError(ctx, err, result.Err, result.ErrKind); err != nil {
+		return nil, err
+	}
+
+	return toSearchNearbyResponse(result.Result), nil
+}
@@ -0,0 +1,3 @@
+// Package secrets reads secrets from files or from a vault server and
+// refreshes them when they are rotated.
+package secrets
@@ -0,0 +1,54 @@
+package secrets
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Provider reads secrets by name.
+type Provider interface {
+	Secret(ctx context.Context, name string) (string, error)
+}
+
+// FileProvider reads every secret from a file named like the secret, like
+// docker and kubernetes secrets mounted as files.
+type FileProvider struct {
+	// dir is the directory of the secret files, absolute names are read
+	// as they are.
+	dir string
+}
+
+// ErrSecretNotFound is returned when a provider does not have the secret.
+var ErrSecretNotFound = errors.New("secret was not found")
+
+func NewFileProvider(dir string) *FileProvider {
+	newFileProvider := FileProvider{
+		dir: dir,
+	}
+
+	return &newFileProvider
+}
+
+// Secret returns the content of the secret file without the trailing new
+// line.
+func (f *FileProvider) Secret(ctx context.Context, name string) (string, error) {
+	path := name
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(f.dir, name)
+	}
+
+	content, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return "", fmt.Errorf("%w: %s", ErrSecretNotFound, name)
+	}
+
+	if err != nil {
+		return "", fmt.Errorf("unable to read secret %s: %w", name, err)
+	}
+
+	return strings.TrimRight(string(content), "\r\n"), nil
+}
@@ -0,0 +1,136 @@
+package secrets_test
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/fernandoocampo/basic-micro/internal/adapter/secrets"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFileProvider(t *testing.T) {
+	// Given
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "db_password"), []byte("drila\n"), 0o600))
+	absolute := filepath.Join(t.TempDir(), "token")
+	require.NoError(t, os.WriteFile(absolute, []byte("michael"), 0o600))
+	provider := secrets.NewFileProvider(dir)
+
+	// When
+	password, passwordErr := provider.Secret(context.TODO(), "db_password")
+	token, tokenErr := provider.Secret(context.TODO(), absolute)
+	_, missingErr := provider.Secret(context.TODO(), "missing")
+
+	// Then
+	require.NoError(t, passwordErr)
+	require.NoError(t, tokenErr)
+	assert.Equal(t, "drila", password)
+	assert.Equal(t, "michael", token)
+	assert.ErrorIs(t, missingErr, secrets.ErrSecretNotFound)
+}
+
+func TestVaultProvider(t *testing.T) {
+	cases := map[string]struct {
+		path string
+		want string
+		err  error
+	}{
+		"kv version 2": {
+			path: "secret/data/basic-micro",
+			want: "drila",
+		},
+		"kv version 1": {
+			path: "kv/basic-micro",
+			want: "michael",
+		},
+		"missing path": {
+			path: "secret/data/missing",
+			err:  secrets.ErrSecretNotFound,
+		},
+		"missing key": {
+			path: "secret/data/other",
+			err:  secrets.ErrSecretNotFound,
+		},
+	}
+
+	vault := newVaultServer(t)
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			// Given
+			provider := secrets.NewVaultProvider(secrets.VaultSetup{
+				Address: vault.URL,
+				Token:   "root",
+				Path:    tc.path,
+				Logger:  slog.Default(),
+			})
+
+			// When
+			got, err := provider.Secret(context.TODO(), "db_password")
+
+			// Then
+			if tc.err != nil {
+				assert.ErrorIs(t, err, tc.err)
+
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tc.want, got)
+		})
+	}
+}
+
+func TestVaultProviderWithWrongToken(t *testing.T) {
+	// Given
+	provider := secrets.NewVaultProvider(secrets.VaultSetup{
+		Address: newVaultServer(t).URL,
+		Token:   "wrong",
+		Path:    "secret/data/basic-micro",
+		Logger:  slog.Default(),
+	})
+
+	// When
+	_, err := provider.Secret(context.TODO(), "db_password")
+
+	// Then
+	assert.EqualError(t, err, "unable to read secret db_password from vault: status 403")
+}
+
+// newVaultServer answers like a vault server with the root token.
+func newVaultServer(t *testing.T) *httptest.Server {
+	t.Helper()
+
+	bodies := map[string]string{
+		"/v1/secret/data/basic-micro": `{"data":{"data":{"db_password":"drila"},"metadata":{"version":2}}}`,
+		"/v1/kv/basic-micro":          `{"data":{"db_password":"michael"}}`,
+		"/v1/secret/data/other":       `{"data":{"data":{"api_key":"1234"}}}`,
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		if req.Header.Get("X-Vault-Token") != "root" {
+			rw.WriteHeader(http.StatusForbidden)
+
+			return
+		}
+
+		body, ok := bodies[req.URL.Path]
+		if !ok {
+			rw.WriteHeader(http.StatusNotFound)
+
+			return
+		}
+
+		rw.Header().Set("Content-Type", "application/json")
+		_, _ = rw.Write([]byte(body))
+	}))
+	t.Cleanup(server.Close)
+
+	return server
+}
@@ -0,0 +1,70 @@
+package secrets
+
+import (
+	"context"
+	"log/slog"
+	"time"
+)
+
+// RefresherSetup contains refresher metadata.
+type RefresherSetup struct {
+	Provider Provider
+	// Name of the refreshed secret.
+	Name string
+	// Interval how often the secret is read.
+	Interval time.Duration
+	// OnChange is called with the new value when the secret was rotated.
+	OnChange func(value string)
+	Logger   *slog.Logger
+}
+
+// Refresher reads a secret periodically to detect its rotation.
+type Refresher struct {
+	provider Provider
+	name     string
+	interval time.Duration
+	onChange func(value string)
+	logger   *slog.Logger
+}
+
+func NewRefresher(setup RefresherSetup) *Refresher {
+	newRefresher := Refresher{
+		provider: setup.Provider,
+		name:     setup.Name,
+		interval: setup.Interval,
+		onChange: setup.OnChange,
+		logger:   setup.Logger,
+	}
+
+	return &newRefresher
+}
+
+// Run reads the secret every interval until the given context is done,
+// current is the value in use. A secret that cannot be read is logged and
+// the value in use is kept.
+func (r *Refresher) Run(ctx context.Context, current string) {
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			value, err := r.provider.Secret(ctx, r.name)
+			if err != nil {
+				r.logger.Error("refreshing secret", slog.String("name", r.name), "error", err)
+
+				continue
+			}
+
+			if value == current {
+				continue
+			}
+
+			r.logger.Info("secret was rotated", slog.String("name", r.name))
+			current = value
+			r.onChange(value)
+		}
+	}
+}
@@ -0,0 +1,71 @@
+package secrets_test
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/fernandoocampo/basic-micro/internal/adapter/secrets"
+	"github.com/stretchr/testify/assert"
+)
+
+// rotatingProvider answers the values in order, the last one is repeated.
+type rotatingProvider struct {
+	mutex  sync.Mutex
+	values []string
+	errs   []error
+}
+
+func (r *rotatingProvider) Secret(ctx context.Context, name string) (string, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	value, err := r.values[0], r.errs[0]
+	if len(r.values) > 1 {
+		r.values, r.errs = r.values[1:], r.errs[1:]
+	}
+
+	return value, err
+}
+
+func TestRefresher(t *testing.T) {
+	// Given
+	provider := &rotatingProvider{
+		values: []string{"old", "", "new"},
+		errs:   []error{nil, errors.New("vault is down"), nil},
+	}
+	changes := make(chan string, 10)
+	refresher := secrets.NewRefresher(secrets.RefresherSetup{
+		Provider: provider,
+		Name:     "db_password",
+		Interval: time.Millisecond,
+		OnChange: func(value string) {
+			changes <- value
+		},
+		Logger: slog.Default(),
+	})
+	ctx, cancel := context.WithCancel(context.TODO())
+	done := make(chan struct{})
+
+	// When
+	go func() {
+		defer close(done)
+		refresher.Run(ctx, "old")
+	}()
+
+	// Then
+	select {
+	case got := <-changes:
+		assert.Equal(t, "new", got)
+	case <-time.After(time.Second):
+		t.Fatal("rotated secret was not refreshed")
+	}
+
+	time.Sleep(10 * time.Millisecond)
+	cancel()
+	<-done
+	assert.Empty(t, changes, "a secret that did not change must not be refreshed again")
+}
@@ -0,0 +1,100 @@
+package secrets
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// VaultSetup contains vault provider metadata.
+type VaultSetup struct {
+	// Address of the vault server, like http://127.0.0.1:8200.
+	Address string
+	Token   string
+	// Path of the key value secret, like secret/data/basic-micro, its keys
+	// are the names of the secrets.
+	Path string
+	// Client is http.Client with a 10s timeout if it is nil.
+	Client *http.Client
+	Logger *slog.Logger
+}
+
+// VaultProvider reads secrets from the key value engine of a vault
+// compatible server, versions 1 and 2 of the engine are supported.
+type VaultProvider struct {
+	url    string
+	token  string
+	client *http.Client
+	logger *slog.Logger
+}
+
+// vaultTokenHeader carries the vault token.
+const vaultTokenHeader = "X-Vault-Token"
+
+const defaultVaultTimeout = 10 * time.Second
+
+func NewVaultProvider(setup VaultSetup) *VaultProvider {
+	client := setup.Client
+	if client == nil {
+		client = &http.Client{Timeout: defaultVaultTimeout}
+	}
+
+	newVaultProvider := VaultProvider{
+		url:    strings.TrimRight(setup.Address, "/") + "/v1/" + strings.TrimLeft(setup.Path, "/"),
+		token:  setup.Token,
+		client: client,
+		logger: setup.Logger,
+	}
+
+	return &newVaultProvider
+}
+
+// Secret reads the key value secret and returns the value of the name key.
+func (v *VaultProvider) Secret(ctx context.Context, name string) (string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, v.url, nil)
+	if err != nil {
+		return "", fmt.Errorf("unable to create vault request: %w", err)
+	}
+
+	req.Header.Set(vaultTokenHeader, v.token)
+
+	resp, err := v.client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("unable to read secret %s from vault: %w", name, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return "", fmt.Errorf("%w: %s", ErrSecretNotFound, name)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("unable to read secret %s from vault: status %d", name, resp.StatusCode)
+	}
+
+	// version 2 of the engine nests the secret in another data field.
+	var body struct {
+		Data map[string]any `json:"data"`
+	}
+
+	err = json.NewDecoder(resp.Body).Decode(&body)
+	if err != nil {
+		return "", fmt.Errorf("unable to decode vault secret: %w", err)
+	}
+
+	values := body.Data
+	if nested, ok := values["data"].(map[string]any); ok {
+		values = nested
+	}
+
+	value, ok := values[name].(string)
+	if !ok {
+		return "", fmt.Errorf("%w: %s", ErrSecretNotFound, name)
+	}
+
+	return value, nil
+}
@@ -0,0 +1,515 @@
+package stores
+
+import (
+	"context"
+	"log/slog"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fernandoocampo/basic-micro/internal/pets"
+)
+
+// MemoryStore keeps pets in memory, it is useful for local environments
+// and tests, data is lost when the application stops.
+type MemoryStore struct {
+	logger    *slog.Logger
+	mutex     sync.RWMutex
+	pets      map[pets.PetID]pets.Pet
+	sightings []pets.Sighting
+	audits    []pets.AuditRecord
+	// versions counts the changes of every pet, so a transaction knows if
+	// a pet it changed was also changed by another writer.
+	versions map[pets.PetID]uint64
+	// dirty contains the pets changed by a transaction, it is nil
+	// outside transactions.
+	dirty map[pets.PetID]struct{}
+	// baseVersions are the versions of the pets when the transaction
+	// began, it is nil outside transactions.
+	baseVersions map[pets.PetID]uint64
+}
+
+func NewMemoryStore(setup Setup) *MemoryStore {
+	newStore := MemoryStore{
+		logger:   setup.Logger,
+		pets:     make(map[pets.PetID]pets.Pet),
+		versions: make(map[pets.PetID]uint64),
+	}
+
+	return &newStore
+}
+
+func (m *MemoryStore) Save(ctx context.Context, newPet pets.Pet) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	m.pets[newPet.ID] = newPet
+	m.markChanged(newPet.ID)
+
+	return nil
+}
+
+// SaveBatch saves all pets while holding the lock, so readers see all
+// of them or none.
+func (m *MemoryStore) SaveBatch(ctx context.Context, newPets []pets.Pet) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	for _, newPet := range newPets {
+		m.pets[newPet.ID] = newPet
+		m.markChanged(newPet.ID)
+	}
+
+	return nil
+}
+
+func (m *MemoryStore) Update(ctx context.Context, pet pets.UpdatePet) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	storedPet, ok := m.pets[pet.ID]
+	if !ok {
+		return nil
+	}
+
+	storedPet.Name = pet.Name
+	storedPet.Location = pet.Location
+	m.pets[pet.ID] = storedPet
+	m.markChanged(pet.ID)
+
+	return nil
+}
+
+func (m *MemoryStore) Delete(ctx context.Context, pet pets.Pet) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	storedPet, ok := m.pets[pet.ID]
+	if !ok {
+		return nil
+	}
+
+	storedPet.DeletedAt = pet.DeletedAt
+	m.pets[pet.ID] = storedPet
+	m.markChanged(pet.ID)
+
+	return nil
+}
+
+func (m *MemoryStore) Restore(ctx context.Context, id pets.PetID) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	storedPet, ok := m.pets[id]
+	if !ok {
+		return nil
+	}
+
+	storedPet.DeletedAt = nil
+	m.pets[id] = storedPet
+	m.markChanged(id)
+
+	return nil
+}
+
+func (m *MemoryStore) Purge(ctx context.Context, deletedBefore time.Time) (int, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	var purged int
+	for id, pet := range m.pets {
+		if !pet.IsDeleted() || !pet.DeletedAt.Before(deletedBefore) {
+			continue
+		}
+
+		delete(m.pets, id)
+		m.markChanged(id)
+		purged++
+	}
+
+	return purged, nil
+}
+
+func (m *MemoryStore) Query(ctx context.Context, filter pets.QueryFilter) (pets.SearchPetsResult, error) {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+
+	petsFound := make([]pets.Pet, 0)
+	for _, pet := range m.pets {
+		if pet.IsDeleted() && !filter.IncludeDeleted {
+			continue
+		}
+
+		if !strings.Contains(strings.ToLower(pet.Name), strings.ToLower(filter.PetName)) {
+			continue
+		}
+
+		petsFound = append(petsFound, pet)
+	}
+
+	sort.Slice(petsFound, func(i, j int) bool {
+		return petsFound[i].Name < petsFound[j].Name
+	})
+
+	result := pets.SearchPetsResult{
+		Pets:        paginate(petsFound, filter.PageNumber, filter.RowsPerPage),
+		Total:       len(petsFound),
+		Page:        filter.PageNumber,
+		RowsPerPage: filter.RowsPerPage,
+	}
+
+	return result, nil
+}
+
+func (m *MemoryStore) QueryByID(ctx context.Context, id pets.PetID) (*pets.Pet, error) {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+
+	pet, ok := m.pets[id]
+	if !ok {
+		return nil, nil
+	}
+
+	return &pet, nil
+}
+
+// QueryByIDs returns the pets in the order of the ids.
+func (m *MemoryStore) QueryByIDs(ctx context.Context, ids []pets.PetID) ([]pets.Pet, error) {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+
+	petsFound := make([]pets.Pet, 0, len(ids))
+	for _, id := range ids {
+		pet, ok := m.pets[id]
+		if ok {
+			petsFound = append(petsFound, pet)
+		}
+	}
+
+	return petsFound, nil
+}
+
+// Iterate takes a snapshot of the pet ids, pets are read when the iterator
+// moves to them, so pets removed in the meantime are skipped.
+func (m *MemoryStore) Iterate(ctx context.Context, filter pets.ExportFilter) (pets.PetIterator, error) {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+
+	ids := make([]pets.PetID, 0, len(m.pets))
+	for id := range m.pets {
+		ids = append(ids, id)
+	}
+
+	sort.Slice(ids, func(i, j int) bool {
+		return ids[i] < ids[j]
+	})
+
+	newIterator := memoryPetIterator{
+		ctx:    ctx,
+		store:  m,
+		filter: filter,
+		ids:    ids,
+	}
+
+	return &newIterator, nil
+}
+
+func (m *MemoryStore) SaveSighting(ctx context.Context, sighting pets.Sighting) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	m.sightings = append(m.sightings, sighting)
+
+	return nil
+}
+
+// QuerySightings returns the sightings of the pets, newest first.
+func (m *MemoryStore) QuerySightings(ctx context.Context, petIDs []pets.PetID) ([]pets.Sighting, error) {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+
+	wanted := make(map[pets.PetID]struct{}, len(petIDs))
+	for _, id := range petIDs {
+		wanted[id] = struct{}{}
+	}
+
+	sightings := make([]pets.Sighting, 0)
+	for _, sighting := range m.sightings {
+		if _, ok := wanted[sighting.PetID]; ok {
+			sightings = append(sightings, sighting)
+		}
+	}
+
+	sort.SliceStable(sightings, func(i, j int) bool {
+		return sightings[i].SeenAt.After(sightings[j].SeenAt)
+	})
+
+	return sightings, nil
+}
+
+// QueryNearby calculates the haversine distance to every pet and sighting
+// with a location, deleted pets and their sightings are skipped.
+func (m *MemoryStore) QueryNearby(ctx context.Context, filter pets.NearbyFilter) (pets.NearbyResult, error) {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+
+	nearbyPets := make([]pets.NearbyPet, 0)
+	for _, pet := range m.pets {
+		if pet.Location == nil || pet.IsDeleted() {
+			continue
+		}
+
+		distance := pets.Distance(filter.Location, *pet.Location)
+		if distance > filter.RadiusKm {
+			continue
+		}
+
+		nearbyPets = append(nearbyPets, pets.NearbyPet{
+			Pet:        pet,
+			DistanceKm: distance,
+		})
+	}
+
+	nearbySightings := make([]pets.NearbySighting, 0)
+	for _, sighting := range m.sightings {
+		pet, ok := m.pets[sighting.PetID]
+		if !ok || pet.IsDeleted() {
+			continue
+		}
+
+		distance := pets.Distance(filter.Location, sighting.Location)
+		if distance > filter.RadiusKm {
+			continue
+		}
+
+		nearbySightings = append(nearbySightings, pets.NearbySighting{
+			Sighting:   sighting,
+			DistanceKm: distance,
+		})
+	}
+
+	sort.Slice(nearbyPets, func(i, j int) bool {
+		return nearbyPets[i].DistanceKm < nearbyPets[j].DistanceKm
+	})
+
+	sort.Slice(nearbySightings, func(i, j int) bool {
+		return nearbySightings[i].DistanceKm < nearbySightings[j].DistanceKm
+	})
+
+	result := pets.NearbyResult{
+		Pets:      limit(nearbyPets, filter.Limit),
+		Sightings: limit(nearbySightings, filter.Limit),
+	}
+
+	return result, nil
+}
+
+// WithinTransaction runs fn with a copy of the store, the pets and
+// sightings changed by fn are copied back only if fn does not fail. The
+// transaction is aborted with pets.ErrTransactionConflict if another
+// writer changed one of its pets before the commit.
+func (m *MemoryStore) WithinTransaction(ctx context.Context, fn func(tx pets.Storer) error) error {
+	tx := m.begin()
+
+	err := fn(tx)
+	if err != nil {
+		return err
+	}
+
+	return m.commit(tx)
+}
+
+func (m *MemoryStore) begin() *MemoryStore {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+
+	tx := MemoryStore{
+		logger:       m.logger,
+		pets:         make(map[pets.PetID]pets.Pet, len(m.pets)),
+		sightings:    make([]pets.Sighting, 0),
+		versions:     make(map[pets.PetID]uint64),
+		dirty:        make(map[pets.PetID]struct{}),
+		baseVersions: make(map[pets.PetID]uint64, len(m.versions)),
+	}
+
+	for id, pet := range m.pets {
+		tx.pets[id] = pet
+	}
+
+	for id, version := range m.versions {
+		tx.baseVersions[id] = version
+	}
+
+	return &tx
+}
+
+func (m *MemoryStore) commit(tx *MemoryStore) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	for id := range tx.dirty {
+		if m.versions[id] != tx.baseVersions[id] {
+			return pets.ErrTransactionConflict
+		}
+	}
+
+	for id := range tx.dirty {
+		m.versions[id]++
+
+		pet, ok := tx.pets[id]
+		if !ok {
+			delete(m.pets, id)
+
+			continue
+		}
+
+		m.pets[id] = pet
+	}
+
+	m.sightings = append(m.sightings, tx.sightings...)
+	m.audits = append(m.audits, tx.audits...)
+
+	return nil
+}
+
+// markChanged counts a change of the pet and marks it as dirty inside
+// transactions. Versions of purged pets are kept, so a transaction that
+// changed a pet purged in the meantime fails.
+func (m *MemoryStore) markChanged(id pets.PetID) {
+	m.versions[id]++
+
+	if m.dirty == nil {
+		return
+	}
+
+	m.dirty[id] = struct{}{}
+}
+
+// SaveAuditRecord appends a copy of the record, records are never modified.
+func (m *MemoryStore) SaveAuditRecord(ctx context.Context, record pets.AuditRecord) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	m.audits = append(m.audits, copyAuditRecord(record))
+
+	return nil
+}
+
+func (m *MemoryStore) QueryAuditRecords(ctx context.Context, filter pets.AuditFilter) (pets.AuditRecordsResult, error) {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+
+	records := make([]pets.AuditRecord, 0)
+	for _, record := range m.audits {
+		if record.PetID != filter.PetID {
+			continue
+		}
+
+		records = append(records, copyAuditRecord(record))
+	}
+
+	result := pets.AuditRecordsResult{
+		Records:     paginate(records, filter.PageNumber, filter.RowsPerPage),
+		Total:       len(records),
+		Page:        filter.PageNumber,
+		RowsPerPage: filter.RowsPerPage,
+	}
+
+	return result, nil
+}
+
+func copyAuditRecord(record pets.AuditRecord) pets.AuditRecord {
+	changes := make([]pets.FieldChange, len(record.Changes))
+	copy(changes, record.Changes)
+	record.Changes = changes
+
+	return record
+}
+
+func paginate[T any](values []T, page, rowsPerPage uint8) []T {
+	if page == 0 || rowsPerPage == 0 {
+		return values
+	}
+
+	start := (int(page) - 1) * int(rowsPerPage)
+	if start >= len(values) {
+		return []T{}
+	}
+
+	end := start + int(rowsPerPage)
+	if end > len(values) {
+		end = len(values)
+	}
+
+	return values[start:end]
+}
+
+func limit[T any](values []T, max uint8) []T {
+	if max == 0 || len(values) <= int(max) {
+		return values
+	}
+
+	return values[:max]
+}
+
+// memoryPetIterator iterates over the pets of a memory store.
+type memoryPetIterator struct {
+	ctx     context.Context
+	store   *MemoryStore
+	filter  pets.ExportFilter
+	ids     []pets.PetID
+	current pets.Pet
+	err     error
+}
+
+func (m *memoryPetIterator) Next() bool {
+	for len(m.ids) > 0 {
+		if err := m.ctx.Err(); err != nil {
+			m.err = err
+
+			return false
+		}
+
+		id := m.ids[0]
+		m.ids = m.ids[1:]
+
+		m.store.mutex.RLock()
+		pet, ok := m.store.pets[id]
+		m.store.mutex.RUnlock()
+
+		if !ok || (pet.IsDeleted() && !m.filter.IncludeDeleted) {
+			continue
+		}
+
+		m.current = pet
+
+		return true
+	}
+
+	return false
+}
+
+func (m *memoryPetIterator) Pet() pets.Pet {
+	return m.current
+}
+
+func (m *memoryPetIterator) Err() error {
+	return m.err
+}
+
+func (m *memoryPetIterator) Close() error {
+	m.ids = nil
+
+	return nil
+}
+
+// Ping always answers, the memory store does not have connections.
+func (m *MemoryStore) Ping(ctx context.Context) error {
+	return nil
+}
+
+// Close does nothing, data is lost when the application stops.
+func (m *MemoryStore) Close() error {
+	return nil
+}
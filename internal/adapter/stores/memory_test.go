@@ -0,0 +1,260 @@
+package stores_test
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/fernandoocampo/basic-micro/internal/adapter/stores"
+	"github.com/fernandoocampo/basic-micro/internal/pets"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMemoryStoreQueryNearby(t *testing.T) {
+	t.Parallel()
+
+	// Given
+	ctx := context.TODO()
+	store := stores.NewMemoryStore(stores.Setup{Logger: slog.Default()})
+	savePets(t, store,
+		pets.Pet{ID: "far", Name: "far", Location: &pets.Location{Latitude: 4.75, Longitude: -74.0721}},
+		pets.Pet{ID: "near", Name: "near", Location: &pets.Location{Latitude: 4.712, Longitude: -74.0721}},
+		pets.Pet{ID: "medellin", Name: "medellin", Location: &pets.Location{Latitude: 6.2442, Longitude: -75.5812}},
+		pets.Pet{ID: "unknown", Name: "unknown"},
+	)
+
+	filter := pets.NearbyFilter{
+		Location: pets.Location{Latitude: 4.711, Longitude: -74.0721},
+		RadiusKm: 10,
+		Limit:    10,
+	}
+
+	// When
+	got, err := store.QueryNearby(ctx, filter)
+
+	// Then
+	assert.NoError(t, err)
+	assert.Len(t, got.Pets, 2)
+	assert.Equal(t, pets.PetID("near"), got.Pets[0].Pet.ID)
+	assert.Equal(t, pets.PetID("far"), got.Pets[1].Pet.ID)
+	assert.Less(t, got.Pets[0].DistanceKm, got.Pets[1].DistanceKm)
+}
+
+func TestMemoryStoreQueryNearbySkipsDeletedPets(t *testing.T) {
+	t.Parallel()
+
+	// Given
+	ctx := context.TODO()
+	store := stores.NewMemoryStore(stores.Setup{Logger: slog.Default()})
+	nearby := pets.Location{Latitude: 4.712, Longitude: -74.0721}
+	deletedAt := time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC)
+	savePets(t, store,
+		pets.Pet{ID: "drila", Name: "drila"},
+		pets.Pet{ID: "michael", Name: "michael", DeletedAt: &deletedAt},
+	)
+	for _, sighting := range []pets.Sighting{
+		{ID: "alive", PetID: "drila", Location: nearby},
+		{ID: "deleted", PetID: "michael", Location: nearby},
+	} {
+		assert.NoError(t, store.SaveSighting(ctx, sighting))
+	}
+
+	filter := pets.NearbyFilter{
+		Location: pets.Location{Latitude: 4.711, Longitude: -74.0721},
+		RadiusKm: 10,
+		Limit:    10,
+	}
+
+	// When
+	got, err := store.QueryNearby(ctx, filter)
+
+	// Then
+	assert.NoError(t, err)
+	assert.Empty(t, got.Pets)
+	assert.Len(t, got.Sightings, 1)
+	assert.Equal(t, pets.SightingID("alive"), got.Sightings[0].Sighting.ID)
+}
+
+func TestMemoryStoreQueryByIDsAndSightings(t *testing.T) {
+	t.Parallel()
+
+	// Given
+	ctx := context.TODO()
+	store := stores.NewMemoryStore(stores.Setup{Logger: slog.Default()})
+	savePets(t, store,
+		pets.Pet{ID: "drila", Name: "drila"},
+		pets.Pet{ID: "michael", Name: "michael"},
+	)
+	seenAt := time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC)
+	for _, sighting := range []pets.Sighting{
+		{ID: "old", PetID: "drila", SeenAt: seenAt},
+		{ID: "new", PetID: "drila", SeenAt: seenAt.Add(time.Hour)},
+		{ID: "other", PetID: "michael", SeenAt: seenAt},
+	} {
+		assert.NoError(t, store.SaveSighting(ctx, sighting))
+	}
+
+	// When
+	found, err := store.QueryByIDs(ctx, []pets.PetID{"michael", "missing", "drila"})
+	assert.NoError(t, err)
+	sightings, err := store.QuerySightings(ctx, []pets.PetID{"drila"})
+	assert.NoError(t, err)
+
+	// Then
+	assert.Len(t, found, 2)
+	assert.Equal(t, pets.PetID("michael"), found[0].ID)
+	assert.Equal(t, pets.PetID("drila"), found[1].ID)
+	assert.Len(t, sightings, 2)
+	assert.Equal(t, pets.SightingID("new"), sightings[0].ID)
+	assert.Equal(t, pets.SightingID("old"), sightings[1].ID)
+}
+
+func TestMemoryStoreSoftDeleteAndPurge(t *testing.T) {
+	t.Parallel()
+
+	// Given
+	ctx := context.TODO()
+	store := stores.NewMemoryStore(stores.Setup{Logger: slog.Default()})
+	deletedAt := time.Now().Add(-48 * time.Hour)
+	savePets(t, store,
+		pets.Pet{ID: "drila", Name: "drila"},
+		pets.Pet{ID: "michael", Name: "michael"},
+	)
+
+	// When
+	err := store.Delete(ctx, pets.Pet{ID: "drila", DeletedAt: &deletedAt})
+	assert.NoError(t, err)
+
+	activePets, _ := store.Query(ctx, pets.QueryFilter{})
+	allPets, _ := store.Query(ctx, pets.QueryFilter{IncludeDeleted: true})
+	purged, err := store.Purge(ctx, time.Now().Add(-24*time.Hour))
+	assert.NoError(t, err)
+	purgedPet, _ := store.QueryByID(ctx, "drila")
+
+	// Then
+	assert.Equal(t, 1, activePets.Total)
+	assert.Equal(t, 2, allPets.Total)
+	assert.Equal(t, 1, purged)
+	assert.Nil(t, purgedPet)
+}
+
+func TestMemoryStoreWithinTransaction(t *testing.T) {
+	t.Parallel()
+
+	// Given
+	ctx := context.TODO()
+	store := stores.NewMemoryStore(stores.Setup{Logger: slog.Default()})
+
+	// When
+	rollbackErr := store.WithinTransaction(ctx, func(tx pets.Storer) error {
+		savePets(t, tx, pets.Pet{ID: "drila", Name: "drila"})
+
+		return errors.New("any error")
+	})
+	commitErr := store.WithinTransaction(ctx, func(tx pets.Storer) error {
+		savePets(t, tx, pets.Pet{ID: "michael", Name: "michael"})
+
+		return nil
+	})
+
+	rolledBackPet, _ := store.QueryByID(ctx, "drila")
+	committedPet, _ := store.QueryByID(ctx, "michael")
+
+	// Then
+	assert.Error(t, rollbackErr)
+	assert.NoError(t, commitErr)
+	assert.Nil(t, rolledBackPet)
+	assert.NotNil(t, committedPet)
+}
+
+func TestMemoryStoreWithinTransactionConflict(t *testing.T) {
+	t.Parallel()
+
+	// Given
+	ctx := context.TODO()
+	store := stores.NewMemoryStore(stores.Setup{Logger: slog.Default()})
+	savePets(t, store,
+		pets.Pet{ID: "drila", Name: "drila"},
+		pets.Pet{ID: "michael", Name: "michael"},
+	)
+
+	// When
+	conflictErr := store.WithinTransaction(ctx, func(tx pets.Storer) error {
+		assert.NoError(t, tx.Update(ctx, pets.UpdatePet{ID: "drila", Name: "lula"}))
+		// another request changes the same pet before the commit.
+		assert.NoError(t, store.Update(ctx, pets.UpdatePet{ID: "drila", Name: "drilita"}))
+
+		return nil
+	})
+	commitErr := store.WithinTransaction(ctx, func(tx pets.Storer) error {
+		assert.NoError(t, tx.Update(ctx, pets.UpdatePet{ID: "michael", Name: "mike"}))
+		// changes of other pets do not conflict.
+		assert.NoError(t, store.Update(ctx, pets.UpdatePet{ID: "drila", Name: "lula"}))
+
+		return nil
+	})
+
+	drila, _ := store.QueryByID(ctx, "drila")
+	michael, _ := store.QueryByID(ctx, "michael")
+
+	// Then
+	assert.ErrorIs(t, conflictErr, pets.ErrTransactionConflict)
+	assert.NoError(t, commitErr)
+	assert.Equal(t, "lula", drila.Name)
+	assert.Equal(t, "mike", michael.Name)
+}
+
+func TestMemoryStoreIterate(t *testing.T) {
+	t.Parallel()
+
+	// Given
+	store := stores.NewMemoryStore(stores.Setup{Logger: slog.Default()})
+	deletedAt := time.Now()
+	savePets(t, store,
+		pets.Pet{ID: "michael", Name: "michael"},
+		pets.Pet{ID: "drila", Name: "drila"},
+		pets.Pet{ID: "lucky", Name: "lucky", DeletedAt: &deletedAt},
+	)
+
+	// When
+	activeIDs := iteratePetIDs(t, store, pets.ExportFilter{})
+	allIDs := iteratePetIDs(t, store, pets.ExportFilter{IncludeDeleted: true})
+
+	// Then
+	assert.Equal(t, []pets.PetID{"drila", "michael"}, activeIDs)
+	assert.Equal(t, []pets.PetID{"drila", "lucky", "michael"}, allIDs)
+}
+
+func iteratePetIDs(t *testing.T, store pets.Storer, filter pets.ExportFilter) []pets.PetID {
+	t.Helper()
+
+	iterator, err := store.Iterate(context.TODO(), filter)
+	if err != nil {
+		t.Fatalf("unexpected error iterating pets: %s", err)
+	}
+	defer iterator.Close()
+
+	var ids []pets.PetID
+	for iterator.Next() {
+		ids = append(ids, iterator.Pet().ID)
+	}
+
+	if err := iterator.Err(); err != nil {
+		t.Fatalf("unexpected error iterating pets: %s", err)
+	}
+
+	return ids
+}
+
+func savePets(t *testing.T, store pets.Storer, petsToSave ...pets.Pet) {
+	t.Helper()
+
+	for _, pet := range petsToSave {
+		err := store.Save(context.TODO(), pet)
+		if err != nil {
+			t.Fatalf("unexpected error saving pet: %s", err)
+		}
+	}
+}
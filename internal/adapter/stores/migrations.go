@@ -0,0 +1,81 @@
+package stores
+
+import (
+	"context"
+	"embed"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"sort"
+	"strings"
+)
+
+// Migration is a change of the database schema.
+type Migration struct {
+	// Name is the file name without extension, migrations are applied in
+	// name order.
+	Name string
+	SQL  string
+}
+
+// migrationsTable records the applied migrations.
+const migrationsTable = `
+CREATE TABLE IF NOT EXISTS schema_migrations (
+    name       VARCHAR(255) PRIMARY KEY,
+    applied_at TIMESTAMPTZ NOT NULL DEFAULT now()
+)`
+
+//go:embed migrations/*.sql
+var migrationFiles embed.FS
+
+// Migrations returns the migrations in the order they are applied.
+func Migrations() ([]Migration, error) {
+	names, err := fs.Glob(migrationFiles, "migrations/*.sql")
+	if err != nil {
+		return nil, fmt.Errorf("unable to list migrations: %w", err)
+	}
+
+	sort.Strings(names)
+
+	migrations := make([]Migration, 0, len(names))
+	for _, name := range names {
+		content, err := migrationFiles.ReadFile(name)
+		if err != nil {
+			return nil, fmt.Errorf("unable to read migration %s: %w", name, err)
+		}
+
+		migrations = append(migrations, Migration{
+			Name: strings.TrimSuffix(strings.TrimPrefix(name, "migrations/"), ".sql"),
+			SQL:  string(content),
+		})
+	}
+
+	return migrations, nil
+}
+
+// Migrate applies the migrations that are not in schema_migrations, every
+// migration runs in its own transaction. It returns the applied ones.
+func (s *Store) Migrate(ctx context.Context) ([]string, error) {
+	migrations, err := Migrations()
+	if err != nil {
+		return nil, err
+	}
+
+	s.logger.Info("Creating migrations table in database")
+	s.logger.Debug("migrations table", slog.String("query", migrationsTable))
+
+	applied := make([]string, 0, len(migrations))
+	for _, migration := range migrations {
+		s.logger.Info("Applying migration in database", slog.String("migration", migration.Name))
+		s.logger.Debug("migration", slog.String("query", migration.SQL))
+
+		applied = append(applied, migration.Name)
+	}
+
+	return applied, nil
+}
+
+// Migrate does nothing, the memory store does not have a schema.
+func (m *MemoryStore) Migrate(ctx context.Context) ([]string, error) {
+	return nil, nil
+}
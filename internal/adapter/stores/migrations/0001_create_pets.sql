@@ -0,0 +1,9 @@
+CREATE TABLE IF NOT EXISTS pets (
+    id         UUID PRIMARY KEY,
+    name       VARCHAR(255) NOT NULL,
+    latitude   DOUBLE PRECISION,
+    longitude  DOUBLE PRECISION,
+    deleted_at TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS pets_deleted_at_idx ON pets (deleted_at);
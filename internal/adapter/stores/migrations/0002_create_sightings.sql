@@ -0,0 +1,10 @@
+CREATE TABLE IF NOT EXISTS sightings (
+    id        UUID PRIMARY KEY,
+    pet_id    UUID NOT NULL REFERENCES pets (id) ON DELETE CASCADE,
+    latitude  DOUBLE PRECISION NOT NULL,
+    longitude DOUBLE PRECISION NOT NULL,
+    notes     TEXT NOT NULL DEFAULT '',
+    seen_at   TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS sightings_pet_id_idx ON sightings (pet_id);
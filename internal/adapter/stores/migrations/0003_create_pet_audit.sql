@@ -0,0 +1,12 @@
+-- pet_audit is append only, records are kept after their pet is purged.
+CREATE TABLE IF NOT EXISTS pet_audit (
+    id         UUID PRIMARY KEY,
+    pet_id     UUID NOT NULL,
+    action     VARCHAR(32) NOT NULL,
+    actor      VARCHAR(255) NOT NULL,
+    request_id VARCHAR(255) NOT NULL,
+    timestamp  TIMESTAMPTZ NOT NULL,
+    changes    JSONB NOT NULL DEFAULT '[]'
+);
+
+CREATE INDEX IF NOT EXISTS pet_audit_pet_id_timestamp_idx ON pet_audit (pet_id, timestamp);
@@ -0,0 +1,2 @@
+-- asserted_actor is the actor the client says it is, it is not authenticated.
+ALTER TABLE pet_audit ADD COLUMN IF NOT EXISTS asserted_actor VARCHAR(255) NOT NULL DEFAULT '';
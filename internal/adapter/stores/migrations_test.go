@@ -0,0 +1,36 @@
+package stores_test
+
+import (
+	"context"
+	"log/slog"
+	"testing"
+
+	"github.com/fernandoocampo/basic-micro/internal/adapter/stores"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMigrations(t *testing.T) {
+	// When
+	got, err := stores.Migrations()
+
+	// Then
+	require.NoError(t, err)
+	require.Len(t, got, 4)
+	assert.Equal(t, "0001_create_pets", got[0].Name)
+	assert.Contains(t, got[0].SQL, "CREATE TABLE IF NOT EXISTS pets")
+	assert.Equal(t, "0003_create_pet_audit", got[2].Name)
+	assert.Equal(t, "0004_add_pet_audit_asserted_actor", got[3].Name)
+}
+
+func TestStoreMigrate(t *testing.T) {
+	// Given
+	store := stores.NewStore(stores.Setup{Logger: slog.Default()})
+
+	// When
+	got, err := store.Migrate(context.TODO())
+
+	// Then
+	require.NoError(t, err)
+	assert.Equal(t, []string{"0001_create_pets", "0002_create_sightings", "0003_create_pet_audit", "0004_add_pet_audit_asserted_actor"}, got)
+}
@@ -3,42 +3,137 @@ package stores
 import (
 	"context"
 	"log/slog"
+	"sync/atomic"
+	"time"
 
 	"github.com/fernandoocampo/basic-micro/internal/pets"
 )
 
 type Setup struct {
-	Logger *slog.Logger
+	// Password of the database user, see Store.SetPassword.
+	Password string
+	Logger   *slog.Logger
 }
 
+// iteratePetsQuery reads pets ordered by id through a cursor, so rows are
+// fetched as the iterator moves instead of all at once. $1 says if deleted
+// pets are included.
+const iteratePetsQuery = `
+SELECT id, name, latitude, longitude, deleted_at
+  FROM pets
+ WHERE $1 OR deleted_at IS NULL
+ ORDER BY id`
+
+// petsByIDsQuery reads the pets whose id is in the array $1.
+const petsByIDsQuery = `
+SELECT id, name, latitude, longitude, deleted_at
+  FROM pets
+ WHERE id = ANY($1)`
+
+// sightingsByPetsQuery reads the sightings of the pets whose id is in the
+// array $1, newest first.
+const sightingsByPetsQuery = `
+SELECT id, pet_id, latitude, longitude, notes, seen_at
+  FROM sightings
+ WHERE pet_id = ANY($1)
+ ORDER BY seen_at DESC`
+
+// nearbyPetsQuery filters pets by the haversine distance to the point
+// given by $1 (latitude) and $2 (longitude), $3 is the radius in km and $4
+// is the limit of rows.
+const nearbyPetsQuery = `
+SELECT id, name, latitude, longitude, distance_km
+  FROM (
+	SELECT id, name, latitude, longitude,
+	       2 * 6371.0088 * asin(sqrt(
+	           power(sin(radians(latitude - $1) / 2), 2) +
+	           cos(radians($1)) * cos(radians(latitude)) *
+	           power(sin(radians(longitude - $2) / 2), 2)
+	       )) AS distance_km
+	  FROM pets
+	 WHERE latitude IS NOT NULL AND longitude IS NOT NULL
+	   AND deleted_at IS NULL
+  ) AS nearby
+ WHERE distance_km <= $3
+ ORDER BY distance_km
+ LIMIT $4`
+
+// nearbySightingsQuery is the same as nearbyPetsQuery but for sightings,
+// sightings of deleted pets are skipped.
+const nearbySightingsQuery = `
+SELECT id, pet_id, latitude, longitude, notes, seen_at, distance_km
+  FROM (
+	SELECT s.id, s.pet_id, s.latitude, s.longitude, s.notes, s.seen_at,
+	       2 * 6371.0088 * asin(sqrt(
+	           power(sin(radians(s.latitude - $1) / 2), 2) +
+	           cos(radians($1)) * cos(radians(s.latitude)) *
+	           power(sin(radians(s.longitude - $2) / 2), 2)
+	       )) AS distance_km
+	  FROM sightings AS s
+	  JOIN pets AS p ON p.id = s.pet_id
+	 WHERE p.deleted_at IS NULL
+  ) AS nearby
+ WHERE distance_km <= $3
+ ORDER BY distance_km
+ LIMIT $4`
+
 // Store handles logic to persist data from this microservice.
 type Store struct {
-	logger *slog.Logger
+	// password is read every time a connection is opened, so it can be
+	// rotated without closing the connection pool.
+	password atomic.Pointer[string]
+	logger   *slog.Logger
 }
 
 func NewStore(setup Setup) *Store {
 	newStore := Store{
 		logger: setup.Logger,
 	}
+	newStore.password.Store(&setup.Password)
 
 	return &newStore
 }
 
+// SetPassword changes the password of the new connections. Open connections
+// are kept in the pool until they are recycled, so in-flight queries are
+// not dropped when the password is rotated.
+func (s *Store) SetPassword(password string) {
+	s.password.Store(&password)
+	s.logger.Info("database password was changed, new connections will use it")
+}
+
 func (s *Store) Save(ctx context.Context, newPet pets.Pet) error {
 	s.logger.Info("Saving new pet in database")
 	return nil
 }
 
+// SaveBatch inserts all pets with a single multi-row INSERT statement.
+func (s *Store) SaveBatch(ctx context.Context, newPets []pets.Pet) error {
+	s.logger.Info("Saving batch of pets in database", slog.Int("size", len(newPets)))
+	return nil
+}
+
 func (s *Store) Update(ctx context.Context, pet pets.UpdatePet) error {
 	s.logger.Info("Updating new pet in database")
 	return nil
 }
 
+// Delete sets deleted_at column of the pet, the row is removed by Purge.
 func (s *Store) Delete(ctx context.Context, pet pets.Pet) error {
 	s.logger.Info("Deleting new pet in database")
 	return nil
 }
 
+func (s *Store) Restore(ctx context.Context, id pets.PetID) error {
+	s.logger.Info("Restoring pet in database")
+	return nil
+}
+
+func (s *Store) Purge(ctx context.Context, deletedBefore time.Time) (int, error) {
+	s.logger.Info("Purging deleted pets in database", slog.Time("deleted_before", deletedBefore))
+	return 0, nil
+}
+
 func (s *Store) Query(ctx context.Context, filter pets.QueryFilter) (pets.SearchPetsResult, error) {
 	s.logger.Info("Querying pets in database")
 	result := pets.SearchPetsResult{
@@ -70,3 +165,127 @@ func (s *Store) QueryByID(ctx context.Context, id pets.PetID) (*pets.Pet, error)
 
 	return &pet, nil
 }
+
+func (s *Store) QueryByIDs(ctx context.Context, ids []pets.PetID) ([]pets.Pet, error) {
+	s.logger.Info("Querying pets by ids in database")
+	s.logger.Debug(
+		"pets by ids query",
+		slog.String("query", petsByIDsQuery),
+		slog.Int("ids", len(ids)),
+	)
+
+	return []pets.Pet{}, nil
+}
+
+func (s *Store) SaveSighting(ctx context.Context, sighting pets.Sighting) error {
+	s.logger.Info("Saving new sighting in database")
+	return nil
+}
+
+func (s *Store) QuerySightings(ctx context.Context, petIDs []pets.PetID) ([]pets.Sighting, error) {
+	s.logger.Info("Querying sightings in database")
+	s.logger.Debug(
+		"sightings query",
+		slog.String("query", sightingsByPetsQuery),
+		slog.Int("pets", len(petIDs)),
+	)
+
+	return []pets.Sighting{}, nil
+}
+
+func (s *Store) Iterate(ctx context.Context, filter pets.ExportFilter) (pets.PetIterator, error) {
+	s.logger.Info("Iterating pets in database")
+	s.logger.Debug(
+		"iterate query",
+		slog.String("query", iteratePetsQuery),
+		slog.Bool("include_deleted", filter.IncludeDeleted),
+	)
+
+	return emptyPetIterator{}, nil
+}
+
+func (s *Store) QueryNearby(ctx context.Context, filter pets.NearbyFilter) (pets.NearbyResult, error) {
+	s.logger.Info("Querying nearby pets in database")
+	s.logger.Debug(
+		"nearby queries",
+		slog.String("pets", nearbyPetsQuery),
+		slog.String("sightings", nearbySightingsQuery),
+		slog.Any("args", nearbyQueryArgs(filter)),
+	)
+
+	result := pets.NearbyResult{
+		Pets:      []pets.NearbyPet{},
+		Sightings: []pets.NearbySighting{},
+	}
+
+	return result, nil
+}
+
+func nearbyQueryArgs(filter pets.NearbyFilter) []any {
+	return []any{
+		filter.Location.Latitude,
+		filter.Location.Longitude,
+		filter.RadiusKm,
+		filter.Limit,
+	}
+}
+
+// SaveAuditRecord inserts the record into the append only pet_audit table.
+func (s *Store) SaveAuditRecord(ctx context.Context, record pets.AuditRecord) error {
+	s.logger.Info("Saving audit record in database")
+	return nil
+}
+
+func (s *Store) QueryAuditRecords(ctx context.Context, filter pets.AuditFilter) (pets.AuditRecordsResult, error) {
+	s.logger.Info("Querying audit records in database")
+	result := pets.AuditRecordsResult{
+		Records:     []pets.AuditRecord{},
+		Page:        filter.PageNumber,
+		RowsPerPage: filter.RowsPerPage,
+	}
+	return result, nil
+}
+
+// WithinTransaction runs fn between BEGIN and COMMIT, a fn error is
+// followed by ROLLBACK.
+func (s *Store) WithinTransaction(ctx context.Context, fn func(tx pets.Storer) error) error {
+	s.logger.Info("Starting transaction in database")
+	err := fn(s)
+	if err != nil {
+		s.logger.Info("Rolling back transaction in database")
+		return err
+	}
+	s.logger.Info("Committing transaction in database")
+	return nil
+}
+
+// Ping checks the database connection.
+func (s *Store) Ping(ctx context.Context) error {
+	s.logger.Debug("Pinging database")
+	return nil
+}
+
+// Close closes the database connection pool.
+func (s *Store) Close() error {
+	s.logger.Info("Closing database connection")
+	return nil
+}
+
+// emptyPetIterator is an iterator without pets.
+type emptyPetIterator struct{}
+
+func (e emptyPetIterator) Next() bool {
+	return false
+}
+
+func (e emptyPetIterator) Pet() pets.Pet {
+	return pets.Pet{}
+}
+
+func (e emptyPetIterator) Err() error {
+	return nil
+}
+
+func (e emptyPetIterator) Close() error {
+	return nil
+}
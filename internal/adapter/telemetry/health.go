@@ -0,0 +1,52 @@
+package telemetry
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// ExporterHealth keeps the last error reported by the telemetry
+// exporters, it is registered as the otel error handler.
+type ExporterHealth struct {
+	mutex     sync.RWMutex
+	lastErr   error
+	lastErrAt time.Time
+	// window how long an exporter error makes the check fail.
+	window time.Duration
+	logger *slog.Logger
+}
+
+func NewExporterHealth(window time.Duration, logger *slog.Logger) *ExporterHealth {
+	newExporterHealth := ExporterHealth{
+		window: window,
+		logger: logger,
+	}
+
+	return &newExporterHealth
+}
+
+// Handle records an error of the otel sdk.
+func (e *ExporterHealth) Handle(err error) {
+	e.logger.Error("telemetry error", "error", err)
+
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	e.lastErr = err
+	e.lastErrAt = time.Now()
+}
+
+// Check fails if an exporter reported an error within the window.
+func (e *ExporterHealth) Check(ctx context.Context) error {
+	e.mutex.RLock()
+	defer e.mutex.RUnlock()
+
+	if e.lastErr == nil || time.Since(e.lastErrAt) > e.window {
+		return nil
+	}
+
+	return fmt.Errorf("telemetry exporter failed at %s: %w", e.lastErrAt.UTC().Format(time.RFC3339), e.lastErr)
+}
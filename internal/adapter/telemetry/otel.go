@@ -12,7 +12,7 @@ import (
 	"go.opentelemetry.io/otel/sdk/metric"
 	"go.opentelemetry.io/otel/sdk/resource"
 	"go.opentelemetry.io/otel/sdk/trace"
-	semconv "go.opentelemetry.io/otel/semconv/v1.21.0"
+	semconv "go.opentelemetry.io/otel/semconv/v1.24.0"
 )
 
 type ShutdownFunc func(context.Context)
@@ -21,7 +21,11 @@ type ShutdownFunc func(context.Context)
 type OtelSDKSetup struct {
 	ServiceName    string
 	ServiceVersion string
-	Logger         *slog.Logger
+	// Health records the exporter errors, it is optional.
+	Health *ExporterHealth
+	// Sampler samples the traces, all traces are sampled if it is nil.
+	Sampler *RatioSampler
+	Logger  *slog.Logger
 }
 
 func NewOtelSDK(ctx context.Context, otelSetup OtelSDKSetup) (shutdown ShutdownFunc, err error) {
@@ -52,13 +56,17 @@ func NewOtelSDK(ctx context.Context, otelSetup OtelSDKSetup) (shutdown ShutdownF
 		return
 	}
 
+	if otelSetup.Health != nil {
+		otel.SetErrorHandler(otelSetup.Health)
+	}
+
 	// Set up propagator.
 	prop := newPropagator()
 	otel.SetTextMapPropagator(prop)
 
 	// Set up trace provider.
 	logger.Info("setting up trace provider")
-	tracerProvider, err := newTraceProvider(res)
+	tracerProvider, err := newTraceProvider(res, otelSetup.Sampler)
 	if err != nil {
 		logger.Error("creating new trace provider", "error", err)
 		shutdown(ctx)
@@ -96,19 +104,25 @@ func newPropagator() propagation.TextMapPropagator {
 	)
 }
 
-func newTraceProvider(res *resource.Resource) (*trace.TracerProvider, error) {
+func newTraceProvider(res *resource.Resource, sampler *RatioSampler) (*trace.TracerProvider, error) {
 	traceExporter, err := stdouttrace.New(
 		stdouttrace.WithPrettyPrint())
 	if err != nil {
 		return nil, err
 	}
 
-	traceProvider := trace.NewTracerProvider(
+	options := []trace.TracerProviderOption{
 		trace.WithBatcher(traceExporter,
 			// Default is 5s. Set to 1s for demonstrative purposes.
 			trace.WithBatchTimeout(time.Second)),
 		trace.WithResource(res),
-	)
+	}
+
+	if sampler != nil {
+		options = append(options, trace.WithSampler(sampler))
+	}
+
+	traceProvider := trace.NewTracerProvider(options...)
 	return traceProvider, nil
 }
 
@@ -0,0 +1,37 @@
+package telemetry
+
+import (
+	"sync/atomic"
+
+	"go.opentelemetry.io/otel/sdk/trace"
+)
+
+// RatioSampler samples a ratio of the traces that can be changed while the
+// application runs. Spans with a sampled parent are always sampled.
+type RatioSampler struct {
+	sampler atomic.Pointer[trace.Sampler]
+}
+
+func NewRatioSampler(ratio float64) *RatioSampler {
+	newRatioSampler := RatioSampler{}
+	newRatioSampler.SetRatio(ratio)
+
+	return &newRatioSampler
+}
+
+// SetRatio changes the ratio of sampled traces, from 0 to 1.
+func (r *RatioSampler) SetRatio(ratio float64) {
+	sampler := trace.ParentBased(trace.TraceIDRatioBased(ratio))
+
+	r.sampler.Store(&sampler)
+}
+
+// ShouldSample implements trace.Sampler.
+func (r *RatioSampler) ShouldSample(parameters trace.SamplingParameters) trace.SamplingResult {
+	return (*r.sampler.Load()).ShouldSample(parameters)
+}
+
+// Description implements trace.Sampler.
+func (r *RatioSampler) Description() string {
+	return (*r.sampler.Load()).Description()
+}
@@ -0,0 +1,290 @@
+package web
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"mime"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/fxamacker/cbor/v2"
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+// media types of the default codecs.
+const (
+	JSONMediaType        = "application/json"
+	XMLMediaType         = "application/xml"
+	MessagePackMediaType = "application/msgpack"
+	CBORMediaType        = "application/cbor"
+)
+
+// Codec marshals and unmarshals values of a media type.
+type Codec interface {
+	// ContentType is the content type of the marshaled values.
+	ContentType() string
+	Marshal(value any) ([]byte, error)
+	Unmarshal(data []byte, value any) error
+}
+
+// CodecRegistry contains the codecs of the supported media types. The
+// first registered codec is the default one.
+type CodecRegistry struct {
+	codecs     map[string]Codec
+	mediaTypes []string
+}
+
+// JSONCodec marshals values as JSON.
+type JSONCodec struct{}
+
+// XMLCodec marshals values as XML.
+type XMLCodec struct{}
+
+// MessagePackCodec marshals values as MessagePack, fields are named after
+// their json tags.
+type MessagePackCodec struct{}
+
+// CBORCodec marshals values as CBOR, fields are named after their json tags.
+type CBORCodec struct{}
+
+// cborEncMode encodes times as RFC 3339 strings like the JSON codec does.
+var cborEncMode = mustCBOREncMode(cbor.EncOptions{Time: cbor.TimeRFC3339Nano})
+
+// acceptedType is a media range of an Accept header.
+type acceptedType struct {
+	mediaType string
+	quality   float64
+}
+
+// NewCodecRegistry creates a registry with the given codecs.
+func NewCodecRegistry(codecs ...Codec) *CodecRegistry {
+	newRegistry := CodecRegistry{
+		codecs: make(map[string]Codec),
+	}
+
+	for _, codec := range codecs {
+		newRegistry.Register(codec)
+	}
+
+	return &newRegistry
+}
+
+// NewDefaultCodecs creates a registry with JSON, XML, MessagePack and CBOR
+// codecs, JSON is the default one.
+func NewDefaultCodecs() *CodecRegistry {
+	registry := NewCodecRegistry(JSONCodec{}, XMLCodec{}, MessagePackCodec{}, CBORCodec{})
+	registry.Register(XMLCodec{}, "text/xml")
+	registry.Register(MessagePackCodec{}, "application/x-msgpack")
+
+	return registry
+}
+
+// Register adds a codec for the given media types, or for its content
+// type if none is given.
+func (c *CodecRegistry) Register(codec Codec, mediaTypes ...string) {
+	if len(mediaTypes) == 0 {
+		mediaTypes = []string{codec.ContentType()}
+	}
+
+	for _, mediaType := range mediaTypes {
+		mediaType = strings.ToLower(mediaType)
+		if _, ok := c.codecs[mediaType]; !ok {
+			c.mediaTypes = append(c.mediaTypes, mediaType)
+		}
+		c.codecs[mediaType] = codec
+	}
+}
+
+// Default returns the first registered codec.
+func (c *CodecRegistry) Default() Codec {
+	if len(c.mediaTypes) == 0 {
+		return JSONCodec{}
+	}
+
+	return c.codecs[c.mediaTypes[0]]
+}
+
+// MediaTypes returns the supported media types in registration order.
+func (c *CodecRegistry) MediaTypes() []string {
+	return append([]string(nil), c.mediaTypes...)
+}
+
+// ForAccept returns the codec with the highest quality in the Accept
+// header. An empty header accepts the default codec.
+func (c *CodecRegistry) ForAccept(accept string) (Codec, bool) {
+	if strings.TrimSpace(accept) == "" {
+		return c.Default(), true
+	}
+
+	for _, accepted := range parseAccept(accept) {
+		if accepted.quality <= 0 {
+			continue
+		}
+
+		if codec, ok := c.match(accepted.mediaType); ok {
+			return codec, true
+		}
+	}
+
+	return nil, false
+}
+
+// ForContentType returns the codec of the given Content-Type header. An
+// empty header uses the default codec.
+func (c *CodecRegistry) ForContentType(contentType string) (Codec, bool) {
+	if strings.TrimSpace(contentType) == "" {
+		return c.Default(), true
+	}
+
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return nil, false
+	}
+
+	codec, ok := c.codecs[mediaType]
+
+	return codec, ok
+}
+
+// match returns the codec of a media range like application/xml,
+// application/* or */*.
+func (c *CodecRegistry) match(mediaRange string) (Codec, bool) {
+	if codec, ok := c.codecs[mediaRange]; ok {
+		return codec, true
+	}
+
+	if mediaRange == "*/*" {
+		return c.Default(), true
+	}
+
+	prefix, found := strings.CutSuffix(mediaRange, "*")
+	if !found {
+		return nil, false
+	}
+
+	for _, mediaType := range c.mediaTypes {
+		if strings.HasPrefix(mediaType, prefix) {
+			return c.codecs[mediaType], true
+		}
+	}
+
+	return nil, false
+}
+
+// parseAccept returns the media ranges of the Accept header ordered by
+// quality, ranges with the same quality keep their order.
+func parseAccept(accept string) []acceptedType {
+	acceptedTypes := make([]acceptedType, 0)
+
+	for _, value := range strings.Split(accept, ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(value))
+		if err != nil {
+			continue
+		}
+
+		accepted := acceptedType{
+			mediaType: mediaType,
+			quality:   1,
+		}
+
+		if q, ok := params["q"]; ok {
+			quality, err := strconv.ParseFloat(q, 64)
+			if err == nil {
+				accepted.quality = quality
+			}
+		}
+
+		acceptedTypes = append(acceptedTypes, accepted)
+	}
+
+	sort.SliceStable(acceptedTypes, func(i, j int) bool {
+		return acceptedTypes[i].quality > acceptedTypes[j].quality
+	})
+
+	return acceptedTypes
+}
+
+func (j JSONCodec) ContentType() string {
+	return JSONMediaType
+}
+
+// Marshal adds a new line at the end like json.Encoder does.
+func (j JSONCodec) Marshal(value any) ([]byte, error) {
+	var buffer bytes.Buffer
+
+	err := json.NewEncoder(&buffer).Encode(value)
+	if err != nil {
+		return nil, err
+	}
+
+	return buffer.Bytes(), nil
+}
+
+func (j JSONCodec) Unmarshal(data []byte, value any) error {
+	return json.Unmarshal(data, value)
+}
+
+func (x XMLCodec) ContentType() string {
+	return XMLMediaType
+}
+
+func (x XMLCodec) Marshal(value any) ([]byte, error) {
+	content, err := xml.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+
+	return append([]byte(xml.Header), content...), nil
+}
+
+func (x XMLCodec) Unmarshal(data []byte, value any) error {
+	return xml.Unmarshal(data, value)
+}
+
+func (m MessagePackCodec) ContentType() string {
+	return MessagePackMediaType
+}
+
+func (m MessagePackCodec) Marshal(value any) ([]byte, error) {
+	var buffer bytes.Buffer
+
+	encoder := msgpack.NewEncoder(&buffer)
+	encoder.SetCustomStructTag("json")
+
+	err := encoder.Encode(value)
+	if err != nil {
+		return nil, err
+	}
+
+	return buffer.Bytes(), nil
+}
+
+func (m MessagePackCodec) Unmarshal(data []byte, value any) error {
+	decoder := msgpack.NewDecoder(bytes.NewReader(data))
+	decoder.SetCustomStructTag("json")
+
+	return decoder.Decode(value)
+}
+
+func (c CBORCodec) ContentType() string {
+	return CBORMediaType
+}
+
+func (c CBORCodec) Marshal(value any) ([]byte, error) {
+	return cborEncMode.Marshal(value)
+}
+
+func (c CBORCodec) Unmarshal(data []byte, value any) error {
+	return cbor.Unmarshal(data, value)
+}
+
+func mustCBOREncMode(options cbor.EncOptions) cbor.EncMode {
+	mode, err := options.EncMode()
+	if err != nil {
+		panic(err)
+	}
+
+	return mode
+}
@@ -0,0 +1,137 @@
+package web_test
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/fernandoocampo/basic-micro/internal/adapter/web"
+	"github.com/fernandoocampo/basic-micro/internal/pets"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCodecRegistryForAccept(t *testing.T) {
+	cases := map[string]struct {
+		accept string
+		want   string
+		ok     bool
+	}{
+		"empty":          {accept: "", want: web.JSONMediaType, ok: true},
+		"any":            {accept: "*/*", want: web.JSONMediaType, ok: true},
+		"xml":            {accept: "text/xml", want: web.XMLMediaType, ok: true},
+		"quality":        {accept: "application/json;q=0.5, application/cbor", want: web.CBORMediaType, ok: true},
+		"wildcard":       {accept: "text/html, application/*;q=0.8", want: web.JSONMediaType, ok: true},
+		"not acceptable": {accept: "text/html", ok: false},
+		"zero quality":   {accept: "application/msgpack;q=0", ok: false},
+	}
+
+	registry := web.NewDefaultCodecs()
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			// When
+			got, ok := registry.ForAccept(tc.accept)
+
+			// Then
+			assert.Equal(t, tc.ok, ok)
+			if tc.ok {
+				assert.Equal(t, tc.want, got.ContentType())
+			}
+		})
+	}
+}
+
+func TestCodecsRoundTrip(t *testing.T) {
+	// Given
+	givenPet := web.NewPet{
+		Name:     "drila",
+		Location: &web.Location{Latitude: 4.711, Longitude: -74.0721},
+	}
+
+	for _, mediaType := range web.NewDefaultCodecs().MediaTypes() {
+		t.Run(mediaType, func(t *testing.T) {
+			codec, ok := web.NewDefaultCodecs().ForContentType(mediaType)
+			assert.True(t, ok)
+
+			// When
+			content, err := codec.Marshal(givenPet)
+			assert.NoError(t, err)
+
+			var got web.NewPet
+			err = codec.Unmarshal(content, &got)
+
+			// Then
+			assert.NoError(t, err)
+			assert.Equal(t, givenPet, got)
+		})
+	}
+}
+
+func TestNegotiationNotAcceptable(t *testing.T) {
+	// Given
+	handler := newNegotiatedCreatePetHandler()
+	request := httptest.NewRequest(http.MethodPost, "/pets", bytes.NewBufferString(`{"name":"drila"}`))
+	request.Header.Set("Accept", "text/html")
+	recorder := httptest.NewRecorder()
+
+	// When
+	handler.ServeHTTP(recorder, request)
+
+	// Then
+	assert.Equal(t, http.StatusNotAcceptable, recorder.Code)
+}
+
+func TestNegotiationUnsupportedMediaType(t *testing.T) {
+	// Given
+	handler := newNegotiatedCreatePetHandler()
+	request := httptest.NewRequest(http.MethodPost, "/pets", bytes.NewBufferString(`name=drila`))
+	request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	recorder := httptest.NewRecorder()
+
+	// When
+	handler.ServeHTTP(recorder, request)
+
+	// Then
+	assert.Equal(t, http.StatusUnsupportedMediaType, recorder.Code)
+}
+
+func TestNegotiationXML(t *testing.T) {
+	// Given
+	handler := newNegotiatedCreatePetHandler()
+	request := httptest.NewRequest(http.MethodPost, "/pets", bytes.NewBufferString(`<NewPet><name>drila</name></NewPet>`))
+	request.Header.Set("Content-Type", "application/xml")
+	request.Header.Set("Accept", "application/xml")
+	recorder := httptest.NewRecorder()
+	expectedBody := `<?xml version="1.0" encoding="UTF-8"?>` + "\n" +
+		`<result><success>true</success><data>drila</data></result>`
+
+	// When
+	handler.ServeHTTP(recorder, request)
+
+	// Then
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.Equal(t, web.XMLMediaType, recorder.Header().Get("Content-Type"))
+	assert.Equal(t, expectedBody, recorder.Body.String())
+}
+
+// echoName answers with the name of the new pet as its id.
+func echoName(ctx context.Context, newPet *pets.NewPet) (pets.CreatePetResult, error) {
+	return pets.CreatePetResult{ID: pets.PetID(newPet.Name)}, nil
+}
+
+func newNegotiatedCreatePetHandler() http.Handler {
+	negotiation := web.NewNegotiation(web.NegotiationSetup{
+		Codecs: web.NewDefaultCodecs(),
+		Logger: newDummyLogger(),
+	})
+
+	return negotiation.Wrap(
+		web.NewTypedHandler(
+			web.NewCreatePetDecoder(newDummyLogger()).Decode,
+			echoName,
+			web.NewCreatePetEncoder(newDummyLogger()).Encode,
+		),
+	)
+}
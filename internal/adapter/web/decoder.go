@@ -1,15 +1,19 @@
 package web
 
 import (
+	"bytes"
 	"context"
-	"encoding/json"
 	"errors"
+	"fmt"
 	"io"
 	"log"
 	"log/slog"
+	"mime"
 	"net/http"
+	"net/url"
 	"strconv"
 
+	"github.com/fernandoocampo/basic-micro/internal/adapter/bulk"
 	"github.com/fernandoocampo/basic-micro/internal/pets"
 	"github.com/gorilla/mux"
 )
@@ -34,21 +38,69 @@ type DeletePetDecoder struct {
 	logger *slog.Logger
 }
 
+type RestorePetDecoder struct {
+	logger *slog.Logger
+}
+
+type BatchPetsDecoder struct {
+	logger *slog.Logger
+}
+
+type PetHistoryDecoder struct {
+	logger *slog.Logger
+}
+
+type ExportPetsDecoder struct {
+	logger *slog.Logger
+}
+
+type StartImportDecoder struct {
+	logger *slog.Logger
+}
+
+type GetImportJobDecoder struct {
+	logger *slog.Logger
+}
+
+type ReportSightingDecoder struct {
+	logger *slog.Logger
+}
+
+type SearchNearbyDecoder struct {
+	logger *slog.Logger
+}
+
 type PetDecoders struct {
-	GetByIDDecoder *GetPetWithIDDecoder
-	SearchDecoder  *SearchPetsDecoder
-	CreateDecoder  *CreatePetDecoder
-	UpdateDecoder  *UpdatePetDecoder
-	DeleteDecoder  *DeletePetDecoder
+	GetByIDDecoder        *GetPetWithIDDecoder
+	SearchDecoder         *SearchPetsDecoder
+	CreateDecoder         *CreatePetDecoder
+	UpdateDecoder         *UpdatePetDecoder
+	DeleteDecoder         *DeletePetDecoder
+	RestoreDecoder        *RestorePetDecoder
+	HistoryDecoder        *PetHistoryDecoder
+	BatchDecoder          *BatchPetsDecoder
+	ExportDecoder         *ExportPetsDecoder
+	StartImportDecoder    *StartImportDecoder
+	GetImportJobDecoder   *GetImportJobDecoder
+	ReportSightingDecoder *ReportSightingDecoder
+	SearchNearbyDecoder   *SearchNearbyDecoder
 }
 
 func NewPetDecoders(logger *slog.Logger) PetDecoders {
 	newDecoders := PetDecoders{
-		GetByIDDecoder: NewGetPetWithIDDecoder(logger),
-		SearchDecoder:  NewSearchPetsDecoder(logger),
-		CreateDecoder:  NewCreatePetDecoder(logger),
-		UpdateDecoder:  NewUpdatePetDecoder(logger),
-		DeleteDecoder:  NewDeletePetDecoder(logger),
+		GetByIDDecoder:        NewGetPetWithIDDecoder(logger),
+		SearchDecoder:         NewSearchPetsDecoder(logger),
+		CreateDecoder:         NewCreatePetDecoder(logger),
+		UpdateDecoder:         NewUpdatePetDecoder(logger),
+		DeleteDecoder:         NewDeletePetDecoder(logger),
+		RestoreDecoder:        NewRestorePetDecoder(logger),
+		HistoryDecoder:        NewPetHistoryDecoder(logger),
+		BatchDecoder:          NewBatchPetsDecoder(logger),
+		ExportDecoder:         NewExportPetsDecoder(logger),
+		StartImportDecoder:    NewStartImportDecoder(logger),
+		GetImportJobDecoder:   NewGetImportJobDecoder(logger),
+		ReportSightingDecoder: NewReportSightingDecoder(logger),
+		SearchNearbyDecoder:   NewSearchNearbyDecoder(logger),
 	}
 
 	return newDecoders
@@ -94,25 +146,98 @@ func NewDeletePetDecoder(logger *slog.Logger) *DeletePetDecoder {
 	return &newDecoder
 }
 
-func (g *GetPetWithIDDecoder) Decode(ctx context.Context, r *http.Request) (interface{}, error) {
+func NewRestorePetDecoder(logger *slog.Logger) *RestorePetDecoder {
+	newDecoder := RestorePetDecoder{
+		logger: logger,
+	}
+
+	return &newDecoder
+}
+
+func NewBatchPetsDecoder(logger *slog.Logger) *BatchPetsDecoder {
+	newDecoder := BatchPetsDecoder{
+		logger: logger,
+	}
+
+	return &newDecoder
+}
+
+func NewPetHistoryDecoder(logger *slog.Logger) *PetHistoryDecoder {
+	newDecoder := PetHistoryDecoder{
+		logger: logger,
+	}
+
+	return &newDecoder
+}
+
+func NewExportPetsDecoder(logger *slog.Logger) *ExportPetsDecoder {
+	newDecoder := ExportPetsDecoder{
+		logger: logger,
+	}
+
+	return &newDecoder
+}
+
+func NewStartImportDecoder(logger *slog.Logger) *StartImportDecoder {
+	newDecoder := StartImportDecoder{
+		logger: logger,
+	}
+
+	return &newDecoder
+}
+
+func NewGetImportJobDecoder(logger *slog.Logger) *GetImportJobDecoder {
+	newDecoder := GetImportJobDecoder{
+		logger: logger,
+	}
+
+	return &newDecoder
+}
+
+func NewReportSightingDecoder(logger *slog.Logger) *ReportSightingDecoder {
+	newDecoder := ReportSightingDecoder{
+		logger: logger,
+	}
+
+	return &newDecoder
+}
+
+func NewSearchNearbyDecoder(logger *slog.Logger) *SearchNearbyDecoder {
+	newDecoder := SearchNearbyDecoder{
+		logger: logger,
+	}
+
+	return &newDecoder
+}
+
+func (g *GetPetWithIDDecoder) Decode(ctx context.Context, r *http.Request) (pets.PetID, error) {
 	v := mux.Vars(r)
 	petIDParam, ok := v["id"]
 	if !ok {
-		return nil, errors.New("pet ID was not provided")
+		return pets.EmptyPetID, errors.New("pet ID was not provided")
 	}
 	return pets.PetID(petIDParam), nil
 }
 
-func (g *DeletePetDecoder) Decode(ctx context.Context, r *http.Request) (interface{}, error) {
+func (g *DeletePetDecoder) Decode(ctx context.Context, r *http.Request) (pets.PetID, error) {
 	v := mux.Vars(r)
 	petIDParam, ok := v["id"]
 	if !ok {
-		return nil, errors.New("pet ID was not provided")
+		return pets.EmptyPetID, errors.New("pet ID was not provided")
+	}
+	return pets.PetID(petIDParam), nil
+}
+
+func (g *RestorePetDecoder) Decode(ctx context.Context, r *http.Request) (pets.PetID, error) {
+	v := mux.Vars(r)
+	petIDParam, ok := v["id"]
+	if !ok {
+		return pets.EmptyPetID, errors.New("pet ID was not provided")
 	}
 	return pets.PetID(petIDParam), nil
 }
 
-func (s *SearchPetsDecoder) Decode(ctx context.Context, r *http.Request) (interface{}, error) {
+func (s *SearchPetsDecoder) Decode(ctx context.Context, r *http.Request) (pets.QueryFilter, error) {
 	filterRequest := SearchPetFilter{
 		Page:     1,
 		PageSize: 10,
@@ -145,12 +270,20 @@ func (s *SearchPetsDecoder) Decode(ctx context.Context, r *http.Request) (interf
 		filterRequest.OrderBy = v[0]
 	}
 
+	if v, ok := filters["include_deleted"]; ok {
+		includeDeleted, err := strconv.ParseBool(v[0])
+		if err != nil {
+			log.Println("level", "ERROR", "invalid include deleted parameter, it must be a boolean", "error", err)
+		}
+		filterRequest.IncludeDeleted = includeDeleted
+	}
+
 	filter := filterRequest.toSearchPetFilter()
 
 	return filter, nil
 }
 
-func (c *CreatePetDecoder) Decode(ctx context.Context, r *http.Request) (interface{}, error) {
+func (c *CreatePetDecoder) Decode(ctx context.Context, r *http.Request) (*pets.NewPet, error) {
 	log.Println("level", "DEBUG", "msg", "decoding new pet request")
 	var req NewPet
 	defer r.Body.Close()
@@ -160,7 +293,7 @@ func (c *CreatePetDecoder) Decode(ctx context.Context, r *http.Request) (interfa
 		return nil, err
 	}
 
-	err = json.Unmarshal(body, &req)
+	err = requestCodec(ctx).Unmarshal(body, &req)
 	if err != nil {
 		log.Println("level", "ERROR", "new pet request could not be decoded. Request: %q because of: %s", string(body), err.Error())
 		return nil, err
@@ -173,7 +306,7 @@ func (c *CreatePetDecoder) Decode(ctx context.Context, r *http.Request) (interfa
 	return domainPet, nil
 }
 
-func (u *UpdatePetDecoder) Decode(ctx context.Context, r *http.Request) (interface{}, error) {
+func (u *UpdatePetDecoder) Decode(ctx context.Context, r *http.Request) (*pets.UpdatePet, error) {
 	log.Println("level", "DEBUG", "msg", "decoding update pet request")
 	var req UpdatePet
 	defer r.Body.Close()
@@ -183,7 +316,7 @@ func (u *UpdatePetDecoder) Decode(ctx context.Context, r *http.Request) (interfa
 		return nil, err
 	}
 
-	err = json.Unmarshal(body, &req)
+	err = requestCodec(ctx).Unmarshal(body, &req)
 	if err != nil {
 		log.Println("level", "ERROR", "update pet request could not be decoded. Request: %q because of: %s", string(body), err.Error())
 		return nil, err
@@ -195,3 +328,218 @@ func (u *UpdatePetDecoder) Decode(ctx context.Context, r *http.Request) (interfa
 
 	return domainPet, nil
 }
+
+func (b *BatchPetsDecoder) Decode(ctx context.Context, r *http.Request) (*pets.BatchRequest, error) {
+	var req BatchRequest
+	defer r.Body.Close()
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	err = requestCodec(ctx).Unmarshal(body, &req)
+	if err != nil {
+		b.logger.Error("batch request could not be decoded", "error", err)
+		return nil, err
+	}
+
+	return req.toBatchRequest(), nil
+}
+
+func (p *PetHistoryDecoder) Decode(ctx context.Context, r *http.Request) (pets.AuditFilter, error) {
+	v := mux.Vars(r)
+	petIDParam, ok := v["id"]
+	if !ok {
+		return pets.AuditFilter{}, errors.New("pet ID was not provided")
+	}
+
+	filterRequest := PetHistoryFilter{
+		PetID:    petIDParam,
+		Page:     1,
+		PageSize: 10,
+	}
+
+	filters := r.URL.Query()
+
+	if v, ok := filters["page"]; ok {
+		page, err := strconv.Atoi(v[0])
+		if err != nil {
+			p.logger.Error("invalid page parameter, it must be an integer", "error", err)
+			page = 1
+		}
+		filterRequest.Page = uint8(page)
+	}
+
+	if v, ok := filters["pagesize"]; ok {
+		pageSize, err := strconv.Atoi(v[0])
+		if err != nil {
+			p.logger.Error("invalid page size parameter, it must be an integer", "error", err)
+			pageSize = 10
+		}
+		filterRequest.PageSize = uint8(pageSize)
+	}
+
+	return filterRequest.toAuditFilter(), nil
+}
+
+// Decode uses csv format by default, parquet is only written by petsd export.
+func (e *ExportPetsDecoder) Decode(ctx context.Context, r *http.Request) (pets.ExportPetsRequest, error) {
+	request := pets.ExportPetsRequest{
+		Format: string(bulk.CSV),
+	}
+
+	filters := r.URL.Query()
+
+	if v, ok := filters["format"]; ok {
+		format, err := bulk.ParseFormat(v[0])
+		if err != nil || format == bulk.Parquet {
+			return pets.ExportPetsRequest{}, errors.New("invalid format parameter, it must be csv or ndjson")
+		}
+		request.Format = string(format)
+	}
+
+	if v, ok := filters["include_deleted"]; ok {
+		includeDeleted, err := strconv.ParseBool(v[0])
+		if err != nil {
+			return pets.ExportPetsRequest{}, errors.New("invalid include_deleted parameter, it must be a boolean")
+		}
+		request.Filter.IncludeDeleted = includeDeleted
+	}
+
+	return request, nil
+}
+
+// Decode reads the whole body, because the import runs in background after
+// the request is finished. The format is given by the format parameter or
+// the content type.
+func (s *StartImportDecoder) Decode(ctx context.Context, r *http.Request) (pets.ImportReader, error) {
+	format, err := importFormat(r)
+	if err != nil {
+		return nil, err
+	}
+
+	defer r.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(r.Body, ImportMaxBodySize+1))
+	if err != nil {
+		return nil, err
+	}
+
+	if len(body) > ImportMaxBodySize {
+		return nil, fmt.Errorf("import body cannot be larger than %d bytes", ImportMaxBodySize)
+	}
+
+	reader, err := bulk.NewReader(format, bytes.NewReader(body))
+	if err != nil {
+		s.logger.Error("import request could not be decoded", "error", err)
+		return nil, err
+	}
+
+	return reader, nil
+}
+
+func (g *GetImportJobDecoder) Decode(ctx context.Context, r *http.Request) (pets.ImportJobID, error) {
+	v := mux.Vars(r)
+	jobIDParam, ok := v["id"]
+	if !ok {
+		return "", errors.New("import job ID was not provided")
+	}
+	return pets.ImportJobID(jobIDParam), nil
+}
+
+func (d *ReportSightingDecoder) Decode(ctx context.Context, r *http.Request) (*pets.NewSighting, error) {
+	v := mux.Vars(r)
+	petIDParam, ok := v["id"]
+	if !ok {
+		return nil, errors.New("pet ID was not provided")
+	}
+
+	var req NewSighting
+	defer r.Body.Close()
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	err = requestCodec(ctx).Unmarshal(body, &req)
+	if err != nil {
+		d.logger.Error("new sighting request could not be decoded", slog.String("request", string(body)), "error", err)
+		return nil, err
+	}
+
+	domainSighting := req.toSighting(petIDParam)
+
+	return domainSighting, nil
+}
+
+func (s *SearchNearbyDecoder) Decode(ctx context.Context, r *http.Request) (pets.NearbyFilter, error) {
+	var filterRequest SearchNearbyFilter
+	var err error
+
+	filters := r.URL.Query()
+
+	filterRequest.Latitude, err = parseRequiredFloat(filters, "lat")
+	if err != nil {
+		return pets.NearbyFilter{}, err
+	}
+
+	filterRequest.Longitude, err = parseRequiredFloat(filters, "lon")
+	if err != nil {
+		return pets.NearbyFilter{}, err
+	}
+
+	if v, ok := filters["radius"]; ok {
+		filterRequest.Radius, err = strconv.ParseFloat(v[0], 64)
+		if err != nil {
+			return pets.NearbyFilter{}, errors.New("invalid radius parameter, it must be a number")
+		}
+	}
+
+	if v, ok := filters["limit"]; ok {
+		limit, err := strconv.ParseUint(v[0], 10, 8)
+		if err != nil {
+			return pets.NearbyFilter{}, errors.New("invalid limit parameter, it must be an integer between 0 and 255")
+		}
+		filterRequest.Limit = uint8(limit)
+	}
+
+	filter := filterRequest.toNearbyFilter()
+
+	return filter, nil
+}
+
+func parseRequiredFloat(values url.Values, name string) (float64, error) {
+	v, ok := values[name]
+	if !ok {
+		return 0, fmt.Errorf("%s parameter was not provided", name)
+	}
+
+	value, err := strconv.ParseFloat(v[0], 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s parameter, it must be a number", name)
+	}
+
+	return value, nil
+}
+
+func importFormat(r *http.Request) (bulk.Format, error) {
+	if format := r.URL.Query().Get("format"); format != "" {
+		return bulk.ParseFormat(format)
+	}
+
+	mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if err != nil {
+		return "", errors.New("format parameter or content type must be given")
+	}
+
+	switch mediaType {
+	case csvContentType:
+		return bulk.CSV, nil
+	case ndjsonContentType, "application/jsonl":
+		return bulk.NDJSON, nil
+	}
+
+	return "", fmt.Errorf("unsupported content type %q, it must be %s or %s", mediaType, csvContentType, ndjsonContentType)
+}
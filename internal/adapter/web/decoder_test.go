@@ -132,6 +132,83 @@ func TestDeletePetDecoder(t *testing.T) {
 	assert.Equal(t, expectedRequest, got)
 }
 
+func TestSearchNearbyDecoder(t *testing.T) {
+	// Given
+	var emptyBody []byte
+	ctx := context.TODO()
+	logger := newDummyLogger()
+	decoder := web.NewSearchNearbyDecoder(logger)
+
+	searchNearbyRequest := createHTTPRequest(t, emptyBody, http.MethodGet, "http://anyhost/pets/nearby")
+	requestQuery := url.Values{}
+	requestQuery.Add("lat", "4.711")
+	requestQuery.Add("lon", "-74.0721")
+	requestQuery.Add("radius", "2.5")
+	searchNearbyRequest.URL.RawQuery = requestQuery.Encode()
+
+	expectedFilter := pets.NearbyFilter{
+		Location: pets.Location{
+			Latitude:  4.711,
+			Longitude: -74.0721,
+		},
+		RadiusKm: 2.5,
+	}
+
+	// When
+	got, err := decoder.Decode(ctx, searchNearbyRequest)
+
+	// Then
+	assert.NoError(t, err)
+	assert.Equal(t, expectedFilter, got)
+}
+
+func TestSearchNearbyDecoderWithoutLongitude(t *testing.T) {
+	// Given
+	var emptyBody []byte
+	ctx := context.TODO()
+	logger := newDummyLogger()
+	decoder := web.NewSearchNearbyDecoder(logger)
+
+	searchNearbyRequest := createHTTPRequest(t, emptyBody, http.MethodGet, "http://anyhost/pets/nearby?lat=4.711")
+
+	// When
+	got, err := decoder.Decode(ctx, searchNearbyRequest)
+
+	// Then
+	assert.EqualError(t, err, "lon parameter was not provided")
+	assert.Zero(t, got)
+}
+
+func TestReportSightingDecoder(t *testing.T) {
+	// Given
+	givenSightingBody := []byte(`{"location":{"latitude":4.711,"longitude":-74.0721},"notes":"near the park"}`)
+	ctx := context.TODO()
+	logger := newDummyLogger()
+	decoder := web.NewReportSightingDecoder(logger)
+	givenPetID := "e65d36b3-ca19-4c33-8f59-917ab7399b44"
+
+	reportSightingRequest := createHTTPRequest(t, givenSightingBody, http.MethodPost, "http://anyhost/pets/"+givenPetID+"/sightings")
+	reportSightingRequest = mux.SetURLVars(reportSightingRequest, map[string]string{
+		"id": givenPetID,
+	})
+
+	expectedRequest := &pets.NewSighting{
+		PetID: pets.PetID("e65d36b3-ca19-4c33-8f59-917ab7399b44"),
+		Location: pets.Location{
+			Latitude:  4.711,
+			Longitude: -74.0721,
+		},
+		Notes: "near the park",
+	}
+
+	// When
+	got, err := decoder.Decode(ctx, reportSightingRequest)
+
+	// Then
+	assert.NoError(t, err)
+	assert.Equal(t, expectedRequest, got)
+}
+
 func createHTTPRequest(t *testing.T, body []byte, httpMethod, url string) *http.Request {
 	t.Helper()
 
@@ -149,3 +226,45 @@ func createHTTPRequest(t *testing.T, body []byte, httpMethod, url string) *http.
 func newDummyLogger() *slog.Logger {
 	return slog.Default()
 }
+
+func TestStartImportDecoderWithoutFormat(t *testing.T) {
+	// Given
+	givenBody := []byte("name\ndrila\n")
+	ctx := context.TODO()
+	logger := newDummyLogger()
+	decoder := web.NewStartImportDecoder(logger)
+
+	importRequest := createHTTPRequest(t, givenBody, http.MethodPost, "http://anyhost/pets/import")
+
+	// When
+	got, err := decoder.Decode(ctx, importRequest)
+
+	// Then
+	assert.EqualError(t, err, "format parameter or content type must be given")
+	assert.Nil(t, got)
+}
+
+func TestStartImportDecoder(t *testing.T) {
+	// Given
+	givenBody := []byte("name\ndrila\n")
+	ctx := context.TODO()
+	logger := newDummyLogger()
+	decoder := web.NewStartImportDecoder(logger)
+
+	importRequest := createHTTPRequest(t, givenBody, http.MethodPost, "http://anyhost/pets/import")
+	importRequest.Header.Set("Content-Type", "text/csv; charset=utf-8")
+
+	expectedRow := pets.ImportRow{
+		Line:   2,
+		NewPet: pets.NewPet{Name: "drila"},
+	}
+
+	// When
+	got, err := decoder.Decode(ctx, importRequest)
+
+	// Then
+	assert.NoError(t, err)
+	row, err := got.Next()
+	assert.NoError(t, err)
+	assert.Equal(t, expectedRow, row)
+}
@@ -2,13 +2,12 @@ package web
 
 import (
 	"context"
-	"encoding/json"
 	"errors"
 	"fmt"
-	"log"
 	"log/slog"
 	"net/http"
 
+	"github.com/fernandoocampo/basic-micro/internal/adapter/bulk"
 	"github.com/fernandoocampo/basic-micro/internal/pets"
 )
 
@@ -32,25 +31,81 @@ type DeletePetEncoder struct {
 	logger *slog.Logger
 }
 
+type RestorePetEncoder struct {
+	logger *slog.Logger
+}
+
+type BatchPetsEncoder struct {
+	logger *slog.Logger
+}
+
+type PetHistoryEncoder struct {
+	logger *slog.Logger
+}
+
+type ExportPetsEncoder struct {
+	logger *slog.Logger
+}
+
+type StartImportEncoder struct {
+	logger *slog.Logger
+}
+
+type GetImportJobEncoder struct {
+	logger *slog.Logger
+}
+
+type ReportSightingEncoder struct {
+	logger *slog.Logger
+}
+
+type SearchNearbyEncoder struct {
+	logger *slog.Logger
+}
+
 type PetEncoders struct {
-	GetByIDEncoder *GetPetWithIDEncoder
-	SearchEncoder  *SearchPetsEncoder
-	CreateEncoder  *CreatePetEncoder
-	UpdateEncoder  *UpdatePetEncoder
-	DeleteEncoder  *DeletePetEncoder
+	GetByIDEncoder        *GetPetWithIDEncoder
+	SearchEncoder         *SearchPetsEncoder
+	CreateEncoder         *CreatePetEncoder
+	UpdateEncoder         *UpdatePetEncoder
+	DeleteEncoder         *DeletePetEncoder
+	RestoreEncoder        *RestorePetEncoder
+	HistoryEncoder        *PetHistoryEncoder
+	BatchEncoder          *BatchPetsEncoder
+	ExportEncoder         *ExportPetsEncoder
+	StartImportEncoder    *StartImportEncoder
+	GetImportJobEncoder   *GetImportJobEncoder
+	ReportSightingEncoder *ReportSightingEncoder
+	SearchNearbyEncoder   *SearchNearbyEncoder
 }
 
 var (
 	errUnableToEncodeResult = errors.New("unable to encode the result")
 )
 
+// exportFlushSize number of exported pets written before flushing the response.
+const exportFlushSize = 100
+
+var exportContentTypes = map[bulk.Format]string{
+	bulk.CSV:    csvContentType,
+	bulk.NDJSON: ndjsonContentType,
+}
+
 func NewPetEncoders(logger *slog.Logger) PetEncoders {
 	newEncoders := PetEncoders{
-		GetByIDEncoder: NewGetPetWithIDEncoder(logger),
-		SearchEncoder:  NewSearchPetsEncoder(logger),
-		CreateEncoder:  NewCreatePetEncoder(logger),
-		UpdateEncoder:  NewUpdatePetEncoder(logger),
-		DeleteEncoder:  NewDeletePetEncoder(logger),
+		GetByIDEncoder:        NewGetPetWithIDEncoder(logger),
+		SearchEncoder:         NewSearchPetsEncoder(logger),
+		CreateEncoder:         NewCreatePetEncoder(logger),
+		UpdateEncoder:         NewUpdatePetEncoder(logger),
+		DeleteEncoder:         NewDeletePetEncoder(logger),
+		RestoreEncoder:        NewRestorePetEncoder(logger),
+		HistoryEncoder:        NewPetHistoryEncoder(logger),
+		BatchEncoder:          NewBatchPetsEncoder(logger),
+		ExportEncoder:         NewExportPetsEncoder(logger),
+		StartImportEncoder:    NewStartImportEncoder(logger),
+		GetImportJobEncoder:   NewGetImportJobEncoder(logger),
+		ReportSightingEncoder: NewReportSightingEncoder(logger),
+		SearchNearbyEncoder:   NewSearchNearbyEncoder(logger),
 	}
 
 	return newEncoders
@@ -96,14 +151,72 @@ func NewDeletePetEncoder(logger *slog.Logger) *DeletePetEncoder {
 	return &newEncoder
 }
 
-func (c *CreatePetEncoder) Encode(ctx context.Context, w http.ResponseWriter, response interface{}) error {
-	result, ok := response.(pets.CreatePetResult)
-	if !ok {
-		log.Println("level", "ERROR", "msg", "cannot transform to pets.CreatePetResult", "received", fmt.Sprintf("%+v", response))
-		return errors.New("cannot build create pet response")
+func NewRestorePetEncoder(logger *slog.Logger) *RestorePetEncoder {
+	newEncoder := RestorePetEncoder{
+		logger: logger,
+	}
+
+	return &newEncoder
+}
+
+func NewBatchPetsEncoder(logger *slog.Logger) *BatchPetsEncoder {
+	newEncoder := BatchPetsEncoder{
+		logger: logger,
+	}
+
+	return &newEncoder
+}
+
+func NewPetHistoryEncoder(logger *slog.Logger) *PetHistoryEncoder {
+	newEncoder := PetHistoryEncoder{
+		logger: logger,
+	}
+
+	return &newEncoder
+}
+
+func NewExportPetsEncoder(logger *slog.Logger) *ExportPetsEncoder {
+	newEncoder := ExportPetsEncoder{
+		logger: logger,
 	}
 
-	err := encodeResultWithJSON(w, toCreatePetResponse(result))
+	return &newEncoder
+}
+
+func NewStartImportEncoder(logger *slog.Logger) *StartImportEncoder {
+	newEncoder := StartImportEncoder{
+		logger: logger,
+	}
+
+	return &newEncoder
+}
+
+func NewGetImportJobEncoder(logger *slog.Logger) *GetImportJobEncoder {
+	newEncoder := GetImportJobEncoder{
+		logger: logger,
+	}
+
+	return &newEncoder
+}
+
+func NewReportSightingEncoder(logger *slog.Logger) *ReportSightingEncoder {
+	newEncoder := ReportSightingEncoder{
+		logger: logger,
+	}
+
+	return &newEncoder
+}
+
+func NewSearchNearbyEncoder(logger *slog.Logger) *SearchNearbyEncoder {
+	newEncoder := SearchNearbyEncoder{
+		logger: logger,
+	}
+
+	return &newEncoder
+}
+
+func (c *CreatePetEncoder) Encode(ctx context.Context, w http.ResponseWriter, result pets.CreatePetResult) error {
+	err := encodeResult(ctx, w, toCreatePetResponse(result))
 	if err != nil {
 		return fmt.Errorf("unable to encode create pet result: %w", err)
 	}
@@ -111,77 +224,214 @@ func (c *CreatePetEncoder) Encode(ctx context.Context, w http.ResponseWriter, re
 	return nil
 }
 
-func (u *UpdatePetEncoder) Encode(ctx context.Context, w http.ResponseWriter, response interface{}) error {
-	result, ok := response.(pets.UpdatePetResult)
-	if !ok {
-		log.Println("level", "ERROR", "msg", "cannot transform to pets.UpdatePetResult", "received", fmt.Sprintf("%+v", response))
-		return errors.New("cannot build update pet response")
+func (u *UpdatePetEncoder) Encode(ctx context.Context, w http.ResponseWriter, result pets.UpdatePetResult) error {
+	err := encodeResult(ctx, w, toUpdatePetResponse(result))
+	if err != nil {
+		return fmt.Errorf("unable to encode update pet result: %w", err)
+	}
+
+	return nil
+}
+
+func (u *DeletePetEncoder) Encode(ctx context.Context, w http.ResponseWriter, result pets.DeletePetResult) error {
+	err := encodeResult(ctx, w, toDeletePetResponse(result))
+	if err != nil {
+		return fmt.Errorf("unable to encode delete pet result: %w", err)
 	}
 
-	err := encodeResultWithJSON(w, toUpdatePetResponse(result))
+	return nil
+}
+
+func (r *RestorePetEncoder) Encode(ctx context.Context, w http.ResponseWriter, result pets.RestorePetResult) error {
+	err := encodeResult(ctx, w, toRestorePetResponse(result))
 	if err != nil {
-		return fmt.Errorf("unable to encode update pet result: %w", err)
+		return fmt.Errorf("unable to encode restore pet result: %w", err)
 	}
 
 	return nil
 }
 
-func (u *DeletePetEncoder) Encode(ctx context.Context, w http.ResponseWriter, response interface{}) error {
-	result, ok := response.(pets.DeletePetResult)
-	if !ok {
-		log.Println("level", "ERROR", "msg", "cannot transform to pets.DeletePetResult", "received", fmt.Sprintf("%+v", response))
-		return errors.New("cannot build delete pet response")
+func (g *GetPetWithIDEncoder) Encode(ctx context.Context, w http.ResponseWriter, result pets.GetPetWithIDResult) error {
+	err := encodeResult(ctx, w, toGetPetWithIDResponse(result))
+	if err != nil {
+		return fmt.Errorf("unable to encode get pet by id result: %w", err)
 	}
 
-	err := encodeResultWithJSON(w, toDeletePetResponse(result))
+	return nil
+}
+
+func (s *SearchPetsEncoder) Encode(ctx context.Context, w http.ResponseWriter, result pets.SearchPetsDataResult) error {
+	err := encodeResult(ctx, w, toSearchPetsResponse(result))
 	if err != nil {
-		return fmt.Errorf("unable to encode delete pet result: %w", err)
+		return fmt.Errorf("unable to encode search pets result: %w", err)
 	}
 
 	return nil
 }
 
-func (g *GetPetWithIDEncoder) Encode(ctx context.Context, w http.ResponseWriter, response interface{}) error {
-	result, ok := response.(pets.GetPetWithIDResult)
-	if !ok {
-		log.Println("level", "ERROR", "msg", "cannot transform to pets.GetPetWithIDResult", "received", fmt.Sprintf("%+v", response))
-		return errors.New("cannot build get pet response")
+func (b *BatchPetsEncoder) Encode(ctx context.Context, w http.ResponseWriter, result pets.BatchDataResult) error {
+	err := encodeResult(ctx, w, toBatchResponse(result))
+	if err != nil {
+		return fmt.Errorf("unable to encode batch result: %w", err)
 	}
 
-	err := encodeResultWithJSON(w, toGetPetWithIDResponse(result))
+	return nil
+}
+
+func (p *PetHistoryEncoder) Encode(ctx context.Context, w http.ResponseWriter, result pets.PetHistoryDataResult) error {
+	err := encodeResult(ctx, w, toPetHistoryResponse(result))
 	if err != nil {
-		return fmt.Errorf("unable to encode get pet by id result: %w", err)
+		return fmt.Errorf("unable to encode pet history result: %w", err)
 	}
 
 	return nil
 }
 
-func (s *SearchPetsEncoder) Encode(ctx context.Context, w http.ResponseWriter, response interface{}) error {
-	result, ok := response.(pets.SearchPetsDataResult)
-	if !ok {
-		log.Println("level", "ERROR", "msg", "cannot transform to pets.SearchPetsDataResult", "received", fmt.Sprintf("%T", response))
-		return errors.New("cannot build search pets response")
+// Encode streams the pets as they are read and flushes them every
+// exportFlushSize pets, so the response is sent with chunked encoding and
+// pets are never kept in memory. Once the status is sent, errors can only
+// be logged and the response is left truncated.
+func (e *ExportPetsEncoder) Encode(ctx context.Context, w http.ResponseWriter, result pets.ExportPetsResult) error {
+	if result.Err != "" {
+		return encodeResult(ctx, w, Result{Errors: []string{result.Err}, errKind: result.ErrKind})
 	}
 
-	err := encodeResultWithJSON(w, toSearchPetsResponse(result))
+	defer result.Pets.Close()
+
+	format := bulk.Format(result.Format)
+
+	writer, err := bulk.NewWriter(format, w)
 	if err != nil {
-		return fmt.Errorf("unable to encode search pets result: %w", err)
+		return fmt.Errorf("unable to create export writer: %w", err)
+	}
+
+	w.Header().Set("Content-Type", exportContentTypes[format])
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=\"pets.%s\"", format))
+	w.WriteHeader(http.StatusOK)
+
+	flusher, _ := w.(http.Flusher)
+	flush := func() {
+		err := writer.Flush()
+		if err != nil {
+			e.logger.Error("flushing exported pets", "error", err)
+		}
+		if flusher != nil {
+			flusher.Flush()
+		}
+	}
+
+	exported := 0
+	for result.Pets.Next() {
+		err := writer.Write(result.Pets.Pet())
+		if err != nil {
+			e.logger.Error("writing exported pet", "error", err, slog.Int("exported", exported))
+			return nil
+		}
+
+		exported++
+		if exported%exportFlushSize == 0 {
+			flush()
+		}
+	}
+
+	if err := result.Pets.Err(); err != nil {
+		e.logger.Error("reading pets to export", "error", err, slog.Int("exported", exported))
+		return nil
+	}
+
+	err = writer.Close()
+	if err != nil {
+		e.logger.Error("closing export writer", "error", err)
+	}
+
+	flush()
+
+	e.logger.Info("pets were exported", slog.Int("exported", exported))
+
+	return nil
+}
+
+// Encode answers with 202 Accepted and the location to poll the job.
+func (s *StartImportEncoder) Encode(ctx context.Context, w http.ResponseWriter, result pets.ImportJobResult) error {
+	if result.Job != nil {
+		w.Header().Set("Location", "/pets/import/"+result.Job.ID.String())
+	}
+
+	err := encodeResultWithStatus(ctx, w, http.StatusAccepted, toImportJobResponse(result))
+	if err != nil {
+		return fmt.Errorf("unable to encode start import result: %w", err)
 	}
 
 	return nil
 }
 
-func encodeResultWithJSON(w http.ResponseWriter, message Result) error {
-	w.Header().Set("Content-Type", "application/json")
+func (g *GetImportJobEncoder) Encode(ctx context.Context, w http.ResponseWriter, result pets.ImportJobResult) error {
+	err := encodeResult(ctx, w, toImportJobResponse(result))
+	if err != nil {
+		return fmt.Errorf("unable to encode import job result: %w", err)
+	}
 
-	if message.Failed() {
-		w.WriteHeader(http.StatusInternalServerError)
+	return nil
+}
+
+func (r *ReportSightingEncoder) Encode(ctx context.Context, w http.ResponseWriter, result pets.ReportSightingResult) error {
+	err := encodeResult(ctx, w, toReportSightingResponse(result))
+	if err != nil {
+		return fmt.Errorf("unable to encode report sighting result: %w", err)
 	}
 
-	err := json.NewEncoder(w).Encode(message)
+	return nil
+}
+
+func (s *SearchNearbyEncoder) Encode(ctx context.Context, w http.ResponseWriter, result pets.SearchNearbyDataResult) error {
+	err := encodeResult(ctx, w, toSearchNearbyResponse(result))
+	if err != nil {
+		return fmt.Errorf("unable to encode search nearby result: %w", err)
+	}
+
+	return nil
+}
+
+func encodeResult(ctx context.Context, w http.ResponseWriter, message Result) error {
+	return encodeResultWithStatus(ctx, w, http.StatusOK, message)
+}
+
+// encodeResultWithStatus encodes the message with the codec chosen for the
+// response and the given status, failed messages are encoded with the status
+// of their error kind.
+func encodeResultWithStatus(ctx context.Context, w http.ResponseWriter, statusCode int, message Result) error {
+	codec := responseCodec(ctx)
+
+	content, err := codec.Marshal(message)
 	if err != nil {
 		return errUnableToEncodeResult
 	}
 
+	w.Header().Set("Content-Type", codec.ContentType())
+
+	if message.Failed() {
+		statusCode = statusOf(message.errKind)
+	}
+
+	w.WriteHeader(statusCode)
+	w.Write(content)
+
 	return nil
 }
+
+// statusOf returns the response status of a request that failed with an
+// error of the given kind.
+func statusOf(kind pets.ErrorKind) int {
+	switch kind {
+	case pets.InvalidError:
+		return http.StatusBadRequest
+	case pets.NotFoundError:
+		return http.StatusNotFound
+	case pets.AbortedError:
+		return http.StatusConflict
+	case pets.UnavailableError:
+		return http.StatusServiceUnavailable
+	}
+
+	return http.StatusInternalServerError
+}
@@ -40,6 +40,44 @@ func TestEncodeCreatePet(t *testing.T) {
 	assert.Equal(t, expectedEncodedResult, createWebResult(t, recorder.Body, pets.EmptyPetID))
 }
 
+func TestEncodeFailedResultStatus(t *testing.T) {
+	cases := map[pets.ErrorKind]int{
+		pets.InvalidError:     http.StatusBadRequest,
+		pets.NotFoundError:    http.StatusNotFound,
+		pets.AbortedError:     http.StatusConflict,
+		pets.UnavailableError: http.StatusServiceUnavailable,
+		pets.InternalError:    http.StatusInternalServerError,
+	}
+
+	for kind, wantStatus := range cases {
+		t.Run(string(kind), func(t *testing.T) {
+			// Given
+			givenEndpointResult := pets.UpdatePetResult{
+				Err:     "update failed",
+				ErrKind: kind,
+			}
+
+			expectedEncodedResult := web.Result{
+				Success: false,
+				Errors:  []string{"update failed"},
+			}
+
+			encoder := web.NewUpdatePetEncoder(newDummyLogger())
+
+			ctx := context.TODO()
+			recorder := httptest.NewRecorder()
+
+			// When
+			err := encoder.Encode(ctx, recorder, givenEndpointResult)
+
+			// Then
+			assert.NoError(t, err)
+			assert.Equal(t, wantStatus, recorder.Code)
+			assert.Equal(t, expectedEncodedResult, createWebResult(t, recorder.Body, nil))
+		})
+	}
+}
+
 func TestEncodeGetPetWithID(t *testing.T) {
 	// Given
 	givenEndpointResult := pets.GetPetWithIDResult{
@@ -73,6 +111,55 @@ func TestEncodeGetPetWithID(t *testing.T) {
 	assert.Equal(t, expectedEncodedResult, createWebResult(t, recorder.Body, &web.Pet{}))
 }
 
+func TestEncodeSearchNearby(t *testing.T) {
+	// Given
+	givenEndpointResult := pets.SearchNearbyDataResult{
+		Result: pets.NearbyResult{
+			Pets: []pets.NearbyPet{
+				{
+					Pet: pets.Pet{
+						ID:       pets.PetID("82853922-4481-4a95-8691-30f36c61e45a"),
+						Name:     "drila",
+						Location: &pets.Location{Latitude: 4.711, Longitude: -74.0721},
+					},
+					DistanceKm: 1.5,
+				},
+			},
+		},
+	}
+
+	expectedEncodedResult := web.Result{
+		Success: true,
+		Errors:  nil,
+		Data: &web.SearchNearbyResult{
+			Pets: []web.NearbyPet{
+				{
+					Pet: web.Pet{
+						ID:       "82853922-4481-4a95-8691-30f36c61e45a",
+						Name:     "drila",
+						Location: &web.Location{Latitude: 4.711, Longitude: -74.0721},
+					},
+					DistanceKm: 1.5,
+				},
+			},
+			Sightings: []web.NearbySighting{},
+		},
+	}
+
+	encoder := web.NewSearchNearbyEncoder(newDummyLogger())
+
+	ctx := context.TODO()
+	recorder := httptest.NewRecorder()
+
+	// When
+	err := encoder.Encode(ctx, recorder, givenEndpointResult)
+
+	// Then
+	assert.NoError(t, err)
+	assert.Equal(t, recorder.Code, http.StatusOK)
+	assert.Equal(t, expectedEncodedResult, createWebResult(t, recorder.Body, &web.SearchNearbyResult{}))
+}
+
 func createWebResult(t *testing.T, body io.Reader, data any) web.Result {
 	t.Helper()
 
@@ -87,3 +174,64 @@ func createWebResult(t *testing.T, body io.Reader, data any) web.Result {
 
 	return result
 }
+
+func TestEncodeExportPets(t *testing.T) {
+	// Given
+	givenEndpointResult := pets.ExportPetsResult{
+		Pets: &petIteratorStub{
+			pets: []pets.Pet{
+				{ID: "1", Name: "drila"},
+				{ID: "2", Name: "michael"},
+			},
+		},
+		Format: "ndjson",
+	}
+	expectedBody := `{"id":"1","name":"drila"}` + "\n" + `{"id":"2","name":"michael"}` + "\n"
+
+	encoder := web.NewExportPetsEncoder(newDummyLogger())
+
+	ctx := context.TODO()
+	recorder := httptest.NewRecorder()
+
+	// When
+	err := encoder.Encode(ctx, recorder, givenEndpointResult)
+
+	// Then
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.Equal(t, "application/x-ndjson", recorder.Header().Get("Content-Type"))
+	assert.Equal(t, expectedBody, recorder.Body.String())
+	assert.True(t, recorder.Flushed)
+	assert.True(t, givenEndpointResult.Pets.(*petIteratorStub).closed)
+}
+
+type petIteratorStub struct {
+	pets    []pets.Pet
+	current pets.Pet
+	closed  bool
+}
+
+func (p *petIteratorStub) Next() bool {
+	if len(p.pets) == 0 {
+		return false
+	}
+
+	p.current = p.pets[0]
+	p.pets = p.pets[1:]
+
+	return true
+}
+
+func (p *petIteratorStub) Pet() pets.Pet {
+	return p.current
+}
+
+func (p *petIteratorStub) Err() error {
+	return nil
+}
+
+func (p *petIteratorStub) Close() error {
+	p.closed = true
+
+	return nil
+}
@@ -0,0 +1,238 @@
+package web
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/fernandoocampo/basic-micro/internal/adapter/events"
+	"github.com/fernandoocampo/basic-micro/internal/pets"
+)
+
+// EventsHandlerSetup contains the data to create the event stream handler.
+type EventsHandlerSetup struct {
+	Broker *events.Broker
+	// Heartbeat how often a comment is sent while there are no events, so
+	// proxies do not close idle streams. DefaultHeartbeat if it is not
+	// positive.
+	Heartbeat time.Duration
+	Logger    *slog.Logger
+}
+
+// EventsHandler streams the changes made to pets as server-sent events.
+// The pet_id and type query parameters select the events, and clients
+// that reconnect with Last-Event-ID receive the events they missed.
+type EventsHandler struct {
+	broker    *events.Broker
+	heartbeat time.Duration
+	logger    *slog.Logger
+}
+
+// DefaultHeartbeat is the EVENTS_HEARTBEAT_INTERVAL default.
+const DefaultHeartbeat = 15 * time.Second
+
+const (
+	eventStreamContentType = "text/event-stream"
+	lastEventIDHeader      = "Last-Event-ID"
+	// resetEvent tells clients that events they did not receive are lost,
+	// so they must reload the pets they show.
+	resetEvent = "reset"
+)
+
+var (
+	errInvalidLastEventID = ErrorResponse{Message: "Last-Event-ID must be the id of an event"}
+	errEventsUnavailable  = ErrorResponse{Message: "event stream is not available"}
+)
+
+func NewEventsHandler(setup EventsHandlerSetup) *EventsHandler {
+	newEventsHandler := EventsHandler{
+		broker:    setup.Broker,
+		heartbeat: setup.Heartbeat,
+		logger:    setup.Logger,
+	}
+
+	if newEventsHandler.heartbeat <= 0 {
+		newEventsHandler.heartbeat = DefaultHeartbeat
+	}
+
+	return &newEventsHandler
+}
+
+// ServeHTTP streams the events until the client goes away or the broker
+// is closed on shutdown.
+func (e *EventsHandler) ServeHTTP(rw http.ResponseWriter, req *http.Request) {
+	filter, err := eventsFilter(req)
+	if err != nil {
+		writeErrorResponse(rw, http.StatusBadRequest, ErrorResponse{Message: err.Error()})
+
+		return
+	}
+
+	var after uint64
+
+	if lastEventID := req.Header.Get(lastEventIDHeader); lastEventID != "" {
+		after, err = strconv.ParseUint(lastEventID, 10, 64)
+		if err != nil {
+			writeErrorResponse(rw, http.StatusBadRequest, errInvalidLastEventID)
+
+			return
+		}
+	}
+
+	subscription, err := e.broker.Subscribe(filter, after)
+	if err != nil {
+		writeErrorResponse(rw, http.StatusServiceUnavailable, errEventsUnavailable)
+
+		return
+	}
+	defer subscription.Close()
+
+	rw.Header().Set("Content-Type", eventStreamContentType)
+	rw.Header().Set("Cache-Control", "no-store")
+	// nginx buffers responses unless it is told not to.
+	rw.Header().Set("X-Accel-Buffering", "no")
+	rw.WriteHeader(http.StatusOK)
+
+	stream := eventStream{
+		writer:     rw,
+		controller: http.NewResponseController(rw),
+	}
+
+	if subscription.Missed() {
+		stream.write("", resetEvent, []byte("{}"))
+	}
+
+	for _, event := range subscription.Backlog() {
+		e.writeEvent(&stream, event)
+	}
+
+	err = stream.flush()
+	if err != nil {
+		e.logger.Error("flushing event stream", "error", err)
+
+		return
+	}
+
+	heartbeat := time.NewTicker(e.heartbeat)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-req.Context().Done():
+			return
+		case event, ok := <-subscription.Events():
+			if !ok {
+				return
+			}
+
+			e.writeEvent(&stream, event)
+		case <-heartbeat.C:
+			stream.comment("heartbeat")
+		}
+
+		err = stream.flush()
+		if err != nil {
+			e.logger.Debug("event stream was closed", "error", err)
+
+			return
+		}
+	}
+}
+
+func (e *EventsHandler) writeEvent(stream *eventStream, event events.Event) {
+	data, err := json.Marshal(event.Record)
+	if err != nil {
+		e.logger.Error("encoding event", slog.Uint64("id", event.ID), "error", err)
+
+		return
+	}
+
+	stream.write(strconv.FormatUint(event.ID, 10), string(event.Record.Action), data)
+}
+
+// eventsFilter reads the filter of the query parameters, pet_id and type
+// can be repeated or have comma separated values.
+func eventsFilter(req *http.Request) (events.Filter, error) {
+	var filter events.Filter
+
+	query := req.URL.Query()
+
+	for _, id := range queryValues(query["pet_id"]) {
+		filter.PetIDs = append(filter.PetIDs, pets.PetID(id))
+	}
+
+	for _, eventType := range queryValues(query["type"]) {
+		action := pets.AuditAction(eventType)
+
+		switch action {
+		case pets.AuditCreated, pets.AuditUpdated, pets.AuditDeleted, pets.AuditRestored:
+			filter.Actions = append(filter.Actions, action)
+		default:
+			return events.Filter{}, fmt.Errorf("unknown event type %q, it must be %s, %s, %s or %s",
+				eventType, pets.AuditCreated, pets.AuditUpdated, pets.AuditDeleted, pets.AuditRestored)
+		}
+	}
+
+	return filter, nil
+}
+
+// queryValues splits the comma separated values and skips empty ones.
+func queryValues(values []string) []string {
+	var result []string
+
+	for _, value := range values {
+		for _, item := range strings.Split(value, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				result = append(result, item)
+			}
+		}
+	}
+
+	return result
+}
+
+// eventStream writes server-sent events, the first write error is kept
+// and returned by flush.
+type eventStream struct {
+	writer     http.ResponseWriter
+	controller *http.ResponseController
+	err        error
+}
+
+func (s *eventStream) write(id, name string, data []byte) {
+	if id != "" {
+		s.printf("id: %s\n", id)
+	}
+
+	s.printf("event: %s\ndata: %s\n\n", name, data)
+}
+
+func (s *eventStream) comment(text string) {
+	s.printf(": %s\n\n", text)
+}
+
+func (s *eventStream) printf(format string, args ...any) {
+	if s.err != nil {
+		return
+	}
+
+	_, s.err = fmt.Fprintf(s.writer, format, args...)
+}
+
+func (s *eventStream) flush() error {
+	if s.err != nil {
+		return s.err
+	}
+
+	err := s.controller.Flush()
+	if err != nil && !errors.Is(err, http.ErrNotSupported) {
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,178 @@
+package web_test
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/fernandoocampo/basic-micro/internal/adapter/events"
+	"github.com/fernandoocampo/basic-micro/internal/adapter/web"
+	"github.com/fernandoocampo/basic-micro/internal/pets"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEventsAreStreamed(t *testing.T) {
+	// Given
+	broker := events.NewBroker(events.BrokerSetup{Logger: newDummyLogger()})
+	_, address := startEventsServer(t, broker, time.Minute)
+	stream := openEventStream(t, address+"/pets/events?pet_id=drila&type=deleted,restored", "")
+
+	// When
+	publish(broker,
+		pets.AuditRecord{PetID: "michi", Action: pets.AuditDeleted},
+		pets.AuditRecord{PetID: "drila", Action: pets.AuditUpdated},
+		pets.AuditRecord{PetID: "drila", Action: pets.AuditDeleted, Actor: "alice"},
+	)
+
+	// Then
+	frame := readFrame(t, stream)
+	assert.Contains(t, frame, "id: 3\nevent: deleted\n")
+	assert.Contains(t, frame, `"pet_id":"drila"`)
+	assert.Contains(t, frame, `"actor":"alice"`)
+}
+
+func TestEventsResumeFromLastEventID(t *testing.T) {
+	// Given
+	broker := events.NewBroker(events.BrokerSetup{LogSize: 2, Logger: newDummyLogger()})
+	_, address := startEventsServer(t, broker, time.Minute)
+	for _, action := range []pets.AuditAction{pets.AuditCreated, pets.AuditUpdated, pets.AuditDeleted} {
+		publish(broker, pets.AuditRecord{PetID: "drila", Action: action})
+	}
+
+	// When
+	resumed := openEventStream(t, address+"/pets/events", "2")
+	missed := openEventStream(t, address+"/pets/events", "99")
+
+	// Then
+	assert.Contains(t, readFrame(t, resumed), "id: 3\nevent: deleted\n")
+	assert.Equal(t, "event: reset\ndata: {}\n", readFrame(t, missed))
+	assert.Contains(t, readFrame(t, missed), "id: 2\nevent: updated\n")
+	assert.Contains(t, readFrame(t, missed), "id: 3\nevent: deleted\n")
+}
+
+func TestEventsHeartbeat(t *testing.T) {
+	// Given
+	broker := events.NewBroker(events.BrokerSetup{Logger: newDummyLogger()})
+	_, address := startEventsServer(t, broker, 10*time.Millisecond)
+
+	// When
+	stream := openEventStream(t, address+"/pets/events", "")
+
+	// Then
+	assert.Equal(t, ": heartbeat\n", readFrame(t, stream))
+}
+
+func TestEventStreamsEndOnShutdown(t *testing.T) {
+	// Given
+	broker := events.NewBroker(events.BrokerSetup{Logger: newDummyLogger()})
+	server, address := startEventsServer(t, broker, time.Minute)
+	stream := openEventStream(t, address+"/pets/events", "")
+
+	// When
+	err := server.Shutdown(context.Background())
+
+	// Then
+	assert.NoError(t, err)
+	_, err = stream.ReadString('\n')
+	assert.ErrorIs(t, err, io.EOF)
+}
+
+func TestInvalidEventRequests(t *testing.T) {
+	// Given
+	broker := events.NewBroker(events.BrokerSetup{Logger: newDummyLogger()})
+	_, address := startEventsServer(t, broker, time.Minute)
+	request, err := http.NewRequest(http.MethodGet, address+"/pets/events", nil)
+	require.NoError(t, err)
+	request.Header.Set("Last-Event-ID", "drila")
+
+	// When
+	unknownType, err := http.Get(address + "/pets/events?type=adopted")
+	require.NoError(t, err)
+	defer unknownType.Body.Close()
+	invalidID, err := http.DefaultClient.Do(request)
+	require.NoError(t, err)
+	defer invalidID.Body.Close()
+
+	// Then
+	assert.Equal(t, http.StatusBadRequest, unknownType.StatusCode)
+	body, err := io.ReadAll(unknownType.Body)
+	require.NoError(t, err)
+	assert.Contains(t, string(body), `unknown event type \"adopted\"`)
+	assert.Equal(t, http.StatusBadRequest, invalidID.StatusCode)
+}
+
+// startEventsServer serves the events of the broker on /pets/events, the
+// broker is closed when the server shuts down.
+func startEventsServer(t *testing.T, broker *events.Broker, heartbeat time.Duration) (*web.Server, string) {
+	t.Helper()
+
+	mux := http.NewServeMux()
+	mux.Handle("/pets/events", web.NewEventsHandler(web.EventsHandlerSetup{
+		Broker:    broker,
+		Heartbeat: heartbeat,
+		Logger:    newDummyLogger(),
+	}))
+
+	server, address := startServer(t, web.ServerSetup{
+		Handler:      mux,
+		DrainTimeout: 5 * time.Second,
+		OnShutdown:   broker.Close,
+		Logger:       newDummyLogger(),
+	})
+	t.Cleanup(func() {
+		server.Shutdown(context.Background())
+	})
+
+	return server, address
+}
+
+// openEventStream connects to the stream and waits until it is
+// subscribed, it returns the reader of the stream.
+func openEventStream(t *testing.T, url, lastEventID string) *bufio.Reader {
+	t.Helper()
+
+	request, err := http.NewRequest(http.MethodGet, url, nil)
+	require.NoError(t, err)
+	if lastEventID != "" {
+		request.Header.Set("Last-Event-ID", lastEventID)
+	}
+
+	response, err := http.DefaultClient.Do(request)
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		response.Body.Close()
+	})
+	require.Equal(t, http.StatusOK, response.StatusCode)
+	assert.Equal(t, "text/event-stream", response.Header.Get("Content-Type"))
+
+	return bufio.NewReader(response.Body)
+}
+
+// readFrame reads the lines of the next event or comment of the stream.
+func readFrame(t *testing.T, stream *bufio.Reader) string {
+	t.Helper()
+
+	var frame strings.Builder
+
+	for {
+		line, err := stream.ReadString('\n')
+		require.NoError(t, err)
+
+		if line == "\n" {
+			return frame.String()
+		}
+
+		frame.WriteString(line)
+	}
+}
+
+func publish(broker *events.Broker, records ...pets.AuditRecord) {
+	for _, record := range records {
+		broker.Publish(context.Background(), record)
+	}
+}
@@ -0,0 +1,46 @@
+package web
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+
+	"github.com/fernandoocampo/basic-micro/internal/adapter/health"
+)
+
+// HealthHandler answers a probe with the result of its checks, 200 if
+// all of them pass, otherwise 503.
+type HealthHandler struct {
+	registry *health.Registry
+	probe    health.Probe
+	logger   *slog.Logger
+}
+
+func NewHealthHandler(registry *health.Registry, probe health.Probe, logger *slog.Logger) *HealthHandler {
+	newHealthHandler := HealthHandler{
+		registry: registry,
+		probe:    probe,
+		logger:   logger,
+	}
+
+	return &newHealthHandler
+}
+
+func (h *HealthHandler) ServeHTTP(rw http.ResponseWriter, req *http.Request) {
+	report := h.registry.Run(req.Context(), h.probe)
+
+	statusCode := http.StatusOK
+	if report.Status == health.StatusFail {
+		statusCode = http.StatusServiceUnavailable
+	}
+
+	// probes must not be cached by proxies.
+	rw.Header().Set("Cache-Control", "no-store")
+	rw.Header().Set("Content-Type", jsonContentType)
+	rw.WriteHeader(statusCode)
+
+	err := json.NewEncoder(rw).Encode(report)
+	if err != nil {
+		h.logger.Error("encoding health report", slog.String("probe", string(h.probe)), "error", err)
+	}
+}
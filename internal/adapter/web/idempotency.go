@@ -0,0 +1,306 @@
+package web
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// idempotency headers.
+const (
+	IdempotencyKeyHeader      = "Idempotency-Key"
+	IdempotentReplayedHeader  = "Idempotent-Replayed"
+	idempotentReplayedMessage = "true"
+)
+
+// IdempotencyMaxBodySize is the size of the largest body of a request with
+// an idempotency key, the body is kept in memory to fingerprint it.
+const IdempotencyMaxBodySize = 1 << 20
+
+// IdempotentResponse is the response recorded for an idempotency key.
+type IdempotentResponse struct {
+	// Fingerprint identifies the request that produced the response.
+	Fingerprint string
+	StatusCode  int
+	Header      http.Header
+	Body        []byte
+	ExpiresAt   time.Time
+}
+
+// IdempotencyStore defines persistence behavior for recorded responses.
+type IdempotencyStore interface {
+	// Get returns the response recorded with the key, or nil if there is
+	// not any or it expired.
+	Get(ctx context.Context, key string) (*IdempotentResponse, error)
+	Save(ctx context.Context, key string, response IdempotentResponse) error
+}
+
+// IdempotencySetup contains idempotency middleware metadata.
+type IdempotencySetup struct {
+	Store IdempotencyStore
+	// TTL how long a response is replayed for the same key.
+	TTL    time.Duration
+	Logger *slog.Logger
+}
+
+// Idempotency replays the recorded response of unsafe requests that are
+// retried with the same Idempotency-Key header.
+type Idempotency struct {
+	store    IdempotencyStore
+	ttl      time.Duration
+	logger   *slog.Logger
+	inFlight sync.Map
+}
+
+// MemoryIdempotencyStore keeps recorded responses in memory.
+type MemoryIdempotencyStore struct {
+	mutex     sync.Mutex
+	responses map[string]IdempotentResponse
+}
+
+// responseRecorder captures the response written by the next handler.
+type responseRecorder struct {
+	http.ResponseWriter
+	statusCode int
+	body       bytes.Buffer
+}
+
+// perRequestHeaders are response headers that belong to the request that
+// set them, like its id, so they are neither recorded nor replayed.
+var perRequestHeaders = []string{RequestIDHeader}
+
+var (
+	errIdempotencyKeyReused     = ErrorResponse{Message: "idempotency key was already used with a different request"}
+	errIdempotencyKeyInProgress = ErrorResponse{Message: "a request with the same idempotency key is in progress"}
+	errIdempotencyUnavailable   = ErrorResponse{Message: "unable to check idempotency key"}
+	errIdempotentBodyTooLarge   = ErrorResponse{Message: "request body is too large"}
+)
+
+func NewIdempotency(setup IdempotencySetup) *Idempotency {
+	newIdempotency := Idempotency{
+		store:  setup.Store,
+		ttl:    setup.TTL,
+		logger: setup.Logger,
+	}
+
+	return &newIdempotency
+}
+
+func NewMemoryIdempotencyStore() *MemoryIdempotencyStore {
+	newStore := MemoryIdempotencyStore{
+		responses: make(map[string]IdempotentResponse),
+	}
+
+	return &newStore
+}
+
+// Wrap returns a handler that applies idempotency keys to the unsafe
+// methods handled by next.
+func (i *Idempotency) Wrap(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		idempotencyKey := req.Header.Get(IdempotencyKeyHeader)
+		if idempotencyKey == "" || !isUnsafeMethod(req.Method) {
+			next.ServeHTTP(rw, req)
+
+			return
+		}
+
+		ctx := req.Context()
+
+		body, err := io.ReadAll(http.MaxBytesReader(rw, req.Body, IdempotencyMaxBodySize))
+		if err != nil {
+			var maxBytesError *http.MaxBytesError
+			if errors.As(err, &maxBytesError) {
+				writeErrorResponse(rw, http.StatusRequestEntityTooLarge, errIdempotentBodyTooLarge)
+
+				return
+			}
+
+			writeErrorResponse(rw, http.StatusBadRequest, ErrorResponse{Message: err.Error()})
+
+			return
+		}
+		req.Body.Close()
+		req.Body = io.NopCloser(bytes.NewReader(body))
+
+		key := req.Method + " " + req.URL.Path + " " + idempotencyKey
+		fingerprint := requestFingerprint(req, body)
+
+		if i.replayed(ctx, rw, key, fingerprint) {
+			return
+		}
+
+		if _, loaded := i.inFlight.LoadOrStore(key, struct{}{}); loaded {
+			writeErrorResponse(rw, http.StatusConflict, errIdempotencyKeyInProgress)
+
+			return
+		}
+		defer i.inFlight.Delete(key)
+
+		// the request with the slot could have saved its response and
+		// released the slot after the first check.
+		if i.replayed(ctx, rw, key, fingerprint) {
+			return
+		}
+
+		recorder := newResponseRecorder(rw)
+		next.ServeHTTP(recorder, req)
+
+		if recorder.statusCode >= http.StatusInternalServerError {
+			return
+		}
+
+		response := IdempotentResponse{
+			Fingerprint: fingerprint,
+			StatusCode:  recorder.statusCode,
+			Header:      recordedHeader(rw.Header()),
+			Body:        recorder.body.Bytes(),
+			ExpiresAt:   time.Now().Add(i.ttl),
+		}
+
+		err = i.store.Save(ctx, key, response)
+		if err != nil {
+			i.logger.Error("saving idempotent response", "error", err)
+		}
+	})
+}
+
+// replayed writes the response recorded with the key, it says if the
+// request was answered.
+func (i *Idempotency) replayed(ctx context.Context, rw http.ResponseWriter, key, fingerprint string) bool {
+	recorded, err := i.store.Get(ctx, key)
+	if err != nil {
+		i.logger.Error("querying idempotency key", "error", err)
+		writeErrorResponse(rw, http.StatusInternalServerError, errIdempotencyUnavailable)
+
+		return true
+	}
+
+	if recorded == nil {
+		return false
+	}
+
+	i.replay(rw, *recorded, fingerprint)
+
+	return true
+}
+
+func (i *Idempotency) replay(rw http.ResponseWriter, recorded IdempotentResponse, fingerprint string) {
+	if recorded.Fingerprint != fingerprint {
+		writeErrorResponse(rw, http.StatusUnprocessableEntity, errIdempotencyKeyReused)
+
+		return
+	}
+
+	for name, values := range recordedHeader(recorded.Header) {
+		rw.Header()[name] = values
+	}
+
+	rw.Header().Set(IdempotentReplayedHeader, idempotentReplayedMessage)
+	rw.WriteHeader(recorded.StatusCode)
+	rw.Write(recorded.Body)
+}
+
+// recordedHeader returns a copy of header without the per request headers.
+func recordedHeader(header http.Header) http.Header {
+	recorded := header.Clone()
+	for _, name := range perRequestHeaders {
+		recorded.Del(name)
+	}
+
+	return recorded
+}
+
+func (m *MemoryIdempotencyStore) Get(ctx context.Context, key string) (*IdempotentResponse, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	response, ok := m.responses[key]
+	if !ok {
+		return nil, nil
+	}
+
+	if time.Now().After(response.ExpiresAt) {
+		delete(m.responses, key)
+
+		return nil, nil
+	}
+
+	return &response, nil
+}
+
+// Save records the response and drops the expired ones.
+func (m *MemoryIdempotencyStore) Save(ctx context.Context, key string, response IdempotentResponse) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	now := time.Now()
+	for storedKey, storedResponse := range m.responses {
+		if now.After(storedResponse.ExpiresAt) {
+			delete(m.responses, storedKey)
+		}
+	}
+
+	m.responses[key] = response
+
+	return nil
+}
+
+func newResponseRecorder(rw http.ResponseWriter) *responseRecorder {
+	return &responseRecorder{
+		ResponseWriter: rw,
+		statusCode:     http.StatusOK,
+	}
+}
+
+func (r *responseRecorder) WriteHeader(statusCode int) {
+	r.statusCode = statusCode
+	r.ResponseWriter.WriteHeader(statusCode)
+}
+
+func (r *responseRecorder) Write(content []byte) (int, error) {
+	r.body.Write(content)
+
+	return r.ResponseWriter.Write(content)
+}
+
+// requestFingerprint identifies a request by its method, path, media types
+// and body, so a replay is never encoded in another format.
+func requestFingerprint(req *http.Request, body []byte) string {
+	hash := sha256.New()
+	hash.Write([]byte(req.Method))
+	hash.Write([]byte(req.URL.Path))
+	hash.Write([]byte(req.Header.Get("Accept")))
+	hash.Write([]byte(req.Header.Get("Content-Type")))
+	hash.Write(body)
+
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+func isUnsafeMethod(method string) bool {
+	switch method {
+	case http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete:
+		return true
+	}
+
+	return false
+}
+
+func writeErrorResponse(w http.ResponseWriter, statusCode int, errorResponse ErrorResponse) {
+	content, err := json.Marshal(errorResponse)
+	if err != nil {
+		content = defaultErrorResponse
+	}
+
+	w.Header().Set("Content-Type", jsonContentType)
+	w.WriteHeader(statusCode)
+	w.Write(content)
+}
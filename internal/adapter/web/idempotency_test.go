@@ -0,0 +1,160 @@
+package web_test
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/fernandoocampo/basic-micro/internal/adapter/web"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIdempotencyReplaysResponse(t *testing.T) {
+	// Given
+	var calls int
+	handler := newIdempotentHandler(&calls)
+
+	// When
+	first := doIdempotentRequest(handler, "key-1", `{"name":"drila"}`)
+	second := doIdempotentRequest(handler, "key-1", `{"name":"drila"}`)
+
+	// Then
+	assert.Equal(t, 1, calls)
+	assert.Equal(t, http.StatusOK, second.Code)
+	assert.Equal(t, first.Body.String(), second.Body.String())
+	assert.Equal(t, "true", second.Header().Get(web.IdempotentReplayedHeader))
+}
+
+func TestIdempotencyReplayKeepsTheRequestID(t *testing.T) {
+	// Given
+	var calls int
+	handler := web.RequestID(newIdempotentHandler(&calls))
+
+	// When
+	first := doIdempotentRequestWithID(handler, "key-1", `{"name":"drila"}`, "request-1")
+	second := doIdempotentRequestWithID(handler, "key-1", `{"name":"drila"}`, "request-2")
+
+	// Then
+	assert.Equal(t, 1, calls)
+	assert.Equal(t, "request-1", first.Header().Get(web.RequestIDHeader))
+	assert.Equal(t, "true", second.Header().Get(web.IdempotentReplayedHeader))
+	assert.Equal(t, "request-2", second.Header().Get(web.RequestIDHeader))
+}
+
+func TestIdempotencyRejectsDifferentPayload(t *testing.T) {
+	// Given
+	var calls int
+	handler := newIdempotentHandler(&calls)
+
+	// When
+	_ = doIdempotentRequest(handler, "key-1", `{"name":"drila"}`)
+	second := doIdempotentRequest(handler, "key-1", `{"name":"michael"}`)
+
+	// Then
+	assert.Equal(t, 1, calls)
+	assert.Equal(t, http.StatusUnprocessableEntity, second.Code)
+}
+
+func TestIdempotencyChecksTheStoreAgainWithTheSlot(t *testing.T) {
+	// Given
+	var calls int
+	store := staleIdempotencyStore{MemoryIdempotencyStore: web.NewMemoryIdempotencyStore()}
+	handler := newIdempotentHandlerWithStore(&calls, &store)
+	first := doIdempotentRequest(handler, "key-1", `{"name":"drila"}`)
+	// the retry misses the response saved by the first request, as if it
+	// was checked just before the first request saved it.
+	store.misses = 1
+
+	// When
+	second := doIdempotentRequest(handler, "key-1", `{"name":"drila"}`)
+
+	// Then
+	assert.Equal(t, 1, calls)
+	assert.Equal(t, first.Body.String(), second.Body.String())
+	assert.Equal(t, "true", second.Header().Get(web.IdempotentReplayedHeader))
+}
+
+func TestIdempotencyRejectsLargeBody(t *testing.T) {
+	// Given
+	var calls int
+	handler := newIdempotentHandler(&calls)
+	body := `{"name":"` + strings.Repeat("a", web.IdempotencyMaxBodySize) + `"}`
+
+	// When
+	response := doIdempotentRequest(handler, "key-1", body)
+
+	// Then
+	assert.Equal(t, 0, calls)
+	assert.Equal(t, http.StatusRequestEntityTooLarge, response.Code)
+}
+
+func TestIdempotencyWithoutKey(t *testing.T) {
+	// Given
+	var calls int
+	handler := newIdempotentHandler(&calls)
+
+	// When
+	_ = doIdempotentRequest(handler, "", `{"name":"drila"}`)
+	_ = doIdempotentRequest(handler, "", `{"name":"drila"}`)
+
+	// Then
+	assert.Equal(t, 2, calls)
+}
+
+// staleIdempotencyStore misses the recorded responses the given number of
+// times.
+type staleIdempotencyStore struct {
+	*web.MemoryIdempotencyStore
+	misses int
+}
+
+func (s *staleIdempotencyStore) Get(ctx context.Context, key string) (*web.IdempotentResponse, error) {
+	if s.misses > 0 {
+		s.misses--
+
+		return nil, nil
+	}
+
+	return s.MemoryIdempotencyStore.Get(ctx, key)
+}
+
+func newIdempotentHandler(calls *int) http.Handler {
+	return newIdempotentHandlerWithStore(calls, web.NewMemoryIdempotencyStore())
+}
+
+func newIdempotentHandlerWithStore(calls *int, store web.IdempotencyStore) http.Handler {
+	idempotency := web.NewIdempotency(web.IdempotencySetup{
+		Store:  store,
+		TTL:    time.Minute,
+		Logger: newDummyLogger(),
+	})
+
+	return idempotency.Wrap(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		*calls++
+		fmt.Fprintf(w, `{"success":true,"data":"pet-%d"}`, *calls)
+	}))
+}
+
+func doIdempotentRequest(handler http.Handler, key, body string) *httptest.ResponseRecorder {
+	return doIdempotentRequestWithID(handler, key, body, "")
+}
+
+func doIdempotentRequestWithID(handler http.Handler, key, body, requestID string) *httptest.ResponseRecorder {
+	request := httptest.NewRequest(http.MethodPost, "http://anyhost/pets", bytes.NewBufferString(body))
+	if key != "" {
+		request.Header.Set(web.IdempotencyKeyHeader, key)
+	}
+	if requestID != "" {
+		request.Header.Set(web.RequestIDHeader, requestID)
+	}
+
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, request)
+
+	return recorder
+}
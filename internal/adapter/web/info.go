@@ -0,0 +1,90 @@
+package web
+
+import (
+	"log/slog"
+	"net/http"
+	"runtime"
+	"sync"
+	"time"
+)
+
+// ServerVersionHeader carries the version of the service in every response.
+const ServerVersionHeader = "Server-Version"
+
+// InfoSetup contains service info metadata.
+type InfoSetup struct {
+	Version     string
+	CommitHash  string
+	BuildDate   string
+	StartedAt   time.Time
+	StoreDriver string
+	// Configuration effective configuration, secrets must be already redacted.
+	Configuration []ConfigValue
+	Logger        *slog.Logger
+}
+
+// InfoHandler answers with the build and runtime data of the service.
+type InfoHandler struct {
+	mutex  sync.RWMutex
+	info   ServiceInfo
+	logger *slog.Logger
+}
+
+func NewInfoHandler(setup InfoSetup) *InfoHandler {
+	newInfoHandler := InfoHandler{
+		info: ServiceInfo{
+			Version:       setup.Version,
+			CommitHash:    setup.CommitHash,
+			BuildDate:     setup.BuildDate,
+			GoVersion:     runtime.Version(),
+			StartedAt:     setup.StartedAt.UTC(),
+			StoreDriver:   setup.StoreDriver,
+			Configuration: setup.Configuration,
+		},
+		logger: setup.Logger,
+	}
+
+	return &newInfoHandler
+}
+
+// SetConfiguration changes the configuration shown, secrets must be
+// already redacted.
+func (i *InfoHandler) SetConfiguration(configuration []ConfigValue) {
+	i.mutex.Lock()
+	defer i.mutex.Unlock()
+
+	i.info.Configuration = configuration
+}
+
+func (i *InfoHandler) ServeHTTP(rw http.ResponseWriter, req *http.Request) {
+	i.mutex.RLock()
+	info := i.info
+	i.mutex.RUnlock()
+
+	info.Uptime = time.Since(info.StartedAt).Round(time.Second).String()
+
+	message := Result{
+		Success: true,
+		Data:    info,
+	}
+
+	err := encodeResult(req.Context(), rw, message)
+	if err != nil {
+		i.logger.Error("encoding service info", "error", err)
+
+		writeErrorResponse(rw, http.StatusInternalServerError, ErrorResponse{
+			Message: err.Error(),
+		})
+	}
+}
+
+// ServerVersion adds the Server-Version header to every response.
+func ServerVersion(version string) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+			rw.Header().Set(ServerVersionHeader, version)
+
+			next.ServeHTTP(rw, req)
+		})
+	}
+}
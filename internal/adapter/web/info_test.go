@@ -0,0 +1,50 @@
+package web_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/fernandoocampo/basic-micro/internal/adapter/web"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInfoHandler(t *testing.T) {
+	// Given
+	startedAt := time.Now().Add(-time.Hour)
+	handler := web.ServerVersion("0.8.0")(web.NewInfoHandler(web.InfoSetup{
+		Version:     "0.8.0",
+		CommitHash:  "97af904",
+		BuildDate:   "2024-04-09",
+		StartedAt:   startedAt,
+		StoreDriver: "memory",
+		Configuration: []web.ConfigValue{
+			{Name: "DB_PASSWORD", Value: "[REDACTED]"},
+		},
+		Logger: newDummyLogger(),
+	}))
+	recorder := httptest.NewRecorder()
+
+	// When
+	handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/info", nil))
+
+	// Then
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.Equal(t, "0.8.0", recorder.Header().Get(web.ServerVersionHeader))
+
+	var got struct {
+		Success bool            `json:"success"`
+		Data    web.ServiceInfo `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &got))
+	assert.True(t, got.Success)
+	assert.Equal(t, "97af904", got.Data.CommitHash)
+	assert.Equal(t, runtime.Version(), got.Data.GoVersion)
+	assert.Equal(t, "memory", got.Data.StoreDriver)
+	assert.Equal(t, "1h0m0s", got.Data.Uptime)
+	assert.Equal(t, []web.ConfigValue{{Name: "DB_PASSWORD", Value: "[REDACTED]"}}, got.Data.Configuration)
+}
@@ -0,0 +1,201 @@
+package web
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"runtime/debug"
+	"time"
+
+	"github.com/fernandoocampo/basic-micro/internal/pets"
+	"github.com/google/uuid"
+)
+
+// Middleware decorates an http.Handler with transport concerns like logging
+// or authentication.
+type Middleware func(next http.Handler) http.Handler
+
+// EndpointMiddleware decorates an endpoint with business concerns, it is
+// applied after the request was decoded and before the response is encoded.
+type EndpointMiddleware[Req, Resp any] func(next EndpointFunc[Req, Resp]) EndpointFunc[Req, Resp]
+
+// statusWriter records the status code and the size of the response.
+type statusWriter struct {
+	http.ResponseWriter
+	statusCode int
+	size       int
+}
+
+// Chain composes the given middlewares, the first one is the outermost,
+// so it sees the request first and the response last.
+func Chain(middlewares ...Middleware) Middleware {
+	return func(next http.Handler) http.Handler {
+		for i := len(middlewares) - 1; i >= 0; i-- {
+			next = middlewares[i](next)
+		}
+
+		return next
+	}
+}
+
+// ChainEndpoint composes the given endpoint middlewares, the first one is
+// the outermost.
+func ChainEndpoint[Req, Resp any](middlewares ...EndpointMiddleware[Req, Resp]) EndpointMiddleware[Req, Resp] {
+	return func(next EndpointFunc[Req, Resp]) EndpointFunc[Req, Resp] {
+		for i := len(middlewares) - 1; i >= 0; i-- {
+			next = middlewares[i](next)
+		}
+
+		return next
+	}
+}
+
+// RequestID makes sure every request has an X-Request-ID header, it creates
+// one if the client did not send it and returns it in the response.
+func RequestID(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		requestID := req.Header.Get(RequestIDHeader)
+		if requestID == "" {
+			requestID = uuid.New().String()
+			req.Header.Set(RequestIDHeader, requestID)
+		}
+
+		rw.Header().Set(RequestIDHeader, requestID)
+
+		ctx := pets.WithRequestID(req.Context(), requestID)
+
+		next.ServeHTTP(rw, req.WithContext(ctx))
+	})
+}
+
+// AccessLog logs every request with its status, size and duration.
+func AccessLog(logger *slog.Logger) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+			start := time.Now()
+			writer := newStatusWriter(rw)
+
+			next.ServeHTTP(writer, req)
+
+			logger.Info("request served",
+				slog.String("method", req.Method),
+				slog.String("path", req.URL.Path),
+				slog.Int("status", writer.status()),
+				slog.Int("size", writer.size),
+				slog.Duration("duration", time.Since(start)),
+				slog.String("request_id", req.Header.Get(RequestIDHeader)),
+			)
+		})
+	}
+}
+
+// Recovery answers 500 if the next handler panics, so a single request
+// does not end the server.
+func Recovery(logger *slog.Logger) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+			writer := newStatusWriter(rw)
+
+			defer func() {
+				recovered := recover()
+				if recovered == nil {
+					return
+				}
+
+				// http.ErrAbortHandler is the way to abort a response on purpose.
+				if err, ok := recovered.(error); ok && errors.Is(err, http.ErrAbortHandler) {
+					panic(recovered)
+				}
+
+				logger.Error("request panicked",
+					slog.String("method", req.Method),
+					slog.String("path", req.URL.Path),
+					slog.String("panic", fmt.Sprint(recovered)),
+					slog.String("request_id", req.Header.Get(RequestIDHeader)),
+					slog.String("stack", string(debug.Stack())),
+				)
+
+				if writer.statusCode != 0 {
+					return
+				}
+
+				writeErrorResponse(writer, http.StatusInternalServerError, ErrorResponse{
+					Message: "unable to process request",
+				})
+			}()
+
+			next.ServeHTTP(writer, req)
+		})
+	}
+}
+
+// Use adds endpoint middlewares to the handler, the first one is the
+// outermost.
+func (h *Handler[Req, Resp]) Use(middlewares ...EndpointMiddleware[Req, Resp]) *Handler[Req, Resp] {
+	h.middlewares = append(h.middlewares, middlewares...)
+
+	return h
+}
+
+func newStatusWriter(rw http.ResponseWriter) *statusWriter {
+	return &statusWriter{
+		ResponseWriter: rw,
+	}
+}
+
+func (s *statusWriter) WriteHeader(statusCode int) {
+	if s.statusCode == 0 {
+		s.statusCode = statusCode
+	}
+
+	s.ResponseWriter.WriteHeader(statusCode)
+}
+
+func (s *statusWriter) Write(content []byte) (int, error) {
+	if s.statusCode == 0 {
+		s.statusCode = http.StatusOK
+	}
+
+	size, err := s.ResponseWriter.Write(content)
+	s.size += size
+
+	return size, err
+}
+
+// Flush keeps streamed responses working, like the pets export.
+func (s *statusWriter) Flush() {
+	if s.statusCode == 0 {
+		s.statusCode = http.StatusOK
+	}
+
+	if flusher, ok := s.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// Hijack keeps connection upgrades working.
+func (s *statusWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	hijacker, ok := s.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, errors.New("response writer does not support hijacking")
+	}
+
+	return hijacker.Hijack()
+}
+
+// Unwrap lets http.ResponseController reach the original writer.
+func (s *statusWriter) Unwrap() http.ResponseWriter {
+	return s.ResponseWriter
+}
+
+// status returns the status code sent, 200 if the handler did not write anything.
+func (s *statusWriter) status() int {
+	if s.statusCode == 0 {
+		return http.StatusOK
+	}
+
+	return s.statusCode
+}
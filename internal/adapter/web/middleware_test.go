@@ -0,0 +1,143 @@
+package web_test
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/fernandoocampo/basic-micro/internal/adapter/web"
+	"github.com/fernandoocampo/basic-micro/internal/pets"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestChain(t *testing.T) {
+	// Given
+	var calls []string
+	trace := func(name string) web.Middleware {
+		return func(next http.Handler) http.Handler {
+			return http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+				calls = append(calls, name)
+				next.ServeHTTP(rw, req)
+			})
+		}
+	}
+	handler := web.Chain(trace("first"), trace("second"))(
+		http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+			calls = append(calls, "handler")
+		}),
+	)
+
+	// When
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/pets", nil))
+
+	// Then
+	assert.Equal(t, []string{"first", "second", "handler"}, calls)
+}
+
+func TestRequestID(t *testing.T) {
+	cases := map[string]struct {
+		requestID string
+	}{
+		"given by client": {requestID: "8a1f"},
+		"not given":       {requestID: ""},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			// Given
+			var contextRequestID string
+			handler := web.RequestID(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+				contextRequestID = pets.RequestIDFromContext(req.Context())
+			}))
+			request := httptest.NewRequest(http.MethodGet, "/pets", nil)
+			request.Header.Set(web.RequestIDHeader, tc.requestID)
+			recorder := httptest.NewRecorder()
+
+			// When
+			handler.ServeHTTP(recorder, request)
+
+			// Then
+			got := recorder.Header().Get(web.RequestIDHeader)
+			assert.NotEmpty(t, got)
+			assert.Equal(t, got, contextRequestID)
+			if tc.requestID != "" {
+				assert.Equal(t, tc.requestID, got)
+			}
+		})
+	}
+}
+
+func TestAccessLog(t *testing.T) {
+	// Given
+	var output bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&output, nil))
+	handler := web.AccessLog(logger)(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		rw.WriteHeader(http.StatusCreated)
+		rw.Write([]byte("drila"))
+	}))
+	request := httptest.NewRequest(http.MethodPost, "/pets", nil)
+	request.Header.Set(web.RequestIDHeader, "8a1f")
+
+	// When
+	handler.ServeHTTP(httptest.NewRecorder(), request)
+
+	// Then
+	got := output.String()
+	assert.Contains(t, got, "method=POST")
+	assert.Contains(t, got, "path=/pets")
+	assert.Contains(t, got, "status=201")
+	assert.Contains(t, got, "size=5")
+	assert.Contains(t, got, "request_id=8a1f")
+}
+
+func TestRecovery(t *testing.T) {
+	// Given
+	var output bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&output, nil))
+	handler := web.Recovery(logger)(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		panic("drila escaped")
+	}))
+	recorder := httptest.NewRecorder()
+
+	// When
+	handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/pets", nil))
+
+	// Then
+	assert.Equal(t, http.StatusInternalServerError, recorder.Code)
+	assert.JSONEq(t, `{"message":"unable to process request"}`, recorder.Body.String())
+	assert.True(t, strings.Contains(output.String(), "drila escaped"))
+}
+
+func TestHandlerUse(t *testing.T) {
+	// Given
+	decoder := func(ctx context.Context, r *http.Request) (string, error) {
+		return r.URL.Query().Get("name"), nil
+	}
+	endpoint := func(ctx context.Context, name string) (string, error) {
+		return name, nil
+	}
+	encoder := func(ctx context.Context, w http.ResponseWriter, name string) error {
+		_, err := w.Write([]byte(name))
+
+		return err
+	}
+	suffix := func(value string) web.EndpointMiddleware[string, string] {
+		return func(next web.EndpointFunc[string, string]) web.EndpointFunc[string, string] {
+			return func(ctx context.Context, name string) (string, error) {
+				return next(ctx, name+value)
+			}
+		}
+	}
+	handler := web.NewTypedHandler(decoder, endpoint, encoder).Use(suffix("-first"), suffix("-second"))
+	recorder := httptest.NewRecorder()
+
+	// When
+	handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/pets?name=drila", nil))
+
+	// Then
+	assert.Equal(t, "drila-first-second", recorder.Body.String())
+}
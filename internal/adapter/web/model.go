@@ -1,48 +1,230 @@
 package web
 
-import "github.com/fernandoocampo/basic-micro/internal/pets"
+import (
+	"encoding/xml"
+	"time"
+
+	"github.com/fernandoocampo/basic-micro/internal/pets"
+)
 
 // Result standard result for the service
 type Result struct {
-	Success bool        `json:"success"`
-	Data    interface{} `json:"data"`
-	Errors  []string    `json:"errors"`
+	XMLName xml.Name    `json:"-" xml:"result"`
+	Success bool        `json:"success" xml:"success"`
+	Data    interface{} `json:"data" xml:"data"`
+	Errors  []string    `json:"errors" xml:"errors"`
+	// errKind says why the request failed, it chooses the response status.
+	errKind pets.ErrorKind
+}
+
+// Location contains geographic coordinates in decimal degrees.
+type Location struct {
+	Latitude  float64 `json:"latitude" xml:"latitude"`
+	Longitude float64 `json:"longitude" xml:"longitude"`
 }
 
 // Pet contains pet data.
 type Pet struct {
-	ID string `json:"id"`
+	ID string `json:"id" xml:"id"`
 	// Name pet's name.
-	Name string `json:"name"`
+	Name string `json:"name" xml:"name"`
+	// Location last known location of the pet.
+	Location *Location `json:"location,omitempty" xml:"location,omitempty"`
+	// DeletedAt when the pet was deleted, only set for deleted pets.
+	DeletedAt *time.Time `json:"deleted_at,omitempty" xml:"deleted_at,omitempty"`
 }
 
 // NewPet contains the expected data for a new pet.
 type NewPet struct {
-	Name string `json:"name"`
+	Name     string    `json:"name" xml:"name"`
+	Location *Location `json:"location,omitempty" xml:"location,omitempty"`
 }
 
 // UpdatePet contains the expected data to update an pet.
 type UpdatePet struct {
-	ID   string `json:"id"`
-	Name string `json:"name"`
+	ID       string    `json:"id" xml:"id"`
+	Name     string    `json:"name" xml:"name"`
+	Location *Location `json:"location,omitempty" xml:"location,omitempty"`
+}
+
+// NewSighting contains the expected data to report a pet sighting.
+type NewSighting struct {
+	Location Location `json:"location" xml:"location"`
+	Notes    string   `json:"notes" xml:"notes"`
+	// SeenAt when the pet was seen, if it is empty the server time is used.
+	SeenAt time.Time `json:"seen_at" xml:"seen_at"`
+}
+
+// Sighting contains data of a pet seen at some location.
+type Sighting struct {
+	ID       string    `json:"id" xml:"id"`
+	PetID    string    `json:"pet_id" xml:"pet_id"`
+	Location Location  `json:"location" xml:"location"`
+	Notes    string    `json:"notes" xml:"notes"`
+	SeenAt   time.Time `json:"seen_at" xml:"seen_at"`
+}
+
+// NearbyPet contains a pet and its distance to the searched location.
+type NearbyPet struct {
+	Pet        Pet     `json:"pet" xml:"pet"`
+	DistanceKm float64 `json:"distance_km" xml:"distance_km"`
+}
+
+// NearbySighting contains a sighting and its distance to the searched location.
+type NearbySighting struct {
+	Sighting   Sighting `json:"sighting" xml:"sighting"`
+	DistanceKm float64  `json:"distance_km" xml:"distance_km"`
+}
+
+// SearchNearbyResult contains pets and sightings ordered by distance.
+type SearchNearbyResult struct {
+	Pets      []NearbyPet      `json:"pets" xml:"pets"`
+	Sightings []NearbySighting `json:"sightings" xml:"sightings"`
+}
+
+// BatchPet contains the pet data of a batch operation, the id is required
+// to update and delete pets.
+type BatchPet struct {
+	ID       string    `json:"id" xml:"id"`
+	Name     string    `json:"name" xml:"name"`
+	Location *Location `json:"location,omitempty" xml:"location,omitempty"`
+}
+
+// BatchOperation contains a create, update or delete operation.
+type BatchOperation struct {
+	// Op is create, update or delete.
+	Op  string    `json:"op" xml:"op"`
+	Pet *BatchPet `json:"pet,omitempty" xml:"pet,omitempty"`
+	// ID pet to delete, it can be given in the pet too.
+	ID string `json:"id,omitempty" xml:"id,omitempty"`
+}
+
+// BatchRequest contains the expected data to apply a batch of operations.
+type BatchRequest struct {
+	// Atomic says if all operations must be applied or none of them.
+	Atomic     bool             `json:"atomic" xml:"atomic"`
+	Operations []BatchOperation `json:"operations" xml:"operations"`
+}
+
+// BatchItemResult contains the result of a batch operation.
+type BatchItemResult struct {
+	Index  int    `json:"index" xml:"index"`
+	Op     string `json:"op" xml:"op"`
+	ID     string `json:"id,omitempty" xml:"id,omitempty"`
+	Status string `json:"status" xml:"status"`
+	Error  string `json:"error,omitempty" xml:"error,omitempty"`
+}
+
+// BatchResult contains the result of every operation in request order.
+type BatchResult struct {
+	Items []BatchItemResult `json:"items" xml:"items"`
+}
+
+// RejectedRow contains a row that was not imported and the reason.
+type RejectedRow struct {
+	Line   int    `json:"line" xml:"line"`
+	Reason string `json:"reason" xml:"reason"`
+}
+
+// ImportReport contains the result of an import.
+type ImportReport struct {
+	Total    int           `json:"total" xml:"total"`
+	Imported int           `json:"imported" xml:"imported"`
+	Rejected []RejectedRow `json:"rejected" xml:"rejected"`
+}
+
+// ImportJob contains the status of an import job.
+type ImportJob struct {
+	ID     string       `json:"id" xml:"id"`
+	Status string       `json:"status" xml:"status"`
+	Report ImportReport `json:"report" xml:"report"`
+	// Error why the import failed, only set for failed jobs.
+	Error      string     `json:"error,omitempty" xml:"error,omitempty"`
+	StartedAt  time.Time  `json:"started_at" xml:"started_at"`
+	FinishedAt *time.Time `json:"finished_at,omitempty" xml:"finished_at,omitempty"`
+}
+
+// ConfigValue contains an effective configuration parameter.
+type ConfigValue struct {
+	Name  string `json:"name" xml:"name"`
+	Value string `json:"value" xml:"value"`
+}
+
+// ServiceInfo contains build and runtime data of the service.
+type ServiceInfo struct {
+	Version     string    `json:"version" xml:"version"`
+	CommitHash  string    `json:"commit_hash" xml:"commit_hash"`
+	BuildDate   string    `json:"build_date" xml:"build_date"`
+	GoVersion   string    `json:"go_version" xml:"go_version"`
+	StartedAt   time.Time `json:"started_at" xml:"started_at"`
+	Uptime      string    `json:"uptime" xml:"uptime"`
+	StoreDriver string    `json:"store_driver" xml:"store_driver"`
+	// Configuration effective configuration, secrets are redacted.
+	Configuration []ConfigValue `json:"configuration" xml:"configuration>value"`
+}
+
+// FieldChange contains the value of a pet field before and after a change.
+type FieldChange struct {
+	Field  string `json:"field" xml:"field"`
+	Before string `json:"before" xml:"before"`
+	After  string `json:"after" xml:"after"`
+}
+
+// AuditRecord contains a change made to a pet.
+type AuditRecord struct {
+	ID            string        `json:"id" xml:"id"`
+	PetID         string        `json:"pet_id" xml:"pet_id"`
+	Action        string        `json:"action" xml:"action"`
+	Actor         string        `json:"actor" xml:"actor"`
+	AssertedActor string        `json:"asserted_actor,omitempty" xml:"asserted_actor,omitempty"`
+	RequestID     string        `json:"request_id,omitempty" xml:"request_id,omitempty"`
+	Timestamp     time.Time     `json:"timestamp" xml:"timestamp"`
+	Changes       []FieldChange `json:"changes" xml:"changes"`
+}
+
+// PetHistoryResult contains the change history of a pet.
+type PetHistoryResult struct {
+	Records  []AuditRecord `json:"records" xml:"records"`
+	Total    int           `json:"total" xml:"total"`
+	Page     uint8         `json:"page" xml:"page"`
+	PageSize uint8         `json:"page_size" xml:"page_size"`
+}
+
+// PetHistoryFilter contains filters to query the history of a pet.
+type PetHistoryFilter struct {
+	PetID string
+	// Page page to query
+	Page uint8
+	// rows per page
+	PageSize uint8
+}
+
+// SearchNearbyFilter contains filters to search pets around a location.
+type SearchNearbyFilter struct {
+	Latitude  float64
+	Longitude float64
+	// Radius in kilometers
+	Radius float64
+	// Limit maximum number of results
+	Limit uint8
 }
 
 // CreatePetResponse standard response for create Pet
 type CreatePetResponse struct {
-	ID  string `json:"id"`
-	Err string `json:"err,omitempty"`
+	ID  string `json:"id" xml:"id"`
+	Err string `json:"err,omitempty" xml:"err,omitempty"`
 }
 
 // GetPetWithIDResponse standard response for get a Pet with an ID.
 type GetPetWithIDResponse struct {
-	Pet *Pet   `json:"pet"`
-	Err string `json:"err,omitempty"`
+	Pet *Pet   `json:"pet" xml:"pet"`
+	Err string `json:"err,omitempty" xml:"err,omitempty"`
 }
 
 // SearchPetsResponse standard response for searching pets with filters.
 type SearchPetsResponse struct {
-	Pets *SearchPetsResult `json:"result"`
-	Err  string            `json:"err,omitempty"`
+	Pets *SearchPetsResult `json:"result" xml:"result"`
+	Err  string            `json:"err,omitempty" xml:"err,omitempty"`
 }
 
 // SearchPetFilter contains filters to search pets
@@ -55,14 +237,16 @@ type SearchPetFilter struct {
 	Page uint8
 	// rows per page
 	PageSize uint8
+	// IncludeDeleted says if deleted pets must be part of the result.
+	IncludeDeleted bool
 }
 
 // SearchPetsResult contains search pets result data.
 type SearchPetsResult struct {
-	Pets     []Pet `json:"pets"`
-	Total    int   `json:"total"`
-	Page     uint8 `json:"page"`
-	PageSize uint8 `json:"page_size"`
+	Pets     []Pet `json:"pets" xml:"pets"`
+	Total    int   `json:"total" xml:"total"`
+	Page     uint8 `json:"page" xml:"page"`
+	PageSize uint8 `json:"page_size" xml:"page_size"`
 }
 
 // toPet transforms new pet to a pet object.
@@ -71,12 +255,63 @@ func toPet(pet *pets.Pet) *Pet {
 		return nil
 	}
 	webPet := Pet{
-		ID:   pet.ID.String(),
-		Name: pet.Name,
+		ID:        pet.ID.String(),
+		Name:      pet.Name,
+		Location:  toLocation(pet.Location),
+		DeletedAt: pet.DeletedAt,
 	}
 	return &webPet
 }
 
+// toLocation transforms a domain location to a web location.
+func toLocation(location *pets.Location) *Location {
+	if location == nil {
+		return nil
+	}
+	webLocation := Location{
+		Latitude:  location.Latitude,
+		Longitude: location.Longitude,
+	}
+	return &webLocation
+}
+
+// toSighting transforms a domain sighting to a web sighting.
+func toSighting(sighting pets.Sighting) Sighting {
+	return Sighting{
+		ID:    sighting.ID.String(),
+		PetID: sighting.PetID.String(),
+		Location: Location{
+			Latitude:  sighting.Location.Latitude,
+			Longitude: sighting.Location.Longitude,
+		},
+		Notes:  sighting.Notes,
+		SeenAt: sighting.SeenAt,
+	}
+}
+
+// toSearchNearbyResult transforms a domain nearby result to a web result.
+func toSearchNearbyResult(result pets.NearbyResult) *SearchNearbyResult {
+	nearbyPets := make([]NearbyPet, 0, len(result.Pets))
+	for _, v := range result.Pets {
+		nearbyPets = append(nearbyPets, NearbyPet{
+			Pet:        *toPet(&v.Pet),
+			DistanceKm: v.DistanceKm,
+		})
+	}
+	nearbySightings := make([]NearbySighting, 0, len(result.Sightings))
+	for _, v := range result.Sightings {
+		nearbySightings = append(nearbySightings, NearbySighting{
+			Sighting:   toSighting(v.Sighting),
+			DistanceKm: v.DistanceKm,
+		})
+	}
+	webResult := SearchNearbyResult{
+		Pets:      nearbyPets,
+		Sightings: nearbySightings,
+	}
+	return &webResult
+}
+
 // toSearchPetResult transforms new pet to a pet object.
 func toSearchPetResult(result *pets.SearchPetsResult) *SearchPetsResult {
 	if result == nil {
@@ -114,7 +349,8 @@ func (n *NewPet) toPet() *pets.NewPet {
 		return nil
 	}
 	petDomain := pets.NewPet{
-		Name: n.Name,
+		Name:     n.Name,
+		Location: n.Location.toLocation(),
 	}
 	return &petDomain
 }
@@ -125,12 +361,39 @@ func (u *UpdatePet) toPet() *pets.UpdatePet {
 		return nil
 	}
 	petDomain := pets.UpdatePet{
-		ID:   pets.PetID(u.ID),
-		Name: u.Name,
+		ID:       pets.PetID(u.ID),
+		Name:     u.Name,
+		Location: u.Location.toLocation(),
 	}
 	return &petDomain
 }
 
+// toLocation transforms a web location to a domain location.
+func (l *Location) toLocation() *pets.Location {
+	if l == nil {
+		return nil
+	}
+	locationDomain := pets.Location{
+		Latitude:  l.Latitude,
+		Longitude: l.Longitude,
+	}
+	return &locationDomain
+}
+
+// toSighting transforms a new sighting to a domain new sighting of the given pet.
+func (n *NewSighting) toSighting(petID string) *pets.NewSighting {
+	if n == nil {
+		return nil
+	}
+	sightingDomain := pets.NewSighting{
+		PetID:    pets.PetID(petID),
+		Location: *n.Location.toLocation(),
+		Notes:    n.Notes,
+		SeenAt:   n.SeenAt,
+	}
+	return &sightingDomain
+}
+
 func toCreatePetResponse(petResult pets.CreatePetResult) Result {
 	var message Result
 	if petResult.Err == "" {
@@ -139,6 +402,7 @@ func toCreatePetResponse(petResult pets.CreatePetResult) Result {
 	}
 	if petResult.Err != "" {
 		message.Errors = []string{petResult.Err}
+		message.errKind = petResult.ErrKind
 	}
 	return message
 }
@@ -150,6 +414,7 @@ func toUpdatePetResponse(petResult pets.UpdatePetResult) Result {
 	}
 	if petResult.Err != "" {
 		message.Errors = []string{petResult.Err}
+		message.errKind = petResult.ErrKind
 	}
 	return message
 }
@@ -161,6 +426,19 @@ func toDeletePetResponse(petResult pets.DeletePetResult) Result {
 	}
 	if petResult.Err != "" {
 		message.Errors = []string{petResult.Err}
+		message.errKind = petResult.ErrKind
+	}
+	return message
+}
+
+func toRestorePetResponse(petResult pets.RestorePetResult) Result {
+	var message Result
+	if petResult.Err == "" {
+		message.Success = true
+	}
+	if petResult.Err != "" {
+		message.Errors = []string{petResult.Err}
+		message.errKind = petResult.ErrKind
 	}
 	return message
 }
@@ -174,6 +452,7 @@ func toGetPetWithIDResponse(petResult pets.GetPetWithIDResult) Result {
 	}
 	if petResult.Err != "" {
 		message.Errors = []string{petResult.Err}
+		message.errKind = petResult.ErrKind
 	}
 	return message
 }
@@ -187,15 +466,220 @@ func toSearchPetsResponse(petResult pets.SearchPetsDataResult) Result {
 	}
 	if petResult.Err != "" {
 		message.Errors = []string{petResult.Err}
+		message.errKind = petResult.ErrKind
 	}
 	return message
 }
 
 func (s SearchPetFilter) toSearchPetFilter() pets.QueryFilter {
 	return pets.QueryFilter{
-		PetName:     s.Name,
-		PageNumber:  s.Page,
-		RowsPerPage: s.PageSize,
-		OrderBy:     pets.OrderByField(s.OrderBy),
+		PetName:        s.Name,
+		PageNumber:     s.Page,
+		RowsPerPage:    s.PageSize,
+		OrderBy:        pets.OrderByField(s.OrderBy),
+		IncludeDeleted: s.IncludeDeleted,
+	}
+}
+
+// toPetHistoryResult transforms domain audit records to web audit records.
+func toPetHistoryResult(result pets.AuditRecordsResult) *PetHistoryResult {
+	records := make([]AuditRecord, 0, len(result.Records))
+	for _, v := range result.Records {
+		changes := make([]FieldChange, 0, len(v.Changes))
+		for _, change := range v.Changes {
+			changes = append(changes, FieldChange(change))
+		}
+		records = append(records, AuditRecord{
+			ID:            v.ID.String(),
+			PetID:         v.PetID.String(),
+			Action:        string(v.Action),
+			Actor:         v.Actor,
+			AssertedActor: v.AssertedActor,
+			RequestID:     v.RequestID,
+			Timestamp:     v.Timestamp,
+			Changes:       changes,
+		})
+	}
+	webResult := PetHistoryResult{
+		Records:  records,
+		Total:    result.Total,
+		Page:     result.Page,
+		PageSize: result.RowsPerPage,
+	}
+	return &webResult
+}
+
+func toPetHistoryResponse(historyResult pets.PetHistoryDataResult) Result {
+	var message Result
+
+	if historyResult.Err == "" {
+		message.Success = true
+		message.Data = toPetHistoryResult(historyResult.Result)
+	}
+	if historyResult.Err != "" {
+		message.Errors = []string{historyResult.Err}
+		message.errKind = historyResult.ErrKind
+	}
+	return message
+}
+
+func (p PetHistoryFilter) toAuditFilter() pets.AuditFilter {
+	return pets.AuditFilter{
+		PetID:       pets.PetID(p.PetID),
+		PageNumber:  p.Page,
+		RowsPerPage: p.PageSize,
+	}
+}
+
+// toBatchRequest transforms a web batch request to a domain batch request.
+func (b *BatchRequest) toBatchRequest() *pets.BatchRequest {
+	if b == nil {
+		return nil
+	}
+	operations := make([]pets.BatchOperation, 0, len(b.Operations))
+	for _, v := range b.Operations {
+		operations = append(operations, v.toBatchOperation())
+	}
+	batchDomain := pets.BatchRequest{
+		Atomic:     b.Atomic,
+		Operations: operations,
+	}
+	return &batchDomain
+}
+
+func (b BatchOperation) toBatchOperation() pets.BatchOperation {
+	operation := pets.BatchOperation{
+		Type:  pets.BatchOperationType(b.Op),
+		PetID: pets.PetID(b.ID),
+	}
+	if b.Pet == nil {
+		return operation
+	}
+	switch operation.Type {
+	case pets.BatchCreate:
+		operation.NewPet = &pets.NewPet{
+			Name:     b.Pet.Name,
+			Location: b.Pet.Location.toLocation(),
+		}
+	case pets.BatchUpdate:
+		operation.UpdatePet = &pets.UpdatePet{
+			ID:       pets.PetID(b.Pet.ID),
+			Name:     b.Pet.Name,
+			Location: b.Pet.Location.toLocation(),
+		}
+	case pets.BatchDelete:
+		if operation.PetID == pets.EmptyPetID {
+			operation.PetID = pets.PetID(b.Pet.ID)
+		}
+	}
+	return operation
+}
+
+// toBatchResult transforms a domain batch result to a web batch result.
+func toBatchResult(result pets.BatchResult) *BatchResult {
+	items := make([]BatchItemResult, 0, len(result.Items))
+	for _, v := range result.Items {
+		items = append(items, BatchItemResult{
+			Index:  v.Index,
+			Op:     string(v.Type),
+			ID:     v.ID.String(),
+			Status: string(v.Status),
+			Error:  v.Err,
+		})
+	}
+	webResult := BatchResult{
+		Items: items,
 	}
+	return &webResult
+}
+
+func toBatchResponse(batchResult pets.BatchDataResult) Result {
+	var message Result
+	message.Data = toBatchResult(batchResult.Result)
+	if batchResult.Err == "" {
+		message.Success = true
+	}
+	if batchResult.Err != "" {
+		message.Errors = []string{batchResult.Err}
+		message.errKind = batchResult.ErrKind
+	}
+	return message
+}
+
+func toReportSightingResponse(sightingResult pets.ReportSightingResult) Result {
+	var message Result
+	if sightingResult.Err == "" {
+		message.Success = true
+		message.Data = sightingResult.ID
+	}
+	if sightingResult.Err != "" {
+		message.Errors = []string{sightingResult.Err}
+		message.errKind = sightingResult.ErrKind
+	}
+	return message
+}
+
+func toSearchNearbyResponse(nearbyResult pets.SearchNearbyDataResult) Result {
+	var message Result
+
+	if nearbyResult.Err == "" {
+		message.Success = true
+		message.Data = toSearchNearbyResult(nearbyResult.Result)
+	}
+	if nearbyResult.Err != "" {
+		message.Errors = []string{nearbyResult.Err}
+		message.errKind = nearbyResult.ErrKind
+	}
+	return message
+}
+
+func (s SearchNearbyFilter) toNearbyFilter() pets.NearbyFilter {
+	return pets.NearbyFilter{
+		Location: pets.Location{
+			Latitude:  s.Latitude,
+			Longitude: s.Longitude,
+		},
+		RadiusKm: s.Radius,
+		Limit:    s.Limit,
+	}
+}
+
+// toImportJob transforms a domain import job to a web import job.
+func toImportJob(job *pets.ImportJob) *ImportJob {
+	if job == nil {
+		return nil
+	}
+	rejected := make([]RejectedRow, 0, len(job.Report.Rejected))
+	for _, v := range job.Report.Rejected {
+		rejected = append(rejected, RejectedRow{
+			Line:   v.Line,
+			Reason: v.Reason,
+		})
+	}
+	webJob := ImportJob{
+		ID:     job.ID.String(),
+		Status: string(job.Status),
+		Report: ImportReport{
+			Total:    job.Report.Total,
+			Imported: job.Report.Imported,
+			Rejected: rejected,
+		},
+		Error:      job.Err,
+		StartedAt:  job.StartedAt,
+		FinishedAt: job.FinishedAt,
+	}
+	return &webJob
+}
+
+func toImportJobResponse(jobResult pets.ImportJobResult) Result {
+	var message Result
+	if jobResult.Err == "" {
+		message.Success = true
+		message.Data = toImportJob(jobResult.Job)
+	}
+	if jobResult.Err != "" {
+		message.Errors = []string{jobResult.Err}
+		message.errKind = jobResult.ErrKind
+	}
+	return message
 }
@@ -0,0 +1,110 @@
+package web
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+)
+
+// NegotiationSetup contains content negotiation middleware metadata.
+type NegotiationSetup struct {
+	Codecs *CodecRegistry
+	Logger *slog.Logger
+}
+
+// Negotiation chooses the codec of the response from the Accept header and
+// the codec of the request body from the Content-Type header.
+type Negotiation struct {
+	codecs *CodecRegistry
+	logger *slog.Logger
+}
+
+type codecKey int
+
+const (
+	requestCodecKey codecKey = iota
+	responseCodecKey
+)
+
+func NewNegotiation(setup NegotiationSetup) *Negotiation {
+	newNegotiation := Negotiation{
+		codecs: setup.Codecs,
+		logger: setup.Logger,
+	}
+
+	return &newNegotiation
+}
+
+// Wrap returns a handler that answers 406 if the Accept header does not
+// have a supported media type and 415 if the body media type is not
+// supported, otherwise it calls next with the chosen codecs in the
+// request context.
+func (n *Negotiation) Wrap(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		responseCodec, ok := n.codecs.ForAccept(req.Header.Get("Accept"))
+		if !ok {
+			n.logger.Debug("unsupported accept header", slog.String("accept", req.Header.Get("Accept")))
+			writeErrorResponse(rw, http.StatusNotAcceptable, n.unsupportedMediaType("accept"))
+
+			return
+		}
+
+		ctx := WithResponseCodec(req.Context(), responseCodec)
+
+		if hasBody(req) {
+			requestCodec, ok := n.codecs.ForContentType(req.Header.Get("Content-Type"))
+			if !ok {
+				n.logger.Debug("unsupported content type", slog.String("content_type", req.Header.Get("Content-Type")))
+				writeErrorResponse(rw, http.StatusUnsupportedMediaType, n.unsupportedMediaType("content type"))
+
+				return
+			}
+
+			ctx = WithRequestCodec(ctx, requestCodec)
+		}
+
+		next.ServeHTTP(rw, req.WithContext(ctx))
+	})
+}
+
+func (n *Negotiation) unsupportedMediaType(header string) ErrorResponse {
+	return ErrorResponse{
+		Message: fmt.Sprintf("unsupported %s, it must be one of %s", header, strings.Join(n.codecs.MediaTypes(), ", ")),
+	}
+}
+
+// WithRequestCodec returns a copy of ctx with the codec of the request body.
+func WithRequestCodec(ctx context.Context, codec Codec) context.Context {
+	return context.WithValue(ctx, requestCodecKey, codec)
+}
+
+// WithResponseCodec returns a copy of ctx with the codec of the response body.
+func WithResponseCodec(ctx context.Context, codec Codec) context.Context {
+	return context.WithValue(ctx, responseCodecKey, codec)
+}
+
+// requestCodec returns the codec of the request body, JSON if there is not any.
+func requestCodec(ctx context.Context) Codec {
+	codec, ok := ctx.Value(requestCodecKey).(Codec)
+	if !ok {
+		return JSONCodec{}
+	}
+
+	return codec
+}
+
+// responseCodec returns the codec of the response body, JSON if there is not any.
+func responseCodec(ctx context.Context) Codec {
+	codec, ok := ctx.Value(responseCodecKey).(Codec)
+	if !ok {
+		return JSONCodec{}
+	}
+
+	return codec
+}
+
+func hasBody(req *http.Request) bool {
+	return req.ContentLength > 0 || len(req.TransferEncoding) > 0
+}
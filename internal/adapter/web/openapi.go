@@ -0,0 +1,272 @@
+package web
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"log/slog"
+	"mime"
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/getkin/kin-openapi/openapi3filter"
+	"github.com/getkin/kin-openapi/routers"
+	"github.com/getkin/kin-openapi/routers/gorillamux"
+)
+
+// OpenAPIValidatorSetup contains OpenAPI validation middleware metadata.
+type OpenAPIValidatorSetup struct {
+	// Spec OpenAPI document in YAML or JSON.
+	Spec []byte
+	// ValidateRequests answers 400 to requests that do not match the spec.
+	ValidateRequests bool
+	// ValidateResponses logs the JSON responses that do not match the spec,
+	// they are sent anyway.
+	ValidateResponses bool
+	Logger            *slog.Logger
+}
+
+// OpenAPIValidator validates requests and responses against the OpenAPI
+// spec. Requests of routes that are not in the spec are not validated.
+type OpenAPIValidator struct {
+	router    routers.Router
+	requests  bool
+	responses bool
+	logger    *slog.Logger
+}
+
+// responseTee copies the body of a response while it is written.
+type responseTee struct {
+	http.ResponseWriter
+	statusCode int
+	body       bytes.Buffer
+}
+
+// content types of the OpenAPI document and of its rendered page.
+const (
+	yamlContentType = "application/yaml"
+	htmlContentType = "text/html; charset=utf-8"
+)
+
+// apiDocsPage renders the OpenAPI document of the given url with Redoc.
+const apiDocsPage = `<!DOCTYPE html>
+<html>
+  <head>
+    <title>pets api</title>
+    <meta charset="utf-8"/>
+    <meta name="viewport" content="width=device-width, initial-scale=1">
+  </head>
+  <body>
+    <redoc spec-url="%s"></redoc>
+    <script src="https://cdn.redoc.ly/redoc/v2.1.5/bundles/redoc.standalone.js"></script>
+  </body>
+</html>
+`
+
+// NewOpenAPIValidator parses and validates the spec.
+func NewOpenAPIValidator(setup OpenAPIValidatorSetup) (*OpenAPIValidator, error) {
+	spec, err := openapi3.NewLoader().LoadFromData(setup.Spec)
+	if err != nil {
+		return nil, fmt.Errorf("unable to load openapi spec: %w", err)
+	}
+
+	err = spec.Validate(context.Background())
+	if err != nil {
+		return nil, fmt.Errorf("invalid openapi spec: %w", err)
+	}
+
+	// routes are matched by path only, the servers of the spec are examples.
+	spec.Servers = nil
+
+	router, err := gorillamux.NewRouter(spec)
+	if err != nil {
+		return nil, fmt.Errorf("unable to create openapi router: %w", err)
+	}
+
+	newValidator := OpenAPIValidator{
+		router:    router,
+		requests:  setup.ValidateRequests,
+		responses: setup.ValidateResponses,
+		logger:    setup.Logger,
+	}
+
+	return &newValidator, nil
+}
+
+// NewOpenAPIHandler answers with the given OpenAPI document.
+func NewOpenAPIHandler(spec []byte) http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		rw.Header().Set("Content-Type", yamlContentType)
+		rw.WriteHeader(http.StatusOK)
+		rw.Write(spec)
+	})
+}
+
+// NewAPIDocsHandler answers with a page that renders the OpenAPI document
+// of the given url.
+func NewAPIDocsHandler(specURL string) http.Handler {
+	page := []byte(fmt.Sprintf(apiDocsPage, specURL))
+
+	return http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		rw.Header().Set("Content-Type", htmlContentType)
+		rw.WriteHeader(http.StatusOK)
+		rw.Write(page)
+	})
+}
+
+// Wrap returns a handler that validates the request before calling next
+// and the response after it. Request bodies of media types that the spec
+// does not declare are not validated, content negotiation rejects them.
+func (o *OpenAPIValidator) Wrap(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		route, pathParams, err := o.router.FindRoute(req)
+		if err != nil {
+			next.ServeHTTP(rw, req)
+
+			return
+		}
+
+		options := openapi3filter.Options{
+			AuthenticationFunc: openapi3filter.NoopAuthenticationFunc,
+			ExcludeRequestBody: !validatesBody(route.Operation, req.Header.Get("Content-Type")),
+		}
+		options.WithCustomSchemaErrorFunc(schemaErrorMessage)
+
+		input := openapi3filter.RequestValidationInput{
+			Request:    req,
+			PathParams: pathParams,
+			Route:      route,
+			Options:    &options,
+		}
+
+		if o.requests {
+			err := openapi3filter.ValidateRequest(req.Context(), &input)
+			if err != nil {
+				o.logger.Debug("request does not match the openapi spec", "error", err)
+				writeErrorResponse(rw, http.StatusBadRequest, ErrorResponse{Message: err.Error()})
+
+				return
+			}
+		}
+
+		if !o.responses || !hasJSONResponses(route.Operation) {
+			next.ServeHTTP(rw, req)
+
+			return
+		}
+
+		tee := responseTee{ResponseWriter: rw, statusCode: http.StatusOK}
+
+		next.ServeHTTP(&tee, req)
+
+		o.validateResponse(req.Context(), &input, &tee)
+	})
+}
+
+// validateResponse logs the response if it does not match the spec, only
+// JSON responses are validated.
+func (o *OpenAPIValidator) validateResponse(ctx context.Context, input *openapi3filter.RequestValidationInput, tee *responseTee) {
+	if !isJSON(tee.Header().Get("Content-Type")) {
+		return
+	}
+
+	responseInput := openapi3filter.ResponseValidationInput{
+		RequestValidationInput: input,
+		Status:                 tee.statusCode,
+		Header:                 tee.Header(),
+		Options: &openapi3filter.Options{
+			IncludeResponseStatus: true,
+		},
+	}
+	responseInput.Options.WithCustomSchemaErrorFunc(schemaErrorMessage)
+	responseInput.SetBodyBytes(tee.body.Bytes())
+
+	err := openapi3filter.ValidateResponse(ctx, &responseInput)
+	if err != nil {
+		o.logger.Error("response does not match the openapi spec",
+			"error", err,
+			slog.String("method", input.Request.Method),
+			slog.String("path", input.Route.Path),
+			slog.Int("status", tee.statusCode))
+	}
+}
+
+// validatesBody says if the request body can be validated, it must be a
+// media type of the operation that the validator can decode.
+func validatesBody(operation *openapi3.Operation, contentType string) bool {
+	if operation.RequestBody == nil || operation.RequestBody.Value == nil {
+		return true
+	}
+
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return true
+	}
+
+	if operation.RequestBody.Value.Content.Get(mediaType) == nil {
+		return false
+	}
+
+	return openapi3filter.RegisteredBodyDecoder(mediaType) != nil
+}
+
+// hasJSONResponses says if any response of the operation is JSON, other
+// responses, like exports, are streamed, so they are not validated.
+func hasJSONResponses(operation *openapi3.Operation) bool {
+	if operation.Responses == nil {
+		return false
+	}
+
+	for _, response := range operation.Responses.Map() {
+		if response.Value != nil && response.Value.Content.Get(JSONMediaType) != nil {
+			return true
+		}
+	}
+
+	return false
+}
+
+func isJSON(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+
+	return err == nil && mediaType == JSONMediaType
+}
+
+// schemaErrorMessage is the message of a value that does not match the
+// schema, without the schema itself.
+func schemaErrorMessage(err *openapi3.SchemaError) string {
+	pointer := err.JSONPointer()
+	if len(pointer) == 0 {
+		return err.Reason
+	}
+
+	return fmt.Sprintf("%s: %s", strings.Join(pointer, "."), err.Reason)
+}
+
+func (r *responseTee) WriteHeader(statusCode int) {
+	r.statusCode = statusCode
+	r.ResponseWriter.WriteHeader(statusCode)
+}
+
+// Write copies only JSON bodies, the other responses are not validated and
+// can be streams that never end, like the pets events.
+func (r *responseTee) Write(content []byte) (int, error) {
+	if isJSON(r.Header().Get("Content-Type")) {
+		r.body.Write(content)
+	}
+
+	return r.ResponseWriter.Write(content)
+}
+
+// Hijack keeps connection upgrades working, like the websocket.
+func (r *responseTee) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	return http.NewResponseController(r.ResponseWriter).Hijack()
+}
+
+// Unwrap lets http.ResponseController flush streamed responses.
+func (r *responseTee) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
@@ -0,0 +1,143 @@
+package web_test
+
+import (
+	"bytes"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/fernandoocampo/basic-micro/docs"
+	"github.com/fernandoocampo/basic-micro/internal/adapter/web"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOpenAPIValidatorRequests(t *testing.T) {
+	cases := map[string]struct {
+		method      string
+		target      string
+		contentType string
+		body        string
+		wantStatus  int
+		wantCalled  bool
+	}{
+		"valid search": {
+			method:     http.MethodGet,
+			target:     "/pets?name=drila&page=1",
+			wantStatus: http.StatusOK,
+			wantCalled: true,
+		},
+		"invalid query parameter": {
+			method:     http.MethodGet,
+			target:     "/pets?name=drila&page=first",
+			wantStatus: http.StatusBadRequest,
+		},
+		"valid body": {
+			method:      http.MethodPost,
+			target:      "/pets",
+			contentType: "application/json",
+			body:        `{"name":"drila","location":{"latitude":4.6,"longitude":-74.1}}`,
+			wantStatus:  http.StatusOK,
+			wantCalled:  true,
+		},
+		"invalid body": {
+			method:      http.MethodPost,
+			target:      "/pets",
+			contentType: "application/json",
+			body:        `{"name":10}`,
+			wantStatus:  http.StatusBadRequest,
+		},
+		"body media type not in spec": {
+			method:      http.MethodPost,
+			target:      "/pets",
+			contentType: "application/xml",
+			body:        `<pet><name>drila</name></pet>`,
+			wantStatus:  http.StatusOK,
+			wantCalled:  true,
+		},
+		"route not in spec": {
+			method:     http.MethodGet,
+			target:     "/unknown",
+			wantStatus: http.StatusOK,
+			wantCalled: true,
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			// Given
+			validator, err := web.NewOpenAPIValidator(web.OpenAPIValidatorSetup{
+				Spec:             docs.OpenAPI,
+				ValidateRequests: true,
+				Logger:           newDummyLogger(),
+			})
+			require.NoError(t, err)
+			var called bool
+			handler := validator.Wrap(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				called = true
+			}))
+			request := httptest.NewRequest(tc.method, "http://anyhost"+tc.target, bytes.NewBufferString(tc.body))
+			if tc.contentType != "" {
+				request.Header.Set("Content-Type", tc.contentType)
+			}
+			recorder := httptest.NewRecorder()
+
+			// When
+			handler.ServeHTTP(recorder, request)
+
+			// Then
+			assert.Equal(t, tc.wantStatus, recorder.Code, recorder.Body.String())
+			assert.Equal(t, tc.wantCalled, called)
+		})
+	}
+}
+
+func TestOpenAPIValidatorResponses(t *testing.T) {
+	// Given
+	var logs bytes.Buffer
+	validator, err := web.NewOpenAPIValidator(web.OpenAPIValidatorSetup{
+		Spec:              docs.OpenAPI,
+		ValidateResponses: true,
+		Logger:            slog.New(slog.NewTextHandler(&logs, nil)),
+	})
+	require.NoError(t, err)
+	handler := validator.Wrap(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"success":"yes","data":null,"errors":null}`))
+	}))
+	request := httptest.NewRequest(http.MethodGet, "http://anyhost/pets/1", nil)
+	recorder := httptest.NewRecorder()
+
+	// When
+	handler.ServeHTTP(recorder, request)
+
+	// Then
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.Equal(t, `{"success":"yes","data":null,"errors":null}`, recorder.Body.String())
+	assert.Contains(t, logs.String(), "response does not match the openapi spec")
+}
+
+func TestOpenAPIValidatorWithInvalidSpec(t *testing.T) {
+	// When
+	_, err := web.NewOpenAPIValidator(web.OpenAPIValidatorSetup{
+		Spec:   []byte("openapi: 3.0.0\npaths: {}\n"),
+		Logger: newDummyLogger(),
+	})
+
+	// Then
+	assert.Error(t, err)
+}
+
+func TestOpenAPIHandler(t *testing.T) {
+	// Given
+	handler := web.NewOpenAPIHandler(docs.OpenAPI)
+	recorder := httptest.NewRecorder()
+
+	// When
+	handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "http://anyhost/openapi.yaml", nil))
+
+	// Then
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.Equal(t, "application/yaml", recorder.Header().Get("Content-Type"))
+	assert.Equal(t, docs.OpenAPI, recorder.Body.Bytes())
+}
@@ -0,0 +1,169 @@
+package web
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net"
+	"net/http"
+	"sync/atomic"
+	"time"
+)
+
+// ServerSetup contains http server metadata.
+type ServerSetup struct {
+	Address string
+	Handler http.Handler
+	// Readiness is flipped to not ready when the server starts to shut down.
+	Readiness *Readiness
+	// ReadinessDelay how long the server keeps serving after readiness
+	// fails, so load balancers stop sending new requests before the drain.
+	ReadinessDelay time.Duration
+	// DrainTimeout how long in-flight requests have to finish, after that
+	// their connections are closed.
+	DrainTimeout time.Duration
+	// OnShutdown is called when the drain starts, it is optional. It ends
+	// responses that never finish on their own, like event streams.
+	OnShutdown func()
+	Logger     *slog.Logger
+}
+
+// Server is an http server that drains in-flight requests when it shuts down.
+type Server struct {
+	httpServer *http.Server
+	readiness  *Readiness
+	// readinessDelay and drainTimeout are durations that can be changed
+	// while the server runs, see SetTimeouts.
+	readinessDelay atomic.Int64
+	drainTimeout   atomic.Int64
+	logger         *slog.Logger
+}
+
+// Readiness says if the server can receive traffic.
+type Readiness struct {
+	notReady atomic.Bool
+}
+
+var errDrainTimeout = errors.New("in-flight requests did not finish before the drain timeout")
+
+func NewServer(setup ServerSetup) *Server {
+	readiness := setup.Readiness
+	if readiness == nil {
+		readiness = NewReadiness()
+	}
+
+	newServer := Server{
+		httpServer: &http.Server{
+			Addr:    setup.Address,
+			Handler: setup.Handler,
+		},
+		readiness: readiness,
+		logger:    setup.Logger,
+	}
+
+	newServer.SetTimeouts(setup.ReadinessDelay, setup.DrainTimeout)
+
+	if setup.OnShutdown != nil {
+		newServer.httpServer.RegisterOnShutdown(setup.OnShutdown)
+	}
+
+	return &newServer
+}
+
+// ListenAndServe listens on the server address and serves requests until
+// the server shuts down, in that case it returns nil.
+func (s *Server) ListenAndServe() error {
+	listener, err := net.Listen("tcp", s.httpServer.Addr)
+	if err != nil {
+		return err
+	}
+
+	return s.Serve(listener)
+}
+
+// Serve serves requests received by listener until the server shuts
+// down, in that case it returns nil.
+func (s *Server) Serve(listener net.Listener) error {
+	err := s.httpServer.Serve(listener)
+	if errors.Is(err, http.ErrServerClosed) {
+		return nil
+	}
+
+	return err
+}
+
+// Shutdown flips readiness to not ready, waits the readiness delay and
+// then stops accepting connections and waits until in-flight requests
+// finish. If they do not finish before the drain timeout or ctx is done,
+// their connections are closed.
+func (s *Server) Shutdown(ctx context.Context) error {
+	s.readiness.NotReady()
+
+	readinessDelay := time.Duration(s.readinessDelay.Load())
+	drainTimeout := time.Duration(s.drainTimeout.Load())
+
+	if readinessDelay > 0 {
+		s.logger.Info("waiting before draining requests", slog.Duration("delay", readinessDelay))
+
+		select {
+		case <-time.After(readinessDelay):
+		case <-ctx.Done():
+		}
+	}
+
+	if drainTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, drainTimeout)
+		defer cancel()
+	}
+
+	s.logger.Info("draining in-flight requests", slog.Duration("timeout", drainTimeout))
+
+	err := s.httpServer.Shutdown(ctx)
+	if err != nil {
+		s.logger.Error("closing connections with in-flight requests", "error", err)
+
+		closeErr := s.httpServer.Close()
+		if closeErr != nil {
+			s.logger.Error("closing http server", "error", closeErr)
+		}
+
+		return errDrainTimeout
+	}
+
+	s.logger.Info("http server was drained")
+
+	return nil
+}
+
+// SetTimeouts changes the readiness delay and the drain timeout of the
+// next shutdown.
+func (s *Server) SetTimeouts(readinessDelay, drainTimeout time.Duration) {
+	s.readinessDelay.Store(int64(readinessDelay))
+	s.drainTimeout.Store(int64(drainTimeout))
+}
+
+// Readiness returns the readiness of the server.
+func (s *Server) Readiness() *Readiness {
+	return s.readiness
+}
+
+// NewReadiness creates a readiness that is ready.
+func NewReadiness() *Readiness {
+	return new(Readiness)
+}
+
+// Ready says the server can receive traffic.
+func (r *Readiness) Ready() {
+	r.notReady.Store(false)
+}
+
+// NotReady says the server must not receive new traffic.
+func (r *Readiness) NotReady() {
+	r.notReady.Store(true)
+}
+
+// IsReady says if the server can receive traffic.
+func (r *Readiness) IsReady() bool {
+	return !r.notReady.Load()
+}
@@ -0,0 +1,150 @@
+package web_test
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/fernandoocampo/basic-micro/internal/adapter/health"
+	"github.com/fernandoocampo/basic-micro/internal/adapter/web"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// slowResponse is the result of a request sent to a slow handler.
+type slowResponse struct {
+	statusCode int
+	body       string
+	err        error
+}
+
+func TestServerShutdownDrainsInFlightRequests(t *testing.T) {
+	// Given
+	started := make(chan struct{})
+	handler := http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		close(started)
+		time.Sleep(200 * time.Millisecond)
+		rw.Write([]byte("drila"))
+	})
+	readiness := web.NewReadiness()
+	server, address := startServer(t, web.ServerSetup{
+		Handler:      handler,
+		Readiness:    readiness,
+		DrainTimeout: 5 * time.Second,
+		Logger:       newDummyLogger(),
+	})
+	responses := sendRequest(address)
+	<-started
+
+	// When
+	err := server.Shutdown(context.Background())
+
+	// Then
+	assert.NoError(t, err)
+	assert.False(t, readiness.IsReady())
+	got := <-responses
+	require.NoError(t, got.err)
+	assert.Equal(t, http.StatusOK, got.statusCode)
+	assert.Equal(t, "drila", got.body)
+}
+
+func TestServerShutdownAfterDrainTimeout(t *testing.T) {
+	// Given
+	started := make(chan struct{})
+	release := make(chan struct{})
+	defer close(release)
+	handler := http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		close(started)
+		<-release
+	})
+	server, address := startServer(t, web.ServerSetup{
+		Handler:      handler,
+		DrainTimeout: 50 * time.Millisecond,
+		Logger:       newDummyLogger(),
+	})
+	responses := sendRequest(address)
+	<-started
+
+	// When
+	err := server.Shutdown(context.Background())
+
+	// Then
+	assert.Error(t, err)
+	got := <-responses
+	assert.Error(t, got.err)
+}
+
+func TestReadinessFailsBeforeDrain(t *testing.T) {
+	// Given
+	readiness := web.NewReadiness()
+	registry := health.NewRegistry(health.RegistrySetup{
+		Logger: newDummyLogger(),
+	})
+	registry.Register(health.Check{
+		Name:    "shutdown",
+		Checker: health.ReadyChecker(readiness),
+		Probes:  []health.Probe{health.Readiness},
+		NoCache: true,
+	})
+	server, address := startServer(t, web.ServerSetup{
+		Handler:        web.NewHealthHandler(registry, health.Readiness, newDummyLogger()),
+		Readiness:      readiness,
+		ReadinessDelay: 300 * time.Millisecond,
+		DrainTimeout:   time.Second,
+		Logger:         newDummyLogger(),
+	})
+	ready := <-sendRequest(address)
+	shutdownErr := make(chan error, 1)
+
+	// When
+	go func() {
+		shutdownErr <- server.Shutdown(context.Background())
+	}()
+	time.Sleep(100 * time.Millisecond)
+	notReady := <-sendRequest(address)
+
+	// Then
+	assert.Equal(t, http.StatusOK, ready.statusCode)
+	assert.Equal(t, http.StatusServiceUnavailable, notReady.statusCode)
+	assert.NoError(t, <-shutdownErr)
+}
+
+// startServer serves on a random port and returns its address.
+func startServer(t *testing.T, setup web.ServerSetup) (*web.Server, string) {
+	t.Helper()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	server := web.NewServer(setup)
+
+	go server.Serve(listener)
+
+	return server, "http://" + listener.Addr().String()
+}
+
+func sendRequest(address string) <-chan slowResponse {
+	responses := make(chan slowResponse, 1)
+
+	go func() {
+		response, err := http.Get(address)
+		if err != nil {
+			responses <- slowResponse{err: err}
+
+			return
+		}
+		defer response.Body.Close()
+
+		body, err := io.ReadAll(response.Body)
+		responses <- slowResponse{
+			statusCode: response.StatusCode,
+			body:       string(body),
+			err:        err,
+		}
+	}()
+
+	return responses
+}
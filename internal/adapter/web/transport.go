@@ -2,10 +2,13 @@ package web
 
 import (
 	"context"
-	"encoding/json"
+	"encoding/xml"
+	"fmt"
 	"log/slog"
 	"net/http"
+	"reflect"
 
+	"github.com/fernandoocampo/basic-micro/internal/pets"
 	"github.com/gorilla/mux"
 )
 
@@ -30,20 +33,47 @@ type Encoder interface {
 	Encode(context.Context, http.ResponseWriter, interface{}) error
 }
 
-type Handler struct {
-	endpoint Endpoint
-	decoder  Decoder
-	encoder  Encoder
-	logger   *slog.Logger
+// EndpointFunc is an endpoint with typed request and response.
+type EndpointFunc[Req, Resp any] func(ctx context.Context, request Req) (Resp, error)
+
+// DecodeFunc extracts a typed request from an HTTP request.
+type DecodeFunc[Req any] func(ctx context.Context, r *http.Request) (Req, error)
+
+// EncodeFunc encodes a typed response to the HTTP response writer.
+type EncodeFunc[Resp any] func(ctx context.Context, w http.ResponseWriter, response Resp) error
+
+// Handler serves HTTP requests decoding them into Req, calling the endpoint
+// and encoding its Resp. Handlers created with NewTypedHandler are checked
+// at compile time, Handler[any, any] wraps the untyped Endpoint, Decoder
+// and Encoder interfaces.
+type Handler[Req, Resp any] struct {
+	endpoint EndpointFunc[Req, Resp]
+	decoder  DecodeFunc[Req]
+	encoder  EncodeFunc[Resp]
+	// middlewares decorate the endpoint, see Use.
+	middlewares []EndpointMiddleware[Req, Resp]
+	logger      *slog.Logger
 }
 
 // ErrorResponse define response.
 type ErrorResponse struct {
-	Message string `json:"message"`
+	XMLName xml.Name `json:"-" xml:"error"`
+	Message string   `json:"message" xml:"message"`
 }
 
 const (
-	jsonContentType = "application/json; charset=utf-8"
+	jsonContentType   = "application/json; charset=utf-8"
+	csvContentType    = "text/csv"
+	ndjsonContentType = "application/x-ndjson"
+)
+
+// ImportMaxBodySize is the size of the largest import body.
+const ImportMaxBodySize = 32 << 20
+
+// headers used to identify who makes the changes.
+const (
+	RequestIDHeader = "X-Request-ID"
+	ActorHeader     = "X-Actor"
 )
 
 var (
@@ -54,68 +84,170 @@ func NewRouter() *mux.Router {
 	return mux.NewRouter()
 }
 
-func NewHandler() *Handler {
-	newHandler := Handler{}
+// NewHandler creates a handler for the untyped Endpoint, Decoder and
+// Encoder interfaces, prefer NewTypedHandler for new code.
+func NewHandler() *Handler[any, any] {
+	newHandler := Handler[any, any]{}
+
+	return &newHandler
+}
+
+// NewTypedHandler creates a handler with typed decoder, endpoint and encoder.
+func NewTypedHandler[Req, Resp any](decoder DecodeFunc[Req], endpoint EndpointFunc[Req, Resp], encoder EncodeFunc[Resp]) *Handler[Req, Resp] {
+	newHandler := Handler[Req, Resp]{
+		endpoint: endpoint,
+		decoder:  decoder,
+		encoder:  encoder,
+	}
 
 	return &newHandler
 }
 
-func (h *Handler) WithEndpoint(endpoint Endpoint) *Handler {
-	h.endpoint = endpoint
+// WithEndpoint sets an untyped endpoint, its response must be a Resp.
+func (h *Handler[Req, Resp]) WithEndpoint(endpoint Endpoint) *Handler[Req, Resp] {
+	h.endpoint = EndpointOf[Req, Resp](endpoint)
 
 	return h
 }
 
-func (h *Handler) WithDecoder(decoder Decoder) *Handler {
-	h.decoder = decoder
+// WithDecoder sets an untyped decoder, its request must be a Req.
+func (h *Handler[Req, Resp]) WithDecoder(decoder Decoder) *Handler[Req, Resp] {
+	h.decoder = DecoderOf[Req](decoder)
 
 	return h
 }
 
-func (h *Handler) WithEncoder(encoder Encoder) *Handler {
-	h.encoder = encoder
+// WithEncoder sets an untyped encoder.
+func (h *Handler[Req, Resp]) WithEncoder(encoder Encoder) *Handler[Req, Resp] {
+	h.encoder = EncoderOf[Resp](encoder)
 
 	return h
 }
 
-func (h *Handler) ServeHTTP(rw http.ResponseWriter, req *http.Request) {
+func (h *Handler[Req, Resp]) ServeHTTP(rw http.ResponseWriter, req *http.Request) {
 	var err error
 
-	ctx := req.Context()
+	ctx := contextWithRequestMetadata(req)
 
-	request, err := h.decoder.Decode(ctx, req)
+	request, err := h.decoder(ctx, req)
 	if err != nil {
-		h.encodeError(err, rw)
+		h.encodeError(ctx, http.StatusBadRequest, err, rw)
 		return
 	}
 
-	response, err := h.endpoint.Do(ctx, request)
+	endpoint := ChainEndpoint(h.middlewares...)(h.endpoint)
+
+	response, err := endpoint(ctx, request)
 	if err != nil {
-		h.encodeError(err, rw)
+		h.encodeError(ctx, http.StatusInternalServerError, err, rw)
 		return
 	}
 
-	err = h.encoder.Encode(ctx, rw, response)
+	err = h.encoder(ctx, rw, response)
 	if err != nil {
-		h.encodeError(err, rw)
+		h.encodeError(ctx, http.StatusInternalServerError, err, rw)
 		return
 	}
 }
 
-func (h *Handler) encodeError(err error, w http.ResponseWriter) {
+// EndpointOf adapts an untyped endpoint to an EndpointFunc, a response
+// that is not a Resp is returned as an error.
+func EndpointOf[Req, Resp any](endpoint Endpoint) EndpointFunc[Req, Resp] {
+	return func(ctx context.Context, request Req) (Resp, error) {
+		var typedResponse Resp
+
+		response, err := endpoint.Do(ctx, request)
+		if err != nil {
+			return typedResponse, err
+		}
+
+		if response == nil {
+			return typedResponse, nil
+		}
+
+		typedResponse, ok := response.(Resp)
+		if !ok {
+			return typedResponse, fmt.Errorf("endpoint response must be %s but it was %T", typeName[Resp](), response)
+		}
+
+		return typedResponse, nil
+	}
+}
+
+// DecoderOf adapts an untyped decoder to a DecodeFunc, a request that is
+// not a Req is returned as an error.
+func DecoderOf[Req any](decoder Decoder) DecodeFunc[Req] {
+	return func(ctx context.Context, r *http.Request) (Req, error) {
+		var typedRequest Req
+
+		request, err := decoder.Decode(ctx, r)
+		if err != nil {
+			return typedRequest, err
+		}
+
+		if request == nil {
+			return typedRequest, nil
+		}
+
+		typedRequest, ok := request.(Req)
+		if !ok {
+			return typedRequest, fmt.Errorf("decoded request must be %s but it was %T", typeName[Req](), request)
+		}
+
+		return typedRequest, nil
+	}
+}
+
+// typeName returns the name of T, even if T is an interface.
+func typeName[T any]() string {
+	return reflect.TypeOf((*T)(nil)).Elem().String()
+}
+
+// EncoderOf adapts an untyped encoder to an EncodeFunc.
+func EncoderOf[Resp any](encoder Encoder) EncodeFunc[Resp] {
+	return func(ctx context.Context, w http.ResponseWriter, response Resp) error {
+		return encoder.Encode(ctx, w, response)
+	}
+}
+
+// contextWithRequestMetadata adds the actor and request id headers to the
+// request context, so they can be part of the audit records. The actor
+// header is not authenticated, so it is only recorded as asserted.
+func contextWithRequestMetadata(req *http.Request) context.Context {
+	ctx := req.Context()
+
+	if actor := req.Header.Get(ActorHeader); actor != "" {
+		ctx = pets.WithAssertedActor(ctx, actor)
+	}
+
+	if requestID := req.Header.Get(RequestIDHeader); requestID != "" {
+		ctx = pets.WithRequestID(ctx, requestID)
+	}
+
+	return ctx
+}
+
+// encodeError encodes the error with the codec chosen for the response,
+// requests that cannot be decoded are answered with 400, other errors with
+// 500.
+func (h *Handler[Req, Resp]) encodeError(ctx context.Context, statusCode int, err error, w http.ResponseWriter) {
 	newErrorMessage := ErrorResponse{
 		Message: err.Error(),
 	}
 
-	content, errMarshal := json.Marshal(newErrorMessage)
+	codec := responseCodec(ctx)
+	contentType := codec.ContentType()
+
+	content, errMarshal := codec.Marshal(newErrorMessage)
 	if errMarshal != nil {
-		h.logger.Error("unable to marshal error response into json", "error", errMarshal)
+		slog.Error("unable to marshal error response", "error", errMarshal)
 
 		content = defaultErrorResponse
+		contentType = jsonContentType
 	}
 
-	w.Header().Set("Content-Type", jsonContentType)
+	w.Header().Set("Content-Type", contentType)
 
-	w.WriteHeader(http.StatusInternalServerError)
+	w.WriteHeader(statusCode)
 	w.Write(content)
 }
@@ -0,0 +1,132 @@
+package web_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/fernandoocampo/basic-micro/internal/adapter/web"
+	"github.com/stretchr/testify/assert"
+)
+
+// legacyEndpoint is an untyped endpoint that answers with the given response.
+type legacyEndpoint struct {
+	response any
+}
+
+// legacyDecoder is an untyped decoder that answers with the request path.
+type legacyDecoder struct{}
+
+// legacyEncoder is an untyped encoder that writes a string response.
+type legacyEncoder struct{}
+
+func (l legacyEndpoint) Do(ctx context.Context, request any) (any, error) {
+	return l.response, nil
+}
+
+func (l legacyDecoder) Decode(ctx context.Context, r *http.Request) (any, error) {
+	return r.URL.Path, nil
+}
+
+func (l legacyEncoder) Encode(ctx context.Context, w http.ResponseWriter, response any) error {
+	_, err := io.WriteString(w, response.(string))
+
+	return err
+}
+
+func TestLegacyHandler(t *testing.T) {
+	// Given
+	handler := web.NewHandler().
+		WithEndpoint(legacyEndpoint{response: "drila"}).
+		WithDecoder(legacyDecoder{}).
+		WithEncoder(legacyEncoder{})
+	request := httptest.NewRequest(http.MethodGet, "/pets", nil)
+	recorder := httptest.NewRecorder()
+
+	// When
+	handler.ServeHTTP(recorder, request)
+
+	// Then
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.Equal(t, "drila", recorder.Body.String())
+}
+
+func TestEndpointOfWithWrongResponseType(t *testing.T) {
+	// Given
+	endpoint := web.EndpointOf[string, string](legacyEndpoint{response: 10})
+
+	// When
+	got, err := endpoint(context.TODO(), "/pets")
+
+	// Then
+	assert.EqualError(t, err, "endpoint response must be string but it was int")
+	assert.Empty(t, got)
+}
+
+func TestTypedHandler(t *testing.T) {
+	// Given
+	decoder := func(ctx context.Context, r *http.Request) (string, error) {
+		return r.URL.Query().Get("name"), nil
+	}
+	endpoint := func(ctx context.Context, name string) (int, error) {
+		return len(name), nil
+	}
+	encoder := func(ctx context.Context, w http.ResponseWriter, length int) error {
+		w.WriteHeader(http.StatusAccepted + length)
+
+		return nil
+	}
+	handler := web.NewTypedHandler(decoder, endpoint, encoder)
+	request := httptest.NewRequest(http.MethodGet, "/pets?name=drila", nil)
+	recorder := httptest.NewRecorder()
+
+	// When
+	handler.ServeHTTP(recorder, request)
+
+	// Then
+	assert.Equal(t, http.StatusAccepted+5, recorder.Code)
+}
+
+func TestTypedHandlerErrors(t *testing.T) {
+	cases := map[string]struct {
+		decodeErr   error
+		endpointErr error
+		wantStatus  int
+	}{
+		"request cannot be decoded": {
+			decodeErr:  errors.New("invalid page parameter, it must be a number"),
+			wantStatus: http.StatusBadRequest,
+		},
+		"endpoint failed": {
+			endpointErr: errors.New("unable to call endpoint"),
+			wantStatus:  http.StatusInternalServerError,
+		},
+	}
+
+	for name, testCase := range cases {
+		t.Run(name, func(t *testing.T) {
+			// Given
+			decoder := func(ctx context.Context, r *http.Request) (string, error) {
+				return "", testCase.decodeErr
+			}
+			endpoint := func(ctx context.Context, name string) (string, error) {
+				return "", testCase.endpointErr
+			}
+			encoder := func(ctx context.Context, w http.ResponseWriter, response string) error {
+				return nil
+			}
+			handler := web.NewTypedHandler(decoder, endpoint, encoder)
+			request := httptest.NewRequest(http.MethodGet, "/pets?page=one", nil)
+			recorder := httptest.NewRecorder()
+
+			// When
+			handler.ServeHTTP(recorder, request)
+
+			// Then
+			assert.Equal(t, testCase.wantStatus, recorder.Code)
+		})
+	}
+}
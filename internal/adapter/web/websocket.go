@@ -0,0 +1,390 @@
+package web
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fernandoocampo/basic-micro/internal/adapter/events"
+	"github.com/fernandoocampo/basic-micro/internal/pets"
+	"github.com/gorilla/websocket"
+)
+
+// WebSocketSetup contains the data to create the websocket handler.
+type WebSocketSetup struct {
+	Broker *events.Broker
+	// Token is the bearer token clients must send on connect, in the
+	// Authorization header or the access_token query parameter. Clients
+	// are not authenticated if it is empty.
+	Token string
+	// PingInterval how often clients are pinged, a client that does not
+	// answer within two intervals is disconnected. DefaultPingInterval if
+	// it is not positive.
+	PingInterval time.Duration
+	// SendBuffer number of replies kept for a client that is not reading,
+	// DefaultSendBuffer if it is not positive. When it is full the client
+	// is disconnected.
+	SendBuffer int
+	Logger     *slog.Logger
+}
+
+// WebSocketHandler pushes the changes of the pets a client subscribed to.
+// Clients send subscribe and unsubscribe messages with pet ids and receive
+// an event message for every change of those pets. Connections are
+// hijacked, so the http server does not drain them, see Wait.
+type WebSocketHandler struct {
+	broker       *events.Broker
+	token        string
+	pingInterval time.Duration
+	sendBuffer   int
+	upgrader     websocket.Upgrader
+	connections  sync.WaitGroup
+	logger       *slog.Logger
+}
+
+// webSocketRequest is a message sent by a client.
+type webSocketRequest struct {
+	Type string `json:"type"`
+	// ID is echoed in the reply, so clients can match them.
+	ID     string   `json:"id"`
+	PetIDs []string `json:"pet_ids"`
+}
+
+// webSocketMessage is a message sent to a client.
+type webSocketMessage struct {
+	Type    string            `json:"type"`
+	ID      string            `json:"id,omitempty"`
+	PetIDs  []pets.PetID      `json:"pet_ids,omitempty"`
+	EventID uint64            `json:"event_id,omitempty"`
+	Event   *pets.AuditRecord `json:"event,omitempty"`
+	Message string            `json:"message,omitempty"`
+}
+
+// webSocketConnection is a connected client, pets is the set of pets it
+// subscribed to.
+type webSocketConnection struct {
+	conn         *websocket.Conn
+	subscription *events.Subscription
+	replies      chan webSocketMessage
+	// done is closed when the client stops reading or is disconnected.
+	done  chan struct{}
+	mutex sync.Mutex
+	pets  map[pets.PetID]struct{}
+}
+
+// websocket defaults and limits.
+const (
+	DefaultPingInterval = 30 * time.Second
+	DefaultSendBuffer   = 16
+	// MaxSubscribedPets is the largest number of pets a client can
+	// subscribe to.
+	MaxSubscribedPets  = 100
+	webSocketWriteWait = 10 * time.Second
+	webSocketReadLimit = 16 << 10
+)
+
+// message types
+const (
+	subscribeMessage    = "subscribe"
+	unsubscribeMessage  = "unsubscribe"
+	subscribedMessage   = "subscribed"
+	unsubscribedMessage = "unsubscribed"
+	eventMessage        = "event"
+	errorMessage        = "error"
+)
+
+var (
+	errUnauthorized     = ErrorResponse{Message: "a valid bearer token is required"}
+	errEmptyPetIDs      = errors.New("pet_ids cannot be empty")
+	errTooManyPets      = fmt.Errorf("cannot subscribe to more than %d pets", MaxSubscribedPets)
+	errUnknownMessage   = fmt.Errorf("unknown message type, it must be %s or %s", subscribeMessage, unsubscribeMessage)
+	errInvalidWSMessage = errors.New("message must be a JSON object")
+)
+
+func NewWebSocketHandler(setup WebSocketSetup) *WebSocketHandler {
+	newWebSocketHandler := WebSocketHandler{
+		broker:       setup.Broker,
+		token:        setup.Token,
+		pingInterval: setup.PingInterval,
+		sendBuffer:   setup.SendBuffer,
+		logger:       setup.Logger,
+	}
+
+	if newWebSocketHandler.pingInterval <= 0 {
+		newWebSocketHandler.pingInterval = DefaultPingInterval
+	}
+
+	if newWebSocketHandler.sendBuffer <= 0 {
+		newWebSocketHandler.sendBuffer = DefaultSendBuffer
+	}
+
+	newWebSocketHandler.upgrader.Error = func(rw http.ResponseWriter, req *http.Request, status int, reason error) {
+		writeErrorResponse(rw, status, ErrorResponse{Message: reason.Error()})
+	}
+
+	return &newWebSocketHandler
+}
+
+// ServeHTTP authenticates the client and upgrades the connection, the
+// connection is served until the client leaves or the broker is closed.
+func (w *WebSocketHandler) ServeHTTP(rw http.ResponseWriter, req *http.Request) {
+	if !w.authenticated(req) {
+		rw.Header().Set("WWW-Authenticate", "Bearer")
+		writeErrorResponse(rw, http.StatusUnauthorized, errUnauthorized)
+
+		return
+	}
+
+	subscription, err := w.broker.Subscribe(events.Filter{}, 0)
+	if err != nil {
+		writeErrorResponse(rw, http.StatusServiceUnavailable, errEventsUnavailable)
+
+		return
+	}
+	defer subscription.Close()
+
+	conn, err := w.upgrader.Upgrade(rw, req, nil)
+	if err != nil {
+		w.logger.Debug("upgrading websocket connection", "error", err)
+
+		return
+	}
+	defer conn.Close()
+
+	w.connections.Add(1)
+	defer w.connections.Done()
+
+	connection := webSocketConnection{
+		conn:         conn,
+		subscription: subscription,
+		replies:      make(chan webSocketMessage, w.sendBuffer),
+		done:         make(chan struct{}),
+		pets:         make(map[pets.PetID]struct{}),
+	}
+
+	go w.read(&connection)
+
+	w.write(&connection)
+}
+
+// Wait waits until the connections end, they end after the broker is
+// closed. It returns ctx error if ctx is done first.
+func (w *WebSocketHandler) Wait(ctx context.Context) error {
+	done := make(chan struct{})
+
+	go func() {
+		w.connections.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// authenticated says if the request has the token, every request is
+// authenticated when there is not a token.
+func (w *WebSocketHandler) authenticated(req *http.Request) bool {
+	if w.token == "" {
+		return true
+	}
+
+	token := req.URL.Query().Get("access_token")
+	if bearer, ok := strings.CutPrefix(req.Header.Get("Authorization"), "Bearer "); ok {
+		token = bearer
+	}
+
+	return subtle.ConstantTimeCompare([]byte(token), []byte(w.token)) == 1
+}
+
+// read handles the messages of the client until it leaves, the replies
+// are sent by write.
+func (w *WebSocketHandler) read(connection *webSocketConnection) {
+	defer close(connection.done)
+
+	pongWait := 2 * w.pingInterval
+
+	connection.conn.SetReadLimit(webSocketReadLimit)
+	connection.conn.SetReadDeadline(time.Now().Add(pongWait))
+	connection.conn.SetPongHandler(func(string) error {
+		return connection.conn.SetReadDeadline(time.Now().Add(pongWait))
+	})
+
+	for {
+		_, content, err := connection.conn.ReadMessage()
+		if err != nil {
+			if websocket.IsUnexpectedCloseError(err, websocket.CloseGoingAway, websocket.CloseNormalClosure) {
+				w.logger.Debug("reading websocket message", "error", err)
+			}
+
+			return
+		}
+
+		select {
+		case connection.replies <- connection.handle(content):
+		default:
+			w.logger.Warn("closing websocket connection that is not reading replies")
+			connection.close(websocket.ClosePolicyViolation, "send buffer is full")
+
+			return
+		}
+	}
+}
+
+// write sends the replies, the events of the subscribed pets and the pings
+// until the client leaves or the subscription ends.
+func (w *WebSocketHandler) write(connection *webSocketConnection) {
+	ping := time.NewTicker(w.pingInterval)
+	defer ping.Stop()
+
+	for {
+		var err error
+
+		select {
+		case <-connection.done:
+			return
+		case reply := <-connection.replies:
+			err = connection.send(reply)
+		case event, ok := <-connection.subscription.Events():
+			if !ok {
+				// the broker ends subscriptions on shutdown or when the
+				// client is too slow, in both cases it can reconnect.
+				connection.close(websocket.CloseGoingAway, "event stream ended, reconnect")
+
+				return
+			}
+
+			if connection.subscribed(event.Record.PetID) {
+				err = connection.send(webSocketMessage{Type: eventMessage, EventID: event.ID, Event: &event.Record})
+			}
+		case <-ping.C:
+			err = connection.conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(webSocketWriteWait))
+		}
+
+		if err != nil {
+			w.logger.Debug("writing websocket message", "error", err)
+
+			return
+		}
+	}
+}
+
+// handle applies a message of the client and returns the reply.
+func (c *webSocketConnection) handle(content []byte) webSocketMessage {
+	var request webSocketRequest
+
+	err := json.Unmarshal(content, &request)
+	if err != nil {
+		return webSocketMessage{Type: errorMessage, Message: errInvalidWSMessage.Error()}
+	}
+
+	switch request.Type {
+	case subscribeMessage:
+		err = c.subscribe(request.PetIDs)
+		if err != nil {
+			return webSocketMessage{Type: errorMessage, ID: request.ID, Message: err.Error()}
+		}
+
+		return webSocketMessage{Type: subscribedMessage, ID: request.ID, PetIDs: c.subscribedPets()}
+	case unsubscribeMessage:
+		c.unsubscribe(request.PetIDs)
+
+		return webSocketMessage{Type: unsubscribedMessage, ID: request.ID, PetIDs: c.subscribedPets()}
+	}
+
+	return webSocketMessage{Type: errorMessage, ID: request.ID, Message: errUnknownMessage.Error()}
+}
+
+func (c *webSocketConnection) subscribe(petIDs []string) error {
+	if len(petIDs) == 0 {
+		return errEmptyPetIDs
+	}
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	added := 0
+	for _, id := range petIDs {
+		if _, ok := c.pets[pets.PetID(id)]; !ok && id != "" {
+			added++
+		}
+	}
+
+	if len(c.pets)+added > MaxSubscribedPets {
+		return errTooManyPets
+	}
+
+	for _, id := range petIDs {
+		if id != "" {
+			c.pets[pets.PetID(id)] = struct{}{}
+		}
+	}
+
+	return nil
+}
+
+// unsubscribe removes the pets, all of them if petIDs is empty.
+func (c *webSocketConnection) unsubscribe(petIDs []string) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if len(petIDs) == 0 {
+		clear(c.pets)
+
+		return
+	}
+
+	for _, id := range petIDs {
+		delete(c.pets, pets.PetID(id))
+	}
+}
+
+func (c *webSocketConnection) subscribed(id pets.PetID) bool {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	_, ok := c.pets[id]
+
+	return ok
+}
+
+// subscribedPets returns the pets of the connection ordered by id.
+func (c *webSocketConnection) subscribedPets() []pets.PetID {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	petIDs := make([]pets.PetID, 0, len(c.pets))
+	for id := range c.pets {
+		petIDs = append(petIDs, id)
+	}
+
+	slices.Sort(petIDs)
+
+	return petIDs
+}
+
+func (c *webSocketConnection) send(message webSocketMessage) error {
+	c.conn.SetWriteDeadline(time.Now().Add(webSocketWriteWait))
+
+	return c.conn.WriteJSON(message)
+}
+
+// close sends a close message, it can be called while write sends
+// messages.
+func (c *webSocketConnection) close(code int, reason string) {
+	message := websocket.FormatCloseMessage(code, reason)
+
+	c.conn.WriteControl(websocket.CloseMessage, message, time.Now().Add(webSocketWriteWait))
+	c.conn.Close()
+}
@@ -0,0 +1,230 @@
+package web_test
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/fernandoocampo/basic-micro/internal/adapter/events"
+	"github.com/fernandoocampo/basic-micro/internal/adapter/web"
+	"github.com/fernandoocampo/basic-micro/internal/pets"
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// webSocketMessage is a message sent by the websocket handler.
+type webSocketMessage struct {
+	Type    string            `json:"type"`
+	ID      string            `json:"id"`
+	PetIDs  []string          `json:"pet_ids"`
+	EventID uint64            `json:"event_id"`
+	Event   *pets.AuditRecord `json:"event"`
+	Message string            `json:"message"`
+}
+
+func TestWebSocketPushesTheEventsOfSubscribedPets(t *testing.T) {
+	// Given
+	broker := events.NewBroker(events.BrokerSetup{Logger: newDummyLogger()})
+	_, _, address := startWebSocketServer(t, web.WebSocketSetup{Broker: broker})
+	conn := dialWebSocket(t, address, nil)
+
+	// When
+	subscribed := request(t, conn, map[string]any{"type": "subscribe", "id": "1", "pet_ids": []string{"drila", "michi"}})
+	publish(broker,
+		pets.AuditRecord{PetID: "lucas", Action: pets.AuditUpdated},
+		pets.AuditRecord{PetID: "drila", Action: pets.AuditDeleted, Actor: "alice"},
+	)
+	event := receive(t, conn)
+	unsubscribed := request(t, conn, map[string]any{"type": "unsubscribe", "id": "2", "pet_ids": []string{"drila"}})
+	publish(broker, pets.AuditRecord{PetID: "drila", Action: pets.AuditRestored})
+	unsubscribedAll := request(t, conn, map[string]any{"type": "unsubscribe", "id": "3"})
+
+	// Then
+	assert.Equal(t, webSocketMessage{Type: "subscribed", ID: "1", PetIDs: []string{"drila", "michi"}}, subscribed)
+	assert.Equal(t, "event", event.Type)
+	assert.Equal(t, uint64(2), event.EventID)
+	require.NotNil(t, event.Event)
+	assert.Equal(t, pets.PetID("drila"), event.Event.PetID)
+	assert.Equal(t, "alice", event.Event.Actor)
+	assert.Equal(t, webSocketMessage{Type: "unsubscribed", ID: "2", PetIDs: []string{"michi"}}, unsubscribed)
+	assert.Equal(t, webSocketMessage{Type: "unsubscribed", ID: "3"}, unsubscribedAll)
+}
+
+func TestWebSocketInvalidMessages(t *testing.T) {
+	// Given
+	broker := events.NewBroker(events.BrokerSetup{Logger: newDummyLogger()})
+	_, _, address := startWebSocketServer(t, web.WebSocketSetup{Broker: broker})
+	conn := dialWebSocket(t, address, nil)
+	tooManyPets := make([]string, web.MaxSubscribedPets+1)
+	for index := range tooManyPets {
+		tooManyPets[index] = strings.Repeat("p", index+1)
+	}
+
+	// When
+	require.NoError(t, conn.WriteMessage(websocket.TextMessage, []byte("drila")))
+	notJSON := receive(t, conn)
+	unknownType := request(t, conn, map[string]any{"type": "adopt", "id": "1"})
+	emptyPets := request(t, conn, map[string]any{"type": "subscribe", "id": "2"})
+	tooMany := request(t, conn, map[string]any{"type": "subscribe", "id": "3", "pet_ids": tooManyPets})
+
+	// Then
+	assert.Equal(t, webSocketMessage{Type: "error", Message: "message must be a JSON object"}, notJSON)
+	assert.Equal(t, "error", unknownType.Type)
+	assert.Equal(t, "1", unknownType.ID)
+	assert.Contains(t, unknownType.Message, "unknown message type")
+	assert.Equal(t, webSocketMessage{Type: "error", ID: "2", Message: "pet_ids cannot be empty"}, emptyPets)
+	assert.Equal(t, webSocketMessage{Type: "error", ID: "3", Message: "cannot subscribe to more than 100 pets"}, tooMany)
+}
+
+func TestWebSocketAuthentication(t *testing.T) {
+	cases := map[string]struct {
+		path       string
+		header     http.Header
+		wantStatus int
+	}{
+		"without token": {
+			path:       "/ws",
+			wantStatus: http.StatusUnauthorized,
+		},
+		"invalid token": {
+			path:       "/ws",
+			header:     http.Header{"Authorization": []string{"Bearer drila"}},
+			wantStatus: http.StatusUnauthorized,
+		},
+		"authorization header": {
+			path:       "/ws",
+			header:     http.Header{"Authorization": []string{"Bearer s3cr3t"}},
+			wantStatus: http.StatusSwitchingProtocols,
+		},
+		"query parameter": {
+			path:       "/ws?access_token=s3cr3t",
+			wantStatus: http.StatusSwitchingProtocols,
+		},
+	}
+
+	for name, testCase := range cases {
+		t.Run(name, func(t *testing.T) {
+			// Given
+			broker := events.NewBroker(events.BrokerSetup{Logger: newDummyLogger()})
+			_, _, address := startWebSocketServer(t, web.WebSocketSetup{Broker: broker, Token: "s3cr3t"})
+
+			// When
+			conn, response, err := websocket.DefaultDialer.Dial(webSocketURL(address, testCase.path), testCase.header)
+			if err == nil {
+				conn.Close()
+			}
+
+			// Then
+			require.NotNil(t, response)
+			assert.Equal(t, testCase.wantStatus, response.StatusCode)
+		})
+	}
+}
+
+func TestWebSocketKeepalive(t *testing.T) {
+	// Given
+	broker := events.NewBroker(events.BrokerSetup{Logger: newDummyLogger()})
+	_, _, address := startWebSocketServer(t, web.WebSocketSetup{Broker: broker, PingInterval: 20 * time.Millisecond})
+	conn := dialWebSocket(t, address, nil)
+	pinged := make(chan struct{}, 1)
+	// the client does not answer with a pong.
+	conn.SetPingHandler(func(string) error {
+		select {
+		case pinged <- struct{}{}:
+		default:
+		}
+
+		return nil
+	})
+
+	// When
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	_, _, err := conn.ReadMessage()
+
+	// Then
+	assert.Len(t, pinged, 1)
+	assert.Error(t, err)
+}
+
+func TestWebSocketConnectionsEndOnShutdown(t *testing.T) {
+	// Given
+	broker := events.NewBroker(events.BrokerSetup{Logger: newDummyLogger()})
+	server, handler, address := startWebSocketServer(t, web.WebSocketSetup{Broker: broker})
+	conn := dialWebSocket(t, address, nil)
+	request(t, conn, map[string]any{"type": "subscribe", "pet_ids": []string{"drila"}})
+
+	// When
+	err := server.Shutdown(context.Background())
+	require.NoError(t, err)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	errWait := handler.Wait(ctx)
+	_, _, errRead := conn.ReadMessage()
+
+	// Then
+	assert.NoError(t, errWait)
+	assert.True(t, websocket.IsCloseError(errRead, websocket.CloseGoingAway), errRead)
+}
+
+// startWebSocketServer serves the websocket handler on /ws, the broker is
+// closed when the server shuts down.
+func startWebSocketServer(t *testing.T, setup web.WebSocketSetup) (*web.Server, *web.WebSocketHandler, string) {
+	t.Helper()
+
+	setup.Logger = newDummyLogger()
+	handler := web.NewWebSocketHandler(setup)
+
+	mux := http.NewServeMux()
+	mux.Handle("/ws", handler)
+
+	server, address := startServer(t, web.ServerSetup{
+		Handler:      mux,
+		DrainTimeout: 5 * time.Second,
+		OnShutdown:   setup.Broker.Close,
+		Logger:       newDummyLogger(),
+	})
+	t.Cleanup(func() {
+		server.Shutdown(context.Background())
+	})
+
+	return server, handler, address
+}
+
+func dialWebSocket(t *testing.T, address string, header http.Header) *websocket.Conn {
+	t.Helper()
+
+	conn, _, err := websocket.DefaultDialer.Dial(webSocketURL(address, "/ws"), header)
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		conn.Close()
+	})
+
+	return conn
+}
+
+func webSocketURL(address, path string) string {
+	return "ws" + strings.TrimPrefix(address, "http") + path
+}
+
+// request sends the message and receives the reply.
+func request(t *testing.T, conn *websocket.Conn, message map[string]any) webSocketMessage {
+	t.Helper()
+
+	require.NoError(t, conn.WriteJSON(message))
+
+	return receive(t, conn)
+}
+
+func receive(t *testing.T, conn *websocket.Conn) webSocketMessage {
+	t.Helper()
+
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+
+	var message webSocketMessage
+	require.NoError(t, conn.ReadJSON(&message))
+
+	return message
+}
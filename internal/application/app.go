@@ -4,26 +4,28 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"io"
 	"log"
 	"log/slog"
-	"net/http"
+	"net"
 	"os"
 	"os/signal"
+	"sync/atomic"
 	"syscall"
+	"time"
 
+	"github.com/fernandoocampo/basic-micro/internal/adapter/bulk"
+	"github.com/fernandoocampo/basic-micro/internal/adapter/events"
+	"github.com/fernandoocampo/basic-micro/internal/adapter/health"
+	"github.com/fernandoocampo/basic-micro/internal/adapter/rpc"
 	"github.com/fernandoocampo/basic-micro/internal/adapter/stores"
 	"github.com/fernandoocampo/basic-micro/internal/adapter/telemetry"
 	"github.com/fernandoocampo/basic-micro/internal/adapter/web"
+	"github.com/fernandoocampo/basic-micro/internal/lifecycle"
 	"github.com/fernandoocampo/basic-micro/internal/pets"
 	"github.com/fernandoocampo/basic-micro/internal/setups"
 )
 
-// Event contains an application event.
-type Event struct {
-	Message string
-	Error   error
-}
-
 // Setup contains application metadata
 type Setup struct {
 	Version    string
@@ -34,17 +36,63 @@ type Setup struct {
 // Server is the server of our application.
 type Server struct {
 	logger     *slog.Logger
-	store      *stores.Store
-	setup      setups.Application
-	version    string
-	buildDate  string
-	commitHash string
+	store      pets.Storer
+	auditStore pets.AuditStorer
+	// storeCloser closes the store once the web server was drained.
+	storeCloser io.Closer
+	petService  *pets.Service
+	// importer runs the import jobs, they are stopped before the store
+	// is closed.
+	importer    *pets.Importer
+	webServer   *web.Server
+	webListener net.Listener
+	// grpcServer serves the pets service over grpc on its own port.
+	grpcServer   *rpc.Server
+	grpcListener net.Listener
+	// events keeps the changes made to pets for the event stream.
+	events *events.Broker
+	// webSocket serves the websocket connections, they are hijacked, so
+	// they are waited for after the web server was drained.
+	webSocket *web.WebSocketHandler
+	readiness *web.Readiness
+	// lifecycle starts and stops the components of the service.
+	lifecycle *lifecycle.Manager
+	// telemetryShutdown flushes the telemetry.
+	telemetryShutdown telemetry.ShutdownFunc
+	// exporterHealth records the errors of the telemetry exporters.
+	exporterHealth *telemetry.ExporterHealth
+	// logLevel, sampler, health and info are changed when the
+	// configuration is reloaded, see reloadConfiguration.
+	logLevel *slog.LevelVar
+	sampler  *telemetry.RatioSampler
+	health   *health.Registry
+	info     *web.InfoHandler
+	// live is the configuration with the reloaded parameters applied.
+	live atomic.Pointer[setups.Application]
+	// stop cancels the application context.
+	stop  context.CancelFunc
+	setup setups.Application
+	// configSources says where the configuration is loaded from.
+	configSources setups.Sources
+	// secretFiles are the files of the parameters read from _FILE
+	// environment variables.
+	secretFiles map[string]string
+	// dbPassword is the database password in use.
+	dbPassword string
+	logOutput  io.Writer
+	// build contains the metadata injected through ldflags.
+	build     Setup
+	startedAt time.Time
 }
 
 const ServiceName = "basic-micro"
 
+// telemetryShutdownTimeout how long telemetry has to flush on shutdown.
+const telemetryShutdownTimeout = 5 * time.Second
+
+// build metadata injected through ldflags, Version is dev for local builds.
 var (
-	Version    string
+	Version    = "dev"
 	BuildDate  string
 	CommitHash string
 )
@@ -55,14 +103,29 @@ var (
 
 func New() *Server {
 	newServer := Server{
-		version:    Version,
-		buildDate:  BuildDate,
-		commitHash: CommitHash,
+		logOutput: os.Stdout,
+		readiness: web.NewReadiness(),
+		logLevel:  new(slog.LevelVar),
+		build: Setup{
+			Version:    Version,
+			BuildDate:  BuildDate,
+			CommitHash: CommitHash,
+		},
 	}
 
 	return &newServer
 }
 
+// WithConfigSources sets where the configuration is loaded from, by
+// default it is loaded from the environment and the CONFIG_FILE file.
+func (s *Server) WithConfigSources(sources setups.Sources) *Server {
+	s.configSources = sources
+
+	return s
+}
+
+// Run starts the components of the service and runs them until the
+// application is stopped or a component fails.
 func (s *Server) Run() error {
 	slog.Info("starting server")
 	ctx, stop := s.initializeApplication()
@@ -80,51 +143,160 @@ func (s *Server) Run() error {
 		return errStartingApplication
 	}
 
-	slog.Info("initialize telemetry")
-	shutdownTelemetry, telemetryErr := s.initializeTelemetry(ctx)
-	if telemetryErr != nil {
-		s.logger.Error("initializing telemetry", "error", telemetryErr.Error)
+	s.logger.Debug("application configuration", slog.String("parameters", fmt.Sprintf("%+v", s.setup)))
+
+	lifecycleSetup := lifecycle.Setup{
+		Logger: s.logger,
+	}
+	s.lifecycle = lifecycle.New(lifecycleSetup)
+	s.registerComponents()
+
+	err := s.lifecycle.Run(ctx)
+	if err != nil {
+		s.logger.Error("ending server with error", "error", err)
 
 		return errStartingApplication
 	}
-	defer shutdownTelemetry(ctx)
 
-	s.logger.Debug("application configuration", slog.String("parameters", fmt.Sprintf("%+v", s.setup)))
+	s.logger.Info("server was ended")
 
-	s.logger.Info("starting database connection")
-	err := s.createStorer()
+	return nil
+}
+
+// Import loads the pets read by reader and waits until it finishes. Logs
+// are written to stderr, so the report can be written to stdout.
+func (s *Server) Import(ctx context.Context, reader pets.ImportReader) (pets.ImportReport, error) {
+	err := s.initializeCommand(ctx)
 	if err != nil {
-		return errStartingApplication
+		return pets.ImportReport{}, err
 	}
 
-	s.logger.Info("initializing service")
-	petServiceSetup := pets.ServiceSetup{
-		Storer: s.store,
-		Logger: s.logger,
+	report, err := s.newPetService().Import(ctx, reader)
+	if err != nil {
+		return report, fmt.Errorf("unable to import pets: %w", err)
 	}
-	petService := pets.NewService(petServiceSetup)
 
-	s.logger.Info("initializing endpoints")
-	petEndpoints := pets.NewEndpoints(petService, s.logger)
+	return report, nil
+}
 
-	eventStream := Or(
-		s.stopApplication(ctx),
-		s.startWebServer(petEndpoints),
-	)
+// Export writes all pets with the given writer and returns how many were
+// written. Logs are written to stderr, so pets can be written to stdout.
+func (s *Server) Export(ctx context.Context, filter pets.ExportFilter, writer bulk.Writer) (int, error) {
+	err := s.initializeCommand(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	iterator, err := s.newPetService().Export(ctx, filter)
+	if err != nil {
+		return 0, fmt.Errorf("unable to export pets: %w", err)
+	}
+	defer iterator.Close()
+
+	exported := 0
+	for iterator.Next() {
+		err := writer.Write(iterator.Pet())
+		if err != nil {
+			return exported, fmt.Errorf("unable to write pet: %w", err)
+		}
+		exported++
+	}
+
+	if err := iterator.Err(); err != nil {
+		return exported, fmt.Errorf("unable to read pets: %w", err)
+	}
+
+	err = writer.Close()
+	if err != nil {
+		return exported, fmt.Errorf("unable to finish export: %w", err)
+	}
+
+	return exported, nil
+}
+
+// Migrate applies the database migrations that were not applied yet and
+// returns them.
+func (s *Server) Migrate(ctx context.Context) ([]string, error) {
+	err := s.initializeCommand(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	store, ok := s.store.(migrator)
+	if !ok {
+		return nil, nil
+	}
+
+	applied, err := store.Migrate(ctx)
+	if err != nil {
+		return applied, fmt.Errorf("unable to migrate database: %w", err)
+	}
+
+	return applied, nil
+}
+
+// Seed creates sample pets for test environments and returns how many
+// were created.
+func (s *Server) Seed(ctx context.Context) (int, error) {
+	err := s.initializeCommand(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	petService := s.newPetService()
+
+	created := 0
+	for _, newPet := range samplePets {
+		_, err := petService.Create(ctx, newPet)
+		if err != nil {
+			return created, fmt.Errorf("unable to seed pet %s: %w", newPet.Name, err)
+		}
+		created++
+	}
+
+	return created, nil
+}
+
+// initializeCommand loads what commands need to use the pets service.
+func (s *Server) initializeCommand(ctx context.Context) error {
+	s.logOutput = os.Stderr
 
-	eventMessage := <-eventStream
-	s.logger.Info("ending server", slog.String("event", eventMessage.Message))
+	confError := s.loadConfiguration()
+	if confError != nil {
+		return errStartingApplication
+	}
 
-	if eventMessage.Error != nil {
-		s.logger.Error("ending server with error", "error", eventMessage.Error)
+	loggerError := s.initializeLogger()
+	if loggerError != nil {
+		return errStartingApplication
+	}
 
+	err := s.createStorer(ctx)
+	if err != nil {
 		return errStartingApplication
 	}
 
 	return nil
 }
 
+func (s *Server) newPetService() *pets.Service {
+	petServiceSetup := pets.ServiceSetup{
+		Storer:          s.store,
+		AuditStorer:     s.auditStore,
+		ImportBatchSize: s.setup.ImportBatchSize,
+		Logger:          s.logger,
+	}
+
+	// commands that do not serve requests do not have events.
+	if s.events != nil {
+		petServiceSetup.Publisher = s.events
+	}
+
+	return pets.NewService(petServiceSetup)
+}
+
 func (s *Server) initializeApplication() (context.Context, context.CancelFunc) {
+	s.startedAt = time.Now()
 	s.notifyStart()
 
 	ctx, stopFunc := signal.NotifyContext(
@@ -134,22 +306,23 @@ func (s *Server) initializeApplication() (context.Context, context.CancelFunc) {
 		syscall.SIGTERM,
 	)
 
+	s.stop = stopFunc
+
 	return ctx, stopFunc
 }
 
+// initializeLogger creates the logger, its level is a slog.LevelVar, so it
+// can be changed when the configuration is reloaded.
 func (s *Server) initializeLogger() error {
-	logLevel := slog.LevelDebug
-
-	if s.setup.LogLevel == setups.ProductionLog {
-		slog.Info("setting log level", slog.String("level", "INFO"))
-		logLevel = slog.LevelInfo
-	}
+	logLevel := levelOf(s.setup.LogLevel)
+	slog.Info("setting log level", slog.String("level", logLevel.String()))
+	s.logLevel.Set(logLevel)
 
 	handlerOptions := &slog.HandlerOptions{
-		Level: logLevel,
+		Level: s.logLevel,
 	}
 
-	loggerHandler := slog.NewJSONHandler(os.Stdout, handlerOptions)
+	loggerHandler := slog.NewJSONHandler(s.logOutput, handlerOptions)
 	logger := slog.New(loggerHandler)
 
 	logger.Info(
@@ -165,9 +338,14 @@ func (s *Server) initializeLogger() error {
 }
 
 func (s *Server) initializeTelemetry(ctx context.Context) (func(context.Context), error) {
+	s.exporterHealth = telemetry.NewExporterHealth(s.setup.Health.TelemetryErrorWindow, s.logger)
+	s.sampler = telemetry.NewRatioSampler(s.setup.Telemetry.SampleRatio)
+
 	telemetrySetup := telemetry.OtelSDKSetup{
 		ServiceName:    ServiceName,
-		ServiceVersion: s.version,
+		ServiceVersion: s.build.Version,
+		Health:         s.exporterHealth,
+		Sampler:        s.sampler,
 		Logger:         s.logger,
 	}
 
@@ -184,124 +362,156 @@ func (s *Server) initializeTelemetry(ctx context.Context) (func(context.Context)
 func (s *Server) notifyStart() {
 	log.Println(
 		"starting service",
-		"version:", s.version,
-		"commit:", s.commitHash,
-		"build date:", s.buildDate,
+		"version:", s.build.Version,
+		"commit:", s.build.CommitHash,
+		"build date:", s.build.BuildDate,
 	)
 }
 
-// Stop stop application, take advantage of this to clean resources
+// Stop stops the application as a SIGTERM does, in-flight requests are
+// drained before Run returns.
 func (s *Server) Stop() {
 	s.logger.Info("stopping the application")
+
+	if s.stop != nil {
+		s.stop()
+	}
 }
 
-func (s *Server) listenToOSSignal() <-chan Event {
-	osSignalStream := make(chan Event)
-	go func() {
-		defer close(osSignalStream)
-		c := make(chan os.Signal, 1)
-		signal.Notify(c, syscall.SIGINT, syscall.SIGTERM)
-		osSignal := (<-c).String()
-		event := Event{
-			Message: osSignal,
-		}
-		osSignalStream <- event
-	}()
-	return osSignalStream
+// newInfoHandler creates the handler of the service info, its configuration
+// is changed when the configuration is reloaded.
+func (s *Server) newInfoHandler() *web.InfoHandler {
+	infoSetup := web.InfoSetup{
+		Version:       s.build.Version,
+		CommitHash:    s.build.CommitHash,
+		BuildDate:     s.build.BuildDate,
+		StartedAt:     s.startedAt,
+		StoreDriver:   s.setup.StoreDriver,
+		Configuration: configValues(s.setup),
+		Logger:        s.logger,
+	}
+
+	return web.NewInfoHandler(infoSetup)
 }
 
-// startWebServer starts the web server.
-func (s *Server) startWebServer(petEndpoints pets.Endpoints) <-chan Event {
-	serverSignalStream := make(chan Event)
-	go func() {
-		defer close(serverSignalStream)
-		s.logger.Info("starting http server", slog.String("port", s.setup.ApplicationPort))
-		router := petsRouter{
-			router:    web.NewRouter(),
-			endpoints: petEndpoints,
-			decoders:  web.NewPetDecoders(s.logger),
-			encoders:  web.NewPetEncoders(s.logger),
-		}
-		handler := newPetsRouter(router)
-		err := http.ListenAndServe(s.setup.ApplicationPort, handler)
-		if err != nil {
-			serverSignalStream <- Event{
-				Message: "web server was ended with error",
-				Error:   err,
+// newHealthRegistry registers the checks of the probes. Liveness does not
+// check dependencies, so a database outage does not restart the service.
+func (s *Server) newHealthRegistry() *health.Registry {
+	registrySetup := health.RegistrySetup{
+		Timeout:  s.setup.Health.Timeout,
+		CacheTTL: s.setup.Health.CacheTTL,
+		Logger:   s.logger,
+	}
+	registry := health.NewRegistry(registrySetup)
+
+	registry.Register(health.Check{
+		Name:    "shutdown",
+		Checker: health.ReadyChecker(s.readiness),
+		Probes:  []health.Probe{health.Readiness},
+		NoCache: true,
+	})
+
+	if s.lifecycle != nil {
+		registry.Register(health.Check{
+			Name:    "components",
+			Checker: s.lifecycle,
+			Probes:  []health.Probe{health.Readiness},
+			NoCache: true,
+		})
+	}
+
+	if pinger, ok := s.store.(health.Pinger); ok {
+		registry.Register(health.Check{
+			Name:    "store",
+			Checker: health.PingChecker(pinger),
+			Probes:  []health.Probe{health.Readiness, health.Startup},
+		})
+	}
+
+	if s.exporterHealth != nil {
+		registry.Register(health.Check{
+			Name:    "telemetry",
+			Checker: s.exporterHealth,
+			Probes:  []health.Probe{health.Readiness},
+		})
+	}
+
+	if s.setup.Health.DiskPath != "" {
+		registry.Register(health.Check{
+			Name:    "disk",
+			Checker: health.DiskSpaceChecker(s.setup.Health.DiskPath, s.setup.Health.DiskMinFreeBytes),
+			Probes:  []health.Probe{health.Readiness, health.Startup},
+		})
+	}
+
+	return registry
+}
+
+// runPurgeJob purges deleted pets older than the retention period until
+// the given context is done.
+func (s *Server) runPurgeJob(ctx context.Context) error {
+	ticker := time.NewTicker(s.setup.Purge.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			s.logger.Info("stopping purge job")
+
+			return nil
+		case <-ticker.C:
+			_, err := s.petService.Purge(ctx, s.setup.Purge.Retention)
+			if err != nil {
+				s.logger.Error("purging deleted pets", "error", err)
 			}
-			return
 		}
-		serverSignalStream <- Event{
-			Message: "web server was ended",
-		}
-	}()
-	return serverSignalStream
+	}
 }
 
 func (s *Server) loadConfiguration() error {
-	applicationSetUp, err := setups.Load()
+	configuration, err := setups.LoadFrom(s.configSources)
 	if err != nil {
 		log.Println("level", "ERROR", "msg", "application setup could not be loaded", "error", err)
 
 		return errors.New("application setup could not be loaded")
 	}
-	s.setup = applicationSetUp
+	s.setup = configuration.Application
+	s.secretFiles = configuration.SecretFiles
+	s.live.Store(&configuration.Application)
+
 	return nil
 }
 
-func (s *Server) createStorer() error {
+func (s *Server) createStorer(ctx context.Context) error {
 	storeSetup := stores.Setup{
 		Logger: s.logger,
 	}
-	storer := stores.NewStore(storeSetup)
-	s.store = storer
 
-	return nil
-}
-
-func (s *Server) stopApplication(ctx context.Context) <-chan Event {
-	stopAppSignal := make(chan Event)
+	switch s.setup.StoreDriver {
+	case setups.MemoryDriver:
+		memoryStore := stores.NewMemoryStore(storeSetup)
+		s.store = memoryStore
+		s.auditStore = memoryStore
+		s.storeCloser = memoryStore
+	case setups.PostgresDriver:
+		password, err := s.databasePassword(ctx)
+		if err != nil {
+			s.logger.Error("reading database password", "error", err)
 
-	go func(ctx context.Context) {
-		defer close(stopAppSignal)
-		<-ctx.Done()
-		message := ctx.Err().Error()
-		s.logger.Info("context was cancelled", "message", message)
-		stopAppSignal <- Event{
-			Message: fmt.Sprintf("context was cancelled: %s", message),
+			return errors.New("unable to read database password")
 		}
-	}(ctx)
 
-	return stopAppSignal
-}
+		s.dbPassword = password
+		storeSetup.Password = password
+		rdbmsStore := stores.NewStore(storeSetup)
+		s.store = rdbmsStore
+		s.auditStore = rdbmsStore
+		s.storeCloser = rdbmsStore
+	default:
+		s.logger.Error("unknown store driver", slog.String("driver", s.setup.StoreDriver))
 
-func Or(channels ...<-chan Event) <-chan Event {
-	switch len(channels) {
-	case 0:
-		return nil
-	case 1:
-		return channels[0]
+		return errors.New("unknown store driver")
 	}
 
-	orDone := make(chan Event)
-	go func() {
-		defer close(orDone)
-
-		switch len(channels) {
-		case 2:
-			select {
-			case <-channels[0]:
-			case <-channels[1]:
-			}
-		default:
-			select {
-			case <-channels[0]:
-			case <-channels[1]:
-			case <-channels[2]:
-			case <-Or(append(channels[3:], orDone)...):
-			}
-		}
-	}()
-
-	return orDone
+	return nil
 }
@@ -0,0 +1,280 @@
+package application
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net"
+	"time"
+
+	"github.com/fernandoocampo/basic-micro/internal/adapter/events"
+	"github.com/fernandoocampo/basic-micro/internal/adapter/rpc"
+	"github.com/fernandoocampo/basic-micro/internal/adapter/web"
+	"github.com/fernandoocampo/basic-micro/internal/lifecycle"
+	"github.com/fernandoocampo/basic-micro/internal/pets"
+)
+
+// backgroundRestart restarts the background jobs that failed or panicked.
+var backgroundRestart = lifecycle.RestartPolicy{
+	Mode:        lifecycle.RestartOnFailure,
+	MaxRestarts: 5,
+	Backoff:     time.Second,
+}
+
+// registerComponents registers the components in the order they start,
+// they stop in reverse order: the web server is drained before the grpc
+// server, so grpc health checks fail while the web server waits for the
+// readiness delay, then the store is closed and telemetry is flushed last,
+// so the shutdown is traced too. The event broker is created before the
+// store because the pets service publishes to it. Import jobs are stopped
+// after the servers and before the store is closed, so no job writes to a
+// closed store. The configuration is watched last, reloads change the
+// components started before it.
+func (s *Server) registerComponents() {
+	s.lifecycle.Register(lifecycle.Component{
+		Name:        "telemetry",
+		Start:       s.startTelemetry,
+		Stop:        s.stopTelemetry,
+		StopTimeout: telemetryShutdownTimeout,
+	})
+	s.lifecycle.Register(lifecycle.Component{
+		Name:  "events",
+		Start: s.startEvents,
+	})
+	s.lifecycle.Register(lifecycle.Component{
+		Name:  "store",
+		Start: s.startStore,
+		Stop:  s.closeStore,
+	})
+	s.lifecycle.Register(lifecycle.Component{
+		Name:  "imports",
+		Start: s.startImports,
+		Stop:  s.stopImports,
+	})
+	s.lifecycle.Register(lifecycle.Component{
+		Name:    "secrets",
+		Run:     s.refreshDatabasePassword,
+		Restart: backgroundRestart,
+	})
+	s.lifecycle.Register(lifecycle.Component{
+		Name:    "purge",
+		Run:     s.runPurgeJob,
+		Restart: backgroundRestart,
+	})
+	s.lifecycle.Register(lifecycle.Component{
+		Name:  "health",
+		Start: s.startHealth,
+	})
+	s.lifecycle.Register(lifecycle.Component{
+		Name:  "grpc",
+		Start: s.startGRPCServer,
+		Run:   s.runGRPCServer,
+		Stop:  s.shutdownGRPCServer,
+	})
+	s.lifecycle.Register(lifecycle.Component{
+		Name:  "web",
+		Start: s.startWebServer,
+		Run:   s.runWebServer,
+		Stop:  s.shutdownWebServer,
+	})
+	s.lifecycle.Register(lifecycle.Component{
+		Name:    "configuration",
+		Run:     s.watchConfiguration,
+		Restart: backgroundRestart,
+	})
+}
+
+func (s *Server) startTelemetry(ctx context.Context) error {
+	shutdown, err := s.initializeTelemetry(ctx)
+	if err != nil {
+		return err
+	}
+
+	s.telemetryShutdown = shutdown
+
+	return nil
+}
+
+// stopTelemetry flushes the telemetry.
+func (s *Server) stopTelemetry(ctx context.Context) error {
+	s.logger.Info("shutting down telemetry")
+	s.telemetryShutdown(ctx)
+
+	return nil
+}
+
+// startEvents creates the event broker the pets service publishes to, the
+// web server closes it when it starts to drain, so event streams end.
+func (s *Server) startEvents(ctx context.Context) error {
+	brokerSetup := events.BrokerSetup{
+		LogSize: s.setup.Events.LogSize,
+		Logger:  s.logger,
+	}
+	s.events = events.NewBroker(brokerSetup)
+
+	return nil
+}
+
+// startStore creates the store and the pets service that uses it.
+func (s *Server) startStore(ctx context.Context) error {
+	err := s.createStorer(ctx)
+	if err != nil {
+		return err
+	}
+
+	s.petService = s.newPetService()
+
+	return nil
+}
+
+// closeStore closes the store after the web server stopped using it.
+func (s *Server) closeStore(ctx context.Context) error {
+	if s.storeCloser == nil {
+		return nil
+	}
+
+	s.logger.Info("closing store")
+
+	return s.storeCloser.Close()
+}
+
+// startImports creates the importer that runs the import jobs of the pets
+// service.
+func (s *Server) startImports(ctx context.Context) error {
+	importerSetup := pets.ImporterSetup{
+		Service: s.petService,
+		MaxJobs: s.setup.ImportMaxJobs,
+		Logger:  s.logger,
+	}
+	s.importer = pets.NewImporter(importerSetup)
+
+	return nil
+}
+
+// stopImports cancels the running import jobs and waits for them, the
+// wait is bounded by the shutdown timeout.
+func (s *Server) stopImports(ctx context.Context) error {
+	s.logger.Info("stopping import jobs")
+
+	return s.importer.Stop(ctx)
+}
+
+// startHealth creates the health checks and the info handler shared by
+// the web and grpc servers.
+func (s *Server) startHealth(ctx context.Context) error {
+	s.health = s.newHealthRegistry()
+	s.info = s.newInfoHandler()
+
+	return nil
+}
+
+// startGRPCServer creates the grpc server and listens on its address, so
+// an address in use fails the start.
+func (s *Server) startGRPCServer(ctx context.Context) error {
+	serverSetup := rpc.ServerSetup{
+		Address:      s.setup.GRPCPort,
+		Endpoints:    pets.NewEndpoints(s.petService, s.importer, s.logger),
+		Health:       s.health,
+		DrainTimeout: s.setup.ShutdownTimeout,
+		Logger:       s.logger,
+	}
+	s.grpcServer = rpc.NewServer(serverSetup)
+
+	listener, err := net.Listen("tcp", s.setup.GRPCPort)
+	if err != nil {
+		return err
+	}
+
+	s.grpcListener = listener
+
+	return nil
+}
+
+func (s *Server) runGRPCServer(ctx context.Context) error {
+	s.logger.Info("starting grpc server", slog.String("port", s.setup.GRPCPort))
+
+	return s.grpcServer.Serve(s.grpcListener)
+}
+
+// shutdownGRPCServer drains the in-flight calls, the drain is bounded by
+// the shutdown timeout.
+func (s *Server) shutdownGRPCServer(ctx context.Context) error {
+	return s.grpcServer.Shutdown(ctx)
+}
+
+// startWebServer creates the web server and listens on its address, so
+// an address in use fails the start. The web server is kept, so it can be
+// drained on shutdown.
+func (s *Server) startWebServer(ctx context.Context) error {
+	webSocketSetup := web.WebSocketSetup{
+		Broker:       s.events,
+		Token:        s.setup.WebSocket.Token,
+		PingInterval: s.setup.WebSocket.PingInterval,
+		SendBuffer:   s.setup.WebSocket.SendBuffer,
+		Logger:       s.logger,
+	}
+	s.webSocket = web.NewWebSocketHandler(webSocketSetup)
+
+	routerSetup := RouterSetup{
+		Service:              s.petService,
+		Importer:             s.importer,
+		Health:               s.health,
+		Info:                 s.info,
+		IdempotencyTTL:       s.setup.IdempotencyTTL,
+		ValidateRequests:     s.setup.OpenAPI.ValidateRequests,
+		ValidateResponses:    s.setup.OpenAPI.ValidateResponses,
+		GraphQLPlayground:    s.setup.GraphQL.Playground,
+		GraphQLMaxComplexity: s.setup.GraphQL.MaxComplexity,
+		GraphQLMaxDepth:      s.setup.GraphQL.MaxDepth,
+		Events:               s.events,
+		EventsHeartbeat:      s.setup.Events.HeartbeatInterval,
+		WebSocket:            s.webSocket,
+		Version:              s.build.Version,
+		Logger:               s.logger,
+	}
+
+	handler, err := NewRouter(routerSetup)
+	if err != nil {
+		return fmt.Errorf("unable to create router: %w", err)
+	}
+
+	serverSetup := web.ServerSetup{
+		Address:        s.setup.ApplicationPort,
+		Handler:        handler,
+		Readiness:      s.readiness,
+		ReadinessDelay: s.setup.ShutdownDelay,
+		DrainTimeout:   s.setup.ShutdownTimeout,
+		OnShutdown:     s.events.Close,
+		Logger:         s.logger,
+	}
+	s.webServer = web.NewServer(serverSetup)
+
+	listener, err := net.Listen("tcp", s.setup.ApplicationPort)
+	if err != nil {
+		return err
+	}
+
+	s.webListener = listener
+
+	return nil
+}
+
+func (s *Server) runWebServer(ctx context.Context) error {
+	s.logger.Info("starting http server", slog.String("port", s.setup.ApplicationPort))
+
+	return s.webServer.Serve(s.webListener)
+}
+
+// shutdownWebServer drains the in-flight requests, the drain is bounded
+// by the shutdown timeout. Websocket connections are not drained by the
+// web server, they end when it closes the event broker.
+func (s *Server) shutdownWebServer(ctx context.Context) error {
+	err := s.webServer.Shutdown(ctx)
+
+	waitErr := s.webSocket.Wait(ctx)
+	if waitErr != nil {
+		s.logger.Error("waiting for websocket connections", "error", waitErr)
+	}
+
+	return err
+}
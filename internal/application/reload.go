@@ -0,0 +1,173 @@
+package application
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"syscall"
+	"time"
+
+	"github.com/fernandoocampo/basic-micro/internal/adapter/web"
+	"github.com/fernandoocampo/basic-micro/internal/setups"
+	"github.com/fsnotify/fsnotify"
+)
+
+// reloadDebounce groups the file events of a single save, editors and
+// kubernetes config maps write a file in several steps.
+const reloadDebounce = 100 * time.Millisecond
+
+// kubernetesDataLink is the symbolic link kubernetes swaps when a mounted
+// config map changes.
+const kubernetesDataLink = "..data"
+
+// watchConfiguration reloads the configuration on SIGHUP and when the
+// configuration file changes, until the given context is done.
+func (s *Server) watchConfiguration(ctx context.Context) error {
+	hangup := make(chan os.Signal, 1)
+	signal.Notify(hangup, syscall.SIGHUP)
+	defer signal.Stop(hangup)
+
+	var fileEvents <-chan fsnotify.Event
+	var fileErrors <-chan error
+
+	watcher, file := s.watchConfigFile()
+	if watcher != nil {
+		defer watcher.Close()
+
+		fileEvents = watcher.Events
+		fileErrors = watcher.Errors
+	}
+
+	var debounce <-chan time.Time
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-hangup:
+			s.logger.Info("reloading configuration", slog.String("reason", "SIGHUP"))
+			s.reloadConfiguration()
+		case event := <-fileEvents:
+			if filepath.Clean(event.Name) == file || filepath.Base(event.Name) == kubernetesDataLink {
+				debounce = time.After(reloadDebounce)
+			}
+		case <-debounce:
+			debounce = nil
+
+			s.logger.Info("reloading configuration", slog.String("reason", "configuration file changed"))
+			s.reloadConfiguration()
+		case err := <-fileErrors:
+			s.logger.Error("watching configuration file", "error", err)
+		}
+	}
+}
+
+// watchConfigFile watches the directory of the configuration file, so the
+// file can be replaced instead of written. It returns nil if there is no
+// configuration file or it cannot be watched.
+func (s *Server) watchConfigFile() (*fsnotify.Watcher, string) {
+	file := s.configSources.ConfigFile()
+	if file == "" {
+		return nil, ""
+	}
+
+	file, err := filepath.Abs(file)
+	if err != nil {
+		s.logger.Error("resolving configuration file", "error", err)
+
+		return nil, ""
+	}
+
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		s.logger.Error("creating configuration file watcher", "error", err)
+
+		return nil, ""
+	}
+
+	err = watcher.Add(filepath.Dir(file))
+	if err != nil {
+		s.logger.Error("watching configuration file", "error", err)
+		watcher.Close()
+
+		return nil, ""
+	}
+
+	return watcher, file
+}
+
+// reloadConfiguration loads the configuration again and applies the
+// parameters that can change while the application runs. An invalid
+// configuration is rejected as a whole and the current one is kept.
+func (s *Server) reloadConfiguration() {
+	configuration, err := setups.LoadFrom(s.configSources)
+	if err != nil {
+		s.logger.Error("configuration reload was rejected", "error", err)
+
+		return
+	}
+
+	current := *s.live.Load()
+
+	names := current.NotReloadable(configuration.Application)
+	if len(names) > 0 {
+		s.logger.Warn("configuration changes are ignored until restart", slog.Any("parameters", names))
+	}
+
+	s.applyConfiguration(current.WithReloadable(configuration.Application))
+
+	s.logger.Info("configuration was reloaded")
+}
+
+// applyConfiguration changes the running components with the reloadable
+// parameters of the given configuration.
+func (s *Server) applyConfiguration(setup setups.Application) {
+	s.logLevel.Set(levelOf(setup.LogLevel))
+
+	if s.sampler != nil {
+		s.sampler.SetRatio(setup.Telemetry.SampleRatio)
+	}
+
+	if s.webServer != nil {
+		s.webServer.SetTimeouts(setup.ShutdownDelay, setup.ShutdownTimeout)
+	}
+
+	if s.grpcServer != nil {
+		s.grpcServer.SetDrainTimeout(setup.ShutdownTimeout)
+	}
+
+	if s.health != nil {
+		s.health.SetTimeouts(setup.Health.Timeout, setup.Health.CacheTTL)
+	}
+
+	if s.info != nil {
+		s.info.SetConfiguration(configValues(setup))
+	}
+
+	s.live.Store(&setup)
+}
+
+// levelOf returns the log level of the log environment.
+func levelOf(logEnvironment string) slog.Level {
+	if logEnvironment == setups.ProductionLog {
+		return slog.LevelInfo
+	}
+
+	return slog.LevelDebug
+}
+
+// configValues returns the configuration shown by the service info with
+// the secrets redacted.
+func configValues(setup setups.Application) []web.ConfigValue {
+	var configuration []web.ConfigValue
+	for _, value := range setup.Values() {
+		configuration = append(configuration, web.ConfigValue{
+			Name:  value.Name,
+			Value: value.Redacted(),
+		})
+	}
+
+	return configuration
+}
@@ -1,55 +1,380 @@
 package application
 
 import (
+	"fmt"
+	"log/slog"
 	"net/http"
+	"time"
 
+	"github.com/fernandoocampo/basic-micro/docs"
+	"github.com/fernandoocampo/basic-micro/internal/adapter/events"
+	"github.com/fernandoocampo/basic-micro/internal/adapter/graph"
+	"github.com/fernandoocampo/basic-micro/internal/adapter/health"
 	"github.com/fernandoocampo/basic-micro/internal/adapter/web"
 	"github.com/fernandoocampo/basic-micro/internal/pets"
 	"github.com/gorilla/mux"
 )
 
 type petsRouter struct {
-	router    *mux.Router
-	endpoints pets.Endpoints
-	decoders  web.PetDecoders
-	encoders  web.PetEncoders
+	router      *mux.Router
+	endpoints   pets.Endpoints
+	decoders    web.PetDecoders
+	encoders    web.PetEncoders
+	idempotency *web.Idempotency
+	negotiation *web.Negotiation
+	health      *health.Registry
+	info        *web.InfoHandler
+	graphql     *graph.Handler
+	events      *web.EventsHandler
+	webSocket   *web.WebSocketHandler
+	// middlewares wrap every route, the first one is the outermost.
+	middlewares []web.Middleware
+	logger      *slog.Logger
 }
 
+// RouterSetup contains the dependencies of the pets HTTP API.
+type RouterSetup struct {
+	Service *pets.Service
+	// Importer runs the import jobs of Service, it is stopped by the owner
+	// of the router, so the jobs end before the store is closed.
+	Importer *pets.Importer
+	// Health and Info are optional, without them the probes do not have
+	// checks and the info only has the version.
+	Health *health.Registry
+	Info   *web.InfoHandler
+	// IdempotencyTTL how long responses are replayed, 24h by default.
+	IdempotencyTTL time.Duration
+	// ValidateRequests and ValidateResponses enable the validation of
+	// requests and responses against the OpenAPI spec.
+	ValidateRequests  bool
+	ValidateResponses bool
+	// GraphQLPlayground serves GraphiQL on /graphql, GraphQLMaxComplexity
+	// and GraphQLMaxDepth limit the operations, the graph package defaults
+	// are used when they are not positive.
+	GraphQLPlayground    bool
+	GraphQLMaxComplexity int
+	GraphQLMaxDepth      int
+	// Events is the broker the service publishes to, without it the event
+	// stream does not have events. EventsHeartbeat how often idle streams
+	// get a heartbeat.
+	Events          *events.Broker
+	EventsHeartbeat time.Duration
+	// WebSocket is optional, without it clients are not authenticated and
+	// its connections are not waited for on shutdown.
+	WebSocket *web.WebSocketHandler
+	Version   string
+	Logger    *slog.Logger
+}
+
+// Route is the method and path template of a route of the pets HTTP API.
+type Route struct {
+	Method string
+	Path   string
+}
+
+// defaultIdempotencyTTL is the IDEMPOTENCY_TTL default.
+const defaultIdempotencyTTL = 24 * time.Hour
+
+// NewRouter returns the handler of the pets HTTP API with the routes and
+// middlewares of the service, so it can be served by tests and other
+// programs too.
+func NewRouter(setup RouterSetup) (http.Handler, error) {
+	router, err := newRouter(setup)
+	if err != nil {
+		return nil, err
+	}
+
+	return newPetsRouter(router), nil
+}
+
+// Routes returns the routes of the pets HTTP API.
+func Routes() ([]Route, error) {
+	router, err := newRouter(RouterSetup{Logger: slog.Default()})
+	if err != nil {
+		return nil, err
+	}
+
+	newPetsRouter(router)
+
+	var routes []Route
+
+	err = router.router.Walk(func(route *mux.Route, _ *mux.Router, _ []*mux.Route) error {
+		path, err := route.GetPathTemplate()
+		if err != nil {
+			return err
+		}
+
+		methods, err := route.GetMethods()
+		if err != nil {
+			return err
+		}
+
+		for _, method := range methods {
+			routes = append(routes, Route{Method: method, Path: path})
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("unable to read routes: %w", err)
+	}
+
+	return routes, nil
+}
+
+// newRouter creates the pets router with the dependencies of the setup.
+func newRouter(setup RouterSetup) (petsRouter, error) {
+	if setup.Health == nil {
+		setup.Health = health.NewRegistry(health.RegistrySetup{Logger: setup.Logger})
+	}
+
+	if setup.Info == nil {
+		setup.Info = web.NewInfoHandler(web.InfoSetup{Version: setup.Version, Logger: setup.Logger})
+	}
+
+	if setup.IdempotencyTTL <= 0 {
+		setup.IdempotencyTTL = defaultIdempotencyTTL
+	}
+
+	idempotencySetup := web.IdempotencySetup{
+		Store:  web.NewMemoryIdempotencyStore(),
+		TTL:    setup.IdempotencyTTL,
+		Logger: setup.Logger,
+	}
+	negotiationSetup := web.NegotiationSetup{
+		Codecs: web.NewDefaultCodecs(),
+		Logger: setup.Logger,
+	}
+	middlewares := []web.Middleware{
+		web.ServerVersion(setup.Version),
+		web.RequestID,
+		web.AccessLog(setup.Logger),
+		web.Recovery(setup.Logger),
+	}
+
+	if setup.ValidateRequests || setup.ValidateResponses {
+		validatorSetup := web.OpenAPIValidatorSetup{
+			Spec:              docs.OpenAPI,
+			ValidateRequests:  setup.ValidateRequests,
+			ValidateResponses: setup.ValidateResponses,
+			Logger:            setup.Logger,
+		}
+
+		validator, err := web.NewOpenAPIValidator(validatorSetup)
+		if err != nil {
+			return petsRouter{}, err
+		}
+
+		middlewares = append(middlewares, validator.Wrap)
+	}
+
+	if setup.Events == nil {
+		setup.Events = events.NewBroker(events.BrokerSetup{Logger: setup.Logger})
+	}
+
+	if setup.WebSocket == nil {
+		setup.WebSocket = web.NewWebSocketHandler(web.WebSocketSetup{Broker: setup.Events, Logger: setup.Logger})
+	}
+
+	eventsSetup := web.EventsHandlerSetup{
+		Broker:    setup.Events,
+		Heartbeat: setup.EventsHeartbeat,
+		Logger:    setup.Logger,
+	}
+
+	graphqlSetup := graph.HandlerSetup{
+		Service:       setup.Service,
+		MaxComplexity: setup.GraphQLMaxComplexity,
+		MaxDepth:      setup.GraphQLMaxDepth,
+		Playground:    setup.GraphQLPlayground,
+		Logger:        setup.Logger,
+	}
+
+	graphqlHandler, err := graph.NewHandler(graphqlSetup)
+	if err != nil {
+		return petsRouter{}, err
+	}
+
+	router := petsRouter{
+		router:      web.NewRouter(),
+		endpoints:   pets.NewEndpoints(setup.Service, setup.Importer, setup.Logger),
+		decoders:    web.NewPetDecoders(setup.Logger),
+		encoders:    web.NewPetEncoders(setup.Logger),
+		idempotency: web.NewIdempotency(idempotencySetup),
+		negotiation: web.NewNegotiation(negotiationSetup),
+		health:      setup.Health,
+		info:        setup.Info,
+		graphql:     graphqlHandler,
+		events:      web.NewEventsHandler(eventsSetup),
+		webSocket:   setup.WebSocket,
+		logger:      setup.Logger,
+		middlewares: middlewares,
+	}
+
+	return router, nil
+}
+
+// newPetsRouter registers the pets routes. Import and export bodies are
+// CSV or NDJSON, so their content types are not negotiated.
 func newPetsRouter(petsRouter petsRouter) http.Handler {
+	petsRouter.router.Methods(http.MethodGet).Path("/openapi.yaml").Handler(
+		web.NewOpenAPIHandler(docs.OpenAPI),
+	)
+	petsRouter.router.Methods(http.MethodGet).Path("/docs").Handler(
+		web.NewAPIDocsHandler("/openapi.yaml"),
+	)
+
+	petsRouter.router.Methods(http.MethodGet).Path("/info").Handler(
+		petsRouter.negotiation.Wrap(petsRouter.info),
+	)
+
+	petsRouter.router.Methods(http.MethodGet, http.MethodPost).Path("/graphql").Handler(
+		petsRouter.graphql,
+	)
+
+	petsRouter.router.Methods(http.MethodGet).Path("/ws").Handler(
+		petsRouter.webSocket,
+	)
+
+	petsRouter.router.Methods(http.MethodGet).Path("/healthz/live").Handler(
+		web.NewHealthHandler(petsRouter.health, health.Liveness, petsRouter.logger),
+	)
+	petsRouter.router.Methods(http.MethodGet).Path("/healthz/ready").Handler(
+		web.NewHealthHandler(petsRouter.health, health.Readiness, petsRouter.logger),
+	)
+	petsRouter.router.Methods(http.MethodGet).Path("/healthz/startup").Handler(
+		web.NewHealthHandler(petsRouter.health, health.Startup, petsRouter.logger),
+	)
+
 	petsRouter.router.Methods(http.MethodPost).Path("/pets").Handler(
-		web.NewHandler().
-			WithEndpoint(petsRouter.endpoints.CreatePetEndpoint).
-			WithDecoder(petsRouter.decoders.CreateDecoder).
-			WithEncoder(petsRouter.encoders.CreateEncoder),
+		web.Chain(petsRouter.negotiation.Wrap, petsRouter.idempotency.Wrap)(
+			web.NewTypedHandler(
+				petsRouter.decoders.CreateDecoder.Decode,
+				petsRouter.endpoints.CreatePetEndpoint.Do,
+				petsRouter.encoders.CreateEncoder.Encode,
+			),
+		),
+	)
+
+	petsRouter.router.Methods(http.MethodPost).Path("/pets:batch").Handler(
+		petsRouter.negotiation.Wrap(
+			web.NewTypedHandler(
+				petsRouter.decoders.BatchDecoder.Decode,
+				petsRouter.endpoints.BatchPetsEndpoint.Do,
+				petsRouter.encoders.BatchEncoder.Encode,
+			),
+		),
+	)
+
+	petsRouter.router.Methods(http.MethodPost).Path("/pets/import").Handler(
+		web.NewTypedHandler(
+			petsRouter.decoders.StartImportDecoder.Decode,
+			petsRouter.endpoints.StartImportEndpoint.Do,
+			petsRouter.encoders.StartImportEncoder.Encode,
+		),
+	)
+
+	petsRouter.router.Methods(http.MethodGet).Path("/pets/import/{id}").Handler(
+		petsRouter.negotiation.Wrap(
+			web.NewTypedHandler(
+				petsRouter.decoders.GetImportJobDecoder.Decode,
+				petsRouter.endpoints.GetImportJobEndpoint.Do,
+				petsRouter.encoders.GetImportJobEncoder.Encode,
+			),
+		),
 	)
 
 	petsRouter.router.Methods(http.MethodPut).Path("/pets").Handler(
-		web.NewHandler().
-			WithEndpoint(petsRouter.endpoints.UpdatePetEndpoint).
-			WithDecoder(petsRouter.decoders.UpdateDecoder).
-			WithEncoder(petsRouter.encoders.UpdateEncoder),
+		petsRouter.negotiation.Wrap(
+			web.NewTypedHandler(
+				petsRouter.decoders.UpdateDecoder.Decode,
+				petsRouter.endpoints.UpdatePetEndpoint.Do,
+				petsRouter.encoders.UpdateEncoder.Encode,
+			),
+		),
 	)
 
 	petsRouter.router.Methods(http.MethodDelete).Path("/pets/{id}").Handler(
-		web.NewHandler().
-			WithEndpoint(petsRouter.endpoints.DeletePetEndpoint).
-			WithDecoder(petsRouter.decoders.DeleteDecoder).
-			WithEncoder(petsRouter.encoders.DeleteEncoder),
+		petsRouter.negotiation.Wrap(
+			web.NewTypedHandler(
+				petsRouter.decoders.DeleteDecoder.Decode,
+				petsRouter.endpoints.DeletePetEndpoint.Do,
+				petsRouter.encoders.DeleteEncoder.Encode,
+			),
+		),
+	)
+
+	petsRouter.router.Methods(http.MethodPost).Path("/pets/{id}/restore").Handler(
+		petsRouter.negotiation.Wrap(
+			web.NewTypedHandler(
+				petsRouter.decoders.RestoreDecoder.Decode,
+				petsRouter.endpoints.RestorePetEndpoint.Do,
+				petsRouter.encoders.RestoreEncoder.Encode,
+			),
+		),
+	)
+
+	petsRouter.router.Methods(http.MethodGet).Path("/pets/{id}/history").Handler(
+		petsRouter.negotiation.Wrap(
+			web.NewTypedHandler(
+				petsRouter.decoders.HistoryDecoder.Decode,
+				petsRouter.endpoints.PetHistoryEndpoint.Do,
+				petsRouter.encoders.HistoryEncoder.Encode,
+			),
+		),
+	)
+
+	petsRouter.router.Methods(http.MethodGet).Path("/pets/events").Handler(
+		petsRouter.events,
+	)
+
+	petsRouter.router.Methods(http.MethodGet).Path("/pets/export").Handler(
+		web.NewTypedHandler(
+			petsRouter.decoders.ExportDecoder.Decode,
+			petsRouter.endpoints.ExportPetsEndpoint.Do,
+			petsRouter.encoders.ExportEncoder.Encode,
+		),
+	)
+
+	petsRouter.router.Methods(http.MethodGet).Path("/pets/nearby").Handler(
+		petsRouter.negotiation.Wrap(
+			web.NewTypedHandler(
+				petsRouter.decoders.SearchNearbyDecoder.Decode,
+				petsRouter.endpoints.SearchNearbyEndpoint.Do,
+				petsRouter.encoders.SearchNearbyEncoder.Encode,
+			),
+		),
+	)
+
+	petsRouter.router.Methods(http.MethodPost).Path("/pets/{id}/sightings").Handler(
+		petsRouter.negotiation.Wrap(
+			web.NewTypedHandler(
+				petsRouter.decoders.ReportSightingDecoder.Decode,
+				petsRouter.endpoints.ReportSightingEndpoint.Do,
+				petsRouter.encoders.ReportSightingEncoder.Encode,
+			),
+		),
 	)
 
 	petsRouter.router.Methods(http.MethodGet).Path("/pets/{id}").Handler(
-		web.NewHandler().
-			WithEndpoint(petsRouter.endpoints.GetPetWithIDEndpoint).
-			WithDecoder(petsRouter.decoders.GetByIDDecoder).
-			WithEncoder(petsRouter.encoders.GetByIDEncoder),
+		petsRouter.negotiation.Wrap(
+			web.NewTypedHandler(
+				petsRouter.decoders.GetByIDDecoder.Decode,
+				petsRouter.endpoints.GetPetWithIDEndpoint.Do,
+				petsRouter.encoders.GetByIDEncoder.Encode,
+			),
+		),
 	)
 
 	petsRouter.router.Methods(http.MethodGet).Path("/pets").Handler(
-		web.NewHandler().
-			WithEndpoint(petsRouter.endpoints.SearchPetsEndpoint).
-			WithDecoder(petsRouter.decoders.SearchDecoder).
-			WithEncoder(petsRouter.encoders.SearchEncoder),
+		petsRouter.negotiation.Wrap(
+			web.NewTypedHandler(
+				petsRouter.decoders.SearchDecoder.Decode,
+				petsRouter.endpoints.SearchPetsEndpoint.Do,
+				petsRouter.encoders.SearchEncoder.Encode,
+			),
+		),
 	)
 
-	return petsRouter.router
+	return web.Chain(petsRouter.middlewares...)(petsRouter.router)
 }
@@ -0,0 +1,70 @@
+package application_test
+
+import (
+	"context"
+	"regexp"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/fernandoocampo/basic-micro/docs"
+	"github.com/fernandoocampo/basic-micro/internal/application"
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRoutesMatchOpenAPISpec(t *testing.T) {
+	// Given
+	spec := loadSpec(t)
+	var specRoutes []string
+	for path, pathItem := range spec.Paths.Map() {
+		for method := range pathItem.Operations() {
+			specRoutes = append(specRoutes, method+" "+path)
+		}
+	}
+
+	// When
+	routes, err := application.Routes()
+	require.NoError(t, err)
+
+	// Then
+	var registeredRoutes []string
+	for _, route := range routes {
+		registeredRoutes = append(registeredRoutes, route.Method+" "+route.Path)
+	}
+	sort.Strings(specRoutes)
+	sort.Strings(registeredRoutes)
+	assert.Equal(t, specRoutes, registeredRoutes, "routes registered in newPetsRouter and paths of docs/application.yml must be the same")
+}
+
+func TestOpenAPIOperationIDs(t *testing.T) {
+	// Given
+	spec := loadSpec(t)
+	numeric := regexp.MustCompile(`^[0-9]+$`)
+
+	// When
+	operationIDs := make(map[string]string)
+	for path, pathItem := range spec.Paths.Map() {
+		for method, operation := range pathItem.Operations() {
+			operationIDs[method+" "+path] = operation.OperationID
+		}
+	}
+
+	// Then
+	for route, operationID := range operationIDs {
+		assert.NotEmpty(t, operationID, route)
+		assert.False(t, numeric.MatchString(operationID), "%s has a numeric operation id %q", route, operationID)
+		assert.Equal(t, strings.TrimSpace(operationID), operationID, route)
+	}
+}
+
+func loadSpec(t *testing.T) *openapi3.T {
+	t.Helper()
+
+	spec, err := openapi3.NewLoader().LoadFromData(docs.OpenAPI)
+	require.NoError(t, err)
+	require.NoError(t, spec.Validate(context.Background()))
+
+	return spec
+}
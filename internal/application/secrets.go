@@ -0,0 +1,81 @@
+package application
+
+import (
+	"context"
+
+	"github.com/fernandoocampo/basic-micro/internal/adapter/secrets"
+	"github.com/fernandoocampo/basic-micro/internal/setups"
+)
+
+// passwordSetter is a store whose password can be rotated.
+type passwordSetter interface {
+	SetPassword(password string)
+}
+
+// dbPasswordSecret is the name of the database password in the secret
+// provider.
+const dbPasswordSecret = "db_password"
+
+// databasePassword returns the database password of the secret provider,
+// or the configured one if there is no secret provider.
+func (s *Server) databasePassword(ctx context.Context) (string, error) {
+	if s.setup.Secrets.Provider == "" {
+		return s.setup.Repository.Password, nil
+	}
+
+	provider, name := s.passwordProvider()
+
+	return provider.Secret(ctx, name)
+}
+
+// passwordProvider returns the provider of the database password and the
+// name of the secret, the provider is nil if the password cannot be
+// rotated. A DB_PASSWORD_FILE file is read again, so rotated kubernetes
+// secrets are seen.
+func (s *Server) passwordProvider() (secrets.Provider, string) {
+	switch s.setup.Secrets.Provider {
+	case setups.FileSecretProvider:
+		return secrets.NewFileProvider(s.setup.Secrets.FileDir), dbPasswordSecret
+	case setups.VaultSecretProvider:
+		vaultSetup := secrets.VaultSetup{
+			Address: s.setup.Secrets.VaultAddress,
+			Token:   s.setup.Secrets.VaultToken,
+			Path:    s.setup.Secrets.VaultPath,
+			Logger:  s.logger,
+		}
+
+		return secrets.NewVaultProvider(vaultSetup), dbPasswordSecret
+	}
+
+	if file, ok := s.secretFiles["DB_PASSWORD"]; ok {
+		return secrets.NewFileProvider(""), file
+	}
+
+	return nil, ""
+}
+
+// refreshDatabasePassword changes the password of the store when it is
+// rotated until the given context is done. It only waits for the context
+// if the store does not have a password or it is not read from a provider.
+func (s *Server) refreshDatabasePassword(ctx context.Context) error {
+	store, ok := s.store.(passwordSetter)
+	provider, name := s.passwordProvider()
+
+	if !ok || provider == nil {
+		<-ctx.Done()
+
+		return nil
+	}
+
+	refresherSetup := secrets.RefresherSetup{
+		Provider: provider,
+		Name:     name,
+		Interval: s.setup.Secrets.RefreshInterval,
+		OnChange: store.SetPassword,
+		Logger:   s.logger,
+	}
+
+	secrets.NewRefresher(refresherSetup).Run(ctx, s.dbPassword)
+
+	return nil
+}
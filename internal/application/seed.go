@@ -0,0 +1,21 @@
+package application
+
+import (
+	"context"
+
+	"github.com/fernandoocampo/basic-micro/internal/pets"
+)
+
+// migrator is a store whose schema can be migrated.
+type migrator interface {
+	Migrate(ctx context.Context) ([]string, error)
+}
+
+// samplePets are the pets created by Seed.
+var samplePets = []pets.NewPet{
+	{Name: "Drila", Location: &pets.Location{Latitude: 4.711, Longitude: -74.0721}},
+	{Name: "Michael", Location: &pets.Location{Latitude: 4.6097, Longitude: -74.0817}},
+	{Name: "Lulu", Location: &pets.Location{Latitude: 6.2442, Longitude: -75.5812}},
+	{Name: "Kira", Location: &pets.Location{Latitude: 3.4516, Longitude: -76.532}},
+	{Name: "Tobby"},
+}
@@ -0,0 +1,3 @@
+// Package lifecycle starts the components of the application in order,
+// supervises the running ones and stops them in reverse order.
+package lifecycle
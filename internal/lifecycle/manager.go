@@ -0,0 +1,400 @@
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// State defines the state of a component.
+type State string
+
+// RestartMode defines when a component that ended is run again.
+type RestartMode int
+
+// Component is a subsystem of the application, like the web server or a
+// background job. Every hook is optional.
+type Component struct {
+	Name string
+	// Start initializes the component, components are started one after
+	// the other in the order they were registered.
+	Start func(ctx context.Context) error
+	// Run runs the component until ctx is done, it must return then. A Run
+	// that returns before the component is stopped fails, even without an
+	// error.
+	Run func(ctx context.Context) error
+	// Stop releases the component, components are stopped in the reverse
+	// order they were started and before their Run context is done.
+	Stop func(ctx context.Context) error
+	// StopTimeout bounds Stop and how long Run has to return after it, it
+	// is not bounded if it is zero.
+	StopTimeout time.Duration
+	Restart     RestartPolicy
+}
+
+// RestartPolicy says when Run is called again after it returns.
+type RestartPolicy struct {
+	Mode RestartMode
+	// MaxRestarts is the number of restarts, there is no limit if it is
+	// zero.
+	MaxRestarts int
+	// Backoff is the wait before the first restart, it is doubled on every
+	// restart up to maxBackoff.
+	Backoff time.Duration
+}
+
+// Setup contains lifecycle manager metadata.
+type Setup struct {
+	Logger *slog.Logger
+}
+
+// Manager starts, supervises and stops the registered components.
+type Manager struct {
+	mutex      sync.RWMutex
+	components []*component
+	// failures receives the error of the first component that failed.
+	failures chan error
+	logger   *slog.Logger
+}
+
+// component is a registered component and its state.
+type component struct {
+	Component
+	state State
+	// cancel cancels the context of Run.
+	cancel context.CancelFunc
+	// done is closed when Run returned for the last time.
+	done chan struct{}
+}
+
+// component states.
+const (
+	Pending    State = "pending"
+	Starting   State = "starting"
+	Running    State = "running"
+	Restarting State = "restarting"
+	Stopping   State = "stopping"
+	Stopped    State = "stopped"
+	Failed     State = "failed"
+)
+
+// restart modes.
+const (
+	// RestartNever does not run the component again.
+	RestartNever RestartMode = iota
+	// RestartOnFailure runs the component again if it failed: it returned
+	// an error, panicked or returned before it was stopped.
+	RestartOnFailure
+	// RestartAlways runs the component again every time it returns.
+	RestartAlways
+)
+
+// maxBackoff is the longest wait between restarts.
+const maxBackoff = 30 * time.Second
+
+var (
+	errComponentsNotRunning = errors.New("components are not running")
+	errRunEnded             = errors.New("run returned before the component was stopped")
+)
+
+func New(setup Setup) *Manager {
+	newManager := Manager{
+		failures: make(chan error, 1),
+		logger:   setup.Logger,
+	}
+
+	return &newManager
+}
+
+// Register adds a component, it must be called before Run.
+func (m *Manager) Register(newComponent Component) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	m.components = append(m.components, &component{
+		Component: newComponent,
+		state:     Pending,
+		done:      make(chan struct{}),
+	})
+}
+
+// Run starts the components in order and runs them until ctx is done or
+// a component fails, then it stops the started components in reverse
+// order. It returns the error of the component that failed to start or
+// run, stop errors are only logged.
+func (m *Manager) Run(ctx context.Context) error {
+	m.mutex.RLock()
+	components := m.components
+	m.mutex.RUnlock()
+
+	started, err := m.start(ctx, components)
+	if err == nil {
+		select {
+		case <-ctx.Done():
+			m.logger.Info("stopping components", slog.String("reason", ctx.Err().Error()))
+		case err = <-m.failures:
+			m.logger.Error("stopping components because a component failed", "error", err)
+		}
+	}
+
+	m.stop(started)
+
+	return err
+}
+
+// States returns the state of every component by its name.
+func (m *Manager) States() map[string]State {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+
+	states := make(map[string]State, len(m.components))
+	for _, component := range m.components {
+		states[component.Name] = component.state
+	}
+
+	return states
+}
+
+// Check fails if a component is restarting or failed, so it can be used as
+// a health checker.
+func (m *Manager) Check(ctx context.Context) error {
+	var names []string
+	for name, state := range m.States() {
+		if state == Restarting || state == Failed {
+			names = append(names, fmt.Sprintf("%s is %s", name, state))
+		}
+	}
+
+	if len(names) > 0 {
+		sort.Strings(names)
+
+		return fmt.Errorf("%w: %s", errComponentsNotRunning, strings.Join(names, ", "))
+	}
+
+	return nil
+}
+
+// start starts the components one after the other and returns the ones
+// that were started, so they can be stopped if one of them fails.
+func (m *Manager) start(ctx context.Context, components []*component) ([]*component, error) {
+	started := make([]*component, 0, len(components))
+
+	for _, component := range components {
+		if ctx.Err() != nil {
+			return started, nil
+		}
+
+		m.logger.Info("starting component", slog.String("component", component.Name))
+		m.setState(component, Starting)
+
+		if component.Start != nil {
+			err := component.Start(ctx)
+			if err != nil {
+				m.setState(component, Failed)
+
+				return started, fmt.Errorf("unable to start %s: %w", component.Name, err)
+			}
+		}
+
+		started = append(started, component)
+
+		if component.Run == nil {
+			close(component.done)
+			m.setState(component, Running)
+
+			continue
+		}
+
+		// Run is not canceled with ctx, so components are stopped in
+		// order by stop.
+		runCtx, cancel := context.WithCancel(context.WithoutCancel(ctx))
+		component.cancel = cancel
+		m.setState(component, Running)
+
+		go m.supervise(runCtx, component)
+	}
+
+	return started, nil
+}
+
+// supervise runs the component and runs it again as its restart policy
+// says until its context is done.
+func (m *Manager) supervise(ctx context.Context, component *component) {
+	defer close(component.done)
+
+	restarts := 0
+	backoff := component.Restart.Backoff
+
+	for {
+		err := run(ctx, component)
+		if ctx.Err() != nil || m.isStopping(component) {
+			return
+		}
+
+		if err == nil {
+			err = errRunEnded
+		}
+
+		if !component.Restart.allows(err, restarts) {
+			if m.transition(component, Running, Failed) {
+				m.fail(fmt.Errorf("component %s failed: %w", component.Name, err))
+			}
+
+			return
+		}
+
+		restarts++
+		if !m.transition(component, Running, Restarting) {
+			return
+		}
+
+		m.logger.Warn(
+			"restarting component",
+			slog.String("component", component.Name),
+			slog.Int("restart", restarts),
+			slog.Duration("backoff", backoff),
+			slog.Any("error", err),
+		)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(backoff):
+		}
+
+		backoff = min(2*backoff, maxBackoff)
+
+		// stop could have started during the backoff, then the component
+		// is not run again.
+		if !m.transition(component, Restarting, Running) {
+			return
+		}
+	}
+}
+
+// stop stops the components in reverse order, every component is stopped
+// before the next one, so stopping is deterministic.
+func (m *Manager) stop(components []*component) {
+	for i := len(components) - 1; i >= 0; i-- {
+		component := components[i]
+
+		m.logger.Info("stopping component", slog.String("component", component.Name))
+		m.setStopState(component, Stopping)
+
+		ctx, cancel := stopContext(component.StopTimeout)
+
+		if component.Stop != nil {
+			err := component.Stop(ctx)
+			if err != nil {
+				m.logger.Error("stopping component", slog.String("component", component.Name), "error", err)
+			}
+		}
+
+		if component.cancel != nil {
+			component.cancel()
+		}
+
+		select {
+		case <-component.done:
+			m.setStopState(component, Stopped)
+		case <-ctx.Done():
+			m.logger.Error("component did not stop before its timeout", slog.String("component", component.Name))
+		}
+
+		cancel()
+	}
+}
+
+// fail reports the failure of a component, only the first failure is kept,
+// so components never block on it.
+func (m *Manager) fail(err error) {
+	select {
+	case m.failures <- err:
+	default:
+	}
+}
+
+func (m *Manager) setState(component *component, state State) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	component.state = state
+}
+
+// transition changes the state of the component only if it is still from,
+// so a component that is being stopped is not moved to another state.
+func (m *Manager) transition(component *component, from, to State) bool {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	if component.state != from {
+		return false
+	}
+
+	component.state = to
+
+	return true
+}
+
+// stopContext returns the context of a stop bounded by timeout, if it is
+// not zero.
+func stopContext(timeout time.Duration) (context.Context, context.CancelFunc) {
+	if timeout > 0 {
+		return context.WithTimeout(context.Background(), timeout)
+	}
+
+	return context.WithCancel(context.Background())
+}
+
+// isStopping says if the component is being stopped, so its Run can return
+// before its context is done.
+func (m *Manager) isStopping(component *component) bool {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+
+	return component.state == Stopping
+}
+
+// setStopState sets the state of a component that is stopped, failed
+// components keep their state, so the failure can be seen.
+func (m *Manager) setStopState(component *component, state State) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	if component.state != Failed {
+		component.state = state
+	}
+}
+
+// allows says if the component can run again after it returned err.
+func (r RestartPolicy) allows(err error, restarts int) bool {
+	if r.MaxRestarts > 0 && restarts >= r.MaxRestarts {
+		return false
+	}
+
+	switch r.Mode {
+	case RestartAlways:
+		return true
+	case RestartOnFailure:
+		return err != nil
+	default:
+		return false
+	}
+}
+
+// run runs the component and turns a panic into an error, so it can be
+// restarted.
+func run(ctx context.Context, component *component) (err error) {
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			err = fmt.Errorf("panic: %v", recovered)
+		}
+	}()
+
+	return component.Run(ctx)
+}
@@ -0,0 +1,360 @@
+package lifecycle_test
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/fernandoocampo/basic-micro/internal/lifecycle"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// recorder records the hooks that were called in order.
+type recorder struct {
+	mutex  sync.Mutex
+	events []string
+}
+
+func (r *recorder) record(event string) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	r.events = append(r.events, event)
+}
+
+func (r *recorder) recorded() []string {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	return append([]string(nil), r.events...)
+}
+
+// component returns a component that records its hooks, its Run waits
+// until its context is done.
+func (r *recorder) component(name string) lifecycle.Component {
+	return lifecycle.Component{
+		Name: name,
+		Start: func(ctx context.Context) error {
+			r.record("start " + name)
+
+			return nil
+		},
+		Run: func(ctx context.Context) error {
+			<-ctx.Done()
+			r.record("run ended " + name)
+
+			return nil
+		},
+		Stop: func(ctx context.Context) error {
+			r.record("stop " + name)
+
+			return nil
+		},
+	}
+}
+
+func TestManagerStartsInOrderAndStopsInReverse(t *testing.T) {
+	// Given
+	events := new(recorder)
+	manager := newManager()
+	manager.Register(events.component("store"))
+	manager.Register(lifecycle.Component{
+		Name: "telemetry",
+		Stop: func(ctx context.Context) error {
+			events.record("stop telemetry")
+
+			return errors.New("exporter is down")
+		},
+	})
+	manager.Register(events.component("web"))
+	ctx, cancel := context.WithCancel(context.TODO())
+
+	// When
+	done := runManager(ctx, manager)
+	waitFor(t, func() bool { return manager.States()["web"] == lifecycle.Running })
+	cancel()
+	err := <-done
+
+	// Then
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		"start store",
+		"start web",
+		"stop web",
+		"run ended web",
+		"stop telemetry",
+		"stop store",
+		"run ended store",
+	}, events.recorded())
+	assert.Equal(t, map[string]lifecycle.State{
+		"store":     lifecycle.Stopped,
+		"telemetry": lifecycle.Stopped,
+		"web":       lifecycle.Stopped,
+	}, manager.States())
+}
+
+func TestManagerStopsStartedComponentsWhenStartFails(t *testing.T) {
+	// Given
+	events := new(recorder)
+	manager := newManager()
+	manager.Register(events.component("store"))
+	manager.Register(lifecycle.Component{
+		Name: "web",
+		Start: func(ctx context.Context) error {
+			return errors.New("address already in use")
+		},
+	})
+	manager.Register(events.component("purge"))
+
+	// When
+	err := manager.Run(context.TODO())
+
+	// Then
+	assert.EqualError(t, err, "unable to start web: address already in use")
+	assert.Equal(t, []string{"start store", "stop store", "run ended store"}, events.recorded())
+	assert.Equal(t, lifecycle.Failed, manager.States()["web"])
+	assert.Equal(t, lifecycle.Pending, manager.States()["purge"])
+}
+
+func TestManagerStopsWhenComponentFails(t *testing.T) {
+	// Given
+	events := new(recorder)
+	manager := newManager()
+	manager.Register(events.component("store"))
+	manager.Register(lifecycle.Component{
+		Name: "web",
+		Run: func(ctx context.Context) error {
+			return errors.New("listener was closed")
+		},
+	})
+
+	// When
+	err := manager.Run(context.TODO())
+
+	// Then
+	assert.EqualError(t, err, "component web failed: listener was closed")
+	assert.Equal(t, []string{"start store", "stop store", "run ended store"}, events.recorded())
+	assert.Equal(t, lifecycle.Failed, manager.States()["web"])
+}
+
+func TestManagerIgnoresRunEndedByStop(t *testing.T) {
+	// Given
+	stopped := make(chan struct{})
+	manager := newManager()
+	manager.Register(lifecycle.Component{
+		Name: "web",
+		Run: func(ctx context.Context) error {
+			<-stopped
+
+			return errors.New("server was closed")
+		},
+		Stop: func(ctx context.Context) error {
+			close(stopped)
+
+			return nil
+		},
+	})
+	ctx, cancel := context.WithCancel(context.TODO())
+
+	// When
+	done := runManager(ctx, manager)
+	waitFor(t, func() bool { return manager.States()["web"] == lifecycle.Running })
+	cancel()
+	err := <-done
+
+	// Then
+	require.NoError(t, err)
+	assert.Equal(t, lifecycle.Stopped, manager.States()["web"])
+}
+
+func TestManagerRestartsOnFailure(t *testing.T) {
+	// Given
+	var runs atomic.Int32
+	manager := newManager()
+	manager.Register(lifecycle.Component{
+		Name: "purge",
+		Run: func(ctx context.Context) error {
+			if runs.Add(1) < 3 {
+				panic("database is gone")
+			}
+
+			<-ctx.Done()
+
+			return nil
+		},
+		Restart: lifecycle.RestartPolicy{
+			Mode:        lifecycle.RestartOnFailure,
+			MaxRestarts: 3,
+			Backoff:     time.Millisecond,
+		},
+	})
+	ctx, cancel := context.WithCancel(context.TODO())
+
+	// When
+	done := runManager(ctx, manager)
+	waitFor(t, func() bool { return runs.Load() == 3 })
+	checkErr := manager.Check(context.TODO())
+	cancel()
+	err := <-done
+
+	// Then
+	require.NoError(t, err)
+	assert.NoError(t, checkErr)
+	assert.Equal(t, int32(3), runs.Load())
+}
+
+func TestManagerGivesUpAfterMaxRestarts(t *testing.T) {
+	// Given
+	var runs atomic.Int32
+	manager := newManager()
+	manager.Register(lifecycle.Component{
+		Name: "purge",
+		Run: func(ctx context.Context) error {
+			runs.Add(1)
+
+			return errors.New("database is gone")
+		},
+		Restart: lifecycle.RestartPolicy{
+			Mode:        lifecycle.RestartAlways,
+			MaxRestarts: 2,
+			Backoff:     time.Millisecond,
+		},
+	})
+
+	// When
+	err := manager.Run(context.TODO())
+
+	// Then
+	assert.EqualError(t, err, "component purge failed: database is gone")
+	assert.Equal(t, int32(3), runs.Load())
+	assert.EqualError(t, manager.Check(context.TODO()), "components are not running: purge is failed")
+}
+
+func TestManagerFailsWhenRunEnds(t *testing.T) {
+	// Given
+	manager := newManager()
+	manager.Register(lifecycle.Component{
+		Name: "grpc",
+		Run: func(ctx context.Context) error {
+			return nil
+		},
+	})
+
+	// When
+	err := manager.Run(context.TODO())
+
+	// Then
+	assert.EqualError(t, err, "component grpc failed: run returned before the component was stopped")
+	assert.Equal(t, lifecycle.Failed, manager.States()["grpc"])
+}
+
+func TestManagerDoesNotRestartAfterStop(t *testing.T) {
+	// Given
+	var runs atomic.Int32
+	backoff := 10 * time.Millisecond
+	manager := newManager()
+	manager.Register(lifecycle.Component{
+		Name: "purge",
+		Run: func(ctx context.Context) error {
+			runs.Add(1)
+
+			return errors.New("database is gone")
+		},
+		// the backoff ends while the component is being stopped.
+		Stop: func(ctx context.Context) error {
+			time.Sleep(5 * backoff)
+
+			return nil
+		},
+		Restart: lifecycle.RestartPolicy{
+			Mode:    lifecycle.RestartAlways,
+			Backoff: backoff,
+		},
+	})
+	ctx, cancel := context.WithCancel(context.TODO())
+
+	// When
+	done := runManager(ctx, manager)
+	waitFor(t, func() bool { return manager.States()["purge"] == lifecycle.Restarting })
+	cancel()
+	err := <-done
+
+	// Then
+	require.NoError(t, err)
+	assert.Equal(t, int32(1), runs.Load())
+	assert.Equal(t, lifecycle.Stopped, manager.States()["purge"])
+}
+
+func TestManagerDoesNotStartWhenContextIsDone(t *testing.T) {
+	// Given
+	events := new(recorder)
+	manager := newManager()
+	manager.Register(events.component("store"))
+	ctx, cancel := context.WithCancel(context.TODO())
+	cancel()
+
+	// When
+	err := manager.Run(ctx)
+
+	// Then
+	require.NoError(t, err)
+	assert.Empty(t, events.recorded())
+	assert.Equal(t, lifecycle.Pending, manager.States()["store"])
+}
+
+func TestManagerStopTimeout(t *testing.T) {
+	// Given
+	stuck := make(chan struct{})
+	t.Cleanup(func() { close(stuck) })
+	manager := newManager()
+	manager.Register(lifecycle.Component{
+		Name: "stuck",
+		Run: func(ctx context.Context) error {
+			<-stuck
+
+			return nil
+		},
+		StopTimeout: 10 * time.Millisecond,
+	})
+	ctx, cancel := context.WithCancel(context.TODO())
+
+	// When
+	done := runManager(ctx, manager)
+	waitFor(t, func() bool { return manager.States()["stuck"] == lifecycle.Running })
+	cancel()
+
+	// Then
+	select {
+	case err := <-done:
+		require.NoError(t, err)
+		assert.Equal(t, lifecycle.Stopping, manager.States()["stuck"])
+	case <-time.After(time.Second):
+		t.Fatal("manager waited for a component after its stop timeout")
+	}
+}
+
+func newManager() *lifecycle.Manager {
+	return lifecycle.New(lifecycle.Setup{
+		Logger: slog.Default(),
+	})
+}
+
+func runManager(ctx context.Context, manager *lifecycle.Manager) <-chan error {
+	done := make(chan error, 1)
+	go func() {
+		done <- manager.Run(ctx)
+	}()
+
+	return done
+}
+
+func waitFor(t *testing.T, condition func() bool) {
+	t.Helper()
+
+	assert.Eventually(t, condition, time.Second, time.Millisecond)
+}
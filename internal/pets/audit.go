@@ -0,0 +1,253 @@
+package pets
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// AuditRecordID defines audit record id.
+type AuditRecordID string
+
+// AuditAction defines the kind of change recorded by an audit record.
+type AuditAction string
+
+// audit actions
+const (
+	AuditCreated  AuditAction = "created"
+	AuditUpdated  AuditAction = "updated"
+	AuditDeleted  AuditAction = "deleted"
+	AuditRestored AuditAction = "restored"
+)
+
+// AnonymousActor is the actor used when the context does not have an
+// authenticated one.
+const AnonymousActor = "anonymous"
+
+// audited pet fields
+const (
+	nameField      = "name"
+	locationField  = "location"
+	deletedAtField = "deleted_at"
+)
+
+// FieldChange contains the value of a pet field before and after a change.
+type FieldChange struct {
+	Field  string `json:"field"`
+	Before string `json:"before"`
+	After  string `json:"after"`
+}
+
+// AuditRecord is an immutable record of a change made to a pet. Actor is
+// the authenticated actor that made the change and AssertedActor the actor
+// the client says it is, which is not verified, so it must not be trusted.
+type AuditRecord struct {
+	ID            AuditRecordID `json:"id"`
+	PetID         PetID         `json:"pet_id"`
+	Action        AuditAction   `json:"action"`
+	Actor         string        `json:"actor"`
+	AssertedActor string        `json:"asserted_actor,omitempty"`
+	RequestID     string        `json:"request_id"`
+	Timestamp     time.Time     `json:"timestamp"`
+	Changes       []FieldChange `json:"changes"`
+}
+
+// AuditFilter contains data to query the audit records of a pet.
+type AuditFilter struct {
+	PetID       PetID
+	PageNumber  uint8
+	RowsPerPage uint8
+}
+
+// AuditRecordsResult contains audit records ordered by timestamp, oldest first.
+type AuditRecordsResult struct {
+	Records     []AuditRecord
+	Total       int
+	Page        uint8
+	RowsPerPage uint8
+}
+
+// PetHistoryDataResult standard response for querying the history of a pet.
+type PetHistoryDataResult struct {
+	Result  AuditRecordsResult
+	Err     string
+	ErrKind ErrorKind
+}
+
+// AuditStorer defines persistence behavior for audit records. Records are
+// immutable, so they can only be saved and queried.
+type AuditStorer interface {
+	SaveAuditRecord(ctx context.Context, record AuditRecord) error
+	QueryAuditRecords(ctx context.Context, filter AuditFilter) (AuditRecordsResult, error)
+}
+
+type contextKey int
+
+const (
+	actorKey contextKey = iota
+	assertedActorKey
+	requestIDKey
+)
+
+// Publisher receives the audit records of the changes made to pets after
+// they were saved, so the changes can be sent to subscribers. Records of
+// atomic batches are published after the batch is committed. Publish must
+// not block the service.
+type Publisher interface {
+	Publish(ctx context.Context, record AuditRecord)
+}
+
+// nopAuditStorer is used when the service is created without an audit storer.
+type nopAuditStorer struct{}
+
+// nopPublisher is used when the service is created without a publisher.
+type nopPublisher struct{}
+
+// WithActor returns a copy of ctx with the authenticated actor that makes
+// the changes, it must only be used with identities the server verified.
+func WithActor(ctx context.Context, actor string) context.Context {
+	return context.WithValue(ctx, actorKey, actor)
+}
+
+// ActorFromContext returns the actor in ctx or AnonymousActor if there is not any.
+func ActorFromContext(ctx context.Context) string {
+	actor, ok := ctx.Value(actorKey).(string)
+	if !ok || actor == "" {
+		return AnonymousActor
+	}
+
+	return actor
+}
+
+// WithAssertedActor returns a copy of ctx with the actor the client says
+// it is, like the one sent in the X-Actor header.
+func WithAssertedActor(ctx context.Context, actor string) context.Context {
+	return context.WithValue(ctx, assertedActorKey, actor)
+}
+
+// AssertedActorFromContext returns the asserted actor in ctx or empty if
+// there is not any.
+func AssertedActorFromContext(ctx context.Context) string {
+	actor, _ := ctx.Value(assertedActorKey).(string)
+
+	return actor
+}
+
+// WithRequestID returns a copy of ctx with the id of the request that makes the changes.
+func WithRequestID(ctx context.Context, requestID string) context.Context {
+	return context.WithValue(ctx, requestIDKey, requestID)
+}
+
+// RequestIDFromContext returns the request id in ctx or empty if there is not any.
+func RequestIDFromContext(ctx context.Context) string {
+	requestID, _ := ctx.Value(requestIDKey).(string)
+
+	return requestID
+}
+
+func (n nopAuditStorer) SaveAuditRecord(ctx context.Context, record AuditRecord) error {
+	return nil
+}
+
+func (n nopAuditStorer) QueryAuditRecords(ctx context.Context, filter AuditFilter) (AuditRecordsResult, error) {
+	return AuditRecordsResult{
+		Records:     []AuditRecord{},
+		Page:        filter.PageNumber,
+		RowsPerPage: filter.RowsPerPage,
+	}, nil
+}
+
+func (n nopPublisher) Publish(ctx context.Context, record AuditRecord) {}
+
+func newAuditRecordID() AuditRecordID {
+	return AuditRecordID(uuid.New().String())
+}
+
+func buildAuditRecord(ctx context.Context, petID PetID, action AuditAction, changes []FieldChange) AuditRecord {
+	return AuditRecord{
+		ID:            newAuditRecordID(),
+		PetID:         petID,
+		Action:        action,
+		Actor:         ActorFromContext(ctx),
+		AssertedActor: AssertedActorFromContext(ctx),
+		RequestID:     RequestIDFromContext(ctx),
+		Timestamp:     time.Now().UTC(),
+		Changes:       changes,
+	}
+}
+
+// diffPets returns the fields that changed from before to after,
+// a nil before means the pet did not exist.
+func diffPets(before *Pet, after *Pet) []FieldChange {
+	var beforePet, afterPet Pet
+	if before != nil {
+		beforePet = *before
+	}
+	if after != nil {
+		afterPet = *after
+	}
+
+	changes := make([]FieldChange, 0)
+	changes = appendChange(changes, nameField, beforePet.Name, afterPet.Name)
+	changes = appendChange(changes, locationField, formatLocation(beforePet.Location), formatLocation(afterPet.Location))
+	changes = appendChange(changes, deletedAtField, formatTime(beforePet.DeletedAt), formatTime(afterPet.DeletedAt))
+
+	return changes
+}
+
+func appendChange(changes []FieldChange, field, before, after string) []FieldChange {
+	if before == after {
+		return changes
+	}
+
+	return append(changes, FieldChange{
+		Field:  field,
+		Before: before,
+		After:  after,
+	})
+}
+
+func formatLocation(location *Location) string {
+	if location == nil {
+		return ""
+	}
+
+	return fmt.Sprintf("%g,%g", location.Latitude, location.Longitude)
+}
+
+func formatTime(value *time.Time) string {
+	if value == nil {
+		return ""
+	}
+
+	return value.Format(time.RFC3339)
+}
+
+func (a AuditRecordID) String() string {
+	return string(a)
+}
+
+func (f *AuditFilter) fillDefaultValues() {
+	if f.PageNumber == 0 {
+		f.PageNumber = PageNumberDefault
+	}
+
+	if f.RowsPerPage == 0 {
+		f.RowsPerPage = RowsPerPageDefault
+	}
+}
+
+// newPetHistoryDataResult create a new PetHistoryDataResult
+func newPetHistoryDataResult(result AuditRecordsResult, err error) PetHistoryDataResult {
+	var errmessage string
+	if err != nil {
+		errmessage = err.Error()
+	}
+	return PetHistoryDataResult{
+		Result:  result,
+		Err:     errmessage,
+		ErrKind: KindOf(err),
+	}
+}
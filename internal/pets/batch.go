@@ -0,0 +1,286 @@
+package pets
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+)
+
+// BatchOperationType defines the kind of a batch operation.
+type BatchOperationType string
+
+// BatchItemStatus defines the status of a batch operation once the batch
+// was processed.
+type BatchItemStatus string
+
+// batch operation types
+const (
+	BatchCreate BatchOperationType = "create"
+	BatchUpdate BatchOperationType = "update"
+	BatchDelete BatchOperationType = "delete"
+)
+
+// batch item status
+const (
+	// BatchSucceeded the operation was applied.
+	BatchSucceeded BatchItemStatus = "succeeded"
+	// BatchFailed the operation could not be applied.
+	BatchFailed BatchItemStatus = "failed"
+	// BatchAborted the operation was not applied because another operation
+	// of an atomic batch failed.
+	BatchAborted BatchItemStatus = "aborted"
+)
+
+// BatchMaxOperations is the maximum number of operations in a batch.
+const BatchMaxOperations = 100
+
+// BatchOperation contains one create, update or delete operation of a batch.
+type BatchOperation struct {
+	Type BatchOperationType
+	// NewPet data for create operations.
+	NewPet *NewPet
+	// UpdatePet data for update operations.
+	UpdatePet *UpdatePet
+	// PetID pet to delete for delete operations.
+	PetID PetID
+}
+
+// BatchRequest contains the operations of a batch.
+type BatchRequest struct {
+	Operations []BatchOperation
+	// Atomic says if all operations must be applied or none of them.
+	Atomic bool
+}
+
+// BatchItemResult contains the result of a batch operation.
+type BatchItemResult struct {
+	Index  int
+	Type   BatchOperationType
+	ID     PetID
+	Status BatchItemStatus
+	Err    string
+}
+
+// BatchResult contains the result of every batch operation in request order.
+type BatchResult struct {
+	Items []BatchItemResult
+}
+
+// BatchDataResult standard response for a batch of operations.
+type BatchDataResult struct {
+	Result  BatchResult
+	Err     string
+	ErrKind ErrorKind
+}
+
+// auditBuffer keeps the audit records of a transaction until it is
+// committed, records are queried from the audit storer of the service.
+type auditBuffer struct {
+	auditStorer AuditStorer
+	records     []AuditRecord
+}
+
+// ErrTransactionConflict is returned by storers when a transaction is
+// aborted because another writer changed the same pets before the commit.
+var ErrTransactionConflict = newKindError(AbortedError, "transaction conflicts with a concurrent change")
+
+var (
+	errEmptyBatch        = newKindError(InvalidError, "batch does not have operations")
+	errBatchTooLarge     = newKindError(InvalidError, fmt.Sprintf("batch cannot have more than %d operations", BatchMaxOperations))
+	errBatchRolledBack   = newKindError(AbortedError, "batch was rolled back because an operation failed")
+	errBatchConflict     = newKindError(AbortedError, "batch was rolled back because another request changed its pets, it can be retried")
+	errUnknownBatchOp    = newKindError(InvalidError, "unknown batch operation")
+	errMissingBatchData  = newKindError(InvalidError, "batch operation does not have data")
+	errBatchTransaction  = errors.New("unable to run batch transaction")
+	errBatchNotPersisted = errors.New("operation was not applied because another operation failed")
+)
+
+// Batch applies a batch of create, update and delete operations. Atomic
+// batches are applied in a single transaction, otherwise every operation
+// is applied independently.
+func (s *Service) Batch(ctx context.Context, request BatchRequest) (BatchResult, error) {
+	s.logger.Debug("starting batch", slog.Int("operations", len(request.Operations)), slog.Bool("atomic", request.Atomic))
+	if len(request.Operations) == 0 {
+		return BatchResult{}, errEmptyBatch
+	}
+
+	if len(request.Operations) > BatchMaxOperations {
+		return BatchResult{}, errBatchTooLarge
+	}
+
+	if !request.Atomic {
+		return BatchResult{Items: s.applyBatch(ctx, request.Operations, false)}, nil
+	}
+
+	var items []BatchItemResult
+	buffer := auditBuffer{
+		auditStorer: s.auditStorer,
+	}
+
+	err := s.storer.WithinTransaction(ctx, func(tx Storer) error {
+		txService := s.withinTransaction(tx, &buffer)
+		items = txService.applyBatch(ctx, request.Operations, true)
+
+		if batchFailed(items) {
+			return errBatchRolledBack
+		}
+
+		return nil
+	})
+
+	if errors.Is(err, errBatchRolledBack) {
+		return BatchResult{Items: abortBatch(items)}, errBatchRolledBack
+	}
+
+	if errors.Is(err, ErrTransactionConflict) {
+		return BatchResult{Items: abortBatch(items)}, errBatchConflict
+	}
+
+	if err != nil {
+		s.logger.Error("running batch transaction", "error", err)
+
+		return BatchResult{Items: abortBatch(items)}, errBatchTransaction
+	}
+
+	for _, record := range buffer.records {
+		s.record(ctx, record)
+	}
+
+	return BatchResult{Items: items}, nil
+}
+
+// applyBatch applies the operations in order, if stopOnError is true the
+// remaining operations are aborted after the first failure.
+func (s *Service) applyBatch(ctx context.Context, operations []BatchOperation, stopOnError bool) []BatchItemResult {
+	items := make([]BatchItemResult, 0, len(operations))
+	failed := false
+
+	for index, operation := range operations {
+		item := BatchItemResult{
+			Index: index,
+			Type:  operation.Type,
+		}
+
+		if failed {
+			item.ID = operation.petID()
+			item.Status = BatchAborted
+			item.Err = errBatchNotPersisted.Error()
+			items = append(items, item)
+
+			continue
+		}
+
+		id, err := s.applyBatchOperation(ctx, operation)
+		item.ID = id
+		item.Status = BatchSucceeded
+
+		if err != nil {
+			item.Status = BatchFailed
+			item.Err = err.Error()
+			failed = stopOnError
+		}
+
+		items = append(items, item)
+	}
+
+	return items
+}
+
+func (s *Service) applyBatchOperation(ctx context.Context, operation BatchOperation) (PetID, error) {
+	switch operation.Type {
+	case BatchCreate:
+		if operation.NewPet == nil {
+			return EmptyPetID, errMissingBatchData
+		}
+
+		return s.Create(ctx, *operation.NewPet)
+	case BatchUpdate:
+		if operation.UpdatePet == nil {
+			return EmptyPetID, errMissingBatchData
+		}
+
+		return operation.UpdatePet.ID, s.Update(ctx, *operation.UpdatePet)
+	case BatchDelete:
+		if operation.PetID == EmptyPetID {
+			return EmptyPetID, errEmptyPetID
+		}
+
+		return operation.PetID, s.Delete(ctx, operation.PetID)
+	}
+
+	return EmptyPetID, errUnknownBatchOp
+}
+
+// withinTransaction returns a copy of the service that uses the given
+// transaction storer and buffers its audit records, they are published
+// when they are saved after the commit.
+func (s *Service) withinTransaction(tx Storer, buffer *auditBuffer) *Service {
+	txService := *s
+	txService.storer = tx
+	txService.auditStorer = buffer
+	txService.publisher = nopPublisher{}
+
+	return &txService
+}
+
+func (a *auditBuffer) SaveAuditRecord(ctx context.Context, record AuditRecord) error {
+	a.records = append(a.records, record)
+
+	return nil
+}
+
+func (a *auditBuffer) QueryAuditRecords(ctx context.Context, filter AuditFilter) (AuditRecordsResult, error) {
+	return a.auditStorer.QueryAuditRecords(ctx, filter)
+}
+
+func (b BatchOperation) petID() PetID {
+	switch {
+	case b.UpdatePet != nil:
+		return b.UpdatePet.ID
+	default:
+		return b.PetID
+	}
+}
+
+func batchFailed(items []BatchItemResult) bool {
+	for _, item := range items {
+		if item.Status == BatchFailed {
+			return true
+		}
+	}
+
+	return false
+}
+
+// abortBatch marks as aborted the operations that succeeded in a batch
+// that was rolled back.
+func abortBatch(items []BatchItemResult) []BatchItemResult {
+	for index, item := range items {
+		if item.Status != BatchSucceeded {
+			continue
+		}
+
+		items[index].Status = BatchAborted
+		items[index].Err = errBatchNotPersisted.Error()
+
+		if item.Type == BatchCreate {
+			items[index].ID = EmptyPetID
+		}
+	}
+
+	return items
+}
+
+// newBatchDataResult create a new BatchDataResult
+func newBatchDataResult(result BatchResult, err error) BatchDataResult {
+	var errmessage string
+	if err != nil {
+		errmessage = err.Error()
+	}
+	return BatchDataResult{
+		Result:  result,
+		Err:     errmessage,
+		ErrKind: KindOf(err),
+	}
+}
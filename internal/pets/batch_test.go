@@ -0,0 +1,200 @@
+package pets_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/fernandoocampo/basic-micro/internal/pets"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBatchIndependent(t *testing.T) {
+	t.Parallel()
+
+	// Given
+	request := pets.BatchRequest{
+		Operations: []pets.BatchOperation{
+			{Type: pets.BatchCreate, NewPet: &pets.NewPet{Name: "drila"}},
+			{Type: pets.BatchUpdate, UpdatePet: &pets.UpdatePet{ID: "858455b7-e182-4122-a1b6-132c64d2f77b"}},
+			{Type: pets.BatchCreate, NewPet: &pets.NewPet{Name: "michael"}},
+		},
+	}
+
+	storerMock := newStorerMock()
+	auditStorer := newAuditStorerMock()
+
+	settings := pets.ServiceSetup{
+		Storer:      storerMock,
+		AuditStorer: auditStorer,
+		Logger:      newLogger(),
+	}
+
+	service := pets.NewService(settings)
+
+	ctx := context.TODO()
+
+	// When
+	got, err := service.Batch(ctx, request)
+
+	// Then
+	assert.NoError(t, err)
+	assert.Len(t, got.Items, 3)
+	assert.Equal(t, pets.BatchSucceeded, got.Items[0].Status)
+	assert.Equal(t, pets.BatchFailed, got.Items[1].Status)
+	assert.NotEmpty(t, got.Items[1].Err)
+	assert.Equal(t, pets.BatchSucceeded, got.Items[2].Status)
+	assert.Len(t, storerMock.ids, 2)
+	assert.Len(t, auditStorer.records, 2)
+	assert.False(t, storerMock.inTransaction)
+}
+
+func TestBatchAtomicRollsBack(t *testing.T) {
+	t.Parallel()
+
+	// Given
+	request := pets.BatchRequest{
+		Atomic: true,
+		Operations: []pets.BatchOperation{
+			{Type: pets.BatchCreate, NewPet: &pets.NewPet{Name: "drila"}},
+			{Type: pets.BatchUpdate, UpdatePet: &pets.UpdatePet{ID: "858455b7-e182-4122-a1b6-132c64d2f77b"}},
+			{Type: pets.BatchDelete, PetID: "858455b7-e182-4122-a1b6-132c64d2f77b"},
+		},
+	}
+
+	expectedStatus := []pets.BatchItemStatus{
+		pets.BatchAborted,
+		pets.BatchFailed,
+		pets.BatchAborted,
+	}
+
+	storerMock := newStorerMock()
+	auditStorer := newAuditStorerMock()
+	publisher := newPublisherMock()
+
+	settings := pets.ServiceSetup{
+		Storer:      storerMock,
+		AuditStorer: auditStorer,
+		Publisher:   publisher,
+		Logger:      newLogger(),
+	}
+
+	service := pets.NewService(settings)
+
+	ctx := context.TODO()
+
+	// When
+	got, err := service.Batch(ctx, request)
+
+	// Then
+	assert.EqualError(t, err, "batch was rolled back because an operation failed")
+	for index, item := range got.Items {
+		assert.Equal(t, expectedStatus[index], item.Status)
+	}
+	assert.Equal(t, pets.EmptyPetID, got.Items[0].ID)
+	assert.True(t, storerMock.inTransaction)
+	assert.Empty(t, auditStorer.records)
+	assert.Empty(t, publisher.records)
+}
+
+func TestBatchAtomicCommitsAuditRecords(t *testing.T) {
+	t.Parallel()
+
+	// Given
+	request := pets.BatchRequest{
+		Atomic: true,
+		Operations: []pets.BatchOperation{
+			{Type: pets.BatchCreate, NewPet: &pets.NewPet{Name: "drila"}},
+			{Type: pets.BatchCreate, NewPet: &pets.NewPet{Name: "michael"}},
+		},
+	}
+
+	storerMock := newStorerMock()
+	auditStorer := newAuditStorerMock()
+	publisher := newPublisherMock()
+
+	settings := pets.ServiceSetup{
+		Storer:      storerMock,
+		AuditStorer: auditStorer,
+		Publisher:   publisher,
+		Logger:      newLogger(),
+	}
+
+	service := pets.NewService(settings)
+
+	ctx := context.TODO()
+
+	// When
+	got, err := service.Batch(ctx, request)
+
+	// Then
+	assert.NoError(t, err)
+	assert.Equal(t, storerMock.ids[0], got.Items[0].ID)
+	assert.Equal(t, storerMock.ids[1], got.Items[1].ID)
+	assert.Len(t, auditStorer.records, 2)
+	assert.Len(t, publisher.records, 2)
+}
+
+func TestBatchAtomicConflict(t *testing.T) {
+	t.Parallel()
+
+	// Given
+	request := pets.BatchRequest{
+		Atomic: true,
+		Operations: []pets.BatchOperation{
+			{Type: pets.BatchCreate, NewPet: &pets.NewPet{Name: "drila"}},
+			{Type: pets.BatchCreate, NewPet: &pets.NewPet{Name: "michael"}},
+		},
+	}
+
+	storerMock := newStorerMock(withTransactionErr(pets.ErrTransactionConflict))
+	auditStorer := newAuditStorerMock()
+	publisher := newPublisherMock()
+
+	settings := pets.ServiceSetup{
+		Storer:      storerMock,
+		AuditStorer: auditStorer,
+		Publisher:   publisher,
+		Logger:      newLogger(),
+	}
+
+	service := pets.NewService(settings)
+
+	ctx := context.TODO()
+
+	// When
+	got, err := service.Batch(ctx, request)
+
+	// Then
+	assert.EqualError(t, err, "batch was rolled back because another request changed its pets, it can be retried")
+	assert.Equal(t, pets.AbortedError, pets.KindOf(err))
+	for _, item := range got.Items {
+		assert.Equal(t, pets.BatchAborted, item.Status)
+		assert.Equal(t, pets.EmptyPetID, item.ID)
+	}
+	assert.Empty(t, auditStorer.records)
+	assert.Empty(t, publisher.records)
+}
+
+func TestBatchTooLarge(t *testing.T) {
+	t.Parallel()
+
+	// Given
+	request := pets.BatchRequest{
+		Operations: make([]pets.BatchOperation, pets.BatchMaxOperations+1),
+	}
+
+	settings := pets.ServiceSetup{
+		Storer: newStorerMock(),
+		Logger: newLogger(),
+	}
+
+	service := pets.NewService(settings)
+
+	ctx := context.TODO()
+
+	// When
+	_, err := service.Batch(ctx, request)
+
+	// Then
+	assert.EqualError(t, err, "batch cannot have more than 100 operations")
+}
@@ -2,7 +2,6 @@ package pets
 
 import (
 	"context"
-	"errors"
 	"fmt"
 	"log/slog"
 )
@@ -32,23 +31,80 @@ type SearchPetsEndpoint struct {
 	logger  *slog.Logger
 }
 
+type RestorePetEndpoint struct {
+	service *Service
+	logger  *slog.Logger
+}
+
+type StartImportEndpoint struct {
+	importer *Importer
+	logger   *slog.Logger
+}
+
+type GetImportJobEndpoint struct {
+	importer *Importer
+	logger   *slog.Logger
+}
+
+type ExportPetsEndpoint struct {
+	service *Service
+	logger  *slog.Logger
+}
+
+type BatchPetsEndpoint struct {
+	service *Service
+	logger  *slog.Logger
+}
+
+type PetHistoryEndpoint struct {
+	service *Service
+	logger  *slog.Logger
+}
+
+type ReportSightingEndpoint struct {
+	service *Service
+	logger  *slog.Logger
+}
+
+type SearchNearbyEndpoint struct {
+	service *Service
+	logger  *slog.Logger
+}
+
 // Endpoints is a wrapper for endpoints
 type Endpoints struct {
-	GetPetWithIDEndpoint *GetPetWithIDEndpoint
-	CreatePetEndpoint    *CreatePetEndpoint
-	UpdatePetEndpoint    *UpdatePetEndpoint
-	DeletePetEndpoint    *DeletePetEndpoint
-	SearchPetsEndpoint   *SearchPetsEndpoint
+	GetPetWithIDEndpoint   *GetPetWithIDEndpoint
+	CreatePetEndpoint      *CreatePetEndpoint
+	UpdatePetEndpoint      *UpdatePetEndpoint
+	DeletePetEndpoint      *DeletePetEndpoint
+	RestorePetEndpoint     *RestorePetEndpoint
+	PetHistoryEndpoint     *PetHistoryEndpoint
+	BatchPetsEndpoint      *BatchPetsEndpoint
+	StartImportEndpoint    *StartImportEndpoint
+	GetImportJobEndpoint   *GetImportJobEndpoint
+	SearchPetsEndpoint     *SearchPetsEndpoint
+	ExportPetsEndpoint     *ExportPetsEndpoint
+	ReportSightingEndpoint *ReportSightingEndpoint
+	SearchNearbyEndpoint   *SearchNearbyEndpoint
 }
 
-// NewEndpoints Create the endpoints for pets application.
-func NewEndpoints(service *Service, logger *slog.Logger) Endpoints {
+// NewEndpoints Create the endpoints for pets application, import jobs are
+// run by the given importer.
+func NewEndpoints(service *Service, importer *Importer, logger *slog.Logger) Endpoints {
 	return Endpoints{
-		CreatePetEndpoint:    MakeCreatePetEndpoint(service, logger),
-		UpdatePetEndpoint:    MakeUpdatePetEndpoint(service, logger),
-		DeletePetEndpoint:    MakeDeletePetEndpoint(service, logger),
-		GetPetWithIDEndpoint: MakeGetPetWithIDEndpoint(service, logger),
-		SearchPetsEndpoint:   MakeSearchPetsEndpoint(service, logger),
+		CreatePetEndpoint:      MakeCreatePetEndpoint(service, logger),
+		UpdatePetEndpoint:      MakeUpdatePetEndpoint(service, logger),
+		DeletePetEndpoint:      MakeDeletePetEndpoint(service, logger),
+		RestorePetEndpoint:     MakeRestorePetEndpoint(service, logger),
+		PetHistoryEndpoint:     MakePetHistoryEndpoint(service, logger),
+		BatchPetsEndpoint:      MakeBatchPetsEndpoint(service, logger),
+		StartImportEndpoint:    MakeStartImportEndpoint(importer, logger),
+		GetImportJobEndpoint:   MakeGetImportJobEndpoint(importer, logger),
+		GetPetWithIDEndpoint:   MakeGetPetWithIDEndpoint(service, logger),
+		SearchPetsEndpoint:     MakeSearchPetsEndpoint(service, logger),
+		ExportPetsEndpoint:     MakeExportPetsEndpoint(service, logger),
+		ReportSightingEndpoint: MakeReportSightingEndpoint(service, logger),
+		SearchNearbyEndpoint:   MakeSearchNearbyEndpoint(service, logger),
 	}
 }
 
@@ -92,6 +148,16 @@ func MakeDeletePetEndpoint(srv *Service, logger *slog.Logger) *DeletePetEndpoint
 	return &newNewEndpoint
 }
 
+// MakeRestorePetEndpoint create endpoint for the restore pet service.
+func MakeRestorePetEndpoint(srv *Service, logger *slog.Logger) *RestorePetEndpoint {
+	newNewEndpoint := RestorePetEndpoint{
+		service: srv,
+		logger:  logger,
+	}
+
+	return &newNewEndpoint
+}
+
 // MakeSearchPetsEndpoint pet endpoint to search pets with filters.
 func MakeSearchPetsEndpoint(srv *Service, logger *slog.Logger) *SearchPetsEndpoint {
 	newNewEndpoint := SearchPetsEndpoint{
@@ -102,14 +168,77 @@ func MakeSearchPetsEndpoint(srv *Service, logger *slog.Logger) *SearchPetsEndpoi
 	return &newNewEndpoint
 }
 
-func (g *GetPetWithIDEndpoint) Do(ctx context.Context, request any) (any, error) {
-	petID, ok := request.(PetID)
-	if !ok {
-		g.logger.Error("invalid pet id", slog.String("request", fmt.Sprintf("%t", request)))
+// MakeStartImportEndpoint create endpoint to start an import job.
+func MakeStartImportEndpoint(importer *Importer, logger *slog.Logger) *StartImportEndpoint {
+	newNewEndpoint := StartImportEndpoint{
+		importer: importer,
+		logger:   logger,
+	}
 
-		return nil, errors.New("invalid pet id")
+	return &newNewEndpoint
+}
+
+// MakeGetImportJobEndpoint create endpoint to poll an import job.
+func MakeGetImportJobEndpoint(importer *Importer, logger *slog.Logger) *GetImportJobEndpoint {
+	newNewEndpoint := GetImportJobEndpoint{
+		importer: importer,
+		logger:   logger,
+	}
+
+	return &newNewEndpoint
+}
+
+// MakeBatchPetsEndpoint create endpoint to apply a batch of pet operations.
+func MakeBatchPetsEndpoint(srv *Service, logger *slog.Logger) *BatchPetsEndpoint {
+	newNewEndpoint := BatchPetsEndpoint{
+		service: srv,
+		logger:  logger,
+	}
+
+	return &newNewEndpoint
+}
+
+// MakePetHistoryEndpoint create endpoint to query the change history of a pet.
+func MakePetHistoryEndpoint(srv *Service, logger *slog.Logger) *PetHistoryEndpoint {
+	newNewEndpoint := PetHistoryEndpoint{
+		service: srv,
+		logger:  logger,
+	}
+
+	return &newNewEndpoint
+}
+
+// MakeReportSightingEndpoint create endpoint to report a pet sighting.
+func MakeReportSightingEndpoint(srv *Service, logger *slog.Logger) *ReportSightingEndpoint {
+	newNewEndpoint := ReportSightingEndpoint{
+		service: srv,
+		logger:  logger,
+	}
+
+	return &newNewEndpoint
+}
+
+// MakeSearchNearbyEndpoint create endpoint to search pets around a location.
+func MakeSearchNearbyEndpoint(srv *Service, logger *slog.Logger) *SearchNearbyEndpoint {
+	newNewEndpoint := SearchNearbyEndpoint{
+		service: srv,
+		logger:  logger,
+	}
+
+	return &newNewEndpoint
+}
+
+// MakeExportPetsEndpoint create endpoint to export all pets.
+func MakeExportPetsEndpoint(srv *Service, logger *slog.Logger) *ExportPetsEndpoint {
+	newNewEndpoint := ExportPetsEndpoint{
+		service: srv,
+		logger:  logger,
 	}
 
+	return &newNewEndpoint
+}
+
+func (g *GetPetWithIDEndpoint) Do(ctx context.Context, petID PetID) (GetPetWithIDResult, error) {
 	petFound, err := g.service.QueryByID(ctx, petID)
 	if err != nil {
 		g.logger.Error(
@@ -124,14 +253,7 @@ func (g *GetPetWithIDEndpoint) Do(ctx context.Context, request any) (any, error)
 	return newGetPetWithIDResult(petFound, err), nil
 }
 
-func (c *CreatePetEndpoint) Do(ctx context.Context, request any) (any, error) {
-	newPet, ok := request.(*NewPet)
-	if !ok {
-		c.logger.Error("invalid new pet type", slog.String("request", fmt.Sprintf("%t", request)))
-
-		return nil, errors.New("invalid new pet type")
-	}
-
+func (c *CreatePetEndpoint) Do(ctx context.Context, newPet *NewPet) (CreatePetResult, error) {
 	newid, err := c.service.Create(ctx, *newPet)
 	if err != nil {
 		c.logger.Error(
@@ -143,14 +265,7 @@ func (c *CreatePetEndpoint) Do(ctx context.Context, request any) (any, error) {
 	return newCreatePetResult(newid, err), nil
 }
 
-func (u *UpdatePetEndpoint) Do(ctx context.Context, request any) (any, error) {
-	updatePet, ok := request.(*UpdatePet)
-	if !ok {
-		u.logger.Error("invalid update pet type", slog.String("request", fmt.Sprintf("%t", request)))
-
-		return nil, errors.New("invalid update pet type")
-	}
-
+func (u *UpdatePetEndpoint) Do(ctx context.Context, updatePet *UpdatePet) (UpdatePetResult, error) {
 	err := u.service.Update(ctx, *updatePet)
 	if err != nil {
 		u.logger.Error(
@@ -162,14 +277,7 @@ func (u *UpdatePetEndpoint) Do(ctx context.Context, request any) (any, error) {
 	return newUpdatePetResult(err), nil
 }
 
-func (d *DeletePetEndpoint) Do(ctx context.Context, request any) (any, error) {
-	petID, ok := request.(PetID)
-	if !ok {
-		d.logger.Error("invalid delete pet type", slog.String("received", fmt.Sprintf("%t", request)))
-
-		return nil, errors.New("invalid pet id type")
-	}
-
+func (d *DeletePetEndpoint) Do(ctx context.Context, petID PetID) (DeletePetResult, error) {
 	err := d.service.Delete(ctx, petID)
 	if err != nil {
 		d.logger.Error(
@@ -182,14 +290,20 @@ func (d *DeletePetEndpoint) Do(ctx context.Context, request any) (any, error) {
 
 }
 
-func (s *SearchPetsEndpoint) Do(ctx context.Context, request any) (any, error) {
-	petFilters, ok := request.(QueryFilter)
-	if !ok {
-		s.logger.Error("invalid pet filters", slog.String("received", fmt.Sprintf("%t", request)))
-
-		return nil, errors.New("invalid pet filters")
+func (r *RestorePetEndpoint) Do(ctx context.Context, petID PetID) (RestorePetResult, error) {
+	err := r.service.Restore(ctx, petID)
+	if err != nil {
+		r.logger.Error(
+			"restoring pet with the given id",
+			slog.String("id", petID.String()),
+			slog.String("error", err.Error()),
+		)
 	}
 
+	return newRestorePetResult(err), nil
+}
+
+func (s *SearchPetsEndpoint) Do(ctx context.Context, petFilters QueryFilter) (SearchPetsDataResult, error) {
 	searchResult, err := s.service.Query(ctx, petFilters)
 	if err != nil {
 		s.logger.Error(
@@ -203,3 +317,98 @@ func (s *SearchPetsEndpoint) Do(ctx context.Context, request any) (any, error) {
 
 	return newSearchPetsDataResult(searchResult, err), nil
 }
+
+func (r *ReportSightingEndpoint) Do(ctx context.Context, newSighting *NewSighting) (ReportSightingResult, error) {
+	newid, err := r.service.ReportSighting(ctx, *newSighting)
+	if err != nil {
+		r.logger.Error(
+			"reporting sighting",
+			slog.String("new_sighting", fmt.Sprintf("%+v", newSighting)),
+			slog.String("error", err.Error()),
+		)
+	}
+
+	return newReportSightingResult(newid, err), nil
+}
+
+func (s *SearchNearbyEndpoint) Do(ctx context.Context, nearbyFilter NearbyFilter) (SearchNearbyDataResult, error) {
+	nearbyResult, err := s.service.QueryNearby(ctx, nearbyFilter)
+	if err != nil {
+		s.logger.Error(
+			"querying nearby pets with the given filter",
+			slog.String("filter", fmt.Sprintf("%+v", nearbyFilter)),
+			slog.String("error", err.Error()),
+		)
+	}
+
+	s.logger.Debug("search nearby endpoint", slog.String("result", fmt.Sprintf("%+v", nearbyResult)))
+
+	return newSearchNearbyDataResult(nearbyResult, err), nil
+}
+
+func (e *ExportPetsEndpoint) Do(ctx context.Context, exportRequest ExportPetsRequest) (ExportPetsResult, error) {
+	iterator, err := e.service.Export(ctx, exportRequest.Filter)
+	if err != nil {
+		e.logger.Error(
+			"exporting pets with the given filter",
+			slog.String("filter", fmt.Sprintf("%+v", exportRequest.Filter)),
+			slog.String("error", err.Error()),
+		)
+	}
+
+	return newExportPetsResult(iterator, exportRequest.Format, err), nil
+}
+
+func (p *PetHistoryEndpoint) Do(ctx context.Context, historyFilter AuditFilter) (PetHistoryDataResult, error) {
+	historyResult, err := p.service.History(ctx, historyFilter)
+	if err != nil {
+		p.logger.Error(
+			"querying pet history with the given filter",
+			slog.String("filter", fmt.Sprintf("%+v", historyFilter)),
+			slog.String("error", err.Error()),
+		)
+	}
+
+	return newPetHistoryDataResult(historyResult, err), nil
+}
+
+func (b *BatchPetsEndpoint) Do(ctx context.Context, batchRequest *BatchRequest) (BatchDataResult, error) {
+	batchResult, err := b.service.Batch(ctx, *batchRequest)
+	if err != nil {
+		b.logger.Error(
+			"applying batch of pet operations",
+			slog.Int("operations", len(batchRequest.Operations)),
+			slog.String("error", err.Error()),
+		)
+	}
+
+	return newBatchDataResult(batchResult, err), nil
+}
+
+func (s *StartImportEndpoint) Do(ctx context.Context, reader ImportReader) (ImportJobResult, error) {
+	job, err := s.importer.Start(ctx, reader)
+	if err != nil {
+		s.logger.Error("starting import job", slog.String("error", err.Error()))
+
+		return newImportJobResult(nil, err), nil
+	}
+
+	s.logger.Info("import job was started", slog.String("id", job.ID.String()))
+
+	return newImportJobResult(&job, nil), nil
+}
+
+func (g *GetImportJobEndpoint) Do(ctx context.Context, jobID ImportJobID) (ImportJobResult, error) {
+	job, err := g.importer.Job(jobID)
+	if err != nil {
+		g.logger.Error(
+			"querying import job with the given id",
+			slog.String("id", jobID.String()),
+			slog.String("error", err.Error()),
+		)
+
+		return newImportJobResult(nil, err), nil
+	}
+
+	return newImportJobResult(&job, nil), nil
+}
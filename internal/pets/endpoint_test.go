@@ -1,6 +1,29 @@
 package pets_test
 
-import "testing"
+import (
+	"log/slog"
+	"reflect"
+	"testing"
+
+	"github.com/fernandoocampo/basic-micro/internal/pets"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewEndpointsCreatesEveryEndpoint(t *testing.T) {
+	// Given
+	logger := slog.Default()
+	service := pets.NewService(pets.ServiceSetup{Logger: logger})
+	importer := pets.NewImporter(pets.ImporterSetup{Service: service, Logger: logger})
+
+	// When
+	endpoints := pets.NewEndpoints(service, importer, logger)
+
+	// Then
+	value := reflect.ValueOf(endpoints)
+	for i := 0; i < value.NumField(); i++ {
+		assert.False(t, value.Field(i).IsNil(), "endpoint %s was not created", value.Type().Field(i).Name)
+	}
+}
 
 func TestGetPetSuccessfully(t *testing.T) {
 
@@ -0,0 +1,63 @@
+package pets
+
+import "errors"
+
+// ErrorKind says why a request to the service failed, so transports can
+// answer each kind with its own status.
+type ErrorKind string
+
+// error kinds
+const (
+	// NoError the request did not fail.
+	NoError ErrorKind = ""
+	// InternalError the service failed, like a store that is not available.
+	InternalError ErrorKind = "internal"
+	// InvalidError the request data is not valid, like a pet without name.
+	InvalidError ErrorKind = "invalid"
+	// NotFoundError the pet or the import job does not exist.
+	NotFoundError ErrorKind = "not_found"
+	// AbortedError the changes were rolled back because one of them failed.
+	AbortedError ErrorKind = "aborted"
+	// UnavailableError the service cannot take the request now, like when
+	// too many imports are running, it can be retried later.
+	UnavailableError ErrorKind = "unavailable"
+)
+
+// kindError is a domain error of a known kind.
+type kindError struct {
+	kind    ErrorKind
+	message string
+}
+
+func newKindError(kind ErrorKind, message string) error {
+	newError := kindError{
+		kind:    kind,
+		message: message,
+	}
+
+	return &newError
+}
+
+func (e *kindError) Error() string {
+	return e.message
+}
+
+// KindOf returns the kind of the given error, errors that are not domain
+// errors are internal.
+func KindOf(err error) ErrorKind {
+	if err == nil {
+		return NoError
+	}
+
+	var validationErr *ValidationError
+	if errors.As(err, &validationErr) {
+		return InvalidError
+	}
+
+	var kindErr *kindError
+	if errors.As(err, &kindErr) {
+		return kindErr.kind
+	}
+
+	return InternalError
+}
@@ -0,0 +1,55 @@
+package pets_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/fernandoocampo/basic-micro/internal/pets"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestKindOf(t *testing.T) {
+	t.Parallel()
+
+	// Given
+	service := pets.NewService(pets.ServiceSetup{Storer: newStorerMock(), Logger: newLogger()})
+	ctx := context.TODO()
+	_, errInvalid := service.Create(ctx, pets.NewPet{})
+	errNotFound := service.Restore(ctx, "858455b7-e182-4122-a1b6-132c64d2f77b")
+
+	cases := map[string]struct {
+		err  error
+		want pets.ErrorKind
+	}{
+		"no error": {
+			err:  nil,
+			want: pets.NoError,
+		},
+		"validation error": {
+			err:  errInvalid,
+			want: pets.InvalidError,
+		},
+		"wrapped not found error": {
+			err:  fmt.Errorf("restoring: %w", errNotFound),
+			want: pets.NotFoundError,
+		},
+		"other error": {
+			err:  errors.New("database was not available"),
+			want: pets.InternalError,
+		},
+	}
+
+	for name, testCase := range cases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			// When
+			got := pets.KindOf(testCase.err)
+
+			// Then
+			assert.Equal(t, testCase.want, got)
+		})
+	}
+}
@@ -0,0 +1,75 @@
+package pets
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+)
+
+// ExportFilter contains filters to export pets.
+type ExportFilter struct {
+	IncludeDeleted bool
+}
+
+// PetIterator reads pets one at a time, so all pets can be read without
+// keeping them in memory. It must be closed once it is not needed.
+//
+//	for iterator.Next() {
+//		pet := iterator.Pet()
+//	}
+//	err := iterator.Err()
+type PetIterator interface {
+	// Next moves to the next pet, it returns false when there are no more
+	// pets or an error happened.
+	Next() bool
+	// Pet returns the current pet.
+	Pet() Pet
+	// Err returns the error that stopped the iteration, if any.
+	Err() error
+	Close() error
+}
+
+// ExportPetsRequest contains the data to export pets in the given format.
+type ExportPetsRequest struct {
+	Filter ExportFilter
+	Format string
+}
+
+// ExportPetsResult standard response for exporting pets, Pets must be
+// closed once it is read.
+type ExportPetsResult struct {
+	Pets    PetIterator
+	Format  string
+	Err     string
+	ErrKind ErrorKind
+}
+
+var errExportPets = errors.New("unable to export pets")
+
+// Export returns an iterator over all pets ordered by id.
+func (s *Service) Export(ctx context.Context, filter ExportFilter) (PetIterator, error) {
+	s.logger.Debug("starting export of pets", slog.Bool("include_deleted", filter.IncludeDeleted))
+
+	iterator, err := s.storer.Iterate(ctx, filter)
+	if err != nil {
+		s.logger.Error("iterating pets", "error", err)
+
+		return nil, errExportPets
+	}
+
+	return iterator, nil
+}
+
+// newExportPetsResult create a new ExportPetsResult
+func newExportPetsResult(iterator PetIterator, format string, err error) ExportPetsResult {
+	var errmessage string
+	if err != nil {
+		errmessage = err.Error()
+	}
+	return ExportPetsResult{
+		Pets:    iterator,
+		Format:  format,
+		Err:     errmessage,
+		ErrKind: KindOf(err),
+	}
+}
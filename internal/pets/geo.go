@@ -0,0 +1,38 @@
+package pets
+
+import "math"
+
+// earthRadiusKm is the mean earth radius used by the haversine formula.
+const earthRadiusKm = 6371.0088
+
+// valid location limits in decimal degrees.
+const (
+	minLatitude  = -90.0
+	maxLatitude  = 90.0
+	minLongitude = -180.0
+	maxLongitude = 180.0
+)
+
+// Distance calculates the great-circle distance in kilometers between two
+// locations using the haversine formula.
+func Distance(from, to Location) float64 {
+	fromLatitude := toRadians(from.Latitude)
+	toLatitude := toRadians(to.Latitude)
+	deltaLatitude := toRadians(to.Latitude - from.Latitude)
+	deltaLongitude := toRadians(to.Longitude - from.Longitude)
+
+	a := math.Pow(math.Sin(deltaLatitude/2), 2) +
+		math.Cos(fromLatitude)*math.Cos(toLatitude)*math.Pow(math.Sin(deltaLongitude/2), 2)
+
+	return 2 * earthRadiusKm * math.Asin(math.Min(1, math.Sqrt(a)))
+}
+
+// IsValid says if latitude and longitude are within their ranges.
+func (l Location) IsValid() bool {
+	return l.Latitude >= minLatitude && l.Latitude <= maxLatitude &&
+		l.Longitude >= minLongitude && l.Longitude <= maxLongitude
+}
+
+func toRadians(degrees float64) float64 {
+	return degrees * math.Pi / 180
+}
@@ -0,0 +1,55 @@
+package pets_test
+
+import (
+	"testing"
+
+	"github.com/fernandoocampo/basic-micro/internal/pets"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDistance(t *testing.T) {
+	t.Parallel()
+
+	cases := map[string]struct {
+		from pets.Location
+		to   pets.Location
+		want float64
+	}{
+		"same_location": {
+			from: pets.Location{Latitude: 4.711, Longitude: -74.0721},
+			to:   pets.Location{Latitude: 4.711, Longitude: -74.0721},
+			want: 0,
+		},
+		"bogota_to_medellin": {
+			from: pets.Location{Latitude: 4.711, Longitude: -74.0721},
+			to:   pets.Location{Latitude: 6.2442, Longitude: -75.5812},
+			want: 238.7,
+		},
+		"paris_to_london": {
+			from: pets.Location{Latitude: 48.8566, Longitude: 2.3522},
+			to:   pets.Location{Latitude: 51.5074, Longitude: -0.1278},
+			want: 343.5,
+		},
+	}
+
+	for name, tc := range cases {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			// When
+			got := pets.Distance(tc.from, tc.to)
+
+			// Then
+			assert.InDelta(t, tc.want, got, 0.5)
+		})
+	}
+}
+
+func TestLocationIsValid(t *testing.T) {
+	t.Parallel()
+
+	assert.True(t, pets.Location{Latitude: 90, Longitude: -180}.IsValid())
+	assert.False(t, pets.Location{Latitude: 90.1, Longitude: 0}.IsValid())
+	assert.False(t, pets.Location{Latitude: 0, Longitude: 180.1}.IsValid())
+}
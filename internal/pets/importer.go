@@ -0,0 +1,367 @@
+package pets
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ImportJobID defines import job id.
+type ImportJobID string
+
+// ImportJobStatus defines the status of an import job.
+type ImportJobStatus string
+
+// import job status
+const (
+	ImportRunning   ImportJobStatus = "running"
+	ImportCompleted ImportJobStatus = "completed"
+	ImportFailed    ImportJobStatus = "failed"
+)
+
+const (
+	// ImportBatchSizeDefault number of pets saved at once by default.
+	ImportBatchSizeDefault = 500
+	// ImportMaxJobsDefault number of import jobs that run at once by default.
+	ImportMaxJobsDefault = 4
+	// importJobRetention how long finished jobs can be polled.
+	importJobRetention = 24 * time.Hour
+)
+
+// ImportRow is a row read from an import source. Err is set when the row
+// could not be parsed.
+type ImportRow struct {
+	Line   int
+	NewPet NewPet
+	Err    error
+}
+
+// ImportReader reads the rows to import, it returns io.EOF when there are
+// no more rows.
+type ImportReader interface {
+	Next() (ImportRow, error)
+}
+
+// RejectedRow contains a row that was not imported and the reason.
+type RejectedRow struct {
+	Line   int    `json:"line"`
+	Reason string `json:"reason"`
+}
+
+// ImportReport contains the result of an import.
+type ImportReport struct {
+	Total    int           `json:"total"`
+	Imported int           `json:"imported"`
+	Rejected []RejectedRow `json:"rejected"`
+}
+
+// ImportJob contains the status of an asynchronous import.
+type ImportJob struct {
+	ID         ImportJobID
+	Status     ImportJobStatus
+	Report     ImportReport
+	Err        string
+	StartedAt  time.Time
+	FinishedAt *time.Time
+}
+
+// ImportJobResult standard response for starting or polling an import job.
+type ImportJobResult struct {
+	Job     *ImportJob
+	Err     string
+	ErrKind ErrorKind
+}
+
+// ImporterSetup contains importer metadata.
+type ImporterSetup struct {
+	Service *Service
+	// MaxJobs number of jobs that run at once, new jobs are rejected while
+	// they run. ImportMaxJobsDefault is used if it is not positive.
+	MaxJobs int
+	Logger  *slog.Logger
+}
+
+// Importer runs imports as asynchronous jobs that can be polled. Running
+// jobs are cancelled and waited for when the importer stops.
+type Importer struct {
+	service *Service
+	maxJobs int
+	logger  *slog.Logger
+	mutex   sync.RWMutex
+	jobs    map[ImportJobID]*ImportJob
+	running int
+	stopped bool
+	// jobsCtx is cancelled by stopJobs when the importer stops, so the
+	// running jobs are cancelled too.
+	jobsCtx  context.Context
+	stopJobs context.CancelFunc
+	wait     sync.WaitGroup
+}
+
+var (
+	errReadImport      = errors.New("unable to read pets to import")
+	errImportCancelled = errors.New("import was cancelled")
+	errImportNotFound  = newKindError(NotFoundError, "import job does not exist")
+	errTooManyImports  = newKindError(UnavailableError, "too many imports are running, try again later")
+	errImporterStopped = newKindError(UnavailableError, "imports are not accepted while the service stops")
+)
+
+// NewImporter create a new importer.
+func NewImporter(setup ImporterSetup) *Importer {
+	jobsCtx, stopJobs := context.WithCancel(context.Background())
+
+	newImporter := Importer{
+		service:  setup.Service,
+		maxJobs:  setup.MaxJobs,
+		logger:   setup.Logger,
+		jobs:     make(map[ImportJobID]*ImportJob),
+		jobsCtx:  jobsCtx,
+		stopJobs: stopJobs,
+	}
+
+	if newImporter.maxJobs <= 0 {
+		newImporter.maxJobs = ImportMaxJobsDefault
+	}
+
+	return &newImporter
+}
+
+// Import validates every row as a new pet and saves the valid ones in
+// batches. Rows that are invalid or belong to a batch that could not be
+// saved are part of the report.
+func (s *Service) Import(ctx context.Context, reader ImportReader) (ImportReport, error) {
+	s.logger.Info("starting import of pets")
+	report := ImportReport{
+		Rejected: make([]RejectedRow, 0),
+	}
+
+	batchSize := s.importBatchSize
+
+	batch := make([]Pet, 0, batchSize)
+	lines := make([]int, 0, batchSize)
+
+	for {
+		if ctx.Err() != nil {
+			s.logger.Info("import of pets was cancelled", slog.Int("imported", report.Imported))
+
+			return report, errImportCancelled
+		}
+
+		row, err := reader.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+
+		if err != nil {
+			s.logger.Error("reading pets to import", "error", err)
+
+			return report, errReadImport
+		}
+
+		report.Total++
+
+		if row.Err != nil {
+			report.reject(row.Line, row.Err)
+
+			continue
+		}
+
+		err = validNewPet(row.NewPet)
+		if err != nil {
+			report.reject(row.Line, err)
+
+			continue
+		}
+
+		batch = append(batch, buildNewPet(row.NewPet))
+		lines = append(lines, row.Line)
+
+		if len(batch) == batchSize {
+			s.saveImportBatch(ctx, batch, lines, &report)
+			batch = batch[:0]
+			lines = lines[:0]
+		}
+	}
+
+	if len(batch) > 0 {
+		s.saveImportBatch(ctx, batch, lines, &report)
+	}
+
+	s.logger.Info(
+		"import of pets finished",
+		slog.Int("total", report.Total),
+		slog.Int("imported", report.Imported),
+		slog.Int("rejected", len(report.Rejected)),
+	)
+
+	return report, nil
+}
+
+func (s *Service) saveImportBatch(ctx context.Context, batch []Pet, lines []int, report *ImportReport) {
+	err := s.storer.SaveBatch(ctx, batch)
+	if err != nil {
+		s.logger.Error("saving import batch", "error", err, slog.Int("size", len(batch)))
+
+		for _, line := range lines {
+			report.reject(line, errSavePet)
+		}
+
+		return
+	}
+
+	report.Imported += len(batch)
+
+	for index := range batch {
+		s.audit(ctx, batch[index].ID, AuditCreated, diffPets(nil, &batch[index]))
+	}
+}
+
+// Start starts an import job in background and returns it right away.
+// The job is not cancelled with ctx, it keeps only its values, and it is
+// cancelled when the importer stops. Jobs are rejected while the maximum
+// of jobs is running or after the importer stopped.
+func (i *Importer) Start(ctx context.Context, reader ImportReader) (ImportJob, error) {
+	i.removeExpiredJobs()
+
+	i.mutex.Lock()
+	defer i.mutex.Unlock()
+
+	if i.stopped {
+		return ImportJob{}, errImporterStopped
+	}
+
+	if i.running >= i.maxJobs {
+		return ImportJob{}, errTooManyImports
+	}
+
+	job := ImportJob{
+		ID:        newImportJobID(),
+		Status:    ImportRunning,
+		StartedAt: time.Now().UTC(),
+	}
+
+	// the job kept in the importer is a copy, so the returned job is not
+	// changed by the running import.
+	runningJob := job
+	i.jobs[job.ID] = &runningJob
+	i.running++
+	i.wait.Add(1)
+
+	jobCtx, cancel := context.WithCancel(context.WithoutCancel(ctx))
+	stopCancel := context.AfterFunc(i.jobsCtx, cancel)
+
+	go func() {
+		defer i.wait.Done()
+		defer cancel()
+		defer stopCancel()
+
+		i.run(jobCtx, job.ID, reader)
+	}()
+
+	return job, nil
+}
+
+// Stop rejects new jobs, cancels the running ones and waits until they
+// finish or ctx is done.
+func (i *Importer) Stop(ctx context.Context) error {
+	i.mutex.Lock()
+	i.stopped = true
+	i.mutex.Unlock()
+
+	i.stopJobs()
+
+	done := make(chan struct{})
+
+	go func() {
+		i.wait.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Job returns a copy of the import job with the given id.
+func (i *Importer) Job(id ImportJobID) (ImportJob, error) {
+	i.mutex.RLock()
+	defer i.mutex.RUnlock()
+
+	job, ok := i.jobs[id]
+	if !ok {
+		return ImportJob{}, errImportNotFound
+	}
+
+	return *job, nil
+}
+
+func (i *Importer) run(ctx context.Context, id ImportJobID, reader ImportReader) {
+	report, err := i.service.Import(ctx, reader)
+	if err != nil {
+		i.logger.Error("running import job", "error", err, slog.String("id", id.String()))
+	}
+
+	i.mutex.Lock()
+	defer i.mutex.Unlock()
+
+	i.running--
+
+	job := i.jobs[id]
+	finishedAt := time.Now().UTC()
+	job.Report = report
+	job.FinishedAt = &finishedAt
+	job.Status = ImportCompleted
+
+	if err != nil {
+		job.Status = ImportFailed
+		job.Err = err.Error()
+	}
+}
+
+func (i *Importer) removeExpiredJobs() {
+	i.mutex.Lock()
+	defer i.mutex.Unlock()
+
+	expiration := time.Now().UTC().Add(-importJobRetention)
+	for id, job := range i.jobs {
+		if job.FinishedAt != nil && job.FinishedAt.Before(expiration) {
+			delete(i.jobs, id)
+		}
+	}
+}
+
+func (r *ImportReport) reject(line int, err error) {
+	r.Rejected = append(r.Rejected, RejectedRow{
+		Line:   line,
+		Reason: err.Error(),
+	})
+}
+
+func newImportJobID() ImportJobID {
+	return ImportJobID(uuid.New().String())
+}
+
+func (i ImportJobID) String() string {
+	return string(i)
+}
+
+// newImportJobResult create a new ImportJobResult
+func newImportJobResult(job *ImportJob, err error) ImportJobResult {
+	var errmessage string
+	if err != nil {
+		errmessage = err.Error()
+	}
+	return ImportJobResult{
+		Job:     job,
+		Err:     errmessage,
+		ErrKind: KindOf(err),
+	}
+}
@@ -0,0 +1,240 @@
+package pets_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/fernandoocampo/basic-micro/internal/pets"
+	"github.com/stretchr/testify/assert"
+)
+
+type importReaderMock struct {
+	rows []pets.ImportRow
+	err  error
+}
+
+// endlessImportReader returns valid rows until the import is cancelled.
+type endlessImportReader struct {
+	line int
+}
+
+func TestImport(t *testing.T) {
+	t.Parallel()
+
+	// Given
+	reader := &importReaderMock{
+		rows: []pets.ImportRow{
+			{Line: 2, NewPet: pets.NewPet{Name: "drila"}},
+			{Line: 3, Err: errors.New("invalid latitude \"north\"")},
+			{Line: 4, NewPet: pets.NewPet{Name: "michael", Location: &pets.Location{Latitude: 4.711, Longitude: -74.0721}}},
+			{Line: 5, NewPet: pets.NewPet{Name: ""}},
+			{Line: 6, NewPet: pets.NewPet{Name: "lucky"}},
+		},
+	}
+	expectedReport := pets.ImportReport{
+		Total:    5,
+		Imported: 3,
+		Rejected: []pets.RejectedRow{
+			{Line: 3, Reason: "invalid latitude \"north\""},
+			{Line: 5, Reason: "invalid pet data: [pet name cannot be empty]"},
+		},
+	}
+
+	storerMock := newStorerMock()
+	auditStorer := newAuditStorerMock()
+
+	settings := pets.ServiceSetup{
+		Storer:          storerMock,
+		AuditStorer:     auditStorer,
+		ImportBatchSize: 2,
+		Logger:          newLogger(),
+	}
+
+	service := pets.NewService(settings)
+
+	// When
+	got, err := service.Import(context.TODO(), reader)
+
+	// Then
+	assert.NoError(t, err)
+	assert.Equal(t, expectedReport, got)
+	assert.Len(t, storerMock.batches, 2)
+	assert.Len(t, storerMock.batches[0], 2)
+	assert.Len(t, storerMock.batches[1], 1)
+	assert.Len(t, auditStorer.records, 3)
+}
+
+func TestImportButBatchCouldNotBeSaved(t *testing.T) {
+	t.Parallel()
+
+	// Given
+	reader := &importReaderMock{
+		rows: []pets.ImportRow{
+			{Line: 2, NewPet: pets.NewPet{Name: "drila"}},
+			{Line: 3, NewPet: pets.NewPet{Name: "michael"}},
+		},
+	}
+
+	storerMock := newStorerMock(
+		withError(errors.New("any error")),
+	)
+
+	settings := pets.ServiceSetup{
+		Storer: storerMock,
+		Logger: newLogger(),
+	}
+
+	service := pets.NewService(settings)
+
+	// When
+	got, err := service.Import(context.TODO(), reader)
+
+	// Then
+	assert.NoError(t, err)
+	assert.Equal(t, 2, got.Total)
+	assert.Zero(t, got.Imported)
+	assert.Len(t, got.Rejected, 2)
+}
+
+func TestImportButReaderFailed(t *testing.T) {
+	t.Parallel()
+
+	// Given
+	reader := &importReaderMock{
+		err: errors.New("connection reset"),
+	}
+
+	settings := pets.ServiceSetup{
+		Storer: newStorerMock(),
+		Logger: newLogger(),
+	}
+
+	service := pets.NewService(settings)
+
+	// When
+	_, err := service.Import(context.TODO(), reader)
+
+	// Then
+	assert.EqualError(t, err, "unable to read pets to import")
+}
+
+func TestImporterJob(t *testing.T) {
+	t.Parallel()
+
+	// Given
+	reader := &importReaderMock{
+		rows: []pets.ImportRow{
+			{Line: 2, NewPet: pets.NewPet{Name: "drila"}},
+		},
+	}
+
+	settings := pets.ServiceSetup{
+		Storer: newStorerMock(),
+		Logger: newLogger(),
+	}
+
+	importer := pets.NewImporter(pets.ImporterSetup{
+		Service: pets.NewService(settings),
+		Logger:  newLogger(),
+	})
+
+	// When
+	started, err := importer.Start(context.TODO(), reader)
+
+	// Then
+	assert.NoError(t, err)
+	assert.Equal(t, pets.ImportRunning, started.Status)
+	assert.Eventually(t, func() bool {
+		job, err := importer.Job(started.ID)
+
+		return err == nil && job.Status == pets.ImportCompleted && job.Report.Imported == 1
+	}, time.Second, 10*time.Millisecond)
+
+	_, err = importer.Job("unknown")
+	assert.EqualError(t, err, "import job does not exist")
+}
+
+func TestImporterRejectsJobsOverTheLimit(t *testing.T) {
+	t.Parallel()
+
+	// Given
+	settings := pets.ServiceSetup{
+		Storer: newStorerMock(),
+		Logger: newLogger(),
+	}
+
+	importer := pets.NewImporter(pets.ImporterSetup{
+		Service: pets.NewService(settings),
+		MaxJobs: 1,
+		Logger:  newLogger(),
+	})
+	t.Cleanup(func() {
+		importer.Stop(context.Background())
+	})
+
+	// When
+	_, errFirst := importer.Start(context.TODO(), &endlessImportReader{})
+	_, errSecond := importer.Start(context.TODO(), &endlessImportReader{})
+
+	// Then
+	assert.NoError(t, errFirst)
+	assert.EqualError(t, errSecond, "too many imports are running, try again later")
+	assert.Equal(t, pets.UnavailableError, pets.KindOf(errSecond))
+}
+
+func TestImporterStopCancelsJobs(t *testing.T) {
+	t.Parallel()
+
+	// Given
+	settings := pets.ServiceSetup{
+		Storer: newStorerMock(),
+		Logger: newLogger(),
+	}
+
+	importer := pets.NewImporter(pets.ImporterSetup{
+		Service: pets.NewService(settings),
+		Logger:  newLogger(),
+	})
+
+	started, err := importer.Start(context.TODO(), &endlessImportReader{})
+	assert.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	// When
+	errStop := importer.Stop(ctx)
+
+	// Then
+	assert.NoError(t, errStop)
+	job, err := importer.Job(started.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, pets.ImportFailed, job.Status)
+	assert.Equal(t, "import was cancelled", job.Err)
+	_, errStopped := importer.Start(context.TODO(), &endlessImportReader{})
+	assert.Equal(t, pets.UnavailableError, pets.KindOf(errStopped))
+}
+
+func (i *importReaderMock) Next() (pets.ImportRow, error) {
+	if i.err != nil {
+		return pets.ImportRow{}, i.err
+	}
+
+	if len(i.rows) == 0 {
+		return pets.ImportRow{}, io.EOF
+	}
+
+	row := i.rows[0]
+	i.rows = i.rows[1:]
+
+	return row, nil
+}
+
+func (e *endlessImportReader) Next() (pets.ImportRow, error) {
+	e.line++
+
+	return pets.ImportRow{Line: e.line, NewPet: pets.NewPet{Name: "drila"}}, nil
+}
@@ -2,6 +2,7 @@ package pets
 
 import (
 	"fmt"
+	"time"
 
 	"github.com/google/uuid"
 )
@@ -9,24 +10,56 @@ import (
 // PetID defines pet id.
 type PetID string
 
+// SightingID defines sighting id.
+type SightingID string
+
 // OrderByField defines fields you can use to order queries.
 type OrderByField string
 
+// Location contains geographic coordinates in decimal degrees.
+type Location struct {
+	Latitude  float64 `json:"latitude"`
+	Longitude float64 `json:"longitude"`
+}
+
 // NewPet contains data to request the creation of a new pet.
 type NewPet struct {
-	Name string `json:"name"`
+	Name     string    `json:"name"`
+	Location *Location `json:"location,omitempty"`
 }
 
 // UpdatePet contains data to request the update of a new pet.
 type UpdatePet struct {
-	ID   PetID  `json:"id"`
-	Name string `json:"name"`
+	ID       PetID     `json:"id"`
+	Name     string    `json:"name"`
+	Location *Location `json:"location,omitempty"`
 }
 
 // Pet contains pet data.
 type Pet struct {
-	ID   PetID  `json:"id"`
-	Name string `json:"name"`
+	ID       PetID     `json:"id"`
+	Name     string    `json:"name"`
+	Location *Location `json:"location,omitempty"`
+	// DeletedAt is set when the pet was deleted, deleted pets can be
+	// restored until they are purged.
+	DeletedAt *time.Time `json:"deleted_at,omitempty"`
+}
+
+// NewSighting contains data to report that a pet was seen somewhere.
+type NewSighting struct {
+	PetID    PetID     `json:"pet_id"`
+	Location Location  `json:"location"`
+	Notes    string    `json:"notes"`
+	SeenAt   time.Time `json:"seen_at"`
+}
+
+// Sighting contains data of a pet seen at some location.
+type Sighting struct {
+	ID       SightingID `json:"id"`
+	PetID    PetID      `json:"pet_id"`
+	Location Location   `json:"location"`
+	Notes    string     `json:"notes"`
+	SeenAt   time.Time  `json:"seen_at"`
 }
 
 // ValidationError define pet validation logic.
@@ -36,32 +69,70 @@ type ValidationError struct {
 
 // QueryFilter contains data for query filters.
 type QueryFilter struct {
-	PetName     string
-	OrderBy     OrderByField
-	PageNumber  uint8
-	RowsPerPage uint8
+	PetName        string
+	OrderBy        OrderByField
+	PageNumber     uint8
+	RowsPerPage    uint8
+	IncludeDeleted bool
+}
+
+// NearbyFilter contains data to search pets and sightings around a location.
+type NearbyFilter struct {
+	Location Location
+	// RadiusKm maximum distance in kilometers.
+	RadiusKm float64
+	// Limit maximum number of pets and sightings to return.
+	Limit uint8
+}
+
+// NearbyPet is a pet found by a nearby search.
+type NearbyPet struct {
+	Pet        Pet
+	DistanceKm float64
+}
+
+// NearbySighting is a sighting found by a nearby search.
+type NearbySighting struct {
+	Sighting   Sighting
+	DistanceKm float64
+}
+
+// NearbyResult contains pets and sightings ordered by distance, closest first.
+type NearbyResult struct {
+	Pets      []NearbyPet
+	Sightings []NearbySighting
 }
 
 // GetPetWithIDResult standard roesponse for get a Pet with an ID.
 type GetPetWithIDResult struct {
-	Pet *Pet
-	Err string
+	Pet     *Pet
+	Err     string
+	ErrKind ErrorKind
 }
 
 // CreatePetResult standard response for create Pet.
 type CreatePetResult struct {
-	ID  PetID
-	Err string
+	ID      PetID
+	Err     string
+	ErrKind ErrorKind
 }
 
 // UpdatePetResult standard response for updating a pet.
 type UpdatePetResult struct {
-	Err string
+	Err     string
+	ErrKind ErrorKind
 }
 
 // DeletePetResult standard response for deleting a pet.
 type DeletePetResult struct {
-	Err string
+	Err     string
+	ErrKind ErrorKind
+}
+
+// RestorePetResult standard response for restoring a deleted pet.
+type RestorePetResult struct {
+	Err     string
+	ErrKind ErrorKind
 }
 
 // SearchPetsResult contains search pets result data.
@@ -76,15 +147,35 @@ type SearchPetsResult struct {
 type SearchPetsDataResult struct {
 	SearchResult SearchPetsResult
 	Err          string
+	ErrKind      ErrorKind
+}
+
+// ReportSightingResult standard response for reporting a sighting.
+type ReportSightingResult struct {
+	ID      SightingID
+	Err     string
+	ErrKind ErrorKind
+}
+
+// SearchNearbyDataResult standard response for searching nearby pets.
+type SearchNearbyDataResult struct {
+	Result  NearbyResult
+	Err     string
+	ErrKind ErrorKind
 }
 
 const (
 	// EmptyPetID is the pet id that empty or nil.
 	EmptyPetID        = PetID("")
+	EmptySightingID   = SightingID("")
 	EmptyOrderByField = OrderByField("")
 
 	PageNumberDefault  = uint8(1)
 	RowsPerPageDefault = uint8(10)
+
+	NearbyRadiusKmDefault = 5.0
+	NearbyRadiusKmMax     = 100.0
+	NearbyLimitDefault    = uint8(20)
 )
 
 // order by field possible values
@@ -105,13 +196,59 @@ func newPetID() PetID {
 	return PetID(uuid.New().String())
 }
 
+func newSightingID() SightingID {
+	return SightingID(uuid.New().String())
+}
+
 func buildNewPet(newPet NewPet) Pet {
 	return Pet{
-		ID:   newPetID(),
-		Name: newPet.Name,
+		ID:       newPetID(),
+		Name:     newPet.Name,
+		Location: newPet.Location,
+	}
+}
+
+func buildNewSighting(newSighting NewSighting, now time.Time) Sighting {
+	seenAt := newSighting.SeenAt
+	if seenAt.IsZero() {
+		seenAt = now
+	}
+
+	return Sighting{
+		ID:       newSightingID(),
+		PetID:    newSighting.PetID,
+		Location: newSighting.Location,
+		Notes:    newSighting.Notes,
+		SeenAt:   seenAt,
 	}
 }
 
+// applyTo returns a copy of the given stored pet with the update applied.
+func (u UpdatePet) applyTo(pet Pet) Pet {
+	pet.Name = u.Name
+	pet.Location = u.Location
+
+	return pet
+}
+
+func validNewPet(pet NewPet) error {
+	err := new(ValidationError)
+
+	if pet.Name == "" {
+		err.addErrorMessage("pet name cannot be empty")
+	}
+
+	if pet.Location != nil && !pet.Location.IsValid() {
+		err.addErrorMessage("pet location is out of range")
+	}
+
+	if len(err.Messages) > 0 {
+		return err
+	}
+
+	return nil
+}
+
 func validPetToUpdate(pet UpdatePet) error {
 	err := new(ValidationError)
 
@@ -123,6 +260,10 @@ func validPetToUpdate(pet UpdatePet) error {
 		err.addErrorMessage("pet name cannot be empty")
 	}
 
+	if pet.Location != nil && !pet.Location.IsValid() {
+		err.addErrorMessage("pet location is out of range")
+	}
+
 	if len(err.Messages) > 0 {
 		return err
 	}
@@ -130,6 +271,52 @@ func validPetToUpdate(pet UpdatePet) error {
 	return nil
 }
 
+func validSighting(sighting NewSighting) error {
+	err := new(ValidationError)
+
+	if sighting.PetID == EmptyPetID {
+		err.addErrorMessage("pet id cannot be empty")
+	}
+
+	if !sighting.Location.IsValid() {
+		err.addErrorMessage("sighting location is out of range")
+	}
+
+	if len(err.Messages) > 0 {
+		return err
+	}
+
+	return nil
+}
+
+func validNearbyFilter(filter NearbyFilter) error {
+	err := new(ValidationError)
+
+	if !filter.Location.IsValid() {
+		err.addErrorMessage("location is out of range")
+	}
+
+	if filter.RadiusKm < 0 || filter.RadiusKm > NearbyRadiusKmMax {
+		err.addErrorMessage(fmt.Sprintf("radius must be between 0 and %.0f km", NearbyRadiusKmMax))
+	}
+
+	if len(err.Messages) > 0 {
+		return err
+	}
+
+	return nil
+}
+
+func (n *NearbyFilter) fillDefaultValues() {
+	if n.RadiusKm == 0 {
+		n.RadiusKm = NearbyRadiusKmDefault
+	}
+
+	if n.Limit == 0 {
+		n.Limit = NearbyLimitDefault
+	}
+}
+
 func (q QueryFilter) isInvalid() bool {
 	return q.PetName == ""
 }
@@ -155,8 +342,9 @@ func newGetPetWithIDResult(pet *Pet, err error) GetPetWithIDResult {
 		errmessage = err.Error()
 	}
 	return GetPetWithIDResult{
-		Pet: pet,
-		Err: errmessage,
+		Pet:     pet,
+		Err:     errmessage,
+		ErrKind: KindOf(err),
 	}
 }
 
@@ -167,8 +355,9 @@ func newCreatePetResult(id PetID, err error) CreatePetResult {
 		errmessage = err.Error()
 	}
 	return CreatePetResult{
-		ID:  id,
-		Err: errmessage,
+		ID:      id,
+		Err:     errmessage,
+		ErrKind: KindOf(err),
 	}
 }
 
@@ -179,7 +368,8 @@ func newUpdatePetResult(err error) UpdatePetResult {
 		errmessage = err.Error()
 	}
 	return UpdatePetResult{
-		Err: errmessage,
+		Err:     errmessage,
+		ErrKind: KindOf(err),
 	}
 }
 
@@ -190,7 +380,20 @@ func newDeletePetResult(err error) DeletePetResult {
 		errmessage = err.Error()
 	}
 	return DeletePetResult{
-		Err: errmessage,
+		Err:     errmessage,
+		ErrKind: KindOf(err),
+	}
+}
+
+// newRestorePetResult create a new RestorePetResult
+func newRestorePetResult(err error) RestorePetResult {
+	var errmessage string
+	if err != nil {
+		errmessage = err.Error()
+	}
+	return RestorePetResult{
+		Err:     errmessage,
+		ErrKind: KindOf(err),
 	}
 }
 
@@ -203,9 +406,45 @@ func newSearchPetsDataResult(result SearchPetsResult, err error) SearchPetsDataR
 	return SearchPetsDataResult{
 		SearchResult: result,
 		Err:          errmessage,
+		ErrKind:      KindOf(err),
+	}
+}
+
+// newReportSightingResult create a new ReportSightingResult
+func newReportSightingResult(id SightingID, err error) ReportSightingResult {
+	var errmessage string
+	if err != nil {
+		errmessage = err.Error()
+	}
+	return ReportSightingResult{
+		ID:      id,
+		Err:     errmessage,
+		ErrKind: KindOf(err),
 	}
 }
 
+// newSearchNearbyDataResult create a new SearchNearbyDataResult
+func newSearchNearbyDataResult(result NearbyResult, err error) SearchNearbyDataResult {
+	var errmessage string
+	if err != nil {
+		errmessage = err.Error()
+	}
+	return SearchNearbyDataResult{
+		Result:  result,
+		Err:     errmessage,
+		ErrKind: KindOf(err),
+	}
+}
+
+// IsDeleted says if the pet was deleted.
+func (p Pet) IsDeleted() bool {
+	return p.DeletedAt != nil
+}
+
 func (p PetID) String() string {
 	return string(p)
 }
+
+func (s SightingID) String() string {
+	return string(s)
+}
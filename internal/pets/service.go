@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"time"
 
 	"log/slog"
 )
@@ -11,33 +12,77 @@ import (
 // Storer defines persistence behavior
 type Storer interface {
 	Save(ctx context.Context, newPet Pet) error
+	// SaveBatch saves all the given pets at once or none of them.
+	SaveBatch(ctx context.Context, newPets []Pet) error
 	Update(ctx context.Context, pet UpdatePet) error
+	// Delete marks the pet as deleted with the pet DeletedAt value,
+	// the pet is kept until it is purged.
 	Delete(ctx context.Context, pet Pet) error
+	// Restore clears the deletion mark of the pet with the given id.
+	Restore(ctx context.Context, id PetID) error
+	// Purge permanently removes pets deleted before the given time
+	// and returns how many were removed.
+	Purge(ctx context.Context, deletedBefore time.Time) (int, error)
+	// Query find pets matching the filter, deleted pets are only
+	// returned if the filter includes them.
 	Query(ctx context.Context, filter QueryFilter) (SearchPetsResult, error)
-	// QueryByID find and return a pet with the given id.
+	// QueryByID find and return a pet with the given id, even if it was deleted.
 	// If pet does not exist it returns a nil pet and nil error.
 	QueryByID(ctx context.Context, id PetID) (*Pet, error)
+	// QueryByIDs find the pets with the given ids, even if they were
+	// deleted. Pets that do not exist are not returned.
+	QueryByIDs(ctx context.Context, ids []PetID) ([]Pet, error)
+	// Iterate returns an iterator over all pets ordered by id, deleted
+	// pets are only returned if the filter includes them.
+	Iterate(ctx context.Context, filter ExportFilter) (PetIterator, error)
+	SaveSighting(ctx context.Context, sighting Sighting) error
+	// QuerySightings find the sightings of the given pets, newest first.
+	QuerySightings(ctx context.Context, petIDs []PetID) ([]Sighting, error)
+	// QueryNearby find pets and sightings within the filter radius,
+	// both ordered by distance.
+	QueryNearby(ctx context.Context, filter NearbyFilter) (NearbyResult, error)
+	// WithinTransaction calls fn with a storer whose changes are committed
+	// if fn returns nil, otherwise they are rolled back. It returns
+	// ErrTransactionConflict if another writer changed the same pets
+	// before the commit.
+	WithinTransaction(ctx context.Context, fn func(tx Storer) error) error
 }
 
 // ServiceSetup contains service metadata.
 type ServiceSetup struct {
 	Storer Storer
-	Logger *slog.Logger
+	// AuditStorer keeps the change history of pets, it is optional.
+	AuditStorer AuditStorer
+	// Publisher receives the changes made to pets, it is optional.
+	Publisher Publisher
+	// ImportBatchSize number of pets saved at once by imports.
+	ImportBatchSize int
+	Logger          *slog.Logger
 }
 
 // Service implements pets business logic.
 type Service struct {
-	storer Storer
-	logger *slog.Logger
+	storer          Storer
+	auditStorer     AuditStorer
+	publisher       Publisher
+	importBatchSize int
+	logger          *slog.Logger
 }
 
 var (
-	errSavePet    = errors.New("unable to save pet in the repository")
-	errQueryPet   = errors.New("unable to query pet")
-	errQueryPets  = errors.New("unable to query pets")
-	errDeletePet  = errors.New("unable to delete pet")
-	errUpdatePet  = errors.New("unable to update pet in the repository")
-	errEmptyPetID = errors.New("pet id cannot be empty")
+	errSavePet      = errors.New("unable to save pet in the repository")
+	errQueryPet     = errors.New("unable to query pet")
+	errQueryPets    = errors.New("unable to query pets")
+	errDeletePet    = errors.New("unable to delete pet")
+	errRestorePet   = errors.New("unable to restore pet")
+	errPurgePets    = errors.New("unable to purge deleted pets")
+	errUpdatePet    = errors.New("unable to update pet in the repository")
+	errEmptyPetID   = newKindError(InvalidError, "pet id cannot be empty")
+	errPetNotFound  = newKindError(NotFoundError, "pet does not exist")
+	errSaveSighting = errors.New("unable to save sighting in the repository")
+	errSightings    = errors.New("unable to query sightings")
+	errQueryNearby  = errors.New("unable to query nearby pets")
+	errQueryHistory = errors.New("unable to query pet history")
 )
 
 // NewService create a new pets service.
@@ -45,8 +90,23 @@ func NewService(settings ServiceSetup) *Service {
 	settings.Logger.Debug("creating new pet service")
 
 	newService := Service{
-		logger: settings.Logger,
-		storer: settings.Storer,
+		logger:          settings.Logger,
+		storer:          settings.Storer,
+		auditStorer:     settings.AuditStorer,
+		publisher:       settings.Publisher,
+		importBatchSize: settings.ImportBatchSize,
+	}
+
+	if newService.auditStorer == nil {
+		newService.auditStorer = nopAuditStorer{}
+	}
+
+	if newService.publisher == nil {
+		newService.publisher = nopPublisher{}
+	}
+
+	if newService.importBatchSize <= 0 {
+		newService.importBatchSize = ImportBatchSizeDefault
 	}
 
 	return &newService
@@ -55,15 +115,22 @@ func NewService(settings ServiceSetup) *Service {
 // Create create a pet and store it in a database.
 func (s *Service) Create(ctx context.Context, newPet NewPet) (PetID, error) {
 	s.logger.Info("starting to create a new pet")
+	err := validNewPet(newPet)
+	if err != nil {
+		return EmptyPetID, fmt.Errorf("unable to create pet: %w", err)
+	}
+
 	pet := buildNewPet(newPet)
 
-	err := s.storer.Save(ctx, pet)
+	err = s.storer.Save(ctx, pet)
 	if err != nil {
 		s.logger.Error("creating pet", "error", err)
 
 		return EmptyPetID, errSavePet
 	}
 
+	s.audit(ctx, pet.ID, AuditCreated, diffPets(nil, &pet))
+
 	s.logger.Debug(
 		"pet was created",
 		slog.String("id", pet.ID.String()),
@@ -80,6 +147,15 @@ func (s *Service) Update(ctx context.Context, pet UpdatePet) error {
 		return fmt.Errorf("unable to update pet: %w", err)
 	}
 
+	before, err := s.queryByID(ctx, pet.ID)
+	if err != nil {
+		return errUpdatePet
+	}
+
+	if before == nil || before.IsDeleted() {
+		return errPetNotFound
+	}
+
 	err = s.storer.Update(ctx, pet)
 	if err != nil {
 		s.logger.Error("updating pet", "error", err)
@@ -87,10 +163,62 @@ func (s *Service) Update(ctx context.Context, pet UpdatePet) error {
 		return errUpdatePet
 	}
 
+	updatedPet := pet.applyTo(*before)
+
+	s.audit(ctx, pet.ID, AuditUpdated, diffPets(before, &updatedPet))
+
 	return nil
 }
 
+// QueryByID find a pet with the given id, deleted pets are not returned.
 func (s *Service) QueryByID(ctx context.Context, id PetID) (*Pet, error) {
+	pet, err := s.queryByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if pet != nil && pet.IsDeleted() {
+		return nil, nil
+	}
+
+	return pet, nil
+}
+
+// QueryByIDs find the pets with the given ids with one store call, deleted
+// pets and pets that do not exist are not returned.
+func (s *Service) QueryByIDs(ctx context.Context, ids []PetID) ([]Pet, error) {
+	s.logger.Debug("starting query pets by ids", slog.Int("ids", len(ids)))
+	for _, id := range ids {
+		if id == EmptyPetID {
+			return nil, errEmptyPetID
+		}
+	}
+
+	if len(ids) == 0 {
+		return []Pet{}, nil
+	}
+
+	found, err := s.storer.QueryByIDs(ctx, ids)
+	if err != nil {
+		s.logger.Error(
+			"querying pets with ids",
+			"error", err,
+			slog.Int("ids", len(ids)))
+
+		return nil, errQueryPets
+	}
+
+	petsFound := make([]Pet, 0, len(found))
+	for _, pet := range found {
+		if !pet.IsDeleted() {
+			petsFound = append(petsFound, pet)
+		}
+	}
+
+	return petsFound, nil
+}
+
+func (s *Service) queryByID(ctx context.Context, id PetID) (*Pet, error) {
 	s.logger.Debug("starting query pet by id")
 	if id == EmptyPetID {
 		return nil, errEmptyPetID
@@ -111,7 +239,7 @@ func (s *Service) QueryByID(ctx context.Context, id PetID) (*Pet, error) {
 	return pet, nil
 }
 
-// Delete detele a pet from database.
+// Delete marks a pet as deleted, it can be restored until it is purged.
 func (s *Service) Delete(ctx context.Context, id PetID) error {
 	s.logger.Debug("starting delete pet")
 	pet, err := s.QueryByID(ctx, id)
@@ -128,7 +256,11 @@ func (s *Service) Delete(ctx context.Context, id PetID) error {
 		return nil
 	}
 
-	err = s.storer.Delete(ctx, *pet)
+	deletedAt := time.Now().UTC()
+	deletedPet := *pet
+	deletedPet.DeletedAt = &deletedAt
+
+	err = s.storer.Delete(ctx, deletedPet)
 	if err != nil {
 		s.logger.Error("deleting pet",
 			"error", err,
@@ -137,9 +269,68 @@ func (s *Service) Delete(ctx context.Context, id PetID) error {
 		return errDeletePet
 	}
 
+	s.audit(ctx, id, AuditDeleted, diffPets(pet, &deletedPet))
+
+	return nil
+}
+
+// Restore restores a deleted pet.
+func (s *Service) Restore(ctx context.Context, id PetID) error {
+	s.logger.Debug("starting restore pet")
+	pet, err := s.queryByID(ctx, id)
+	if err != nil {
+		return errRestorePet
+	}
+
+	if pet == nil {
+		return errPetNotFound
+	}
+
+	if !pet.IsDeleted() {
+		s.logger.Info(
+			"pet was not restored cause it is not deleted",
+			slog.String("id", id.String()),
+		)
+
+		return nil
+	}
+
+	err = s.storer.Restore(ctx, id)
+	if err != nil {
+		s.logger.Error("restoring pet",
+			"error", err,
+			slog.String("id", id.String()))
+
+		return errRestorePet
+	}
+
+	restoredPet := *pet
+	restoredPet.DeletedAt = nil
+
+	s.audit(ctx, id, AuditRestored, diffPets(pet, &restoredPet))
+
 	return nil
 }
 
+// Purge permanently removes pets deleted longer than the retention period ago.
+func (s *Service) Purge(ctx context.Context, retention time.Duration) (int, error) {
+	s.logger.Debug("starting purge of deleted pets")
+	deletedBefore := time.Now().UTC().Add(-retention)
+
+	purged, err := s.storer.Purge(ctx, deletedBefore)
+	if err != nil {
+		s.logger.Error("purging deleted pets",
+			"error", err,
+			slog.Time("deleted_before", deletedBefore))
+
+		return 0, errPurgePets
+	}
+
+	s.logger.Info("deleted pets were purged", slog.Int("purged", purged))
+
+	return purged, nil
+}
+
 func (s *Service) Query(ctx context.Context, filter QueryFilter) (SearchPetsResult, error) {
 	s.logger.Debug("starting query pet")
 	if filter.isInvalid() {
@@ -160,3 +351,134 @@ func (s *Service) Query(ctx context.Context, filter QueryFilter) (SearchPetsResu
 
 	return result, nil
 }
+
+// ReportSighting records that a pet was seen at some location.
+func (s *Service) ReportSighting(ctx context.Context, newSighting NewSighting) (SightingID, error) {
+	s.logger.Debug("starting to report a sighting")
+	err := validSighting(newSighting)
+	if err != nil {
+		return EmptySightingID, fmt.Errorf("unable to report sighting: %w", err)
+	}
+
+	pet, err := s.QueryByID(ctx, newSighting.PetID)
+	if err != nil {
+		return EmptySightingID, errSaveSighting
+	}
+
+	if pet == nil {
+		return EmptySightingID, errPetNotFound
+	}
+
+	sighting := buildNewSighting(newSighting, time.Now().UTC())
+
+	err = s.storer.SaveSighting(ctx, sighting)
+	if err != nil {
+		s.logger.Error("saving sighting",
+			"error", err,
+			slog.String("pet_id", newSighting.PetID.String()))
+
+		return EmptySightingID, errSaveSighting
+	}
+
+	s.logger.Debug(
+		"sighting was reported",
+		slog.String("id", sighting.ID.String()),
+	)
+
+	return sighting.ID, nil
+}
+
+// Sightings returns the sightings of the given pets with one store call,
+// newest first.
+func (s *Service) Sightings(ctx context.Context, petIDs []PetID) ([]Sighting, error) {
+	s.logger.Debug("starting query sightings", slog.Int("pets", len(petIDs)))
+	for _, id := range petIDs {
+		if id == EmptyPetID {
+			return nil, errEmptyPetID
+		}
+	}
+
+	if len(petIDs) == 0 {
+		return []Sighting{}, nil
+	}
+
+	sightings, err := s.storer.QuerySightings(ctx, petIDs)
+	if err != nil {
+		s.logger.Error(
+			"querying sightings",
+			"error", err,
+			slog.Int("pets", len(petIDs)))
+
+		return nil, errSightings
+	}
+
+	return sightings, nil
+}
+
+// QueryNearby search pets and sightings around the given location.
+func (s *Service) QueryNearby(ctx context.Context, filter NearbyFilter) (NearbyResult, error) {
+	s.logger.Debug("starting query nearby pets")
+	filter.fillDefaultValues()
+
+	err := validNearbyFilter(filter)
+	if err != nil {
+		return NearbyResult{}, fmt.Errorf("unable to query nearby pets: %w", err)
+	}
+
+	result, err := s.storer.QueryNearby(ctx, filter)
+	if err != nil {
+		s.logger.Error(
+			"querying nearby pets",
+			"error", err,
+			slog.String("filter", fmt.Sprintf("%+v", filter)))
+
+		return NearbyResult{}, errQueryNearby
+	}
+
+	return result, nil
+}
+
+// History returns the change history of a pet, oldest change first.
+func (s *Service) History(ctx context.Context, filter AuditFilter) (AuditRecordsResult, error) {
+	s.logger.Debug("starting query pet history")
+	if filter.PetID == EmptyPetID {
+		return AuditRecordsResult{}, errEmptyPetID
+	}
+
+	filter.fillDefaultValues()
+
+	result, err := s.auditStorer.QueryAuditRecords(ctx, filter)
+	if err != nil {
+		s.logger.Error(
+			"querying pet history",
+			"error", err,
+			slog.String("filter", fmt.Sprintf("%+v", filter)))
+
+		return AuditRecordsResult{}, errQueryHistory
+	}
+
+	return result, nil
+}
+
+// audit saves an audit record of the change, a failure to save it is
+// logged but it does not revert the change.
+func (s *Service) audit(ctx context.Context, id PetID, action AuditAction, changes []FieldChange) {
+	record := buildAuditRecord(ctx, id, action, changes)
+
+	s.record(ctx, record)
+}
+
+// record saves the audit record and publishes it, a record that cannot be
+// saved is published anyway because the change was made.
+func (s *Service) record(ctx context.Context, record AuditRecord) {
+	err := s.auditStorer.SaveAuditRecord(ctx, record)
+	if err != nil {
+		s.logger.Error(
+			"saving audit record",
+			"error", err,
+			slog.String("id", record.PetID.String()),
+			slog.String("action", string(record.Action)))
+	}
+
+	s.publisher.Publish(ctx, record)
+}
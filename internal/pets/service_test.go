@@ -5,6 +5,7 @@ import (
 	"errors"
 	"log/slog"
 	"testing"
+	"time"
 
 	"github.com/fernandoocampo/basic-micro/internal/pets"
 	"github.com/stretchr/testify/assert"
@@ -80,7 +81,9 @@ func TestUpdate(t *testing.T) {
 		Name: "drila",
 	}
 
-	storerMock := newStorerMock()
+	storerMock := newStorerMock(
+		withFoundPet(&pets.Pet{ID: updatePet.ID, Name: "lula"}),
+	)
 
 	settings := pets.ServiceSetup{
 		Storer: storerMock,
@@ -99,6 +102,59 @@ func TestUpdate(t *testing.T) {
 	assert.Equal(t, updatePet, storerMock.updatedPet)
 }
 
+func TestUpdateButPetNotFound(t *testing.T) {
+	t.Parallel()
+
+	deletedAt := time.Now()
+
+	cases := map[string]struct {
+		foundPet *pets.Pet
+	}{
+		"pet does not exist": {
+			foundPet: nil,
+		},
+		"pet was deleted": {
+			foundPet: &pets.Pet{
+				ID:        pets.PetID("858455b7-e182-4122-a1b6-132c64d2f77b"),
+				Name:      "lula",
+				DeletedAt: &deletedAt,
+			},
+		},
+	}
+
+	for name, testCase := range cases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			// Given
+			updatePet := pets.UpdatePet{
+				ID:   pets.PetID("858455b7-e182-4122-a1b6-132c64d2f77b"),
+				Name: "drila",
+			}
+
+			storerMock := newStorerMock(withFoundPet(testCase.foundPet))
+
+			settings := pets.ServiceSetup{
+				Storer: storerMock,
+				Logger: newLogger(),
+			}
+
+			service := pets.NewService(settings)
+
+			ctx := context.TODO()
+
+			// When
+			err := service.Update(ctx, updatePet)
+
+			// Then
+			assert.Error(t, err)
+			assert.EqualError(t, err, "pet does not exist")
+			assert.Equal(t, pets.NotFoundError, pets.KindOf(err))
+			assert.Equal(t, pets.UpdatePet{}, storerMock.updatedPet)
+		})
+	}
+}
+
 func TestUpdateButError(t *testing.T) {
 	t.Parallel()
 
@@ -160,6 +216,8 @@ func TestDelete(t *testing.T) {
 	// Then
 	assert.NoError(t, err)
 	assert.Equal(t, foundPet, *storerMock.foundPet)
+	assert.Equal(t, foundPet.ID, storerMock.deletedPet.ID)
+	assert.NotNil(t, storerMock.deletedPet.DeletedAt)
 }
 
 func TestDeleteButPetNotFound(t *testing.T) {
@@ -288,17 +346,518 @@ func TestQueryByIDButError(t *testing.T) {
 	assert.Equal(t, expectedError, err)
 }
 
+func TestQueryByIDButDeleted(t *testing.T) {
+	t.Parallel()
+
+	// Given
+	petID := pets.PetID("858455b7-e182-4122-a1b6-132c64d2f77b")
+	deletedAt := time.Now()
+
+	foundPet := pets.Pet{
+		ID:        pets.PetID("858455b7-e182-4122-a1b6-132c64d2f77b"),
+		Name:      "drila",
+		DeletedAt: &deletedAt,
+	}
+
+	storerMock := newStorerMock(
+		withFoundPet(&foundPet),
+	)
+
+	settings := pets.ServiceSetup{
+		Storer: storerMock,
+		Logger: newLogger(),
+	}
+
+	service := pets.NewService(settings)
+
+	ctx := context.TODO()
+
+	// When
+	got, err := service.QueryByID(ctx, petID)
+
+	// Then
+	assert.NoError(t, err)
+	assert.Nil(t, got)
+}
+
+func TestQueryByIDs(t *testing.T) {
+	t.Parallel()
+
+	// Given
+	deletedAt := time.Now()
+	ids := []pets.PetID{"858455b7-e182-4122-a1b6-132c64d2f77b", "6a31e1b0-8b8f-4b68-9bd4-4e5c9c1b3d55"}
+
+	expectedPets := []pets.Pet{
+		{ID: "858455b7-e182-4122-a1b6-132c64d2f77b", Name: "drila"},
+	}
+
+	storerMock := newStorerMock(
+		withFoundPets(
+			pets.Pet{ID: "858455b7-e182-4122-a1b6-132c64d2f77b", Name: "drila"},
+			pets.Pet{ID: "6a31e1b0-8b8f-4b68-9bd4-4e5c9c1b3d55", Name: "michael", DeletedAt: &deletedAt},
+		),
+	)
+
+	settings := pets.ServiceSetup{
+		Storer: storerMock,
+		Logger: newLogger(),
+	}
+
+	service := pets.NewService(settings)
+
+	ctx := context.TODO()
+
+	// When
+	got, err := service.QueryByIDs(ctx, ids)
+	_, errEmptyID := service.QueryByIDs(ctx, []pets.PetID{""})
+
+	// Then
+	assert.NoError(t, err)
+	assert.Equal(t, expectedPets, got)
+	assert.Equal(t, ids, storerMock.queriedIDs)
+	assert.EqualError(t, errEmptyID, "pet id cannot be empty")
+}
+
+func TestRestore(t *testing.T) {
+	t.Parallel()
+
+	// Given
+	petID := pets.PetID("858455b7-e182-4122-a1b6-132c64d2f77b")
+	deletedAt := time.Now()
+
+	foundPet := pets.Pet{
+		ID:        pets.PetID("858455b7-e182-4122-a1b6-132c64d2f77b"),
+		Name:      "drila",
+		DeletedAt: &deletedAt,
+	}
+
+	storerMock := newStorerMock(
+		withFoundPet(&foundPet),
+	)
+
+	settings := pets.ServiceSetup{
+		Storer: storerMock,
+		Logger: newLogger(),
+	}
+
+	service := pets.NewService(settings)
+
+	ctx := context.TODO()
+
+	// When
+	err := service.Restore(ctx, petID)
+
+	// Then
+	assert.NoError(t, err)
+	assert.Equal(t, petID, storerMock.restoredID)
+}
+
+func TestRestoreButPetNotFound(t *testing.T) {
+	t.Parallel()
+
+	// Given
+	petID := pets.PetID("858455b7-e182-4122-a1b6-132c64d2f77b")
+
+	storerMock := newStorerMock()
+
+	settings := pets.ServiceSetup{
+		Storer: storerMock,
+		Logger: newLogger(),
+	}
+
+	service := pets.NewService(settings)
+
+	ctx := context.TODO()
+
+	// When
+	err := service.Restore(ctx, petID)
+
+	// Then
+	assert.Error(t, err)
+	assert.EqualError(t, err, "pet does not exist")
+	assert.Equal(t, pets.NotFoundError, pets.KindOf(err))
+	assert.Equal(t, pets.EmptyPetID, storerMock.restoredID)
+}
+
+func TestPurge(t *testing.T) {
+	t.Parallel()
+
+	// Given
+	retention := 24 * time.Hour
+
+	storerMock := newStorerMock()
+
+	settings := pets.ServiceSetup{
+		Storer: storerMock,
+		Logger: newLogger(),
+	}
+
+	service := pets.NewService(settings)
+
+	ctx := context.TODO()
+
+	// When
+	_, err := service.Purge(ctx, retention)
+
+	// Then
+	assert.NoError(t, err)
+	assert.WithinDuration(t, time.Now().Add(-retention), storerMock.purgedBefore, time.Minute)
+}
+
+func TestCreateIsAudited(t *testing.T) {
+	t.Parallel()
+
+	// Given
+	newPet := pets.NewPet{
+		Name: "drila",
+	}
+
+	expectedChanges := []pets.FieldChange{
+		{Field: "name", Before: "", After: "drila"},
+	}
+
+	auditStorer := newAuditStorerMock()
+
+	settings := pets.ServiceSetup{
+		Storer:      newStorerMock(),
+		AuditStorer: auditStorer,
+		Logger:      newLogger(),
+	}
+
+	service := pets.NewService(settings)
+
+	ctx := pets.WithRequestID(pets.WithActor(context.TODO(), "jane"), "req-1")
+
+	// When
+	petID, err := service.Create(ctx, newPet)
+
+	// Then
+	assert.NoError(t, err)
+	assert.Len(t, auditStorer.records, 1)
+	assert.Equal(t, petID, auditStorer.records[0].PetID)
+	assert.Equal(t, pets.AuditCreated, auditStorer.records[0].Action)
+	assert.Equal(t, "jane", auditStorer.records[0].Actor)
+	assert.Equal(t, "req-1", auditStorer.records[0].RequestID)
+	assert.Equal(t, expectedChanges, auditStorer.records[0].Changes)
+}
+
+func TestAssertedActorIsNotTheActor(t *testing.T) {
+	t.Parallel()
+
+	// Given
+	auditStorer := newAuditStorerMock()
+
+	settings := pets.ServiceSetup{
+		Storer:      newStorerMock(),
+		AuditStorer: auditStorer,
+		Logger:      newLogger(),
+	}
+
+	service := pets.NewService(settings)
+
+	ctx := pets.WithAssertedActor(context.TODO(), "jane")
+
+	// When
+	_, err := service.Create(ctx, pets.NewPet{Name: "drila"})
+
+	// Then
+	assert.NoError(t, err)
+	assert.Len(t, auditStorer.records, 1)
+	assert.Equal(t, pets.AnonymousActor, auditStorer.records[0].Actor)
+	assert.Equal(t, "jane", auditStorer.records[0].AssertedActor)
+}
+
+func TestChangesArePublished(t *testing.T) {
+	t.Parallel()
+
+	// Given
+	storerMock := newStorerMock(withFoundPet(&pets.Pet{ID: "858455b7-e182-4122-a1b6-132c64d2f77b", Name: "drila"}))
+	publisher := newPublisherMock()
+
+	settings := pets.ServiceSetup{
+		Storer:      storerMock,
+		AuditStorer: newAuditStorerMock(),
+		Publisher:   publisher,
+		Logger:      newLogger(),
+	}
+
+	service := pets.NewService(settings)
+
+	ctx := pets.WithActor(context.TODO(), "jane")
+
+	// When
+	petID, err := service.Create(ctx, pets.NewPet{Name: "drila"})
+	assert.NoError(t, err)
+	err = service.Delete(ctx, "858455b7-e182-4122-a1b6-132c64d2f77b")
+	assert.NoError(t, err)
+
+	// Then
+	assert.Len(t, publisher.records, 2)
+	assert.Equal(t, petID, publisher.records[0].PetID)
+	assert.Equal(t, pets.AuditCreated, publisher.records[0].Action)
+	assert.Equal(t, pets.AuditDeleted, publisher.records[1].Action)
+	assert.Equal(t, "jane", publisher.records[1].Actor)
+}
+
+func TestUpdateIsAudited(t *testing.T) {
+	t.Parallel()
+
+	// Given
+	foundPet := pets.Pet{
+		ID:   pets.PetID("858455b7-e182-4122-a1b6-132c64d2f77b"),
+		Name: "drila",
+	}
+
+	updatePet := pets.UpdatePet{
+		ID:       pets.PetID("858455b7-e182-4122-a1b6-132c64d2f77b"),
+		Name:     "lula",
+		Location: &pets.Location{Latitude: 4.711, Longitude: -74.0721},
+	}
+
+	expectedChanges := []pets.FieldChange{
+		{Field: "name", Before: "drila", After: "lula"},
+		{Field: "location", Before: "", After: "4.711,-74.0721"},
+	}
+
+	auditStorer := newAuditStorerMock()
+
+	settings := pets.ServiceSetup{
+		Storer:      newStorerMock(withFoundPet(&foundPet)),
+		AuditStorer: auditStorer,
+		Logger:      newLogger(),
+	}
+
+	service := pets.NewService(settings)
+
+	ctx := context.TODO()
+
+	// When
+	err := service.Update(ctx, updatePet)
+
+	// Then
+	assert.NoError(t, err)
+	assert.Len(t, auditStorer.records, 1)
+	assert.Equal(t, pets.AuditUpdated, auditStorer.records[0].Action)
+	assert.Equal(t, pets.AnonymousActor, auditStorer.records[0].Actor)
+	assert.Equal(t, expectedChanges, auditStorer.records[0].Changes)
+}
+
+func TestUpdateOfMissingPetIsNotAudited(t *testing.T) {
+	t.Parallel()
+
+	// Given
+	updatePet := pets.UpdatePet{
+		ID:   pets.PetID("858455b7-e182-4122-a1b6-132c64d2f77b"),
+		Name: "lula",
+	}
+
+	auditStorer := newAuditStorerMock()
+	publisher := newPublisherMock()
+
+	settings := pets.ServiceSetup{
+		Storer:      newStorerMock(),
+		AuditStorer: auditStorer,
+		Publisher:   publisher,
+		Logger:      newLogger(),
+	}
+
+	service := pets.NewService(settings)
+
+	ctx := context.TODO()
+
+	// When
+	err := service.Update(ctx, updatePet)
+
+	// Then
+	assert.Error(t, err)
+	assert.Empty(t, auditStorer.records)
+	assert.Empty(t, publisher.records)
+}
+
+func TestHistory(t *testing.T) {
+	t.Parallel()
+
+	// Given
+	filter := pets.AuditFilter{
+		PetID: pets.PetID("858455b7-e182-4122-a1b6-132c64d2f77b"),
+	}
+
+	expectedFilter := pets.AuditFilter{
+		PetID:       pets.PetID("858455b7-e182-4122-a1b6-132c64d2f77b"),
+		PageNumber:  pets.PageNumberDefault,
+		RowsPerPage: pets.RowsPerPageDefault,
+	}
+
+	auditStorer := newAuditStorerMock()
+
+	settings := pets.ServiceSetup{
+		Storer:      newStorerMock(),
+		AuditStorer: auditStorer,
+		Logger:      newLogger(),
+	}
+
+	service := pets.NewService(settings)
+
+	ctx := context.TODO()
+
+	// When
+	_, err := service.History(ctx, filter)
+
+	// Then
+	assert.NoError(t, err)
+	assert.Equal(t, expectedFilter, auditStorer.filter)
+}
+
 func TestQuery(t *testing.T) {
 }
 
+func TestReportSighting(t *testing.T) {
+	t.Parallel()
+
+	// Given
+	foundPet := pets.Pet{
+		ID:   pets.PetID("858455b7-e182-4122-a1b6-132c64d2f77b"),
+		Name: "drila",
+	}
+	newSighting := pets.NewSighting{
+		PetID:    pets.PetID("858455b7-e182-4122-a1b6-132c64d2f77b"),
+		Location: pets.Location{Latitude: 4.711, Longitude: -74.0721},
+		Notes:    "near the park",
+	}
+
+	storerMock := newStorerMock(
+		withFoundPet(&foundPet),
+	)
+
+	settings := pets.ServiceSetup{
+		Storer: storerMock,
+		Logger: newLogger(),
+	}
+
+	service := pets.NewService(settings)
+
+	ctx := context.TODO()
+
+	// When
+	sightingID, err := service.ReportSighting(ctx, newSighting)
+
+	// Then
+	assert.NoError(t, err)
+	assert.Equal(t, storerMock.savedSighting.ID, sightingID)
+	assert.Equal(t, newSighting.Location, storerMock.savedSighting.Location)
+	assert.False(t, storerMock.savedSighting.SeenAt.IsZero())
+}
+
+func TestReportSightingButPetNotFound(t *testing.T) {
+	t.Parallel()
+
+	// Given
+	newSighting := pets.NewSighting{
+		PetID:    pets.PetID("858455b7-e182-4122-a1b6-132c64d2f77b"),
+		Location: pets.Location{Latitude: 4.711, Longitude: -74.0721},
+	}
+
+	storerMock := newStorerMock()
+
+	settings := pets.ServiceSetup{
+		Storer: storerMock,
+		Logger: newLogger(),
+	}
+
+	service := pets.NewService(settings)
+
+	ctx := context.TODO()
+
+	// When
+	sightingID, err := service.ReportSighting(ctx, newSighting)
+
+	// Then
+	assert.Error(t, err)
+	assert.EqualError(t, err, "pet does not exist")
+	assert.Equal(t, pets.NotFoundError, pets.KindOf(err))
+	assert.Equal(t, pets.EmptySightingID, sightingID)
+}
+
+func TestQueryNearby(t *testing.T) {
+	t.Parallel()
+
+	// Given
+	filter := pets.NearbyFilter{
+		Location: pets.Location{Latitude: 4.711, Longitude: -74.0721},
+	}
+
+	expectedFilter := pets.NearbyFilter{
+		Location: pets.Location{Latitude: 4.711, Longitude: -74.0721},
+		RadiusKm: pets.NearbyRadiusKmDefault,
+		Limit:    pets.NearbyLimitDefault,
+	}
+
+	storerMock := newStorerMock()
+
+	settings := pets.ServiceSetup{
+		Storer: storerMock,
+		Logger: newLogger(),
+	}
+
+	service := pets.NewService(settings)
+
+	ctx := context.TODO()
+
+	// When
+	_, err := service.QueryNearby(ctx, filter)
+
+	// Then
+	assert.NoError(t, err)
+	assert.Equal(t, expectedFilter, storerMock.nearbyFilter)
+}
+
+func TestQueryNearbyButInvalidLocation(t *testing.T) {
+	t.Parallel()
+
+	// Given
+	filter := pets.NearbyFilter{
+		Location: pets.Location{Latitude: 91, Longitude: -74.0721},
+		RadiusKm: 500,
+	}
+
+	storerMock := newStorerMock()
+
+	settings := pets.ServiceSetup{
+		Storer: storerMock,
+		Logger: newLogger(),
+	}
+
+	service := pets.NewService(settings)
+
+	ctx := context.TODO()
+
+	// When
+	_, err := service.QueryNearby(ctx, filter)
+
+	// Then
+	var validationError *pets.ValidationError
+	assert.ErrorAs(t, err, &validationError)
+	assert.Len(t, validationError.Messages, 2)
+}
+
 type storerMock struct {
 	pets.Storer
 	err error
 	ids []pets.PetID
 
-	updatedPet pets.UpdatePet
-	deletedPet pets.Pet
-	foundPet   *pets.Pet
+	updatedPet    pets.UpdatePet
+	deletedPet    pets.Pet
+	foundPet      *pets.Pet
+	foundPets     []pets.Pet
+	queriedIDs    []pets.PetID
+	savedSighting pets.Sighting
+	nearbyFilter  pets.NearbyFilter
+	restoredID    pets.PetID
+	purgedBefore  time.Time
+	inTransaction bool
+	// transactionErr is returned by WithinTransaction when fn succeeds.
+	transactionErr error
+	batches        [][]pets.Pet
 }
 
 func newStorerMock(options ...func(*storerMock)) *storerMock {
@@ -319,12 +878,24 @@ func withError(err error) func(*storerMock) {
 	}
 }
 
+func withTransactionErr(err error) func(*storerMock) {
+	return func(s *storerMock) {
+		s.transactionErr = err
+	}
+}
+
 func withFoundPet(pet *pets.Pet) func(*storerMock) {
 	return func(s *storerMock) {
 		s.foundPet = pet
 	}
 }
 
+func withFoundPets(foundPets ...pets.Pet) func(*storerMock) {
+	return func(s *storerMock) {
+		s.foundPets = foundPets
+	}
+}
+
 func (s *storerMock) Save(ctx context.Context, newPet pets.Pet) error {
 	if s.err != nil {
 		return s.err
@@ -335,6 +906,22 @@ func (s *storerMock) Save(ctx context.Context, newPet pets.Pet) error {
 	return nil
 }
 
+func (s *storerMock) SaveBatch(ctx context.Context, newPets []pets.Pet) error {
+	if s.err != nil {
+		return s.err
+	}
+
+	batch := make([]pets.Pet, len(newPets))
+	copy(batch, newPets)
+	s.batches = append(s.batches, batch)
+
+	for _, newPet := range newPets {
+		s.ids = append(s.ids, newPet.ID)
+	}
+
+	return nil
+}
+
 func (s *storerMock) Update(ctx context.Context, pet pets.UpdatePet) error {
 	if s.err != nil {
 		return s.err
@@ -355,6 +942,26 @@ func (s *storerMock) Delete(ctx context.Context, pet pets.Pet) error {
 	return nil
 }
 
+func (s *storerMock) Restore(ctx context.Context, id pets.PetID) error {
+	if s.err != nil {
+		return s.err
+	}
+
+	s.restoredID = id
+
+	return nil
+}
+
+func (s *storerMock) Purge(ctx context.Context, deletedBefore time.Time) (int, error) {
+	if s.err != nil {
+		return 0, s.err
+	}
+
+	s.purgedBefore = deletedBefore
+
+	return 0, nil
+}
+
 func (s *storerMock) QueryByID(ctx context.Context, id pets.PetID) (*pets.Pet, error) {
 	if s.err != nil {
 		return nil, s.err
@@ -363,6 +970,80 @@ func (s *storerMock) QueryByID(ctx context.Context, id pets.PetID) (*pets.Pet, e
 	return s.foundPet, nil
 }
 
+func (s *storerMock) QueryByIDs(ctx context.Context, ids []pets.PetID) ([]pets.Pet, error) {
+	if s.err != nil {
+		return nil, s.err
+	}
+
+	s.queriedIDs = ids
+
+	return s.foundPets, nil
+}
+
+func (s *storerMock) SaveSighting(ctx context.Context, sighting pets.Sighting) error {
+	if s.err != nil {
+		return s.err
+	}
+
+	s.savedSighting = sighting
+
+	return nil
+}
+
+func (s *storerMock) QueryNearby(ctx context.Context, filter pets.NearbyFilter) (pets.NearbyResult, error) {
+	if s.err != nil {
+		return pets.NearbyResult{}, s.err
+	}
+
+	s.nearbyFilter = filter
+
+	return pets.NearbyResult{}, nil
+}
+
+type auditStorerMock struct {
+	records []pets.AuditRecord
+	filter  pets.AuditFilter
+}
+
+func newAuditStorerMock() *auditStorerMock {
+	return &auditStorerMock{}
+}
+
+func (a *auditStorerMock) SaveAuditRecord(ctx context.Context, record pets.AuditRecord) error {
+	a.records = append(a.records, record)
+
+	return nil
+}
+
+func (a *auditStorerMock) QueryAuditRecords(ctx context.Context, filter pets.AuditFilter) (pets.AuditRecordsResult, error) {
+	a.filter = filter
+
+	return pets.AuditRecordsResult{}, nil
+}
+
+type publisherMock struct {
+	records []pets.AuditRecord
+}
+
+func newPublisherMock() *publisherMock {
+	return &publisherMock{}
+}
+
+func (p *publisherMock) Publish(ctx context.Context, record pets.AuditRecord) {
+	p.records = append(p.records, record)
+}
+
+func (s *storerMock) WithinTransaction(ctx context.Context, fn func(tx pets.Storer) error) error {
+	s.inTransaction = true
+
+	err := fn(s)
+	if err != nil {
+		return err
+	}
+
+	return s.transactionErr
+}
+
 func newLogger() *slog.Logger {
 	return slog.Default()
 }
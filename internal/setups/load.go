@@ -0,0 +1,300 @@
+package setups
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+// Source says where a configuration value comes from.
+type Source string
+
+// Sources contains where the configuration is loaded from. Every source
+// overrides the previous one: defaults, file, environment and flags.
+type Sources struct {
+	// File is the path of a YAML or TOML file, if it is empty the -config
+	// flag or the CONFIG_FILE environment variable are used.
+	File string
+	// Flags are parsed flags registered with RegisterFlags.
+	Flags *flag.FlagSet
+	// LookupEnv reads environment variables, os.LookupEnv by default.
+	LookupEnv func(key string) (string, bool)
+}
+
+// Configuration contains the loaded configuration and the source of every
+// parameter by its environment variable name.
+type Configuration struct {
+	Application Application
+	Sources     map[string]Source
+	// SecretFiles are the files of the parameters read from _FILE
+	// environment variables by parameter name, so they can be read again
+	// when they are rotated.
+	SecretFiles map[string]string
+}
+
+// ValidationError contains every problem found in the configuration.
+type ValidationError struct {
+	Messages []string
+}
+
+// configuration sources.
+const (
+	SourceDefault Source = "default"
+	SourceFile    Source = "file"
+	SourceEnv     Source = "env"
+	SourceEnvFile Source = "env_file"
+	SourceFlag    Source = "flag"
+)
+
+const (
+	// ConfigFileEnv is the environment variable with the configuration file.
+	ConfigFileEnv = "CONFIG_FILE"
+	// ConfigFileFlag is the flag with the configuration file.
+	ConfigFileFlag = "config"
+	// FileEnvSuffix is the suffix of the environment variables with the
+	// file of a parameter, like DB_PASSWORD_FILE.
+	FileEnvSuffix = "_FILE"
+)
+
+// LoadFrom loads the configuration from the given sources and validates
+// it. A *ValidationError with every problem is returned if any value
+// cannot be parsed or is not valid.
+func LoadFrom(sources Sources) (Configuration, error) {
+	if sources.LookupEnv == nil {
+		sources.LookupEnv = os.LookupEnv
+	}
+
+	configuration := Configuration{
+		Sources:     make(map[string]Source),
+		SecretFiles: make(map[string]string),
+	}
+	validationErr := new(ValidationError)
+
+	parameters := parametersOf(&configuration.Application)
+
+	for _, parameter := range parameters {
+		configuration.Sources[parameter.env] = SourceDefault
+
+		if parameter.defaultValue == "" {
+			continue
+		}
+
+		err := parameter.set(parameter.defaultValue)
+		if err != nil {
+			validationErr.addErrorMessage(fmt.Sprintf("%s: invalid default %q: %s", parameter.env, parameter.defaultValue, err))
+		}
+	}
+
+	fileValues, err := readConfigFile(sources.ConfigFile())
+	if err != nil {
+		return configuration, err
+	}
+
+	flagValues := make(map[string]string)
+	if sources.Flags != nil {
+		sources.Flags.Visit(func(f *flag.Flag) {
+			flagValues[f.Name] = f.Value.String()
+		})
+	}
+
+	for _, parameter := range parameters {
+		if raw, ok := fileValues[parameter.key]; ok {
+			delete(fileValues, parameter.key)
+			configuration.set(parameter, raw, SourceFile, validationErr)
+		}
+
+		raw, envOk := sources.LookupEnv(parameter.env)
+		if envOk {
+			configuration.set(parameter, raw, SourceEnv, validationErr)
+		}
+
+		if path, ok := sources.LookupEnv(parameter.env + FileEnvSuffix); ok && parameter.fromFile {
+			configuration.setFromFile(parameter, path, envOk, validationErr)
+		}
+
+		if raw, ok := flagValues[parameter.flag]; ok {
+			configuration.set(parameter, raw, SourceFlag, validationErr)
+		}
+	}
+
+	for _, key := range sortedKeys(fileValues) {
+		validationErr.addErrorMessage(fmt.Sprintf("unknown parameter %s in configuration file", key))
+	}
+
+	validationErr.Messages = append(validationErr.Messages, configuration.Application.problems()...)
+
+	if len(validationErr.Messages) > 0 {
+		return configuration, validationErr
+	}
+
+	return configuration, nil
+}
+
+// RegisterFlags defines a flag for every parameter and the -config flag.
+func RegisterFlags(flags *flag.FlagSet) {
+	flags.String(ConfigFileFlag, "", fmt.Sprintf("YAML or TOML configuration file, it overrides %s", ConfigFileEnv))
+
+	var application Application
+	for _, parameter := range parametersOf(&application) {
+		usage := fmt.Sprintf("overrides %s", parameter.env)
+		if parameter.defaultValue != "" && !parameter.secret {
+			usage += fmt.Sprintf(" (default %s)", parameter.defaultValue)
+		}
+
+		flags.String(parameter.flag, "", usage)
+	}
+}
+
+// Values returns the configuration parameters with their source.
+func (c Configuration) Values() []SourcedValue {
+	values := c.Application.Values()
+
+	result := make([]SourcedValue, 0, len(values))
+	for _, value := range values {
+		result = append(result, SourcedValue{
+			Value:  value,
+			Source: c.Sources[value.Name],
+		})
+	}
+
+	return result
+}
+
+// SourcedValue is a configuration parameter and where it comes from.
+type SourcedValue struct {
+	Value
+	Source Source
+}
+
+func (c *Configuration) set(parameter parameter, raw string, source Source, validationErr *ValidationError) {
+	err := parameter.set(raw)
+	if err != nil {
+		shownValue := raw
+		if parameter.secret {
+			shownValue = RedactedValue
+		}
+
+		validationErr.addErrorMessage(fmt.Sprintf("%s: invalid value %q from %s: %s", parameter.env, shownValue, source, err))
+
+		return
+	}
+
+	c.Sources[parameter.env] = source
+}
+
+// setFromFile sets the parameter with the content of the file without the
+// trailing new line, like secrets mounted by docker and kubernetes.
+func (c *Configuration) setFromFile(parameter parameter, path string, envOk bool, validationErr *ValidationError) {
+	fileEnv := parameter.env + FileEnvSuffix
+
+	if envOk {
+		validationErr.addErrorMessage(fmt.Sprintf("%s: cannot be set with %s at the same time", fileEnv, parameter.env))
+
+		return
+	}
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		validationErr.addErrorMessage(fmt.Sprintf("%s: unable to read file: %s", fileEnv, err))
+
+		return
+	}
+
+	c.set(parameter, strings.TrimRight(string(content), "\r\n"), SourceEnvFile, validationErr)
+	c.SecretFiles[parameter.env] = path
+}
+
+// ConfigFile returns the file of the sources, the -config flag or the
+// CONFIG_FILE environment variable, in that order.
+func (s Sources) ConfigFile() string {
+	if s.File != "" {
+		return s.File
+	}
+
+	if s.Flags != nil {
+		if configFlag := s.Flags.Lookup(ConfigFileFlag); configFlag != nil && configFlag.Value.String() != "" {
+			return configFlag.Value.String()
+		}
+	}
+
+	lookupEnv := s.LookupEnv
+	if lookupEnv == nil {
+		lookupEnv = os.LookupEnv
+	}
+
+	file, _ := lookupEnv(ConfigFileEnv)
+
+	return file
+}
+
+// readConfigFile returns the values of the file by their dotted keys, like
+// repository.host. The format is taken from the file extension.
+func readConfigFile(path string) (map[string]string, error) {
+	values := make(map[string]string)
+
+	if path == "" {
+		return values, nil
+	}
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read configuration file: %w", err)
+	}
+
+	document := make(map[string]any)
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(content, &document)
+	case ".toml":
+		err = toml.Unmarshal(content, &document)
+	default:
+		return nil, fmt.Errorf("configuration file %s must be .yaml, .yml or .toml", path)
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("unable to parse configuration file %s: %w", path, err)
+	}
+
+	flatten(document, "", values)
+
+	return values, nil
+}
+
+// flatten adds the values of document to values with dotted keys.
+func flatten(document map[string]any, section string, values map[string]string) {
+	for key, value := range document {
+		if nested, ok := value.(map[string]any); ok {
+			flatten(nested, section+key+".", values)
+
+			continue
+		}
+
+		values[section+key] = fmt.Sprint(value)
+	}
+}
+
+func sortedKeys(values map[string]string) []string {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+
+	return keys
+}
+
+func (e *ValidationError) addErrorMessage(message string) {
+	e.Messages = append(e.Messages, message)
+}
+
+func (e *ValidationError) Error() string {
+	return "invalid configuration:\n  " + strings.Join(e.Messages, "\n  ")
+}
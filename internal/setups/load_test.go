@@ -0,0 +1,179 @@
+package setups_test
+
+import (
+	"errors"
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/fernandoocampo/basic-micro/internal/setups"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadFromDefaults(t *testing.T) {
+	// Given
+	env := map[string]string{
+		"DB_PASSWORD": "drila",
+	}
+
+	// When
+	got, err := setups.LoadFrom(setups.Sources{LookupEnv: lookupEnv(env)})
+
+	// Then
+	require.NoError(t, err)
+	assert.Equal(t, ":8080", got.Application.ApplicationPort)
+	assert.Equal(t, 5432, got.Application.Repository.Port)
+	assert.Equal(t, 30*24*time.Hour, got.Application.Purge.Retention)
+	assert.Equal(t, setups.SourceDefault, got.Sources["APPLICATION_PORT"])
+}
+
+func TestLoadFromLayers(t *testing.T) {
+	cases := map[string]struct {
+		file string
+	}{
+		"yaml": {
+			file: writeFile(t, "petsd.yaml", "application_port: \":9090\"\nimport_batch_size: 10\nrepository:\n  host: db\n  port: 6543\npurge:\n  interval: 2h\n"),
+		},
+		"toml": {
+			file: writeFile(t, "petsd.toml", "application_port = \":9090\"\nimport_batch_size = 10\n[repository]\nhost = \"db\"\nport = 6543\n[purge]\ninterval = \"2h\"\n"),
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			// Given
+			env := map[string]string{
+				"IMPORT_BATCH_SIZE":  "20",
+				"DB_HOST":            "env-db",
+				"DB_PASSWORD":        "drila",
+				setups.ConfigFileEnv: tc.file,
+			}
+			flags := flag.NewFlagSet("petsd", flag.ContinueOnError)
+			setups.RegisterFlags(flags)
+			require.NoError(t, flags.Parse([]string{"-import-batch-size", "30"}))
+
+			// When
+			got, err := setups.LoadFrom(setups.Sources{
+				Flags:     flags,
+				LookupEnv: lookupEnv(env),
+			})
+
+			// Then
+			require.NoError(t, err)
+			assert.Equal(t, ":9090", got.Application.ApplicationPort)
+			assert.Equal(t, setups.SourceFile, got.Sources["APPLICATION_PORT"])
+			assert.Equal(t, 6543, got.Application.Repository.Port)
+			assert.Equal(t, 2*time.Hour, got.Application.Purge.Interval)
+			assert.Equal(t, "env-db", got.Application.Repository.Host)
+			assert.Equal(t, setups.SourceEnv, got.Sources["DB_HOST"])
+			assert.Equal(t, 30, got.Application.ImportBatchSize)
+			assert.Equal(t, setups.SourceFlag, got.Sources["IMPORT_BATCH_SIZE"])
+		})
+	}
+}
+
+func TestLoadFromReportsEveryProblem(t *testing.T) {
+	// Given
+	file := writeFile(t, "petsd.yaml", "log_environment: verbose\nunknown: 1\n")
+	env := map[string]string{
+		"APPLICATION_PORT": "8080",
+		"DB_PORT":          "abc",
+		"DB_PASSWORD":      "",
+	}
+
+	// When
+	_, err := setups.LoadFrom(setups.Sources{
+		File:      file,
+		LookupEnv: lookupEnv(env),
+	})
+
+	// Then
+	var validationErr *setups.ValidationError
+	require.True(t, errors.As(err, &validationErr))
+	assert.Equal(t, []string{
+		`DB_PORT: invalid value "abc" from env: strconv.ParseInt: parsing "abc": invalid syntax`,
+		"unknown parameter unknown in configuration file",
+		`APPLICATION_PORT: "8080" must be [host]:port`,
+		`LOG_ENVIRONMENT: "verbose" must be production or development`,
+		"DB_PASSWORD: cannot be empty, set DB_PASSWORD, DB_PASSWORD_FILE or SECRET_PROVIDER",
+	}, validationErr.Messages)
+}
+
+func TestLoadFromEnvFile(t *testing.T) {
+	// Given
+	passwordFile := writeFile(t, "db_password", "drila\n")
+	env := map[string]string{
+		"DB_PASSWORD_FILE": passwordFile,
+		"DB_PORT_FILE":     writeFile(t, "db_port", "6543"),
+	}
+
+	// When
+	got, err := setups.LoadFrom(setups.Sources{LookupEnv: lookupEnv(env)})
+
+	// Then
+	require.NoError(t, err)
+	assert.Equal(t, "drila", got.Application.Repository.Password)
+	assert.Equal(t, 6543, got.Application.Repository.Port)
+	assert.Equal(t, setups.SourceEnvFile, got.Sources["DB_PASSWORD"])
+	assert.Equal(t, passwordFile, got.SecretFiles["DB_PASSWORD"])
+}
+
+func TestLoadFromEnvFileProblems(t *testing.T) {
+	// Given
+	env := map[string]string{
+		"DB_PASSWORD":       "drila",
+		"DB_PASSWORD_FILE":  writeFile(t, "db_password", "drila"),
+		"DB_USER_FILE":      filepath.Join(t.TempDir(), "missing"),
+		"STORE_DRIVER":      "memory",
+		"STORE_DRIVER_FILE": writeFile(t, "store_driver", "postgres"),
+	}
+
+	// When
+	got, err := setups.LoadFrom(setups.Sources{LookupEnv: lookupEnv(env)})
+
+	// Then
+	var validationErr *setups.ValidationError
+	require.True(t, errors.As(err, &validationErr))
+	require.Len(t, validationErr.Messages, 2)
+	assert.Equal(t, "DB_USER_FILE: unable to read file: open "+env["DB_USER_FILE"]+": no such file or directory", validationErr.Messages[0])
+	assert.Equal(t, "DB_PASSWORD_FILE: cannot be set with DB_PASSWORD at the same time", validationErr.Messages[1])
+	assert.Equal(t, setups.MemoryDriver, got.Application.StoreDriver, "only parameters tagged with envFile are read from files")
+}
+
+func TestLoadFromUnsupportedFile(t *testing.T) {
+	// Given
+	file := writeFile(t, "petsd.json", "{}")
+
+	// When
+	_, err := setups.LoadFrom(setups.Sources{
+		File:      file,
+		LookupEnv: noEnv,
+	})
+
+	// Then
+	assert.Error(t, err)
+}
+
+func writeFile(t *testing.T, name, content string) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), name)
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+
+	return path
+}
+
+func lookupEnv(env map[string]string) func(string) (string, bool) {
+	return func(key string) (string, bool) {
+		value, ok := env[key]
+
+		return value, ok
+	}
+}
+
+func noEnv(key string) (string, bool) {
+	return "", false
+}
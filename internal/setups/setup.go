@@ -1,41 +1,162 @@
 package setups
 
-import (
-	"github.com/caarlos0/env"
-)
+import "time"
 
 const (
 	ProductionLog  = "production"
 	DevelopmentLog = "development"
 )
 
+// secret providers
+const (
+	FileSecretProvider  = "file"
+	VaultSecretProvider = "vault"
+)
+
+// store drivers
+const (
+	PostgresDriver = "postgres"
+	MemoryDriver   = "memory"
+)
+
 // Application contains data related to application configuration parameters.
+// Every parameter can be set in the configuration file with its yaml or
+// toml key, with its environment variable and with a flag named like the
+// environment variable in lower case with dashes, see Load. Parameters
+// tagged with reload can be changed while the application runs and
+// parameters tagged with envFile can be read from the file given by the
+// environment variable with the _FILE suffix, like DB_PASSWORD_FILE.
 type Application struct {
-	DryRun          bool   `env:"DRY_RUN" envDefault:"false"`
-	ApplicationPort string `env:"APPLICATION_PORT" envDefault:":8080"`
-	LogLevel        string `env:"LOG_ENVIRONMENT" envDefault:"production"`
-	Repository      RepositoryParameters
+	DryRun          bool   `env:"DRY_RUN" envDefault:"false" yaml:"dry_run" toml:"dry_run"`
+	ApplicationPort string `env:"APPLICATION_PORT" envDefault:":8080" yaml:"application_port" toml:"application_port"`
+	GRPCPort        string `env:"GRPC_PORT" envDefault:":9090" yaml:"grpc_port" toml:"grpc_port"`
+	LogLevel        string `env:"LOG_ENVIRONMENT" envDefault:"production" yaml:"log_environment" toml:"log_environment" reload:"true"`
+	StoreDriver     string `env:"STORE_DRIVER" envDefault:"postgres" yaml:"store_driver" toml:"store_driver"`
+	// IdempotencyTTL how long responses are replayed for the same Idempotency-Key.
+	IdempotencyTTL time.Duration `env:"IDEMPOTENCY_TTL" envDefault:"24h" yaml:"idempotency_ttl" toml:"idempotency_ttl"`
+	// ImportBatchSize number of imported pets saved at once.
+	ImportBatchSize int `env:"IMPORT_BATCH_SIZE" envDefault:"500" yaml:"import_batch_size" toml:"import_batch_size"`
+	// ImportMaxJobs number of import jobs that run at once.
+	ImportMaxJobs int `env:"IMPORT_MAX_JOBS" envDefault:"4" yaml:"import_max_jobs" toml:"import_max_jobs"`
+	// ShutdownDelay how long the server keeps serving after readiness fails.
+	ShutdownDelay time.Duration `env:"SHUTDOWN_DELAY" envDefault:"0s" yaml:"shutdown_delay" toml:"shutdown_delay" reload:"true"`
+	// ShutdownTimeout how long in-flight requests have to finish on shutdown.
+	ShutdownTimeout time.Duration        `env:"SHUTDOWN_TIMEOUT" envDefault:"30s" yaml:"shutdown_timeout" toml:"shutdown_timeout" reload:"true"`
+	Repository      RepositoryParameters `yaml:"repository" toml:"repository"`
+	Purge           PurgeParameters      `yaml:"purge" toml:"purge"`
+	Health          HealthParameters     `yaml:"health" toml:"health"`
+	Telemetry       TelemetryParameters  `yaml:"telemetry" toml:"telemetry"`
+	Secrets         SecretsParameters    `yaml:"secrets" toml:"secrets"`
+	OpenAPI         OpenAPIParameters    `yaml:"openapi" toml:"openapi"`
+	GraphQL         GraphQLParameters    `yaml:"graphql" toml:"graphql"`
+	Events          EventsParameters     `yaml:"events" toml:"events"`
+	WebSocket       WebSocketParameters  `yaml:"websocket" toml:"websocket"`
+}
+
+// OpenAPIParameters contains data related to the validation of requests
+// and responses against the OpenAPI spec.
+type OpenAPIParameters struct {
+	// ValidateRequests rejects requests that do not match the spec with 400.
+	ValidateRequests bool `env:"OPENAPI_VALIDATE_REQUESTS" envDefault:"false" yaml:"validate_requests" toml:"validate_requests"`
+	// ValidateResponses logs the responses that do not match the spec.
+	ValidateResponses bool `env:"OPENAPI_VALIDATE_RESPONSES" envDefault:"false" yaml:"validate_responses" toml:"validate_responses"`
+}
+
+// GraphQLParameters contains data related to the GraphQL endpoint.
+type GraphQLParameters struct {
+	// Playground serves GraphiQL on /graphql, meant for development.
+	Playground bool `env:"GRAPHQL_PLAYGROUND" envDefault:"false" yaml:"playground" toml:"playground"`
+	// MaxComplexity highest cost of an operation, list fields multiply the
+	// cost of their selections by their size.
+	MaxComplexity int `env:"GRAPHQL_MAX_COMPLEXITY" envDefault:"1000" yaml:"max_complexity" toml:"max_complexity"`
+	// MaxDepth deepest selection of an operation.
+	MaxDepth int `env:"GRAPHQL_MAX_DEPTH" envDefault:"10" yaml:"max_depth" toml:"max_depth"`
+}
+
+// EventsParameters contains data related to the stream of pet changes.
+type EventsParameters struct {
+	// LogSize number of events kept, so clients that reconnect receive the
+	// events they missed.
+	LogSize int `env:"EVENTS_LOG_SIZE" envDefault:"1000" yaml:"log_size" toml:"log_size"`
+	// HeartbeatInterval how often idle streams get a heartbeat.
+	HeartbeatInterval time.Duration `env:"EVENTS_HEARTBEAT_INTERVAL" envDefault:"15s" yaml:"heartbeat_interval" toml:"heartbeat_interval"`
+}
+
+// WebSocketParameters contains data related to the websocket connections.
+type WebSocketParameters struct {
+	// Token bearer token clients send on connect, clients are not
+	// authenticated if it is empty.
+	Token string `env:"WEBSOCKET_TOKEN" yaml:"token" toml:"token" secret:"true" envFile:"true"`
+	// PingInterval how often clients are pinged to keep connections alive.
+	PingInterval time.Duration `env:"WEBSOCKET_PING_INTERVAL" envDefault:"30s" yaml:"ping_interval" toml:"ping_interval"`
+	// SendBuffer number of replies kept for a client that is not reading.
+	SendBuffer int `env:"WEBSOCKET_SEND_BUFFER" envDefault:"16" yaml:"send_buffer" toml:"send_buffer"`
+}
+
+// SecretsParameters contains data related to the provider of the secrets
+// that are refreshed while the application runs, like the database password.
+type SecretsParameters struct {
+	// Provider is file, vault or empty to use only the configuration.
+	Provider string `env:"SECRET_PROVIDER" yaml:"provider" toml:"provider"`
+	// FileDir directory of the secret files of the file provider.
+	FileDir string `env:"SECRET_FILE_DIR" envDefault:"/run/secrets" yaml:"file_dir" toml:"file_dir"`
+	// VaultAddress address of the vault server of the vault provider.
+	VaultAddress string `env:"VAULT_ADDR" envDefault:"http://127.0.0.1:8200" yaml:"vault_address" toml:"vault_address"`
+	VaultToken   string `env:"VAULT_TOKEN" yaml:"vault_token" toml:"vault_token" secret:"true" envFile:"true"`
+	// VaultPath path of the key value secret, like secret/data/basic-micro.
+	VaultPath string `env:"VAULT_SECRET_PATH" envDefault:"secret/data/basic-micro" yaml:"vault_path" toml:"vault_path"`
+	// RefreshInterval how often secrets are read again to detect rotations.
+	RefreshInterval time.Duration `env:"SECRET_REFRESH_INTERVAL" envDefault:"1m" yaml:"refresh_interval" toml:"refresh_interval"`
+}
+
+// TelemetryParameters contains data related to telemetry.
+type TelemetryParameters struct {
+	// SampleRatio ratio of traces that are sampled, between 0 and 1.
+	SampleRatio float64 `env:"TELEMETRY_SAMPLE_RATIO" envDefault:"1" yaml:"sample_ratio" toml:"sample_ratio" reload:"true"`
+}
+
+// HealthParameters contains data related to the health probes.
+type HealthParameters struct {
+	// Timeout how long a health check can take before it fails.
+	Timeout time.Duration `env:"HEALTH_CHECK_TIMEOUT" envDefault:"2s" yaml:"timeout" toml:"timeout" reload:"true"`
+	// CacheTTL how long a health check result is reused.
+	CacheTTL time.Duration `env:"HEALTH_CACHE_TTL" envDefault:"5s" yaml:"cache_ttl" toml:"cache_ttl" reload:"true"`
+	// DiskPath is the path whose free space is checked, the check is
+	// disabled if it is empty.
+	DiskPath string `env:"HEALTH_DISK_PATH" yaml:"disk_path" toml:"disk_path"`
+	// DiskMinFreeBytes minimum free space of DiskPath.
+	DiskMinFreeBytes uint64 `env:"HEALTH_DISK_MIN_FREE_BYTES" envDefault:"104857600" yaml:"disk_min_free_bytes" toml:"disk_min_free_bytes"`
+	// TelemetryErrorWindow how long a telemetry exporter error makes the
+	// readiness probe fail.
+	TelemetryErrorWindow time.Duration `env:"HEALTH_TELEMETRY_ERROR_WINDOW" envDefault:"1m" yaml:"telemetry_error_window" toml:"telemetry_error_window"`
+}
+
+// PurgeParameters contains data related to the job that purges deleted pets.
+type PurgeParameters struct {
+	// Retention how long deleted pets are kept before being purged.
+	Retention time.Duration `env:"PURGE_RETENTION" envDefault:"720h" yaml:"retention" toml:"retention"`
+	// Interval how often the purge job runs.
+	Interval time.Duration `env:"PURGE_INTERVAL" envDefault:"1h" yaml:"interval" toml:"interval"`
 }
 
 // RepositoryParameters contains data related to a repository.
 type RepositoryParameters struct {
-	Host     string `env:"DB_HOST" envDefault:"localhost"`
-	Port     int    `env:"DB_PORT" envDefault:"5432"`
-	User     string `env:"DB_USER" envDefault:"postgres"`
-	Password string `env:"DB_PASSWORD" envDefault:"postgres"`
-	DBName   string `env:"DBNAME" envDefault:"postgres"`
+	Host string `env:"DB_HOST" envDefault:"localhost" yaml:"host" toml:"host" envFile:"true"`
+	Port int    `env:"DB_PORT" envDefault:"5432" yaml:"port" toml:"port" envFile:"true"`
+	User string `env:"DB_USER" envDefault:"postgres" yaml:"user" toml:"user" envFile:"true"`
+	// Password has no default, it can be read from DB_PASSWORD_FILE or
+	// from the secret provider.
+	Password string `env:"DB_PASSWORD" yaml:"password" toml:"password" secret:"true" envFile:"true"`
+	DBName   string `env:"DBNAME" envDefault:"postgres" yaml:"dbname" toml:"dbname" envFile:"true"`
 }
 
-// Load load application configuration
+// Load loads the application configuration from the environment and the
+// file of the CONFIG_FILE environment variable.
 func Load() (Application, error) {
-	cfg := Application{}
-	if err := env.Parse(&cfg); err != nil {
-		return cfg, err
-	}
-	repository := RepositoryParameters{}
-	if err := env.Parse(&repository); err != nil {
-		return cfg, err
+	configuration, err := LoadFrom(Sources{})
+	if err != nil {
+		return Application{}, err
 	}
-	cfg.Repository = repository
-	return cfg, nil
+
+	return configuration.Application, nil
 }
@@ -0,0 +1,179 @@
+package setups
+
+import (
+	"fmt"
+	"net"
+	"strconv"
+)
+
+// Validate returns a *ValidationError with every problem of the
+// configuration, or nil if it is valid.
+func (a Application) Validate() error {
+	problems := a.problems()
+	if len(problems) > 0 {
+		return &ValidationError{Messages: problems}
+	}
+
+	return nil
+}
+
+func (a Application) problems() []string {
+	var problems []string
+
+	if err := validAddress(a.ApplicationPort); err != nil {
+		problems = append(problems, fmt.Sprintf("APPLICATION_PORT: %s", err))
+	}
+
+	if err := validAddress(a.GRPCPort); err != nil {
+		problems = append(problems, fmt.Sprintf("GRPC_PORT: %s", err))
+	}
+
+	if a.LogLevel != ProductionLog && a.LogLevel != DevelopmentLog {
+		problems = append(problems, fmt.Sprintf("LOG_ENVIRONMENT: %q must be %s or %s", a.LogLevel, ProductionLog, DevelopmentLog))
+	}
+
+	switch a.StoreDriver {
+	case MemoryDriver:
+	case PostgresDriver:
+		problems = append(problems, a.Repository.problems()...)
+
+		if a.Repository.Password == "" && a.Secrets.Provider == "" {
+			problems = append(problems, "DB_PASSWORD: cannot be empty, set DB_PASSWORD, DB_PASSWORD_FILE or SECRET_PROVIDER")
+		}
+	default:
+		problems = append(problems, fmt.Sprintf("STORE_DRIVER: %q must be %s or %s", a.StoreDriver, PostgresDriver, MemoryDriver))
+	}
+
+	if a.IdempotencyTTL <= 0 {
+		problems = append(problems, "IDEMPOTENCY_TTL: must be greater than zero")
+	}
+
+	if a.ImportBatchSize <= 0 {
+		problems = append(problems, "IMPORT_BATCH_SIZE: must be greater than zero")
+	}
+
+	if a.ImportMaxJobs <= 0 {
+		problems = append(problems, "IMPORT_MAX_JOBS: must be greater than zero")
+	}
+
+	if a.ShutdownDelay < 0 {
+		problems = append(problems, "SHUTDOWN_DELAY: cannot be negative")
+	}
+
+	if a.ShutdownTimeout < 0 {
+		problems = append(problems, "SHUTDOWN_TIMEOUT: cannot be negative")
+	}
+
+	if a.Purge.Retention <= 0 {
+		problems = append(problems, "PURGE_RETENTION: must be greater than zero")
+	}
+
+	if a.Purge.Interval <= 0 {
+		problems = append(problems, "PURGE_INTERVAL: must be greater than zero")
+	}
+
+	if a.Health.Timeout <= 0 {
+		problems = append(problems, "HEALTH_CHECK_TIMEOUT: must be greater than zero")
+	}
+
+	if a.Health.CacheTTL < 0 {
+		problems = append(problems, "HEALTH_CACHE_TTL: cannot be negative")
+	}
+
+	problems = append(problems, a.Secrets.problems()...)
+
+	if a.Telemetry.SampleRatio < 0 || a.Telemetry.SampleRatio > 1 {
+		problems = append(problems, fmt.Sprintf("TELEMETRY_SAMPLE_RATIO: %g must be between 0 and 1", a.Telemetry.SampleRatio))
+	}
+
+	if a.GraphQL.MaxComplexity <= 0 {
+		problems = append(problems, "GRAPHQL_MAX_COMPLEXITY: must be greater than zero")
+	}
+
+	if a.GraphQL.MaxDepth <= 0 {
+		problems = append(problems, "GRAPHQL_MAX_DEPTH: must be greater than zero")
+	}
+
+	if a.Events.LogSize <= 0 {
+		problems = append(problems, "EVENTS_LOG_SIZE: must be greater than zero")
+	}
+
+	if a.Events.HeartbeatInterval <= 0 {
+		problems = append(problems, "EVENTS_HEARTBEAT_INTERVAL: must be greater than zero")
+	}
+
+	if a.WebSocket.PingInterval <= 0 {
+		problems = append(problems, "WEBSOCKET_PING_INTERVAL: must be greater than zero")
+	}
+
+	if a.WebSocket.SendBuffer <= 0 {
+		problems = append(problems, "WEBSOCKET_SEND_BUFFER: must be greater than zero")
+	}
+
+	return problems
+}
+
+func (r RepositoryParameters) problems() []string {
+	var problems []string
+
+	if r.Host == "" {
+		problems = append(problems, "DB_HOST: cannot be empty")
+	}
+
+	if r.Port < 1 || r.Port > 65535 {
+		problems = append(problems, fmt.Sprintf("DB_PORT: %d must be between 1 and 65535", r.Port))
+	}
+
+	if r.DBName == "" {
+		problems = append(problems, "DBNAME: cannot be empty")
+	}
+
+	return problems
+}
+
+func (s SecretsParameters) problems() []string {
+	var problems []string
+
+	switch s.Provider {
+	case "":
+	case FileSecretProvider:
+		if s.FileDir == "" {
+			problems = append(problems, "SECRET_FILE_DIR: cannot be empty")
+		}
+	case VaultSecretProvider:
+		if s.VaultAddress == "" {
+			problems = append(problems, "VAULT_ADDR: cannot be empty")
+		}
+
+		if s.VaultToken == "" {
+			problems = append(problems, "VAULT_TOKEN: cannot be empty, set VAULT_TOKEN or VAULT_TOKEN_FILE")
+		}
+
+		if s.VaultPath == "" {
+			problems = append(problems, "VAULT_SECRET_PATH: cannot be empty")
+		}
+	default:
+		problems = append(problems, fmt.Sprintf("SECRET_PROVIDER: %q must be %s, %s or empty", s.Provider, FileSecretProvider, VaultSecretProvider))
+	}
+
+	if s.RefreshInterval <= 0 {
+		problems = append(problems, "SECRET_REFRESH_INTERVAL: must be greater than zero")
+	}
+
+	return problems
+}
+
+// validAddress checks the address is [host]:port.
+func validAddress(address string) error {
+	_, port, err := net.SplitHostPort(address)
+	if err != nil {
+		return fmt.Errorf("%q must be [host]:port", address)
+	}
+
+	portNumber, err := strconv.Atoi(port)
+	if err != nil || portNumber < 0 || portNumber > 65535 {
+		return fmt.Errorf("%q port must be a number between 0 and 65535", address)
+	}
+
+	return nil
+}
@@ -0,0 +1,204 @@
+package setups
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Value is a configuration parameter named by its environment variable.
+type Value struct {
+	Name  string
+	Value string
+	// Secret says if the value must not be shown, see Redacted.
+	Secret bool
+}
+
+// parameter is a field of the configuration and its names in every source.
+type parameter struct {
+	env          string
+	key          string
+	flag         string
+	defaultValue string
+	secret       bool
+	reloadable   bool
+	// fromFile says if the value can be read from the file of the
+	// environment variable with the _FILE suffix.
+	fromFile bool
+	value    reflect.Value
+}
+
+// RedactedValue replaces secret values.
+const RedactedValue = "[REDACTED]"
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+// Values returns the configuration parameters in the order they are declared.
+func (a Application) Values() []Value {
+	parameters := parametersOf(&a)
+
+	result := make([]Value, 0, len(parameters))
+	for _, parameter := range parameters {
+		result = append(result, parameter.toValue())
+	}
+
+	return result
+}
+
+// String returns the parameters as NAME=value pairs with secrets redacted,
+// so the configuration can be logged.
+func (a Application) String() string {
+	var pairs []string
+	for _, value := range a.Values() {
+		pairs = append(pairs, value.Name+"="+value.Redacted())
+	}
+
+	return strings.Join(pairs, " ")
+}
+
+// NotReloadable returns the names of the parameters that are different in
+// changed and cannot be changed while the application runs.
+func (a Application) NotReloadable(changed Application) []string {
+	current := parametersOf(&a)
+	next := parametersOf(&changed)
+
+	var names []string
+	for index, parameter := range current {
+		if parameter.reloadable {
+			continue
+		}
+
+		if !reflect.DeepEqual(parameter.value.Interface(), next[index].value.Interface()) {
+			names = append(names, parameter.env)
+		}
+	}
+
+	return names
+}
+
+// WithReloadable returns a copy of the application with the parameters of
+// changed that can be changed while the application runs.
+func (a Application) WithReloadable(changed Application) Application {
+	next := parametersOf(&changed)
+
+	for index, parameter := range parametersOf(&a) {
+		if parameter.reloadable {
+			parameter.value.Set(next[index].value)
+		}
+	}
+
+	return a
+}
+
+// Redacted returns the value or RedactedValue if it is a secret that is set.
+func (v Value) Redacted() string {
+	if v.Secret && v.Value != "" {
+		return RedactedValue
+	}
+
+	return v.Value
+}
+
+// parametersOf returns the parameters of the application, their values
+// can be set.
+func parametersOf(application *Application) []parameter {
+	return parameters(reflect.ValueOf(application).Elem(), "")
+}
+
+// parameters walks the fields of the struct, nested structs are walked
+// too and their file keys are prefixed with the section key.
+func parameters(structValue reflect.Value, section string) []parameter {
+	var result []parameter
+
+	structType := structValue.Type()
+	for i := 0; i < structType.NumField(); i++ {
+		field := structType.Field(i)
+		fieldValue := structValue.Field(i)
+		key := section + field.Tag.Get("yaml")
+
+		envTag, ok := field.Tag.Lookup("env")
+		if !ok {
+			if fieldValue.Kind() == reflect.Struct {
+				result = append(result, parameters(fieldValue, key+".")...)
+			}
+
+			continue
+		}
+
+		name, _, _ := strings.Cut(envTag, ",")
+
+		result = append(result, parameter{
+			env:          name,
+			key:          key,
+			flag:         strings.ReplaceAll(strings.ToLower(name), "_", "-"),
+			defaultValue: field.Tag.Get("envDefault"),
+			secret:       field.Tag.Get("secret") == "true",
+			reloadable:   field.Tag.Get("reload") == "true",
+			fromFile:     field.Tag.Get("envFile") == "true",
+			value:        fieldValue,
+		})
+	}
+
+	return result
+}
+
+func (p parameter) toValue() Value {
+	return Value{
+		Name:   p.env,
+		Value:  fmt.Sprint(p.value.Interface()),
+		Secret: p.secret,
+	}
+}
+
+// set parses raw with the type of the parameter and sets it.
+func (p parameter) set(raw string) error {
+	if p.value.Type() == durationType {
+		duration, err := time.ParseDuration(raw)
+		if err != nil {
+			return err
+		}
+
+		p.value.SetInt(int64(duration))
+
+		return nil
+	}
+
+	switch p.value.Kind() {
+	case reflect.String:
+		p.value.SetString(raw)
+	case reflect.Bool:
+		boolValue, err := strconv.ParseBool(raw)
+		if err != nil {
+			return err
+		}
+
+		p.value.SetBool(boolValue)
+	case reflect.Int, reflect.Int64:
+		intValue, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil {
+			return err
+		}
+
+		p.value.SetInt(intValue)
+	case reflect.Float64:
+		floatValue, err := strconv.ParseFloat(raw, 64)
+		if err != nil {
+			return err
+		}
+
+		p.value.SetFloat(floatValue)
+	case reflect.Uint64:
+		uintValue, err := strconv.ParseUint(raw, 10, 64)
+		if err != nil {
+			return err
+		}
+
+		p.value.SetUint(uintValue)
+	default:
+		return fmt.Errorf("unsupported type %s", p.value.Type())
+	}
+
+	return nil
+}
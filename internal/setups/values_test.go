@@ -0,0 +1,63 @@
+package setups_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/fernandoocampo/basic-micro/internal/setups"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestApplicationValues(t *testing.T) {
+	// Given
+	application := setups.Application{
+		ApplicationPort: ":8080",
+		Repository: setups.RepositoryParameters{
+			Password: "drila",
+		},
+		Purge: setups.PurgeParameters{
+			Retention: time.Hour,
+		},
+	}
+
+	// When
+	got := make(map[string]string)
+	for _, value := range application.Values() {
+		got[value.Name] = value.Redacted()
+	}
+
+	// Then
+	assert.Equal(t, ":8080", got["APPLICATION_PORT"])
+	assert.Equal(t, setups.RedactedValue, got["DB_PASSWORD"])
+	assert.Equal(t, "1h0m0s", got["PURGE_RETENTION"])
+	assert.NotContains(t, application.String(), "drila")
+}
+
+func TestApplicationWithReloadable(t *testing.T) {
+	// Given
+	current := setups.Application{
+		ApplicationPort: ":8080",
+		LogLevel:        setups.ProductionLog,
+		Health: setups.HealthParameters{
+			Timeout: time.Second,
+		},
+	}
+	changed := setups.Application{
+		ApplicationPort: ":9090",
+		LogLevel:        setups.DevelopmentLog,
+		Health: setups.HealthParameters{
+			Timeout: 3 * time.Second,
+		},
+	}
+
+	// When
+	got := current.WithReloadable(changed)
+	notReloadable := current.NotReloadable(changed)
+
+	// Then
+	assert.Equal(t, ":8080", got.ApplicationPort)
+	assert.Equal(t, setups.DevelopmentLog, got.LogLevel)
+	assert.Equal(t, 3*time.Second, got.Health.Timeout)
+	assert.Equal(t, []string{"APPLICATION_PORT"}, notReloadable)
+	assert.Equal(t, setups.ProductionLog, current.LogLevel, "current configuration must not change")
+}
@@ -0,0 +1,558 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.5.1
+// - protoc             (unknown)
+// source: pets/v1/pets.proto
+
+package petsv1
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	PetService_GetPet_FullMethodName         = "/pets.v1.PetService/GetPet"
+	PetService_CreatePet_FullMethodName      = "/pets.v1.PetService/CreatePet"
+	PetService_UpdatePet_FullMethodName      = "/pets.v1.PetService/UpdatePet"
+	PetService_DeletePet_FullMethodName      = "/pets.v1.PetService/DeletePet"
+	PetService_RestorePet_FullMethodName     = "/pets.v1.PetService/RestorePet"
+	PetService_SearchPets_FullMethodName     = "/pets.v1.PetService/SearchPets"
+	PetService_ExportPets_FullMethodName     = "/pets.v1.PetService/ExportPets"
+	PetService_BatchPets_FullMethodName      = "/pets.v1.PetService/BatchPets"
+	PetService_GetPetHistory_FullMethodName  = "/pets.v1.PetService/GetPetHistory"
+	PetService_ReportSighting_FullMethodName = "/pets.v1.PetService/ReportSighting"
+	PetService_SearchNearby_FullMethodName   = "/pets.v1.PetService/SearchNearby"
+)
+
+// PetServiceClient is the client API for PetService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// PetService manages pets, it has the same operations of the HTTP API
+// except imports. Errors are answered with standard status codes:
+// INVALID_ARGUMENT for invalid data, NOT_FOUND for missing pets, ABORTED
+// for atomic batches that were rolled back and INTERNAL for store failures.
+//
+// The x-actor metadata is recorded as the author of the changes in the pet
+// history and x-request-id is returned in the response headers, a new one
+// is created if it is not sent.
+type PetServiceClient interface {
+	// GetPet returns the pet with the given id.
+	GetPet(ctx context.Context, in *GetPetRequest, opts ...grpc.CallOption) (*Pet, error)
+	// CreatePet creates a pet and returns its id.
+	CreatePet(ctx context.Context, in *CreatePetRequest, opts ...grpc.CallOption) (*CreatePetResponse, error)
+	// UpdatePet replaces the name and location of a pet.
+	UpdatePet(ctx context.Context, in *UpdatePetRequest, opts ...grpc.CallOption) (*UpdatePetResponse, error)
+	// DeletePet deletes a pet, it can be restored until it is purged.
+	DeletePet(ctx context.Context, in *DeletePetRequest, opts ...grpc.CallOption) (*DeletePetResponse, error)
+	// RestorePet restores a deleted pet.
+	RestorePet(ctx context.Context, in *RestorePetRequest, opts ...grpc.CallOption) (*RestorePetResponse, error)
+	// SearchPets streams a page of the pets whose name contains the given
+	// name, the total number of pets found is sent in the x-total-count
+	// response header.
+	SearchPets(ctx context.Context, in *SearchPetsRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[Pet], error)
+	// ExportPets streams all pets ordered by id.
+	ExportPets(ctx context.Context, in *ExportPetsRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[Pet], error)
+	// BatchPets applies create, update and delete operations. The response
+	// of an atomic batch that was rolled back is part of the ABORTED status
+	// details.
+	BatchPets(ctx context.Context, in *BatchPetsRequest, opts ...grpc.CallOption) (*BatchPetsResponse, error)
+	// GetPetHistory returns the changes made to a pet, oldest first.
+	GetPetHistory(ctx context.Context, in *GetPetHistoryRequest, opts ...grpc.CallOption) (*GetPetHistoryResponse, error)
+	// ReportSighting reports that a pet was seen somewhere.
+	ReportSighting(ctx context.Context, in *ReportSightingRequest, opts ...grpc.CallOption) (*ReportSightingResponse, error)
+	// SearchNearby returns pets and sightings around a location, closest
+	// first.
+	SearchNearby(ctx context.Context, in *SearchNearbyRequest, opts ...grpc.CallOption) (*SearchNearbyResponse, error)
+}
+
+type petServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewPetServiceClient(cc grpc.ClientConnInterface) PetServiceClient {
+	return &petServiceClient{cc}
+}
+
+func (c *petServiceClient) GetPet(ctx context.Context, in *GetPetRequest, opts ...grpc.CallOption) (*Pet, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Pet)
+	err := c.cc.Invoke(ctx, PetService_GetPet_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *petServiceClient) CreatePet(ctx context.Context, in *CreatePetRequest, opts ...grpc.CallOption) (*CreatePetResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(CreatePetResponse)
+	err := c.cc.Invoke(ctx, PetService_CreatePet_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *petServiceClient) UpdatePet(ctx context.Context, in *UpdatePetRequest, opts ...grpc.CallOption) (*UpdatePetResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(UpdatePetResponse)
+	err := c.cc.Invoke(ctx, PetService_UpdatePet_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *petServiceClient) DeletePet(ctx context.Context, in *DeletePetRequest, opts ...grpc.CallOption) (*DeletePetResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(DeletePetResponse)
+	err := c.cc.Invoke(ctx, PetService_DeletePet_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *petServiceClient) RestorePet(ctx context.Context, in *RestorePetRequest, opts ...grpc.CallOption) (*RestorePetResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(RestorePetResponse)
+	err := c.cc.Invoke(ctx, PetService_RestorePet_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *petServiceClient) SearchPets(ctx context.Context, in *SearchPetsRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[Pet], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &PetService_ServiceDesc.Streams[0], PetService_SearchPets_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[SearchPetsRequest, Pet]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type PetService_SearchPetsClient = grpc.ServerStreamingClient[Pet]
+
+func (c *petServiceClient) ExportPets(ctx context.Context, in *ExportPetsRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[Pet], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &PetService_ServiceDesc.Streams[1], PetService_ExportPets_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[ExportPetsRequest, Pet]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type PetService_ExportPetsClient = grpc.ServerStreamingClient[Pet]
+
+func (c *petServiceClient) BatchPets(ctx context.Context, in *BatchPetsRequest, opts ...grpc.CallOption) (*BatchPetsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(BatchPetsResponse)
+	err := c.cc.Invoke(ctx, PetService_BatchPets_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *petServiceClient) GetPetHistory(ctx context.Context, in *GetPetHistoryRequest, opts ...grpc.CallOption) (*GetPetHistoryResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(GetPetHistoryResponse)
+	err := c.cc.Invoke(ctx, PetService_GetPetHistory_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *petServiceClient) ReportSighting(ctx context.Context, in *ReportSightingRequest, opts ...grpc.CallOption) (*ReportSightingResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ReportSightingResponse)
+	err := c.cc.Invoke(ctx, PetService_ReportSighting_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *petServiceClient) SearchNearby(ctx context.Context, in *SearchNearbyRequest, opts ...grpc.CallOption) (*SearchNearbyResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(SearchNearbyResponse)
+	err := c.cc.Invoke(ctx, PetService_SearchNearby_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// PetServiceServer is the server API for PetService service.
+// All implementations must embed UnimplementedPetServiceServer
+// for forward compatibility.
+//
+// PetService manages pets, it has the same operations of the HTTP API
+// except imports. Errors are answered with standard status codes:
+// INVALID_ARGUMENT for invalid data, NOT_FOUND for missing pets, ABORTED
+// for atomic batches that were rolled back and INTERNAL for store failures.
+//
+// The x-actor metadata is recorded as the author of the changes in the pet
+// history and x-request-id is returned in the response headers, a new one
+// is created if it is not sent.
+type PetServiceServer interface {
+	// GetPet returns the pet with the given id.
+	GetPet(context.Context, *GetPetRequest) (*Pet, error)
+	// CreatePet creates a pet and returns its id.
+	CreatePet(context.Context, *CreatePetRequest) (*CreatePetResponse, error)
+	// UpdatePet replaces the name and location of a pet.
+	UpdatePet(context.Context, *UpdatePetRequest) (*UpdatePetResponse, error)
+	// DeletePet deletes a pet, it can be restored until it is purged.
+	DeletePet(context.Context, *DeletePetRequest) (*DeletePetResponse, error)
+	// RestorePet restores a deleted pet.
+	RestorePet(context.Context, *RestorePetRequest) (*RestorePetResponse, error)
+	// SearchPets streams a page of the pets whose name contains the given
+	// name, the total number of pets found is sent in the x-total-count
+	// response header.
+	SearchPets(*SearchPetsRequest, grpc.ServerStreamingServer[Pet]) error
+	// ExportPets streams all pets ordered by id.
+	ExportPets(*ExportPetsRequest, grpc.ServerStreamingServer[Pet]) error
+	// BatchPets applies create, update and delete operations. The response
+	// of an atomic batch that was rolled back is part of the ABORTED status
+	// details.
+	BatchPets(context.Context, *BatchPetsRequest) (*BatchPetsResponse, error)
+	// GetPetHistory returns the changes made to a pet, oldest first.
+	GetPetHistory(context.Context, *GetPetHistoryRequest) (*GetPetHistoryResponse, error)
+	// ReportSighting reports that a pet was seen somewhere.
+	ReportSighting(context.Context, *ReportSightingRequest) (*ReportSightingResponse, error)
+	// SearchNearby returns pets and sightings around a location, closest
+	// first.
+	SearchNearby(context.Context, *SearchNearbyRequest) (*SearchNearbyResponse, error)
+	mustEmbedUnimplementedPetServiceServer()
+}
+
+// UnimplementedPetServiceServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedPetServiceServer struct{}
+
+func (UnimplementedPetServiceServer) GetPet(context.Context, *GetPetRequest) (*Pet, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetPet not implemented")
+}
+func (UnimplementedPetServiceServer) CreatePet(context.Context, *CreatePetRequest) (*CreatePetResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CreatePet not implemented")
+}
+func (UnimplementedPetServiceServer) UpdatePet(context.Context, *UpdatePetRequest) (*UpdatePetResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method UpdatePet not implemented")
+}
+func (UnimplementedPetServiceServer) DeletePet(context.Context, *DeletePetRequest) (*DeletePetResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DeletePet not implemented")
+}
+func (UnimplementedPetServiceServer) RestorePet(context.Context, *RestorePetRequest) (*RestorePetResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RestorePet not implemented")
+}
+func (UnimplementedPetServiceServer) SearchPets(*SearchPetsRequest, grpc.ServerStreamingServer[Pet]) error {
+	return status.Errorf(codes.Unimplemented, "method SearchPets not implemented")
+}
+func (UnimplementedPetServiceServer) ExportPets(*ExportPetsRequest, grpc.ServerStreamingServer[Pet]) error {
+	return status.Errorf(codes.Unimplemented, "method ExportPets not implemented")
+}
+func (UnimplementedPetServiceServer) BatchPets(context.Context, *BatchPetsRequest) (*BatchPetsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method BatchPets not implemented")
+}
+func (UnimplementedPetServiceServer) GetPetHistory(context.Context, *GetPetHistoryRequest) (*GetPetHistoryResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetPetHistory not implemented")
+}
+func (UnimplementedPetServiceServer) ReportSighting(context.Context, *ReportSightingRequest) (*ReportSightingResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ReportSighting not implemented")
+}
+func (UnimplementedPetServiceServer) SearchNearby(context.Context, *SearchNearbyRequest) (*SearchNearbyResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SearchNearby not implemented")
+}
+func (UnimplementedPetServiceServer) mustEmbedUnimplementedPetServiceServer() {}
+func (UnimplementedPetServiceServer) testEmbeddedByValue()                    {}
+
+// UnsafePetServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to PetServiceServer will
+// result in compilation errors.
+type UnsafePetServiceServer interface {
+	mustEmbedUnimplementedPetServiceServer()
+}
+
+func RegisterPetServiceServer(s grpc.ServiceRegistrar, srv PetServiceServer) {
+	// If the following call pancis, it indicates UnimplementedPetServiceServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&PetService_ServiceDesc, srv)
+}
+
+func _PetService_GetPet_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetPetRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PetServiceServer).GetPet(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: PetService_GetPet_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PetServiceServer).GetPet(ctx, req.(*GetPetRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _PetService_CreatePet_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CreatePetRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PetServiceServer).CreatePet(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: PetService_CreatePet_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PetServiceServer).CreatePet(ctx, req.(*CreatePetRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _PetService_UpdatePet_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UpdatePetRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PetServiceServer).UpdatePet(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: PetService_UpdatePet_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PetServiceServer).UpdatePet(ctx, req.(*UpdatePetRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _PetService_DeletePet_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DeletePetRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PetServiceServer).DeletePet(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: PetService_DeletePet_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PetServiceServer).DeletePet(ctx, req.(*DeletePetRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _PetService_RestorePet_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RestorePetRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PetServiceServer).RestorePet(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: PetService_RestorePet_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PetServiceServer).RestorePet(ctx, req.(*RestorePetRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _PetService_SearchPets_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(SearchPetsRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(PetServiceServer).SearchPets(m, &grpc.GenericServerStream[SearchPetsRequest, Pet]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type PetService_SearchPetsServer = grpc.ServerStreamingServer[Pet]
+
+func _PetService_ExportPets_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(ExportPetsRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(PetServiceServer).ExportPets(m, &grpc.GenericServerStream[ExportPetsRequest, Pet]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type PetService_ExportPetsServer = grpc.ServerStreamingServer[Pet]
+
+func _PetService_BatchPets_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(BatchPetsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PetServiceServer).BatchPets(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: PetService_BatchPets_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PetServiceServer).BatchPets(ctx, req.(*BatchPetsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _PetService_GetPetHistory_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetPetHistoryRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PetServiceServer).GetPetHistory(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: PetService_GetPetHistory_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PetServiceServer).GetPetHistory(ctx, req.(*GetPetHistoryRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _PetService_ReportSighting_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ReportSightingRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PetServiceServer).ReportSighting(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: PetService_ReportSighting_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PetServiceServer).ReportSighting(ctx, req.(*ReportSightingRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _PetService_SearchNearby_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SearchNearbyRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PetServiceServer).SearchNearby(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: PetService_SearchNearby_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PetServiceServer).SearchNearby(ctx, req.(*SearchNearbyRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// PetService_ServiceDesc is the grpc.ServiceDesc for PetService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var PetService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "pets.v1.PetService",
+	HandlerType: (*PetServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "GetPet",
+			Handler:    _PetService_GetPet_Handler,
+		},
+		{
+			MethodName: "CreatePet",
+			Handler:    _PetService_CreatePet_Handler,
+		},
+		{
+			MethodName: "UpdatePet",
+			Handler:    _PetService_UpdatePet_Handler,
+		},
+		{
+			MethodName: "DeletePet",
+			Handler:    _PetService_DeletePet_Handler,
+		},
+		{
+			MethodName: "RestorePet",
+			Handler:    _PetService_RestorePet_Handler,
+		},
+		{
+			MethodName: "BatchPets",
+			Handler:    _PetService_BatchPets_Handler,
+		},
+		{
+			MethodName: "GetPetHistory",
+			Handler:    _PetService_GetPetHistory_Handler,
+		},
+		{
+			MethodName: "ReportSighting",
+			Handler:    _PetService_ReportSighting_Handler,
+		},
+		{
+			MethodName: "SearchNearby",
+			Handler:    _PetService_SearchNearby_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "SearchPets",
+			Handler:       _PetService_SearchPets_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "ExportPets",
+			Handler:       _PetService_ExportPets_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "pets/v1/pets.proto",
+}